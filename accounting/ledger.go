@@ -0,0 +1,336 @@
+// Package accounting builds the tax lots of an account from its fills, reports the realized
+// and unrealized P&L of the lots, and detects the wash sales.
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// Method is the method selecting the lots closed by a fill.
+type Method int
+
+const (
+	// FIFO closes the oldest lots first.
+	FIFO Method = iota
+	// LIFO closes the newest lots first.
+	LIFO
+	// SpecificID closes the lots listed in Fill.LotIDs, then the oldest lots if they are not
+	// enough to close the qty of the fill.
+	SpecificID
+)
+
+var (
+	// ErrOutOfOrder is returned when a fill or a split is older than a fill already added to
+	// the ledger.
+	ErrOutOfOrder = errors.New("accounting: events must be added in chronological order")
+	// ErrUnknownLot is returned when a fill refers to a lot that isn't open.
+	ErrUnknownLot = errors.New("accounting: unknown lot")
+)
+
+// Fill is an execution of an order.
+type Fill struct {
+	// ID identifies the fill. The fills already added to the ledger with the same ID are ignored.
+	ID      string
+	OrderID string
+	Symbol  string
+	// Side is the side of the order. Every side other than buy (e.g. sell_short) is a sell.
+	Side alpaca.Side
+	// Qty is the filled qty, always positive.
+	Qty   decimal.Decimal
+	Price decimal.Decimal
+	Time  time.Time
+	// LotIDs are the IDs of the lots to close first when the ledger uses SpecificID.
+	LotIDs []string
+}
+
+// FillFromActivity returns the fill of a FILL account activity. It returns false if the
+// activity is not a fill.
+func FillFromActivity(a alpaca.AccountActivity) (Fill, bool) {
+	t, ok := a.Trade()
+	if !ok {
+		return Fill{}, false
+	}
+	return Fill{
+		ID:      t.ID,
+		OrderID: t.OrderID,
+		Symbol:  t.Symbol,
+		Side:    t.Side,
+		Qty:     t.Qty,
+		Price:   t.Price,
+		Time:    t.TransactionTime,
+	}, true
+}
+
+// FillFromTradeUpdate returns the fill of a fill or partial_fill trade update. It returns false
+// for the other events.
+func FillFromTradeUpdate(tu alpaca.TradeUpdate) (Fill, bool) {
+	if (tu.Event != alpaca.TradeEventFill && tu.Event != alpaca.TradeEventPartialFill) ||
+		tu.Qty == nil || tu.Price == nil {
+		return Fill{}, false
+	}
+	at := tu.At
+	if tu.Timestamp != nil {
+		at = *tu.Timestamp
+	}
+	return Fill{
+		ID:      tu.ExecutionID,
+		OrderID: tu.Order.ID,
+		Symbol:  tu.Order.Symbol,
+		Side:    tu.Order.Side,
+		Qty:     *tu.Qty,
+		Price:   *tu.Price,
+		Time:    at,
+	}, true
+}
+
+// Lot is an open tax lot: the shares bought (or sold short) by a fill that are not closed yet.
+type Lot struct {
+	// ID is the ID of the fill that opened the lot.
+	ID       string
+	Symbol   string
+	OpenedAt time.Time
+	// Qty is negative for the short lots.
+	Qty decimal.Decimal
+	// Price is the cost of a share, adjusted for the splits.
+	Price decimal.Decimal
+}
+
+// CostBasis returns the cost of the lot, negative for the short lots.
+func (l Lot) CostBasis() decimal.Decimal {
+	return l.Qty.Mul(l.Price)
+}
+
+// Realization is the closing of (a part of) a lot by a fill.
+type Realization struct {
+	LotID    string
+	Symbol   string
+	OpenedAt time.Time
+	ClosedAt time.Time
+	// Qty is the closed qty of the lot, negative for the short lots.
+	Qty        decimal.Decimal
+	OpenPrice  decimal.Decimal
+	ClosePrice decimal.Decimal
+	// FillID is the ID of the closing fill.
+	FillID string
+}
+
+// PL returns the realized P&L.
+func (r Realization) PL() decimal.Decimal {
+	return r.ClosePrice.Sub(r.OpenPrice).Mul(r.Qty)
+}
+
+// LedgerOpts contains the options of a Ledger.
+type LedgerOpts struct {
+	// Method selects the lots closed by the fills. Defaults to FIFO.
+	Method Method
+	// Location is the time zone of the days of the reports and of the ex-dates of the splits.
+	// Defaults to UTC. It should be set to America/New_York for the US equities.
+	Location *time.Location
+}
+
+// Ledger builds the tax lots from the fills added to it, in chronological order, and records
+// the realized P&L of the closed lots.
+//
+// A fill first closes the open lots on the other side (the short lots for a buy), then
+// opens a new lot with the rest of its qty.
+//
+// A Ledger is not safe for concurrent use.
+type Ledger struct {
+	opts LedgerOpts
+	lots map[string][]Lot
+	// splits are the splits not applied yet, sorted by their ex-date
+	splits   []Split
+	realized []Realization
+	seen     map[string]bool
+	last     time.Time
+	// days are the open lots at the end of each day with a fill
+	days map[civil.Date]map[string][]Lot
+}
+
+// NewLedger returns a new empty Ledger.
+func NewLedger(opts LedgerOpts) *Ledger {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	return &Ledger{
+		opts: opts,
+		lots: make(map[string][]Lot),
+		seen: make(map[string]bool),
+		days: make(map[civil.Date]map[string][]Lot),
+	}
+}
+
+// AddActivities adds the FILL activities to the ledger, sorted by their transaction time.
+// The other activities are ignored.
+func (l *Ledger) AddActivities(activities []alpaca.AccountActivity) error {
+	var fills []Fill
+	for _, a := range activities {
+		if f, ok := FillFromActivity(a); ok {
+			fills = append(fills, f)
+		}
+	}
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+	for _, f := range fills {
+		if err := l.AddFill(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTradeUpdate adds the fill of the trade update to the ledger. The other events are ignored.
+func (l *Ledger) AddTradeUpdate(tu alpaca.TradeUpdate) error {
+	f, ok := FillFromTradeUpdate(tu)
+	if !ok {
+		return nil
+	}
+	return l.AddFill(f)
+}
+
+// AddFill adds the fill to the ledger. The fills must be added in chronological order.
+func (l *Ledger) AddFill(f Fill) error {
+	if f.Symbol == "" || !f.Qty.IsPositive() {
+		return fmt.Errorf("accounting: invalid fill %q: the symbol and a positive qty are required", f.ID)
+	}
+	if f.ID != "" && l.seen[f.ID] {
+		return nil
+	}
+	if f.Time.Before(l.last) {
+		return fmt.Errorf("%w: fill %q at %s is before %s", ErrOutOfOrder, f.ID, f.Time, l.last)
+	}
+	l.applySplits(f.Time)
+	if err := l.fill(f); err != nil {
+		return err
+	}
+	if f.ID != "" {
+		l.seen[f.ID] = true
+	}
+	l.last = f.Time
+	l.snapshot(civil.DateOf(f.Time.In(l.opts.Location)))
+	return nil
+}
+
+func (l *Ledger) fill(f Fill) error {
+	remaining := f.Qty
+	if f.Side != alpaca.Buy {
+		remaining = remaining.Neg()
+	}
+	lots := l.lots[f.Symbol]
+	selected := f.LotIDs
+	if l.opts.Method != SpecificID {
+		selected = nil
+	}
+	for _, id := range selected {
+		if lotIndex(lots, id) < 0 {
+			return fmt.Errorf("%w: %s has no open lot %q", ErrUnknownLot, f.Symbol, id)
+		}
+	}
+	// All the lots of a symbol are on the same side, since a fill closes the other side first
+	for len(lots) > 0 && !remaining.IsZero() && lots[0].Qty.Sign() != remaining.Sign() {
+		i := l.pick(lots, &selected)
+		lot := &lots[i]
+		closed := decimal.Min(remaining.Abs(), lot.Qty.Abs())
+		if lot.Qty.IsNegative() {
+			closed = closed.Neg()
+		}
+		l.realized = append(l.realized, Realization{
+			LotID:      lot.ID,
+			Symbol:     f.Symbol,
+			OpenedAt:   lot.OpenedAt,
+			ClosedAt:   f.Time,
+			Qty:        closed,
+			OpenPrice:  lot.Price,
+			ClosePrice: f.Price,
+			FillID:     f.ID,
+		})
+		lot.Qty = lot.Qty.Sub(closed)
+		remaining = remaining.Add(closed)
+		if lot.Qty.IsZero() {
+			lots = append(lots[:i], lots[i+1:]...)
+		}
+	}
+	if !remaining.IsZero() {
+		id := f.ID
+		if id == "" {
+			id = fmt.Sprintf("%s-%d", f.Symbol, f.Time.UnixNano())
+		}
+		lots = append(lots, Lot{ID: id, Symbol: f.Symbol, OpenedAt: f.Time, Qty: remaining, Price: f.Price})
+	}
+	if len(lots) == 0 {
+		delete(l.lots, f.Symbol)
+	} else {
+		l.lots[f.Symbol] = lots
+	}
+	return nil
+}
+
+// pick returns the index of the next lot to close, consuming the selected lot IDs.
+func (l *Ledger) pick(lots []Lot, selected *[]string) int {
+	for len(*selected) > 0 {
+		i := lotIndex(lots, (*selected)[0])
+		if i >= 0 {
+			return i
+		}
+		// The lot was closed by the previous iterations
+		*selected = (*selected)[1:]
+	}
+	if l.opts.Method == LIFO {
+		return len(lots) - 1
+	}
+	return 0
+}
+
+func lotIndex(lots []Lot, id string) int {
+	for i, lot := range lots {
+		if lot.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *Ledger) snapshot(date civil.Date) {
+	lots := make(map[string][]Lot, len(l.lots))
+	for symbol, symbolLots := range l.lots {
+		lots[symbol] = append([]Lot(nil), symbolLots...)
+	}
+	l.days[date] = lots
+}
+
+// Lots returns the open lots of the symbol, from the oldest to the newest, adjusted for the
+// splits whose ex-date has passed.
+func (l *Ledger) Lots(symbol string) []Lot {
+	return l.currentLots(symbol)
+}
+
+// OpenLots returns all the open lots, sorted by symbol then from the oldest to the newest,
+// adjusted for the splits whose ex-date has passed.
+func (l *Ledger) OpenLots() []Lot {
+	var lots []Lot
+	for _, symbol := range sortedKeys(l.lots) {
+		lots = append(lots, l.currentLots(symbol)...)
+	}
+	return lots
+}
+
+// Realized returns the realizations of the lots closed so far, in the order they were closed.
+func (l *Ledger) Realized() Realizations {
+	return append(Realizations(nil), l.realized...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
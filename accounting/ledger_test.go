@@ -0,0 +1,200 @@
+package accounting
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+var t0 = time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)
+
+func fill(id string, side alpaca.Side, qty, price int64, at time.Duration) Fill {
+	return Fill{
+		ID: id, Symbol: "AAPL", Side: side, Qty: decimal.NewFromInt(qty), Price: decimal.NewFromInt(price),
+		Time: t0.Add(at),
+	}
+}
+
+func lotQtys(lots []Lot) map[string]string {
+	qtys := make(map[string]string, len(lots))
+	for _, lot := range lots {
+		qtys[lot.ID] = lot.Qty.String()
+	}
+	return qtys
+}
+
+func TestLedger_Methods(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		method   Method
+		lotIDs   []string
+		realized string
+		lots     map[string]string
+	}{
+		{name: "fifo", method: FIFO, realized: "100", lots: map[string]string{"2": "5"}},
+		{name: "lifo", method: LIFO, realized: "50", lots: map[string]string{"1": "5"}},
+		{name: "specific id", method: SpecificID, lotIDs: []string{"2"}, realized: "50", lots: map[string]string{"1": "5"}},
+		// The lot IDs are ignored by the other methods
+		{name: "fifo with lot ids", method: FIFO, lotIDs: []string{"2"}, realized: "100", lots: map[string]string{"2": "5"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewLedger(LedgerOpts{Method: tc.method})
+			require.NoError(t, l.AddFill(fill("1", alpaca.Buy, 5, 100, 0)))
+			require.NoError(t, l.AddFill(fill("2", alpaca.Buy, 5, 110, time.Minute)))
+			sell := fill("3", alpaca.Sell, 5, 120, 2*time.Minute)
+			sell.LotIDs = tc.lotIDs
+			require.NoError(t, l.AddFill(sell))
+
+			realized := l.Realized()
+			require.Len(t, realized, 1)
+			assert.Equal(t, tc.realized, realized[0].PL().String())
+			assert.Equal(t, tc.lots, lotQtys(l.Lots("AAPL")))
+		})
+	}
+}
+
+func TestLedger_PartialAndShort(t *testing.T) {
+	l := NewLedger(LedgerOpts{Method: SpecificID})
+	require.NoError(t, l.AddFill(fill("1", alpaca.Buy, 10, 100, 0)))
+	require.NoError(t, l.AddFill(fill("2", alpaca.Buy, 10, 90, time.Minute)))
+	// Partially closes the second lot, then the first one
+	sell := fill("3", alpaca.Sell, 15, 95, 2*time.Minute)
+	sell.LotIDs = []string{"2"}
+	require.NoError(t, l.AddFill(sell))
+	assert.Equal(t, map[string]string{"1": "5"}, lotQtys(l.Lots("AAPL")))
+	// Closes the long lot and opens a short one with the rest
+	require.NoError(t, l.AddFill(fill("4", alpaca.Side("sell_short"), 8, 80, 3*time.Minute)))
+	assert.Equal(t, map[string]string{"4": "-3"}, lotQtys(l.Lots("AAPL")))
+	// A fill already added is ignored
+	require.NoError(t, l.AddFill(fill("4", alpaca.Sell, 8, 80, 3*time.Minute)))
+	// Covers the short lot
+	require.NoError(t, l.AddFill(fill("5", alpaca.Buy, 3, 70, 4*time.Minute)))
+	assert.Empty(t, l.OpenLots())
+
+	var pls []string
+	for _, r := range l.Realized() {
+		pls = append(pls, r.LotID+" "+r.Qty.String()+" "+r.PL().String())
+	}
+	assert.Equal(t, []string{"2 10 50", "1 5 -25", "1 5 -100", "4 -3 30"}, pls)
+
+	err := l.AddFill(fill("6", alpaca.Buy, 1, 100, time.Minute))
+	require.ErrorIs(t, err, ErrOutOfOrder)
+	sell = fill("7", alpaca.Sell, 1, 100, 5*time.Minute)
+	sell.LotIDs = []string{"1"}
+	require.ErrorIs(t, l.AddFill(sell), ErrUnknownLot)
+	require.Error(t, l.AddFill(fill("8", alpaca.Buy, 0, 100, 5*time.Minute)))
+}
+
+func TestLedger_Splits(t *testing.T) {
+	l := NewLedger(LedgerOpts{})
+	require.NoError(t, l.AddCorporateActions(marketdata.CorporateActions{
+		ForwardSplits: []marketdata.ForwardSplit{
+			{Symbol: "AAPL", ExDate: civil.Date{Year: 2024, Month: 3, Day: 5}, NewRate: 4, OldRate: 1},
+		},
+		ReverseSplits: []marketdata.ReverseSplit{
+			{Symbol: "AAPL", ExDate: civil.Date{Year: 2024, Month: 3, Day: 7}, NewRate: 1, OldRate: 2},
+		},
+	}))
+	require.NoError(t, l.AddFill(fill("1", alpaca.Buy, 10, 100, 0)))
+	// The forward split applies before the fills of its ex-date
+	require.NoError(t, l.AddFill(fill("2", alpaca.Sell, 20, 30, 24*time.Hour)))
+	assert.Equal(t, "100", l.Realized()[0].PL().String())
+	// The lots are read adjusted for the remaining splits: 20 shares at 25 become 10 shares at 50
+	lots := l.Lots("AAPL")
+	require.Len(t, lots, 1)
+	assert.Equal(t, "10", lots[0].Qty.String())
+	assert.Equal(t, "50", lots[0].Price.String())
+	// Reading the lots doesn't apply the splits, so the fills preceding them can still be added
+	require.NoError(t, l.AddFill(fill("3", alpaca.Sell, 2, 60, 24*time.Hour)))
+	assert.Equal(t, map[string]string{"1": "9"}, lotQtys(l.OpenLots()))
+	assert.Equal(t, "9", l.SymbolReport(nil)[0].Qty.String())
+	require.NoError(t, l.AddFill(fill("4", alpaca.Sell, 1, 60, 72*time.Hour)))
+	require.ErrorIs(t, l.AddFill(fill("5", alpaca.Sell, 1, 60, 48*time.Hour)), ErrOutOfOrder)
+	err := l.AddSplit(Split{
+		Symbol: "AAPL", ExDate: civil.Date{Year: 2024, Month: 3, Day: 6}, NewRate: decimal.NewFromInt(2),
+		OldRate: decimal.NewFromInt(1),
+	})
+	require.ErrorIs(t, err, ErrOutOfOrder)
+}
+
+func TestLedger_PendingSplits(t *testing.T) {
+	l := NewLedger(LedgerOpts{})
+	require.NoError(t, l.AddFill(fill("1", alpaca.Buy, 10, 100, 0)))
+	// The splits whose ex-date hasn't passed yet don't adjust the lots
+	future := civil.DateOf(time.Now()).AddDays(7)
+	require.NoError(t, l.AddSplit(Split{
+		Symbol: "AAPL", ExDate: future, NewRate: decimal.NewFromInt(2), OldRate: decimal.NewFromInt(1),
+	}))
+	assert.Equal(t, map[string]string{"1": "10"}, lotQtys(l.Lots("AAPL")))
+
+	// If any split is invalid, none is added
+	err := l.AddCorporateActions(marketdata.CorporateActions{
+		ForwardSplits: []marketdata.ForwardSplit{
+			{Symbol: "AAPL", ExDate: civil.Date{Year: 2024, Month: 3, Day: 5}, NewRate: 4, OldRate: 1},
+		},
+		ReverseSplits: []marketdata.ReverseSplit{
+			{Symbol: "AAPL", ExDate: civil.Date{Year: 2024, Month: 3, Day: 7}, NewRate: 0, OldRate: 2},
+		},
+	})
+	require.Error(t, err)
+	assert.Equal(t, map[string]string{"1": "10"}, lotQtys(l.Lots("AAPL")))
+}
+
+func TestLedger_FromAPI(t *testing.T) {
+	l := NewLedger(LedgerOpts{})
+	qty, price := decimal.NewFromInt(10), decimal.NewFromInt(100)
+	require.NoError(t, l.AddActivities([]alpaca.AccountActivity{
+		{ID: "2", ActivityType: alpaca.ActivityFill, Symbol: "AAPL", Side: "sell", Qty: decimal.NewFromInt(4),
+			Price: decimal.NewFromInt(110), TransactionTime: t0.Add(time.Minute)},
+		{ID: "div", ActivityType: "DIV", Symbol: "AAPL"},
+		{ID: "1", ActivityType: alpaca.ActivityFill, Symbol: "AAPL", Side: "buy", Qty: qty, Price: price,
+			TransactionTime: t0},
+	}))
+	ts := t0.Add(2 * time.Minute)
+	require.NoError(t, l.AddTradeUpdate(alpaca.TradeUpdate{Event: alpaca.TradeEventNew}))
+	require.NoError(t, l.AddTradeUpdate(alpaca.TradeUpdate{
+		Event: alpaca.TradeEventPartialFill, ExecutionID: "3", Order: alpaca.Order{Symbol: "AAPL", Side: alpaca.Buy},
+		Qty: &qty, Price: &price, Timestamp: &ts,
+	}))
+	assert.Equal(t, map[string]string{"1": "6", "3": "10"}, lotQtys(l.Lots("AAPL")))
+	assert.Equal(t, "40", l.Realized()[0].PL().String())
+}
+
+func TestLedger_Reports(t *testing.T) {
+	l := NewLedger(LedgerOpts{})
+	require.NoError(t, l.AddFill(fill("1", alpaca.Buy, 10, 100, 0)))
+	require.NoError(t, l.AddFill(fill("2", alpaca.Sell, 4, 110, time.Hour)))
+	msft := fill("3", alpaca.Sell, 2, 400, 24*time.Hour)
+	msft.Symbol = "MSFT"
+	require.NoError(t, l.AddFill(msft))
+
+	symbols := l.SymbolReport(map[string]decimal.Decimal{"AAPL": decimal.NewFromInt(120)})
+	require.Len(t, symbols, 2)
+	assert.Equal(t, "40", symbols[0].Realized.String())
+	assert.Equal(t, "120", symbols[0].Unrealized.String())
+	assert.Nil(t, symbols[1].Unrealized)
+	var buf bytes.Buffer
+	require.NoError(t, symbols.WriteCSV(&buf))
+	assert.Equal(t, "symbol,qty,cost_basis,realized_pl,unrealized_pl\nAAPL,6,600,40,120\nMSFT,-2,-800,0,\n", buf.String())
+
+	days := l.DailyReport(func(symbol string, date civil.Date) (decimal.Decimal, bool) {
+		return decimal.NewFromInt(105), symbol == "AAPL"
+	})
+	buf.Reset()
+	require.NoError(t, days.WriteCSV(&buf))
+	assert.Equal(t, "date,symbol,realized_pl,unrealized_pl\n2024-03-04,AAPL,40,30\n"+
+		"2024-03-05,AAPL,0,30\n2024-03-05,MSFT,0,\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, l.Realized().WriteCSV(&buf))
+	assert.Equal(t, "symbol,lot_id,opened_at,closed_at,qty,open_price,close_price,realized_pl,fill_id\n"+
+		"AAPL,1,2024-03-04T15:00:00Z,2024-03-04T16:00:00Z,4,100,110,40,2\n", buf.String())
+}
@@ -0,0 +1,183 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+)
+
+// Realizations are realizations of lots, that can be exported as CSV.
+type Realizations []Realization
+
+// WriteCSV writes the realizations as CSV, with a header row.
+func (rs Realizations) WriteCSV(w io.Writer) error {
+	rows := [][]string{{
+		"symbol", "lot_id", "opened_at", "closed_at", "qty", "open_price", "close_price", "realized_pl", "fill_id",
+	}}
+	for _, r := range rs {
+		rows = append(rows, []string{
+			r.Symbol, r.LotID, r.OpenedAt.Format(time.RFC3339Nano), r.ClosedAt.Format(time.RFC3339Nano),
+			r.Qty.String(), r.OpenPrice.String(), r.ClosePrice.String(), r.PL().String(), r.FillID,
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// SymbolPL is the P&L of a symbol.
+type SymbolPL struct {
+	Symbol string
+	// Qty is the qty of the open lots, negative if they are short.
+	Qty       decimal.Decimal
+	CostBasis decimal.Decimal
+	Realized  decimal.Decimal
+	// Unrealized is the P&L of the open lots at the price of the symbol, nil if the price is unknown.
+	Unrealized *decimal.Decimal
+}
+
+// SymbolReport is the P&L of each symbol, sorted by symbol.
+type SymbolReport []SymbolPL
+
+// SymbolReport returns the realized P&L of each symbol traded so far, and the unrealized P&L of
+// their open lots valued at the given prices, which must be adjusted for the splits whose
+// ex-date has passed.
+func (l *Ledger) SymbolReport(prices map[string]decimal.Decimal) SymbolReport {
+	bySymbol := make(map[string]*SymbolPL)
+	get := func(symbol string) *SymbolPL {
+		pl, ok := bySymbol[symbol]
+		if !ok {
+			pl = &SymbolPL{Symbol: symbol}
+			bySymbol[symbol] = pl
+		}
+		return pl
+	}
+	for _, r := range l.realized {
+		pl := get(r.Symbol)
+		pl.Realized = pl.Realized.Add(r.PL())
+	}
+	for symbol := range l.lots {
+		pl := get(symbol)
+		pl.Qty, pl.CostBasis = sumLots(l.currentLots(symbol))
+		if price, ok := prices[symbol]; ok {
+			unrealized := pl.Qty.Mul(price).Sub(pl.CostBasis)
+			pl.Unrealized = &unrealized
+		}
+	}
+	report := make(SymbolReport, 0, len(bySymbol))
+	for _, symbol := range sortedKeys(bySymbol) {
+		report = append(report, *bySymbol[symbol])
+	}
+	return report
+}
+
+// WriteCSV writes the report as CSV, with a header row. The unknown unrealized P&Ls are empty.
+func (r SymbolReport) WriteCSV(w io.Writer) error {
+	rows := [][]string{{"symbol", "qty", "cost_basis", "realized_pl", "unrealized_pl"}}
+	for _, pl := range r {
+		rows = append(rows, []string{
+			pl.Symbol, pl.Qty.String(), pl.CostBasis.String(), pl.Realized.String(), optionalString(pl.Unrealized),
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// DailyPL is the P&L of a symbol on a day.
+type DailyPL struct {
+	Date   civil.Date
+	Symbol string
+	// Realized is the P&L of the lots closed on the day.
+	Realized decimal.Decimal
+	// Unrealized is the P&L of the lots open at the end of the day, valued at the closing price
+	// of the day. It's nil if the closing price is unknown.
+	Unrealized *decimal.Decimal
+}
+
+// DailyReport is the P&L of each symbol on each day, sorted by date then symbol.
+type DailyReport []DailyPL
+
+// ClosingPriceFunc returns the closing price of a symbol on a day.
+type ClosingPriceFunc func(symbol string, date civil.Date) (decimal.Decimal, bool)
+
+// DailyReport returns the P&L of the symbols traded on each day with fills. The unrealized P&L
+// of a day is computed from the lots open at the end of the day, with their qty and price as of
+// that day, so the closing prices must not be adjusted for the later splits. closingPrice may be
+// nil, in which case only the realized P&L is reported.
+func (l *Ledger) DailyReport(closingPrice ClosingPriceFunc) DailyReport {
+	type key struct {
+		date   civil.Date
+		symbol string
+	}
+	byKey := make(map[key]*DailyPL)
+	get := func(k key) *DailyPL {
+		pl, ok := byKey[k]
+		if !ok {
+			pl = &DailyPL{Date: k.date, Symbol: k.symbol}
+			byKey[k] = pl
+		}
+		return pl
+	}
+	for _, r := range l.realized {
+		pl := get(key{date: civil.DateOf(r.ClosedAt.In(l.opts.Location)), symbol: r.Symbol})
+		pl.Realized = pl.Realized.Add(r.PL())
+	}
+	for date, lots := range l.days {
+		for symbol, symbolLots := range lots {
+			pl := get(key{date: date, symbol: symbol})
+			if closingPrice == nil {
+				continue
+			}
+			if price, ok := closingPrice(symbol, date); ok {
+				qty, costBasis := sumLots(symbolLots)
+				unrealized := qty.Mul(price).Sub(costBasis)
+				pl.Unrealized = &unrealized
+			}
+		}
+	}
+	report := make(DailyReport, 0, len(byKey))
+	for _, pl := range byKey {
+		report = append(report, *pl)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Date != report[j].Date {
+			return report[i].Date.Before(report[j].Date)
+		}
+		return report[i].Symbol < report[j].Symbol
+	})
+	return report
+}
+
+// WriteCSV writes the report as CSV, with a header row. The unknown unrealized P&Ls are empty.
+func (r DailyReport) WriteCSV(w io.Writer) error {
+	rows := [][]string{{"date", "symbol", "realized_pl", "unrealized_pl"}}
+	for _, pl := range r {
+		rows = append(rows, []string{pl.Date.String(), pl.Symbol, pl.Realized.String(), optionalString(pl.Unrealized)})
+	}
+	return writeCSV(w, rows)
+}
+
+func sumLots(lots []Lot) (qty, costBasis decimal.Decimal) {
+	for _, lot := range lots {
+		qty = qty.Add(lot.Qty)
+		costBasis = costBasis.Add(lot.CostBasis())
+	}
+	return qty, costBasis
+}
+
+func optionalString(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("accounting: failed to write the CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// Split is a stock split: from its ex-date, OldRate shares become NewRate shares.
+type Split struct {
+	Symbol  string
+	ExDate  civil.Date
+	NewRate decimal.Decimal
+	OldRate decimal.Decimal
+}
+
+// AddSplit adds the split to the ledger. It's applied to the open lots of its symbol at the
+// beginning of its ex-date, i.e. before the fills of that day: their qty is multiplied by
+// NewRate/OldRate and their price divided by it. The realized P&L is not affected.
+//
+// The splits can be added before the fills preceding their ex-date, but not after the fills
+// following it.
+func (l *Ledger) AddSplit(s Split) error {
+	if err := l.checkSplit(s); err != nil {
+		return err
+	}
+	l.insertSplit(s)
+	return nil
+}
+
+// AddCorporateActions adds the forward and reverse splits of the corporate actions returned by
+// marketdata.Client.GetCorporateActions to the ledger. The other corporate actions are ignored.
+// If any split can't be added, none is.
+func (l *Ledger) AddCorporateActions(actions marketdata.CorporateActions) error {
+	var splits []Split
+	for _, s := range actions.ForwardSplits {
+		splits = append(splits, newSplit(s.Symbol, s.ExDate, s.NewRate, s.OldRate))
+	}
+	for _, s := range actions.ReverseSplits {
+		splits = append(splits, newSplit(s.Symbol, s.ExDate, s.NewRate, s.OldRate))
+	}
+	for _, s := range splits {
+		if err := l.checkSplit(s); err != nil {
+			return err
+		}
+	}
+	for _, s := range splits {
+		l.insertSplit(s)
+	}
+	return nil
+}
+
+func (l *Ledger) checkSplit(s Split) error {
+	if !s.NewRate.IsPositive() || !s.OldRate.IsPositive() {
+		return fmt.Errorf("accounting: invalid split of %s on %s: the rates must be positive", s.Symbol, s.ExDate)
+	}
+	if l.splitTime(s).Before(l.last) {
+		return fmt.Errorf("%w: split of %s on %s is before %s", ErrOutOfOrder, s.Symbol, s.ExDate, l.last)
+	}
+	return nil
+}
+
+func (l *Ledger) insertSplit(s Split) {
+	i := sort.Search(len(l.splits), func(i int) bool { return s.ExDate.Before(l.splits[i].ExDate) })
+	l.splits = append(l.splits[:i], append([]Split{s}, l.splits[i:]...)...)
+}
+
+func newSplit(symbol string, exDate civil.Date, newRate, oldRate float64) Split {
+	return Split{
+		Symbol:  symbol,
+		ExDate:  exDate,
+		NewRate: decimal.NewFromFloat(newRate),
+		OldRate: decimal.NewFromFloat(oldRate),
+	}
+}
+
+func (l *Ledger) splitTime(s Split) time.Time {
+	return s.ExDate.In(l.opts.Location)
+}
+
+// applySplits applies the pending splits taking effect until the given time.
+func (l *Ledger) applySplits(until time.Time) {
+	for len(l.splits) > 0 {
+		s := l.splits[0]
+		if l.splitTime(s).After(until) {
+			return
+		}
+		l.splits = l.splits[1:]
+		// The fills preceding the split can't be added anymore
+		if t := l.splitTime(s); t.After(l.last) {
+			l.last = t
+		}
+		splitLots(l.lots[s.Symbol], s)
+	}
+}
+
+// currentLots returns a copy of the open lots of the symbol, adjusted for the pending splits
+// that have taken effect by now. Unlike applySplits, the splits are left pending, so that the
+// fills preceding them can still be added.
+func (l *Ledger) currentLots(symbol string) []Lot {
+	lots := append([]Lot(nil), l.lots[symbol]...)
+	now := time.Now()
+	for _, s := range l.splits {
+		if l.splitTime(s).After(now) {
+			break
+		}
+		if s.Symbol == symbol {
+			splitLots(lots, s)
+		}
+	}
+	return lots
+}
+
+func splitLots(lots []Lot, s Split) {
+	ratio := s.NewRate.Div(s.OldRate)
+	for i := range lots {
+		lots[i].Qty = lots[i].Qty.Mul(ratio)
+		lots[i].Price = lots[i].Price.Div(ratio)
+	}
+}
@@ -0,0 +1,313 @@
+package accounting
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// DefaultWashSaleWindow is the number of days before and after a sale at a loss in which a
+// purchase of the same symbol makes it a wash sale, if WashSaleOpts.Window is not set.
+const DefaultWashSaleWindow = 30
+
+// WashSaleOpts contains the options of AnalyzeWashSales.
+type WashSaleOpts struct {
+	// Window is the number of calendar days before and after a sale in which the purchases are
+	// replacements. Defaults to DefaultWashSaleWindow.
+	Window int
+	// Location is the time zone of the days of the fills. Defaults to UTC. It should be set to
+	// America/New_York for the US equities.
+	Location *time.Location
+}
+
+// WashSale is a sale at a loss matched with the purchase of replacement shares.
+type WashSale struct {
+	Symbol string
+	// SaleFillID is the ID of the sale, and SoldLotID the ID of the purchase of the sold shares.
+	SaleFillID string
+	SoldLotID  string
+	SaleTime   time.Time
+	// ReplacementLotID is the ID of the purchase of the replacement shares.
+	ReplacementLotID string
+	ReplacementTime  time.Time
+	// Qty is the number of sold shares matched with replacement shares.
+	Qty decimal.Decimal
+	// DisallowedLoss is the loss of the matched shares that is disallowed, as a positive amount.
+	DisallowedLoss decimal.Decimal
+	// BasisAdjustment is the disallowed loss per share, added to the cost basis of the
+	// replacement shares.
+	BasisAdjustment decimal.Decimal
+}
+
+// WashSales are wash sales, that can be exported as CSV.
+type WashSales []WashSale
+
+// WriteCSV writes the wash sales as CSV, with a header row.
+func (ws WashSales) WriteCSV(w io.Writer) error {
+	rows := [][]string{{
+		"symbol", "sale_fill_id", "sold_lot_id", "sale_time", "replacement_lot_id", "replacement_time", "qty",
+		"disallowed_loss", "basis_adjustment",
+	}}
+	for _, s := range ws {
+		rows = append(rows, []string{
+			s.Symbol, s.SaleFillID, s.SoldLotID, s.SaleTime.Format(time.RFC3339Nano), s.ReplacementLotID,
+			s.ReplacementTime.Format(time.RFC3339Nano), s.Qty.String(), s.DisallowedLoss.String(),
+			s.BasisAdjustment.String(),
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// WashSaleYear is the summary of the realized P&L and of the wash sales of a year.
+type WashSaleYear struct {
+	Year int
+	// RealizedPL is the P&L of the lots closed during the year, computed with the cost bases
+	// adjusted by the previous wash sales.
+	RealizedPL decimal.Decimal
+	// DisallowedLoss is the total loss disallowed by the wash sales of the year.
+	DisallowedLoss decimal.Decimal
+	WashSales      int
+}
+
+// AllowedPL returns the realized P&L without the disallowed losses.
+func (y WashSaleYear) AllowedPL() decimal.Decimal {
+	return y.RealizedPL.Add(y.DisallowedLoss)
+}
+
+// WashSaleYears are yearly summaries, that can be exported as CSV.
+type WashSaleYears []WashSaleYear
+
+// WriteCSV writes the summaries as CSV, with a header row.
+func (ys WashSaleYears) WriteCSV(w io.Writer) error {
+	rows := [][]string{{"year", "realized_pl", "disallowed_loss", "allowed_pl", "wash_sales"}}
+	for _, y := range ys {
+		rows = append(rows, []string{
+			strconv.Itoa(y.Year), y.RealizedPL.String(), y.DisallowedLoss.String(), y.AllowedPL().String(),
+			strconv.Itoa(y.WashSales),
+		})
+	}
+	return writeCSV(w, rows)
+}
+
+// WashSaleReport is the result of AnalyzeWashSales.
+type WashSaleReport struct {
+	// WashSales are sorted by sale, then by replacement purchase.
+	WashSales WashSales
+	// Years are sorted by year.
+	Years WashSaleYears
+}
+
+// replacement is a purchase that may replace the shares of a sale at a loss.
+type replacement struct {
+	id   string
+	time time.Time
+	date civil.Date
+	qty  decimal.Decimal
+	// used is the qty already matched with sales, and adjustments the basis adjustments of the
+	// matched shares, applied to them when they are sold
+	used        decimal.Decimal
+	adjustments []basisAdjustment
+}
+
+type basisAdjustment struct {
+	qty      decimal.Decimal
+	perShare decimal.Decimal
+}
+
+// AnalyzeWashSaleActivities runs AnalyzeWashSales on the FILL activities, e.g. the ones
+// returned by alpaca.Client.GetAccountActivities. The other activities are ignored.
+func AnalyzeWashSaleActivities(activities []alpaca.AccountActivity, opts WashSaleOpts) (*WashSaleReport, error) {
+	var fills []Fill
+	for _, a := range activities {
+		if f, ok := FillFromActivity(a); ok {
+			fills = append(fills, f)
+		}
+	}
+	return AnalyzeWashSales(fills, opts)
+}
+
+// AnalyzeWashSales finds the wash sales among the fills: the sales of long lots at a loss
+// matched with the purchases of the same symbol within the window around the sale, excluding
+// the purchase of the sold shares. The lots are closed FIFO, the replacement shares are matched
+// in the order they were purchased and each of them replaces at most one sold share.
+//
+// The disallowed loss of a wash sale is added to the cost basis of its replacement shares, so
+// it's deducted from the P&L of their own sale, which may be a wash sale too. The symbol is
+// the only criterion of substantially identical securities, and the splits are not applied.
+func AnalyzeWashSales(fills []Fill, opts WashSaleOpts) (*WashSaleReport, error) {
+	if opts.Window == 0 {
+		opts.Window = DefaultWashSaleWindow
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	fills = append([]Fill(nil), fills...)
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+	ledger := NewLedger(LedgerOpts{Location: opts.Location})
+	for i := range fills {
+		// The purchases are identified by the IDs of their lots
+		if fills[i].ID == "" {
+			fills[i].ID = "fill-" + strconv.Itoa(i)
+		}
+		if err := ledger.AddFill(fills[i]); err != nil {
+			return nil, fmt.Errorf("accounting: failed to build the lots: %w", err)
+		}
+	}
+	a := washSaleAnalysis{
+		opts:         opts,
+		replacements: replacements(fills, ledger.realized, opts.Location),
+		closed:       make(map[string]decimal.Decimal),
+		years:        make(map[int]*WashSaleYear),
+	}
+	for _, r := range ledger.realized {
+		a.realize(r)
+	}
+	report := &WashSaleReport{WashSales: a.washSales}
+	for _, year := range sortedYears(a.years) {
+		report.Years = append(report.Years, *a.years[year])
+	}
+	return report, nil
+}
+
+// replacements returns the purchases that opened long lots, by symbol, in chronological order.
+func replacements(fills []Fill, realized []Realization, loc *time.Location) map[string][]*replacement {
+	// The purchases closing short lots only open a lot with the rest of their qty
+	covered := make(map[string]decimal.Decimal)
+	for _, r := range realized {
+		if r.Qty.IsNegative() {
+			covered[r.FillID] = covered[r.FillID].Sub(r.Qty)
+		}
+	}
+	bySymbol := make(map[string][]*replacement)
+	for _, f := range fills {
+		qty := f.Qty.Sub(covered[f.ID])
+		if f.Side != alpaca.Buy || !qty.IsPositive() {
+			continue
+		}
+		bySymbol[f.Symbol] = append(bySymbol[f.Symbol], &replacement{
+			id:   f.ID,
+			time: f.Time,
+			date: civil.DateOf(f.Time.In(loc)),
+			qty:  qty,
+		})
+	}
+	return bySymbol
+}
+
+type washSaleAnalysis struct {
+	opts         WashSaleOpts
+	replacements map[string][]*replacement
+	// closed is the closed qty of each long lot
+	closed    map[string]decimal.Decimal
+	years     map[int]*WashSaleYear
+	washSales WashSales
+}
+
+func (a *washSaleAnalysis) year(t time.Time) *WashSaleYear {
+	year := t.In(a.opts.Location).Year()
+	y, ok := a.years[year]
+	if !ok {
+		y = &WashSaleYear{Year: year}
+		a.years[year] = y
+	}
+	return y
+}
+
+func (a *washSaleAnalysis) lot(symbol, id string) *replacement {
+	for _, p := range a.replacements[symbol] {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (a *washSaleAnalysis) realize(r Realization) {
+	pl := r.PL()
+	if r.Qty.IsPositive() {
+		a.closed[r.LotID] = a.closed[r.LotID].Add(r.Qty)
+		if p := a.lot(r.Symbol, r.LotID); p != nil {
+			pl = pl.Sub(p.consumeAdjustments(r.Qty))
+		}
+	}
+	y := a.year(r.ClosedAt)
+	y.RealizedPL = y.RealizedPL.Add(pl)
+	if !r.Qty.IsPositive() || !pl.IsNegative() {
+		return
+	}
+	lossPerShare := pl.Neg().Div(r.Qty)
+	saleDate := civil.DateOf(r.ClosedAt.In(a.opts.Location))
+	remaining := r.Qty
+	for _, p := range a.replacements[r.Symbol] {
+		if p.id == r.LotID || abs(p.date.DaysSince(saleDate)) > a.opts.Window {
+			continue
+		}
+		available := p.qty.Sub(p.used)
+		// The shares purchased before the sale must still be held after it
+		if !p.time.After(r.ClosedAt) {
+			available = decimal.Min(available, p.qty.Sub(a.closed[p.id]))
+		}
+		if !available.IsPositive() {
+			continue
+		}
+		qty := decimal.Min(available, remaining)
+		p.used = p.used.Add(qty)
+		p.adjustments = append(p.adjustments, basisAdjustment{qty: qty, perShare: lossPerShare})
+		disallowed := lossPerShare.Mul(qty)
+		a.washSales = append(a.washSales, WashSale{
+			Symbol:           r.Symbol,
+			SaleFillID:       r.FillID,
+			SoldLotID:        r.LotID,
+			SaleTime:         r.ClosedAt,
+			ReplacementLotID: p.id,
+			ReplacementTime:  p.time,
+			Qty:              qty,
+			DisallowedLoss:   disallowed,
+			BasisAdjustment:  lossPerShare,
+		})
+		y.DisallowedLoss = y.DisallowedLoss.Add(disallowed)
+		y.WashSales++
+		if remaining = remaining.Sub(qty); remaining.IsZero() {
+			return
+		}
+	}
+}
+
+// consumeAdjustments returns the basis adjustments of the qty of sold shares. The adjusted
+// shares are considered sold first.
+func (p *replacement) consumeAdjustments(qty decimal.Decimal) decimal.Decimal {
+	total := decimal.Zero
+	for len(p.adjustments) > 0 && qty.IsPositive() {
+		adj := &p.adjustments[0]
+		q := decimal.Min(adj.qty, qty)
+		total = total.Add(q.Mul(adj.perShare))
+		qty = qty.Sub(q)
+		if adj.qty = adj.qty.Sub(q); adj.qty.IsZero() {
+			p.adjustments = p.adjustments[1:]
+		}
+	}
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sortedYears(years map[int]*WashSaleYear) []int {
+	keys := make([]int, 0, len(years))
+	for year := range years {
+		keys = append(keys, year)
+	}
+	sort.Ints(keys)
+	return keys
+}
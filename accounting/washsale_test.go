@@ -0,0 +1,86 @@
+package accounting
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+func activity(id, symbol, side string, qty, price int64, date string) alpaca.AccountActivity {
+	at, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		panic(err)
+	}
+	return alpaca.AccountActivity{
+		ID: id, ActivityType: alpaca.ActivityFill, Symbol: symbol, Side: side, Qty: decimal.NewFromInt(qty),
+		Price: decimal.NewFromInt(price), TransactionTime: at.Add(15 * time.Hour),
+	}
+}
+
+func TestAnalyzeWashSales(t *testing.T) {
+	report, err := AnalyzeWashSaleActivities([]alpaca.AccountActivity{
+		activity("b1", "AAPL", "buy", 10, 100, "2024-01-02"),
+		activity("s1", "AAPL", "sell", 10, 90, "2024-01-10"),
+		// Replaces 6 of the 10 shares sold at a loss
+		activity("b2", "AAPL", "buy", 6, 95, "2024-01-20"),
+		// The adjusted basis turns the gain into a loss, without replacement
+		activity("s2", "AAPL", "sell", 6, 100, "2024-04-10"),
+		activity("b3", "AAPL", "buy", 5, 100, "2024-12-20"),
+		activity("s3", "AAPL", "sell", 5, 80, "2024-12-30"),
+		// Replaces the shares sold in the previous year
+		activity("b4", "AAPL", "buy", 5, 85, "2025-01-10"),
+		activity("s4", "AAPL", "sell", 5, 90, "2025-02-20"),
+		// The shares bought before the sale and still held replace the sold ones
+		activity("m1", "MSFT", "buy", 10, 100, "2025-03-03"),
+		activity("m2", "MSFT", "buy", 5, 100, "2025-03-05"),
+		activity("m3", "MSFT", "sell", 10, 90, "2025-03-10"),
+		// A short sale at a loss is not a wash sale
+		activity("t1", "TSLA", "sell_short", 1, 100, "2025-04-01"),
+		activity("t2", "TSLA", "buy", 1, 110, "2025-04-02"),
+	}, WashSaleOpts{})
+	require.NoError(t, err)
+
+	var sales []string
+	for _, ws := range report.WashSales {
+		sales = append(sales, ws.SaleFillID+" "+ws.ReplacementLotID+" "+ws.Qty.String()+" "+ws.DisallowedLoss.String()+
+			" "+ws.BasisAdjustment.String())
+	}
+	assert.Equal(t, []string{"s1 b2 6 60 10", "s3 b4 5 100 20", "m3 m2 5 50 10"}, sales)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Years.WriteCSV(&buf))
+	assert.Equal(t, "year,realized_pl,disallowed_loss,allowed_pl,wash_sales\n"+
+		"2024,-230,160,-70,2\n2025,-185,50,-135,1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, report.WashSales[:1].WriteCSV(&buf))
+	assert.Equal(t, "symbol,sale_fill_id,sold_lot_id,sale_time,replacement_lot_id,replacement_time,qty,"+
+		"disallowed_loss,basis_adjustment\n"+
+		"AAPL,s1,b1,2024-01-10T15:00:00Z,b2,2024-01-20T15:00:00Z,6,60,10\n", buf.String())
+}
+
+func TestAnalyzeWashSales_Window(t *testing.T) {
+	fills := []Fill{
+		fill("1", alpaca.Buy, 10, 100, 0),
+		fill("2", alpaca.Sell, 10, 90, 24*time.Hour),
+		fill("3", alpaca.Buy, 10, 90, 5*24*time.Hour),
+	}
+	report, err := AnalyzeWashSales(fills, WashSaleOpts{})
+	require.NoError(t, err)
+	assert.Len(t, report.WashSales, 1)
+
+	report, err = AnalyzeWashSales(fills, WashSaleOpts{Window: 3})
+	require.NoError(t, err)
+	assert.Empty(t, report.WashSales)
+	require.Len(t, report.Years, 1)
+	assert.Equal(t, "-100", report.Years[0].AllowedPL().String())
+
+	_, err = AnalyzeWashSales([]Fill{{Symbol: "AAPL", Side: alpaca.Buy}}, WashSaleOpts{})
+	require.Error(t, err)
+}
@@ -0,0 +1,170 @@
+// Package aggregate builds the bars of custom timeframes from the market data: it resamples
+// bars into coarser timeframes and builds bars from trades.
+//
+// The bars are aligned to the calendar of the time zone of Opts.Location, or to the trading
+// sessions of Opts.Sessions, which drops the data out of the sessions.
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// Opts contains the options of the aggregation.
+type Opts struct {
+	// Location is the time zone of the day, week and month boundaries. Defaults to
+	// America/New_York.
+	Location *time.Location
+	// Sessions, if set, align the bars to the trading sessions: the intraday bars start at the
+	// open of the sessions, the bars of N days span N sessions, and the data out of the sessions
+	// is dropped. The daily and longer bars, whose timestamp is the start of their day, belong to
+	// the session of their day. See SessionsFromCalendar.
+	Sessions []Session
+	// TradeFilter tells which values of the bars the trades update in BarsFromTrades. Defaults
+	// to SIPConditions.
+	TradeFilter TradeFilter
+}
+
+// Session is the trading session of a day.
+type Session struct {
+	Date  civil.Date
+	Open  time.Time
+	Close time.Time
+}
+
+// SessionsFromCalendar returns the sessions of the days of the market calendar, whose times are
+// in the time zone of the exchange, America/New_York. The times of the sessions are in UTC.
+func SessionsFromCalendar(calendar []alpaca.CalendarDay) ([]Session, error) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, fmt.Errorf("aggregate: failed to load the New York time zone: %w", err)
+	}
+	sessions := make([]Session, 0, len(calendar))
+	for _, day := range calendar {
+		date, err := civil.ParseDate(day.Date)
+		if err != nil {
+			return nil, fmt.Errorf("aggregate: invalid calendar date %q: %w", day.Date, err)
+		}
+		s := Session{Date: date}
+		if s.Open, err = sessionTime(date, day.Open, newYork); err != nil {
+			return nil, err
+		}
+		if s.Close, err = sessionTime(date, day.Close, newYork); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// sessionTime parses the open or close time of a calendar day, "15:04" or RFC 3339.
+func sessionTime(date civil.Date, value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := civil.ParseTime(value + ":00")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("aggregate: invalid session time %q of %s", value, date)
+	}
+	return civil.DateTime{Date: date, Time: t}.In(loc).UTC(), nil
+}
+
+// aligner finds the bar of a timeframe containing a time.
+type aligner struct {
+	tf       marketdata.TimeFrame
+	loc      *time.Location
+	sessions []Session
+}
+
+func newAligner(tf marketdata.TimeFrame, opts Opts) (*aligner, error) {
+	if tf.N <= 0 {
+		return nil, errors.New("aggregate: the timeframe must be positive")
+	}
+	switch tf.Unit {
+	case marketdata.Min, marketdata.Hour, marketdata.Day, marketdata.Week, marketdata.Month:
+	default:
+		return nil, fmt.Errorf("aggregate: invalid timeframe unit %q", tf.Unit)
+	}
+	a := &aligner{tf: tf, loc: opts.Location}
+	if a.loc == nil {
+		var err error
+		if a.loc, err = time.LoadLocation("America/New_York"); err != nil {
+			return nil, fmt.Errorf("aggregate: failed to load the New York time zone: %w", err)
+		}
+	}
+	if len(opts.Sessions) > 0 {
+		a.sessions = append([]Session(nil), opts.Sessions...)
+		sort.Slice(a.sessions, func(i, j int) bool { return a.sessions[i].Date.Before(a.sessions[j].Date) })
+	}
+	return a, nil
+}
+
+// epoch is the origin of the bars of several days, weeks or months without sessions. It's a
+// Monday.
+var epoch = civil.Date{Year: 1970, Month: 1, Day: 5}
+
+// start returns the start of the bar containing t, and false if t is out of the sessions.
+func (a *aligner) start(t time.Time) (time.Time, bool) {
+	date := civil.DateOf(t.In(a.loc))
+	if a.sessions == nil {
+		return a.calendarStart(t, date), true
+	}
+	i := sort.Search(len(a.sessions), func(i int) bool { return !a.sessions[i].Date.Before(date) })
+	if i == len(a.sessions) || a.sessions[i].Date != date {
+		return time.Time{}, false
+	}
+	s := a.sessions[i]
+	intraday := a.tf.Unit == marketdata.Min || a.tf.Unit == marketdata.Hour
+	inSession := !t.Before(s.Open) && t.Before(s.Close)
+	// The daily bars, stamped at the start of the day, are in the session of their day
+	if !inSession && (intraday || !t.Equal(date.In(a.loc))) {
+		return time.Time{}, false
+	}
+	switch a.tf.Unit {
+	case marketdata.Min, marketdata.Hour:
+		d := a.duration()
+		return s.Open.Add(t.Sub(s.Open) / d * d).UTC(), true
+	case marketdata.Day:
+		return a.sessions[i-i%a.tf.N].Date.In(a.loc).UTC(), true
+	default:
+		return a.calendarStart(t, date), true
+	}
+}
+
+func (a *aligner) duration() time.Duration {
+	if a.tf.Unit == marketdata.Hour {
+		return time.Duration(a.tf.N) * time.Hour
+	}
+	return time.Duration(a.tf.N) * time.Minute
+}
+
+// calendarStart returns the start of the bar containing t, of date, aligned to the calendar.
+func (a *aligner) calendarStart(t time.Time, date civil.Date) time.Time {
+	n := a.tf.N
+	switch a.tf.Unit {
+	case marketdata.Min, marketdata.Hour:
+		dayStart := date.In(a.loc)
+		d := a.duration()
+		return dayStart.Add(t.Sub(dayStart) / d * d).UTC()
+	case marketdata.Day:
+		date = date.AddDays(-mod(date.DaysSince(epoch), n))
+	case marketdata.Week:
+		date = date.AddDays(-mod(date.DaysSince(epoch), 7*n))
+	default:
+		months := date.Year*12 + int(date.Month) - 1
+		months -= mod(months, n)
+		date = civil.Date{Year: months / 12, Month: time.Month(months%12 + 1), Day: 1}
+	}
+	return date.In(a.loc).UTC()
+}
+
+func mod(a, n int) int {
+	return ((a % n) + n) % n
+}
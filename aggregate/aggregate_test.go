@@ -0,0 +1,150 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+func newYork(t *testing.T) *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	return loc
+}
+
+func minuteBar(t *testing.T, day, hour, minute int, price float64, volume uint64) marketdata.Bar {
+	return marketdata.Bar{
+		Timestamp: time.Date(2024, 3, day, hour, minute, 0, 0, newYork(t)).UTC(),
+		Open:      price, High: price + 1, Low: price - 1, Close: price,
+		Volume: volume, TradeCount: 1, VWAP: price,
+	}
+}
+
+func TestResampleBars(t *testing.T) {
+	var bars []marketdata.Bar
+	for i := 0; i < 10; i++ {
+		bars = append(bars, minuteBar(t, 11, 9, 30+i, float64(100+i), uint64(100*(i+1))))
+	}
+
+	resampled, err := ResampleBars(bars, marketdata.NewTimeFrame(3, marketdata.Min), Opts{})
+	require.NoError(t, err)
+	require.Len(t, resampled, 4)
+	assert.Equal(t, marketdata.Bar{
+		Timestamp:  time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC),
+		Open:       100,
+		High:       103,
+		Low:        99,
+		Close:      102,
+		Volume:     600,
+		TradeCount: 3,
+		VWAP:       (100*100 + 101*200 + 102*300) / 600.0,
+	}, resampled[0])
+	assert.Equal(t, time.Date(2024, 3, 11, 13, 39, 0, 0, time.UTC), resampled[3].Timestamp)
+	assert.Equal(t, uint64(1000), resampled[3].Volume)
+
+	// The bars of the days are aligned to midnight in New York
+	daily, err := ResampleBars(bars, marketdata.OneDay, Opts{})
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC), daily[0].Timestamp)
+	assert.Equal(t, uint64(5500), daily[0].Volume)
+
+	_, err = ResampleBars(bars, marketdata.TimeFrame{}, Opts{})
+	require.Error(t, err)
+}
+
+func TestResampleBars_Calendar(t *testing.T) {
+	day := func(d int) marketdata.Bar {
+		return marketdata.Bar{Timestamp: civil.Date{Year: 2024, Month: 3, Day: d}.In(newYork(t)).UTC(), Close: float64(d)}
+	}
+	bars := []marketdata.Bar{day(8), day(11), day(12), day(28)}
+
+	weekly, err := ResampleBars(bars, marketdata.OneWeek, Opts{})
+	require.NoError(t, err)
+	require.Len(t, weekly, 3)
+	assert.Equal(t, day(4).Timestamp, weekly[0].Timestamp)
+	assert.Equal(t, day(11).Timestamp, weekly[1].Timestamp)
+	assert.Equal(t, float64(12), weekly[1].Close)
+
+	quarterly, err := ResampleBars(bars, marketdata.NewTimeFrame(3, marketdata.Month), Opts{Location: time.UTC})
+	require.NoError(t, err)
+	require.Len(t, quarterly, 1)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), quarterly[0].Timestamp)
+	assert.Equal(t, float64(28), quarterly[0].Close)
+}
+
+func TestResampleBars_Sessions(t *testing.T) {
+	sessions, err := SessionsFromCalendar([]alpaca.CalendarDay{
+		{Date: "2024-03-08", Open: "09:30", Close: "16:00"},
+		{Date: "2024-03-11", Open: "09:30", Close: "16:00"},
+		{Date: "2024-03-12", Open: "09:30", Close: "13:00"},
+	})
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, time.Date(2024, 3, 8, 14, 30, 0, 0, time.UTC), sessions[0].Open)
+	assert.Equal(t, time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC), sessions[1].Open)
+	opts := Opts{Sessions: sessions}
+
+	bars := []marketdata.Bar{
+		minuteBar(t, 11, 9, 0, 100, 1),
+		minuteBar(t, 11, 9, 30, 101, 1),
+		minuteBar(t, 11, 11, 29, 102, 1),
+		minuteBar(t, 11, 11, 30, 103, 1),
+		minuteBar(t, 11, 15, 59, 104, 1),
+		minuteBar(t, 11, 16, 0, 105, 1),
+	}
+	resampled, err := ResampleBars(bars, marketdata.NewTimeFrame(2, marketdata.Hour), opts)
+	require.NoError(t, err)
+	require.Len(t, resampled, 3)
+	// The minute bars at midnight are out of the session too
+	midnight, err := ResampleBars(append([]marketdata.Bar{minuteBar(t, 11, 0, 0, 99, 1)}, bars...),
+		marketdata.NewTimeFrame(2, marketdata.Hour), opts)
+	require.NoError(t, err)
+	assert.Equal(t, resampled, midnight)
+	assert.Equal(t, time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC), resampled[0].Timestamp)
+	assert.Equal(t, uint64(2), resampled[0].Volume)
+	assert.Equal(t, time.Date(2024, 3, 11, 15, 30, 0, 0, time.UTC), resampled[1].Timestamp)
+	assert.Equal(t, time.Date(2024, 3, 11, 19, 30, 0, 0, time.UTC), resampled[2].Timestamp)
+
+	// The daily bars of the days without session are dropped
+	day := func(d int) marketdata.Bar {
+		return marketdata.Bar{Timestamp: civil.Date{Year: 2024, Month: 3, Day: d}.In(newYork(t)).UTC(), Volume: 1}
+	}
+	resampled, err = ResampleBars([]marketdata.Bar{day(8), day(9), day(11), day(12)},
+		marketdata.NewTimeFrame(2, marketdata.Day), opts)
+	require.NoError(t, err)
+	require.Len(t, resampled, 2)
+	assert.Equal(t, day(8).Timestamp, resampled[0].Timestamp)
+	assert.Equal(t, uint64(2), resampled[0].Volume)
+	assert.Equal(t, day(12).Timestamp, resampled[1].Timestamp)
+}
+
+func TestResampleCryptoAndOptionBars(t *testing.T) {
+	start := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	crypto := []marketdata.CryptoBar{
+		{Timestamp: start.Add(time.Hour), Open: 1, High: 2, Low: 1, Close: 2, Volume: 0.5, VWAP: 1.5},
+		{Timestamp: start, Open: 3, High: 3, Low: 2, Close: 2, Volume: 1.5, VWAP: 2.5},
+	}
+	resampled, err := ResampleCryptoBars(crypto, marketdata.OneDay, Opts{Location: time.UTC})
+	require.NoError(t, err)
+	assert.Equal(t, []marketdata.CryptoBar{
+		{Timestamp: start, Open: 3, High: 3, Low: 1, Close: 2, Volume: 2, VWAP: 2.25},
+	}, resampled)
+
+	options := []marketdata.OptionBar{
+		{Timestamp: start, Open: 1, High: 1, Low: 1, Close: 1, Volume: 3, TradeCount: 2, VWAP: 1},
+		{Timestamp: start.Add(time.Hour), Open: 2, High: 2, Low: 2, Close: 2, Volume: 1, TradeCount: 1, VWAP: 2},
+	}
+	optionBars, err := ResampleOptionBars(options, marketdata.NewTimeFrame(2, marketdata.Hour), Opts{Location: time.UTC})
+	require.NoError(t, err)
+	require.Len(t, optionBars, 1)
+	assert.Equal(t, uint64(4), optionBars[0].Volume)
+	assert.Equal(t, uint64(3), optionBars[0].TradeCount)
+	assert.InDelta(t, 1.25, optionBars[0].VWAP, 1e-9)
+}
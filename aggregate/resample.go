@@ -0,0 +1,125 @@
+package aggregate
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// bar is the common form of the bars of all the asset classes.
+type bar struct {
+	Timestamp  time.Time
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Volume     float64
+	TradeCount uint64
+	VWAP       float64
+}
+
+// merge adds the next bar b to the bar.
+func (m *bar) merge(b bar) {
+	m.High = math.Max(m.High, b.High)
+	m.Low = math.Min(m.Low, b.Low)
+	m.Close = b.Close
+	if volume := m.Volume + b.Volume; volume > 0 {
+		m.VWAP = (m.VWAP*m.Volume + b.VWAP*b.Volume) / volume
+	}
+	m.Volume += b.Volume
+	m.TradeCount += b.TradeCount
+}
+
+func resample(bars []bar, tf marketdata.TimeFrame, opts Opts) ([]bar, error) {
+	a, err := newAligner(tf, opts)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Timestamp.Before(bars[j].Timestamp) })
+	var resampled []bar
+	for _, b := range bars {
+		start, ok := a.start(b.Timestamp)
+		if !ok {
+			continue
+		}
+		if n := len(resampled); n > 0 && resampled[n-1].Timestamp.Equal(start) {
+			resampled[n-1].merge(b)
+			continue
+		}
+		b.Timestamp = start
+		resampled = append(resampled, b)
+	}
+	return resampled, nil
+}
+
+// ResampleBars aggregates the bars into bars of the timeframe, which must be a multiple of
+// theirs. The VWAP of the bars is weighted by their volume.
+func ResampleBars(bars []marketdata.Bar, tf marketdata.TimeFrame, opts Opts) ([]marketdata.Bar, error) {
+	in := make([]bar, len(bars))
+	for i, b := range bars {
+		in[i] = bar{
+			Timestamp: b.Timestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close,
+			Volume: float64(b.Volume), TradeCount: b.TradeCount, VWAP: b.VWAP,
+		}
+	}
+	out, err := resample(in, tf, opts)
+	if err != nil {
+		return nil, err
+	}
+	resampled := make([]marketdata.Bar, len(out))
+	for i, b := range out {
+		resampled[i] = marketdata.Bar{
+			Timestamp: b.Timestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close,
+			Volume: uint64(b.Volume), TradeCount: b.TradeCount, VWAP: b.VWAP,
+		}
+	}
+	return resampled, nil
+}
+
+// ResampleCryptoBars aggregates the crypto bars into bars of the timeframe, which must be a
+// multiple of theirs. The VWAP of the bars is weighted by their volume.
+func ResampleCryptoBars(
+	bars []marketdata.CryptoBar, tf marketdata.TimeFrame, opts Opts,
+) ([]marketdata.CryptoBar, error) {
+	in := make([]bar, len(bars))
+	for i, b := range bars {
+		in[i] = bar(b)
+	}
+	out, err := resample(in, tf, opts)
+	if err != nil {
+		return nil, err
+	}
+	resampled := make([]marketdata.CryptoBar, len(out))
+	for i, b := range out {
+		resampled[i] = marketdata.CryptoBar(b)
+	}
+	return resampled, nil
+}
+
+// ResampleOptionBars aggregates the option bars into bars of the timeframe, which must be a
+// multiple of theirs. The VWAP of the bars is weighted by their volume.
+func ResampleOptionBars(
+	bars []marketdata.OptionBar, tf marketdata.TimeFrame, opts Opts,
+) ([]marketdata.OptionBar, error) {
+	in := make([]bar, len(bars))
+	for i, b := range bars {
+		in[i] = bar{
+			Timestamp: b.Timestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close,
+			Volume: float64(b.Volume), TradeCount: b.TradeCount, VWAP: b.VWAP,
+		}
+	}
+	out, err := resample(in, tf, opts)
+	if err != nil {
+		return nil, err
+	}
+	resampled := make([]marketdata.OptionBar, len(out))
+	for i, b := range out {
+		resampled[i] = marketdata.OptionBar{
+			Timestamp: b.Timestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close,
+			Volume: uint64(b.Volume), TradeCount: b.TradeCount, VWAP: b.VWAP,
+		}
+	}
+	return resampled, nil
+}
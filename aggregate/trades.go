@@ -0,0 +1,157 @@
+package aggregate
+
+import (
+	"math"
+	"sort"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// Updates are the values of a bar updated by a trade.
+type Updates struct {
+	// HighLow is whether the trade updates the high and low prices.
+	HighLow bool
+	// Last is whether the trade updates the open and close prices.
+	Last bool
+	// Volume is whether the trade updates the volume, the trade count and the VWAP.
+	Volume bool
+}
+
+// TradeFilter returns the values of a bar updated by a trade.
+type TradeFilter func(t marketdata.Trade) Updates
+
+var all = Updates{HighLow: true, Last: true, Volume: true}
+
+// The updates of the sale conditions of the CTA plan (tapes A and B), the others update all the
+// values.
+var ctaConditions = map[string]Updates{
+	"B": {Volume: true},
+	"C": {Volume: true},
+	"G": {HighLow: true, Volume: true},
+	"H": {Volume: true},
+	"I": {Volume: true},
+	"M": {},
+	"N": {Volume: true},
+	"P": {HighLow: true, Volume: true},
+	"Q": {},
+	"R": {Volume: true},
+	"T": {Volume: true},
+	"U": {Volume: true},
+	"V": {Volume: true},
+	"Z": {HighLow: true, Volume: true},
+	"4": {HighLow: true, Volume: true},
+	"7": {Volume: true},
+	"9": {HighLow: true, Last: true},
+}
+
+// The updates of the sale conditions of the UTP plan (tape C), the others update all the values.
+var utpConditions = map[string]Updates{
+	"C": {Volume: true},
+	"G": {HighLow: true, Volume: true},
+	"H": {Volume: true},
+	"I": {Volume: true},
+	"M": {},
+	"N": {Volume: true},
+	"P": {HighLow: true, Volume: true},
+	"Q": {},
+	"R": {Volume: true},
+	"T": {Volume: true},
+	"U": {Volume: true},
+	"V": {Volume: true},
+	"W": {Volume: true},
+	"Z": {HighLow: true, Volume: true},
+	"4": {HighLow: true, Volume: true},
+	"7": {Volume: true},
+	"9": {HighLow: true, Last: true},
+}
+
+// SIPConditions is the TradeFilter of the bars of Alpaca: it applies the rules of the SIPs
+// (the CTA plan for the tapes A and B, the UTP plan for the tape C) to the sale conditions of
+// the trade. A trade only updates the values that all its conditions update. The canceled and
+// incorrect trades update nothing.
+func SIPConditions(t marketdata.Trade) Updates {
+	if t.Update == "canceled" || t.Update == "incorrect" {
+		return Updates{}
+	}
+	conditions := ctaConditions
+	if t.Tape == "C" {
+		conditions = utpConditions
+	}
+	u := all
+	for _, c := range t.Conditions {
+		if cu, ok := conditions[c]; ok {
+			u.HighLow = u.HighLow && cu.HighLow
+			u.Last = u.Last && cu.Last
+			u.Volume = u.Volume && cu.Volume
+		}
+	}
+	return u
+}
+
+// BarsFromTrades builds the bars of the timeframe from the trades, filtered by
+// Opts.TradeFilter. The bars without any trade updating the last price are left out.
+func BarsFromTrades(trades []marketdata.Trade, tf marketdata.TimeFrame, opts Opts) ([]marketdata.Bar, error) {
+	a, err := newAligner(tf, opts)
+	if err != nil {
+		return nil, err
+	}
+	filter := opts.TradeFilter
+	if filter == nil {
+		filter = SIPConditions
+	}
+	sorted := append([]marketdata.Trade(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	type building struct {
+		bar      marketdata.Bar
+		priced   bool
+		last     bool
+		notional float64
+	}
+	var (
+		bars []marketdata.Bar
+		cur  *building
+	)
+	flush := func() {
+		if cur != nil && cur.last {
+			if cur.bar.Volume > 0 {
+				cur.bar.VWAP = cur.notional / float64(cur.bar.Volume)
+			}
+			bars = append(bars, cur.bar)
+		}
+	}
+	for _, t := range sorted {
+		u := filter(t)
+		if u == (Updates{}) {
+			continue
+		}
+		start, ok := a.start(t.Timestamp)
+		if !ok {
+			continue
+		}
+		if cur == nil || !cur.bar.Timestamp.Equal(start) {
+			flush()
+			cur = &building{bar: marketdata.Bar{Timestamp: start}}
+		}
+		b := &cur.bar
+		if u.HighLow || u.Last {
+			if !cur.priced {
+				b.High, b.Low, cur.priced = t.Price, t.Price, true
+			}
+			b.High, b.Low = math.Max(b.High, t.Price), math.Min(b.Low, t.Price)
+		}
+		if u.Last {
+			if !cur.last {
+				b.Open, cur.last = t.Price, true
+			}
+			b.Close = t.Price
+		}
+		if u.Volume {
+			b.Volume += uint64(t.Size)
+			b.TradeCount++
+			cur.notional += t.Price * float64(t.Size)
+		}
+	}
+	flush()
+	return bars, nil
+}
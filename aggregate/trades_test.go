@@ -0,0 +1,70 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+func TestSIPConditions(t *testing.T) {
+	assert.Equal(t, all, SIPConditions(marketdata.Trade{Tape: "A", Conditions: []string{" "}}))
+	assert.Equal(t, Updates{Volume: true}, SIPConditions(marketdata.Trade{Tape: "A", Conditions: []string{" ", "I"}}))
+	assert.Equal(t, Updates{}, SIPConditions(marketdata.Trade{Tape: "B", Conditions: []string{"M"}}))
+	assert.Equal(t, Updates{HighLow: true, Volume: true},
+		SIPConditions(marketdata.Trade{Tape: "C", Conditions: []string{"Z"}}))
+	// Average price trades are "W" on the tape C, but "B" on the tapes A and B
+	assert.Equal(t, Updates{Volume: true}, SIPConditions(marketdata.Trade{Tape: "C", Conditions: []string{"W"}}))
+	assert.Equal(t, all, SIPConditions(marketdata.Trade{Tape: "C", Conditions: []string{"B"}}))
+	assert.Equal(t, Updates{}, SIPConditions(marketdata.Trade{Tape: "A", Update: "canceled"}))
+}
+
+func TestBarsFromTrades(t *testing.T) {
+	start := time.Date(2024, 3, 11, 14, 0, 0, 0, time.UTC)
+	trade := func(seconds int, price float64, size uint32, conditions ...string) marketdata.Trade {
+		return marketdata.Trade{
+			Timestamp: start.Add(time.Duration(seconds) * time.Second), Price: price, Size: size,
+			Tape: "A", Conditions: append([]string{"@"}, conditions...),
+		}
+	}
+	trades := []marketdata.Trade{
+		trade(1, 100, 100),
+		trade(2, 101, 10, "I"),
+		trade(3, 200, 1000, "M"),
+		trade(4, 99, 50, "Z"),
+		trade(5, 100.5, 100),
+		{Timestamp: start.Add(6 * time.Second), Price: 300, Size: 100, Tape: "A", Update: "canceled"},
+		trade(65, 102, 200),
+		// Only updates the volume
+		trade(130, 103, 5, "I"),
+	}
+
+	bars, err := BarsFromTrades(trades, marketdata.OneMin, Opts{})
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.Equal(t, marketdata.Bar{
+		Timestamp:  start,
+		Open:       100,
+		High:       100.5,
+		Low:        99,
+		Close:      100.5,
+		Volume:     260,
+		TradeCount: 4,
+		VWAP:       (100*100 + 101*10 + 99*50 + 100.5*100) / 260,
+	}, bars[0])
+	assert.Equal(t, start.Add(time.Minute), bars[1].Timestamp)
+	assert.Equal(t, float64(102), bars[1].VWAP)
+
+	// Custom filter
+	bars, err = BarsFromTrades(trades, marketdata.NewTimeFrame(5, marketdata.Min), Opts{
+		TradeFilter: func(marketdata.Trade) Updates { return all },
+	})
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.Equal(t, float64(300), bars[0].High)
+	assert.Equal(t, uint64(8), bars[0].TradeCount)
+	assert.Equal(t, float64(103), bars[0].Close)
+}
@@ -0,0 +1,180 @@
+package alpaca
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultReconcileInterval is the interval of the reconciliations of an AccountState if
+// AccountStateOpts.ReconcileInterval is not set.
+const DefaultReconcileInterval = time.Minute
+
+// AccountStateOpts contains the options of an AccountState.
+type AccountStateOpts struct {
+	// ReconcileInterval is the interval the books are reconciled with the API at.
+	// Defaults to DefaultReconcileInterval. A negative value disables the periodic reconciliation.
+	ReconcileInterval time.Duration
+	// OnDrift is called with the differences found by the periodic reconciliations, after
+	// the books have been corrected.
+	OnDrift func(Drift)
+	// OnUpdate is called for each trade update, after it has been applied to the books.
+	OnUpdate func(TradeUpdate)
+	// StreamOptions configure the trade updates stream, e.g. its logger, which also logs the
+	// failed reconciliations.
+	StreamOptions []StreamOption
+}
+
+// AccountState keeps an in-memory view of the open orders and positions of the account, so
+// that they don't have to be requested from the API. The books are loaded from the API
+// (GetOrders and GetPositions) on Connect, then updated by the trade updates stream and
+// periodically reconciled with the API. The differences found by the reconciliations are
+// reported to OnDrift.
+type AccountState struct {
+	Orders    *OrderBook
+	Positions *PositionBook
+
+	client     *Client
+	opts       AccountStateOpts
+	streamOpts streamOptions
+	// reconciling serializes the reconciliations
+	reconciling sync.Mutex
+	// connecting serializes the calls to Connect, connected is set once one succeeded
+	connecting sync.Mutex
+	connected  bool
+	terminated chan error
+}
+
+// NewAccountState returns a new AccountState using the client. After constructing,
+// Connect must be called.
+func (c *Client) NewAccountState(opts AccountStateOpts) *AccountState {
+	if opts.ReconcileInterval == 0 {
+		opts.ReconcileInterval = DefaultReconcileInterval
+	}
+	o := defaultStreamOptions()
+	for _, opt := range opts.StreamOptions {
+		opt.applyStream(&o)
+	}
+	return &AccountState{
+		Orders:     NewOrderBook(nil),
+		Positions:  NewPositionBook(nil),
+		client:     c,
+		opts:       opts,
+		streamOpts: o,
+		terminated: make(chan error, 1),
+	}
+}
+
+// Connect starts streaming the trade updates and loads the books from the API. It blocks until
+// the books have been loaded (or it failed to do so). The state is kept up to date until ctx is
+// canceled or the trade updates stream terminates. Connect can be called again after it failed,
+// but not after it succeeded, even once the state has terminated.
+func (s *AccountState) Connect(ctx context.Context) error {
+	s.connecting.Lock()
+	defer s.connecting.Unlock()
+	if s.connected {
+		return errors.New("alpaca: the account state is already connected")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	connected := make(chan struct{})
+	var once sync.Once
+	onConnect := s.streamOpts.connectCallback
+	// The books are loaded once the stream is connected, so that no update is missed
+	streamOpts := append(append([]StreamOption(nil), s.opts.StreamOptions...), WithConnectCallback(func() {
+		once.Do(func() { close(connected) })
+		if onConnect != nil {
+			onConnect()
+		}
+	}))
+	sub := s.client.StreamTradeUpdatesInBackground(ctx, s.apply, streamOpts...)
+	select {
+	case <-connected:
+	case err := <-sub.Terminated():
+		cancel()
+		if err == nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("alpaca: failed to connect to the trade updates stream: %w", err)
+	}
+	if _, err := s.reconcile(ctx); err != nil {
+		cancel()
+		<-sub.Terminated()
+		return err
+	}
+	s.connected = true
+	go s.maintain(ctx, cancel, sub)
+	return nil
+}
+
+// Terminated returns a channel that the state sends an error to when it has terminated. The
+// error is nil if the context was canceled. The channel is also closed upon termination.
+func (s *AccountState) Terminated() <-chan error {
+	return s.terminated
+}
+
+// Reconcile reconciles the books with the API: they are replaced by the open orders and the
+// positions returned by the API, and the differences are returned. Unlike the periodic
+// reconciliations, OnDrift isn't called.
+func (s *AccountState) Reconcile(ctx context.Context) (Drift, error) {
+	return s.reconcile(ctx)
+}
+
+func (s *AccountState) reconcile(ctx context.Context) (Drift, error) {
+	s.reconciling.Lock()
+	defer s.reconciling.Unlock()
+	ordersVersion, positionsVersion := s.Orders.version(), s.Positions.version()
+	orders, err := s.client.GetAllOrdersWithContext(ctx, GetOrdersRequest{Status: "open"})
+	if err != nil {
+		return Drift{}, fmt.Errorf("alpaca: failed to get the open orders: %w", err)
+	}
+	positions, err := s.client.GetPositionsWithContext(ctx)
+	if err != nil {
+		return Drift{}, fmt.Errorf("alpaca: failed to get the positions: %w", err)
+	}
+	return Drift{
+		Orders:    s.Orders.reconcile(orders, ordersVersion),
+		Positions: s.Positions.reconcile(positions, positionsVersion),
+	}, nil
+}
+
+func (s *AccountState) apply(tu TradeUpdate) {
+	s.Orders.Apply(tu)
+	s.Positions.Apply(tu)
+	if s.opts.OnUpdate != nil {
+		s.opts.OnUpdate(tu)
+	}
+}
+
+func (s *AccountState) maintain(ctx context.Context, cancel context.CancelFunc, sub *TradeUpdatesSubscription) {
+	defer cancel()
+	var tick <-chan time.Time
+	if s.opts.ReconcileInterval > 0 {
+		ticker := time.NewTicker(s.opts.ReconcileInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case err := <-sub.Terminated():
+			s.terminated <- err
+			close(s.terminated)
+			return
+		case <-tick:
+			drift, err := s.reconcile(ctx)
+			switch {
+			case err != nil:
+				if ctx.Err() == nil {
+					s.streamOpts.logger.Errorf("alpaca: failed to reconcile the account state: %v", err)
+				}
+			case !drift.IsEmpty():
+				s.streamOpts.logger.Warnf("alpaca: the account state drifted from the API (%d orders, %d positions)",
+					len(drift.Orders), len(drift.Positions))
+				if s.opts.OnDrift != nil {
+					s.opts.OnDrift(drift)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package alpaca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accountServer serves the open orders, the positions and the trade updates of an account.
+type accountServer struct {
+	mu        sync.Mutex
+	orders    []Order
+	positions []Position
+	updates   chan TradeUpdate
+}
+
+func (s *accountServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	orders, positions := s.orders, s.positions
+	s.mu.Unlock()
+	switch r.URL.Path {
+	case "/v2/orders":
+		if r.URL.Query().Get("status") != "open" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(orders)
+	case "/v2/positions":
+		_ = json.NewEncoder(w).Encode(positions)
+	case "/v2/events/trades":
+		flusher := w.(http.Flusher)
+		flusher.Flush()
+		for {
+			select {
+			case tu := <-s.updates:
+				b, _ := json.Marshal(tu)
+				fmt.Fprintf(w, "data: %s\n\n", b)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *accountServer) set(orders []Order, positions []Position) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders, s.positions = orders, positions
+}
+
+func TestAccountState(t *testing.T) {
+	srv := &accountServer{updates: make(chan TradeUpdate)}
+	srv.set([]Order{bookOrder("1", OrderNew, 0, 0)}, []Position{{Symbol: "AAPL", Qty: decimal.NewFromInt(5)}})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	updated := make(chan TradeUpdate, 10)
+	drifts := make(chan Drift, 10)
+	s := c.NewAccountState(AccountStateOpts{
+		ReconcileInterval: 20 * time.Millisecond,
+		OnUpdate:          func(tu TradeUpdate) { updated <- tu },
+		OnDrift:           func(d Drift) { drifts <- d },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, s.Connect(ctx))
+
+	require.Len(t, s.Orders.Orders(), 1)
+	p, ok := s.Positions.Get("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, "5", p.Qty.String())
+
+	// The trade updates are applied
+	filled := bookOrder("1", OrderFilled, 10, time.Second)
+	srv.set(nil, []Position{{Symbol: "AAPL", Qty: decimal.NewFromInt(15)}})
+	srv.updates <- TradeUpdate{
+		EventID: "01", Event: TradeEventFill, Order: filled,
+		PositionQty: decimalPtr(decimal.NewFromInt(15)), Price: decimalPtr(decimal.NewFromInt(100)),
+	}
+	select {
+	case <-updated:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "no trade update received")
+	}
+	assert.Empty(t, s.Orders.Orders())
+	p, _ = s.Positions.Get("AAPL")
+	assert.Equal(t, "15", p.Qty.String())
+
+	// A change without trade update is reported as drift by the next reconciliation
+	srv.set(nil, []Position{{Symbol: "AAPL", Qty: decimal.NewFromInt(15)}, {Symbol: "MSFT", Qty: decimal.NewFromInt(1)}})
+	select {
+	case d := <-drifts:
+		assert.Empty(t, d.Orders)
+		assert.Equal(t, []PositionDrift{{Symbol: "MSFT", RemoteQty: decimal.NewFromInt(1)}}, d.Positions)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "no drift reported")
+	}
+	assert.Len(t, s.Positions.Positions(), 2)
+	d, err := s.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.True(t, d.IsEmpty())
+
+	cancel()
+	select {
+	case err := <-s.Terminated():
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "not terminated")
+	}
+}
+
+func TestAccountState_ConnectError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"code":40110000,"message":"request is not authorized"}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	s := c.NewAccountState(AccountStateOpts{})
+	err := s.Connect(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	// A failed Connect can be retried
+	err = s.Connect(context.Background())
+	require.ErrorAs(t, err, &apiErr)
+}
+
+func TestAccountState_ConnectTwice(t *testing.T) {
+	srv := &accountServer{updates: make(chan TradeUpdate)}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	s := NewClient(ClientOpts{BaseURL: ts.URL}).NewAccountState(AccountStateOpts{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, s.Connect(ctx))
+	err := s.Connect(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already connected")
+
+	cancel()
+	select {
+	case err := <-s.Terminated():
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "not terminated")
+	}
+	// The state can't be reconnected once terminated either
+	require.Error(t, s.Connect(context.Background()))
+}
@@ -0,0 +1,97 @@
+package alpaca
+
+import (
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+)
+
+// TradeActivity is an account activity resulting from an order fill (FILL).
+type TradeActivity struct {
+	ID              string
+	TransactionTime time.Time
+	// Type is either "fill" or "partial_fill".
+	Type        string
+	Symbol      string
+	Side        Side
+	Price       decimal.Decimal
+	Qty         decimal.Decimal
+	LeavesQty   decimal.Decimal
+	CumQty      decimal.Decimal
+	OrderID     string
+	OrderStatus OrderStatus
+}
+
+// NonTradeActivity is an account activity not resulting from an order fill,
+// e.g. a dividend, a fee or a cash journal.
+type NonTradeActivity struct {
+	ID           string
+	ActivityType ActivityType
+	Date         civil.Date
+	NetAmount    decimal.Decimal
+	Description  string
+	// Symbol, Qty and PerShareAmount are only set for activities related to an asset, e.g. dividends.
+	Symbol         string
+	Qty            decimal.Decimal
+	PerShareAmount decimal.Decimal
+	Status         string
+}
+
+// IsTrade reports whether the activity is an order fill.
+func (a AccountActivity) IsTrade() bool {
+	return a.ActivityType == ActivityFill
+}
+
+// Trade returns the activity as a TradeActivity. It returns false if the activity is not a trade.
+func (a AccountActivity) Trade() (TradeActivity, bool) {
+	if !a.IsTrade() {
+		return TradeActivity{}, false
+	}
+	return TradeActivity{
+		ID:              a.ID,
+		TransactionTime: a.TransactionTime,
+		Type:            a.Type,
+		Symbol:          a.Symbol,
+		Side:            Side(a.Side),
+		Price:           a.Price,
+		Qty:             a.Qty,
+		LeavesQty:       a.LeavesQty,
+		CumQty:          a.CumQty,
+		OrderID:         a.OrderID,
+		OrderStatus:     OrderStatus(a.OrderStatus),
+	}, true
+}
+
+// NonTrade returns the activity as a NonTradeActivity. It returns false if the activity is a trade.
+func (a AccountActivity) NonTrade() (NonTradeActivity, bool) {
+	if a.IsTrade() {
+		return NonTradeActivity{}, false
+	}
+	return NonTradeActivity{
+		ID:             a.ID,
+		ActivityType:   a.ActivityType,
+		Date:           a.Date,
+		NetAmount:      a.NetAmount,
+		Description:    a.Description,
+		Symbol:         a.Symbol,
+		Qty:            a.Qty,
+		PerShareAmount: a.PerShareAmount,
+		Status:         a.Status,
+	}, true
+}
+
+// SplitActivities separates the trade activities from the non-trade activities,
+// preserving their order.
+func SplitActivities(activities []AccountActivity) ([]TradeActivity, []NonTradeActivity) {
+	var trades []TradeActivity
+	var nonTrades []NonTradeActivity
+	for _, a := range activities {
+		if t, ok := a.Trade(); ok {
+			trades = append(trades, t)
+		} else if nt, ok := a.NonTrade(); ok {
+			nonTrades = append(nonTrades, nt)
+		}
+	}
+	return trades, nonTrades
+}
@@ -0,0 +1,69 @@
+package alpaca
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitActivities(t *testing.T) {
+	activities := []AccountActivity{
+		{
+			ID:             "div1",
+			ActivityType:   ActivityDividend,
+			Date:           civil.Date{Year: 2019, Month: 8, Day: 1},
+			NetAmount:      decimal.RequireFromString("1.02"),
+			Symbol:         "T",
+			Qty:            decimal.NewFromInt(2),
+			PerShareAmount: decimal.RequireFromString("0.51"),
+			Status:         "executed",
+		},
+		{
+			ID:              "fill1",
+			ActivityType:    ActivityFill,
+			TransactionTime: time.Date(2024, 6, 24, 13, 30, 4, 0, time.UTC),
+			Type:            "partial_fill",
+			Price:           decimal.RequireFromString("3.8"),
+			Qty:             decimal.NewFromInt(643),
+			Side:            "sell",
+			Symbol:          "AAPL",
+			LeavesQty:       decimal.NewFromInt(1457),
+			CumQty:          decimal.NewFromInt(643),
+			OrderID:         "order1",
+			OrderStatus:     "partially_filled",
+		},
+		{
+			ID:           "fee1",
+			ActivityType: ActivityFee,
+			NetAmount:    decimal.RequireFromString("-0.01"),
+			Description:  "REG fee",
+		},
+	}
+
+	assert.False(t, activities[0].IsTrade())
+	assert.True(t, activities[1].IsTrade())
+	_, ok := activities[0].Trade()
+	assert.False(t, ok)
+	_, ok = activities[1].NonTrade()
+	assert.False(t, ok)
+
+	trades, nonTrades := SplitActivities(activities)
+	require.Len(t, trades, 1)
+	assert.Equal(t, "fill1", trades[0].ID)
+	assert.Equal(t, Sell, trades[0].Side)
+	assert.True(t, trades[0].Price.Equal(decimal.RequireFromString("3.8")))
+	assert.True(t, trades[0].LeavesQty.Equal(decimal.NewFromInt(1457)))
+	assert.Equal(t, "order1", trades[0].OrderID)
+
+	require.Len(t, nonTrades, 2)
+	assert.Equal(t, "div1", nonTrades[0].ID)
+	assert.Equal(t, ActivityDividend, nonTrades[0].ActivityType)
+	assert.Equal(t, civil.Date{Year: 2019, Month: 8, Day: 1}, nonTrades[0].Date)
+	assert.True(t, nonTrades[0].PerShareAmount.Equal(decimal.RequireFromString("0.51")))
+	assert.Equal(t, ActivityFee, nonTrades[1].ActivityType)
+	assert.Equal(t, "REG fee", nonTrades[1].Description)
+}
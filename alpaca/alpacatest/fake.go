@@ -0,0 +1,517 @@
+// Package alpacatest provides test doubles for the Trading API client: Fake, whose methods
+// can be stubbed one by one, Recorder, which records the calls made to another
+// alpaca.TradingAPI, and Server, an in-process fake of the Trading API that the real
+// client can be pointed at.
+package alpacatest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// ErrNotStubbed is returned by the methods of Fake that have not been stubbed.
+var ErrNotStubbed = errors.New("method not stubbed")
+
+func notStubbed(method string) error {
+	return fmt.Errorf("alpacatest: %s: %w", method, ErrNotStubbed)
+}
+
+// Fake is an alpaca.TradingAPI whose methods call the corresponding function fields, e.g.
+// PlaceOrder and PlaceOrderWithContext both call PlaceOrderFunc. The methods whose
+// function is not set return an error wrapping ErrNotStubbed. The methods without
+// a context pass context.Background() to the function.
+type Fake struct {
+	GetAccountFunc                  func(ctx context.Context) (*alpaca.Account, error)
+	GetAccountConfigurationsFunc    func(ctx context.Context) (*alpaca.AccountConfigurations, error)
+	UpdateAccountConfigurationsFunc func(
+		ctx context.Context, req alpaca.UpdateAccountConfigurationsRequest,
+	) (*alpaca.AccountConfigurations, error)
+	GetAccountActivitiesFunc func(
+		ctx context.Context, req alpaca.GetAccountActivitiesRequest,
+	) ([]alpaca.AccountActivity, error)
+	GetAllAccountActivitiesFunc func(
+		ctx context.Context, req alpaca.GetAccountActivitiesRequest,
+	) ([]alpaca.AccountActivity, error)
+	GetPortfolioHistoryFunc func(
+		ctx context.Context, req alpaca.GetPortfolioHistoryRequest,
+	) (*alpaca.PortfolioHistory, error)
+	GetPositionsFunc      func(ctx context.Context) ([]alpaca.Position, error)
+	GetPositionFunc       func(ctx context.Context, symbol string) (*alpaca.Position, error)
+	CloseAllPositionsFunc func(ctx context.Context, req alpaca.CloseAllPositionsRequest) ([]alpaca.Order, error)
+	ClosePositionFunc     func(
+		ctx context.Context, symbol string, req alpaca.ClosePositionRequest,
+	) (*alpaca.Order, error)
+	GetClockFunc                func(ctx context.Context) (*alpaca.Clock, error)
+	GetCalendarFunc             func(ctx context.Context, req alpaca.GetCalendarRequest) ([]alpaca.CalendarDay, error)
+	GetOrdersFunc               func(ctx context.Context, req alpaca.GetOrdersRequest) ([]alpaca.Order, error)
+	GetAllOrdersFunc            func(ctx context.Context, req alpaca.GetOrdersRequest) ([]alpaca.Order, error)
+	PlaceOrderFunc              func(ctx context.Context, req alpaca.PlaceOrderRequest) (*alpaca.Order, error)
+	GetOrderFunc                func(ctx context.Context, orderID string) (*alpaca.Order, error)
+	GetOrderByClientOrderIDFunc func(ctx context.Context, clientOrderID string) (*alpaca.Order, error)
+	ReplaceOrderFunc            func(
+		ctx context.Context, orderID string, req alpaca.ReplaceOrderRequest,
+	) (*alpaca.Order, error)
+	CancelOrderFunc        func(ctx context.Context, orderID string) error
+	CancelAllOrdersFunc    func(ctx context.Context) error
+	GetAssetsFunc          func(ctx context.Context, req alpaca.GetAssetsRequest) ([]alpaca.Asset, error)
+	GetAssetFunc           func(ctx context.Context, symbol string) (*alpaca.Asset, error)
+	GetOptionContractsFunc func(
+		ctx context.Context, req alpaca.GetOptionContractsRequest,
+	) ([]alpaca.OptionContract, error)
+	GetOptionContractFunc func(ctx context.Context, symbolOrID string) (*alpaca.OptionContract, error)
+	GetAnnouncementsFunc  func(ctx context.Context, req alpaca.GetAnnouncementsRequest) ([]alpaca.Announcement, error)
+	GetAnnouncementFunc   func(ctx context.Context, announcementID string) (*alpaca.Announcement, error)
+	GetWatchlistsFunc     func(ctx context.Context) ([]alpaca.Watchlist, error)
+	CreateWatchlistFunc   func(ctx context.Context, req alpaca.CreateWatchlistRequest) (*alpaca.Watchlist, error)
+	GetWatchlistFunc      func(ctx context.Context, watchlistID string) (*alpaca.Watchlist, error)
+	UpdateWatchlistFunc   func(
+		ctx context.Context, watchlistID string, req alpaca.UpdateWatchlistRequest,
+	) (*alpaca.Watchlist, error)
+	AddSymbolToWatchlistFunc func(
+		ctx context.Context, watchlistID string, req alpaca.AddSymbolToWatchlistRequest,
+	) (*alpaca.Watchlist, error)
+	RemoveSymbolFromWatchlistFunc func(
+		ctx context.Context, watchlistID string, req alpaca.RemoveSymbolFromWatchlistRequest,
+	) error
+	DeleteWatchlistFunc    func(ctx context.Context, watchlistID string) error
+	GetUSTreasuriesFunc    func(ctx context.Context, req alpaca.GetUSTreasuriesRequest) ([]alpaca.USTreasury, error)
+	GetUSCorporatesFunc    func(ctx context.Context, req alpaca.GetUSCorporatesRequest) ([]alpaca.USCorporate, error)
+	StreamTradeUpdatesFunc func(
+		ctx context.Context, handler func(alpaca.TradeUpdate), req alpaca.StreamTradeUpdatesRequest,
+	) error
+}
+
+var _ alpaca.TradingAPI = (*Fake)(nil)
+
+func (f *Fake) GetAccount() (*alpaca.Account, error) {
+	return f.GetAccountWithContext(context.Background())
+}
+
+func (f *Fake) GetAccountWithContext(ctx context.Context) (*alpaca.Account, error) {
+	if f.GetAccountFunc == nil {
+		return nil, notStubbed("GetAccount")
+	}
+	return f.GetAccountFunc(ctx)
+}
+
+func (f *Fake) GetAccountConfigurations() (*alpaca.AccountConfigurations, error) {
+	return f.GetAccountConfigurationsWithContext(context.Background())
+}
+
+func (f *Fake) GetAccountConfigurationsWithContext(ctx context.Context) (*alpaca.AccountConfigurations, error) {
+	if f.GetAccountConfigurationsFunc == nil {
+		return nil, notStubbed("GetAccountConfigurations")
+	}
+	return f.GetAccountConfigurationsFunc(ctx)
+}
+
+func (f *Fake) UpdateAccountConfigurations(
+	req alpaca.UpdateAccountConfigurationsRequest,
+) (*alpaca.AccountConfigurations, error) {
+	return f.UpdateAccountConfigurationsWithContext(context.Background(), req)
+}
+
+func (f *Fake) UpdateAccountConfigurationsWithContext(
+	ctx context.Context, req alpaca.UpdateAccountConfigurationsRequest,
+) (*alpaca.AccountConfigurations, error) {
+	if f.UpdateAccountConfigurationsFunc == nil {
+		return nil, notStubbed("UpdateAccountConfigurations")
+	}
+	return f.UpdateAccountConfigurationsFunc(ctx, req)
+}
+
+func (f *Fake) GetAccountActivities(req alpaca.GetAccountActivitiesRequest) ([]alpaca.AccountActivity, error) {
+	return f.GetAccountActivitiesWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetAccountActivitiesWithContext(
+	ctx context.Context, req alpaca.GetAccountActivitiesRequest,
+) ([]alpaca.AccountActivity, error) {
+	if f.GetAccountActivitiesFunc == nil {
+		return nil, notStubbed("GetAccountActivities")
+	}
+	return f.GetAccountActivitiesFunc(ctx, req)
+}
+
+func (f *Fake) GetAllAccountActivities(req alpaca.GetAccountActivitiesRequest) ([]alpaca.AccountActivity, error) {
+	return f.GetAllAccountActivitiesWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetAllAccountActivitiesWithContext(
+	ctx context.Context, req alpaca.GetAccountActivitiesRequest,
+) ([]alpaca.AccountActivity, error) {
+	if f.GetAllAccountActivitiesFunc == nil {
+		return nil, notStubbed("GetAllAccountActivities")
+	}
+	return f.GetAllAccountActivitiesFunc(ctx, req)
+}
+
+func (f *Fake) GetPortfolioHistory(req alpaca.GetPortfolioHistoryRequest) (*alpaca.PortfolioHistory, error) {
+	return f.GetPortfolioHistoryWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetPortfolioHistoryWithContext(
+	ctx context.Context, req alpaca.GetPortfolioHistoryRequest,
+) (*alpaca.PortfolioHistory, error) {
+	if f.GetPortfolioHistoryFunc == nil {
+		return nil, notStubbed("GetPortfolioHistory")
+	}
+	return f.GetPortfolioHistoryFunc(ctx, req)
+}
+
+func (f *Fake) GetPositions() ([]alpaca.Position, error) {
+	return f.GetPositionsWithContext(context.Background())
+}
+
+func (f *Fake) GetPositionsWithContext(ctx context.Context) ([]alpaca.Position, error) {
+	if f.GetPositionsFunc == nil {
+		return nil, notStubbed("GetPositions")
+	}
+	return f.GetPositionsFunc(ctx)
+}
+
+func (f *Fake) GetPosition(symbol string) (*alpaca.Position, error) {
+	return f.GetPositionWithContext(context.Background(), symbol)
+}
+
+func (f *Fake) GetPositionWithContext(ctx context.Context, symbol string) (*alpaca.Position, error) {
+	if f.GetPositionFunc == nil {
+		return nil, notStubbed("GetPosition")
+	}
+	return f.GetPositionFunc(ctx, symbol)
+}
+
+func (f *Fake) CloseAllPositions(req alpaca.CloseAllPositionsRequest) ([]alpaca.Order, error) {
+	return f.CloseAllPositionsWithContext(context.Background(), req)
+}
+
+func (f *Fake) CloseAllPositionsWithContext(
+	ctx context.Context, req alpaca.CloseAllPositionsRequest,
+) ([]alpaca.Order, error) {
+	if f.CloseAllPositionsFunc == nil {
+		return nil, notStubbed("CloseAllPositions")
+	}
+	return f.CloseAllPositionsFunc(ctx, req)
+}
+
+func (f *Fake) ClosePosition(symbol string, req alpaca.ClosePositionRequest) (*alpaca.Order, error) {
+	return f.ClosePositionWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) ClosePositionWithContext(
+	ctx context.Context, symbol string, req alpaca.ClosePositionRequest,
+) (*alpaca.Order, error) {
+	if f.ClosePositionFunc == nil {
+		return nil, notStubbed("ClosePosition")
+	}
+	return f.ClosePositionFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetClock() (*alpaca.Clock, error) {
+	return f.GetClockWithContext(context.Background())
+}
+
+func (f *Fake) GetClockWithContext(ctx context.Context) (*alpaca.Clock, error) {
+	if f.GetClockFunc == nil {
+		return nil, notStubbed("GetClock")
+	}
+	return f.GetClockFunc(ctx)
+}
+
+func (f *Fake) GetCalendar(req alpaca.GetCalendarRequest) ([]alpaca.CalendarDay, error) {
+	return f.GetCalendarWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetCalendarWithContext(
+	ctx context.Context, req alpaca.GetCalendarRequest,
+) ([]alpaca.CalendarDay, error) {
+	if f.GetCalendarFunc == nil {
+		return nil, notStubbed("GetCalendar")
+	}
+	return f.GetCalendarFunc(ctx, req)
+}
+
+func (f *Fake) GetOrders(req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	return f.GetOrdersWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetOrdersWithContext(ctx context.Context, req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	if f.GetOrdersFunc == nil {
+		return nil, notStubbed("GetOrders")
+	}
+	return f.GetOrdersFunc(ctx, req)
+}
+
+func (f *Fake) GetAllOrders(req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	return f.GetAllOrdersWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetAllOrdersWithContext(ctx context.Context, req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	if f.GetAllOrdersFunc == nil {
+		return nil, notStubbed("GetAllOrders")
+	}
+	return f.GetAllOrdersFunc(ctx, req)
+}
+
+func (f *Fake) PlaceOrder(req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+	return f.PlaceOrderWithContext(context.Background(), req)
+}
+
+func (f *Fake) PlaceOrderWithContext(ctx context.Context, req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+	if f.PlaceOrderFunc == nil {
+		return nil, notStubbed("PlaceOrder")
+	}
+	return f.PlaceOrderFunc(ctx, req)
+}
+
+func (f *Fake) GetOrder(orderID string) (*alpaca.Order, error) {
+	return f.GetOrderWithContext(context.Background(), orderID)
+}
+
+func (f *Fake) GetOrderWithContext(ctx context.Context, orderID string) (*alpaca.Order, error) {
+	if f.GetOrderFunc == nil {
+		return nil, notStubbed("GetOrder")
+	}
+	return f.GetOrderFunc(ctx, orderID)
+}
+
+func (f *Fake) GetOrderByClientOrderID(clientOrderID string) (*alpaca.Order, error) {
+	return f.GetOrderByClientOrderIDWithContext(context.Background(), clientOrderID)
+}
+
+func (f *Fake) GetOrderByClientOrderIDWithContext(ctx context.Context, clientOrderID string) (*alpaca.Order, error) {
+	if f.GetOrderByClientOrderIDFunc == nil {
+		return nil, notStubbed("GetOrderByClientOrderID")
+	}
+	return f.GetOrderByClientOrderIDFunc(ctx, clientOrderID)
+}
+
+func (f *Fake) ReplaceOrder(orderID string, req alpaca.ReplaceOrderRequest) (*alpaca.Order, error) {
+	return f.ReplaceOrderWithContext(context.Background(), orderID, req)
+}
+
+func (f *Fake) ReplaceOrderWithContext(
+	ctx context.Context, orderID string, req alpaca.ReplaceOrderRequest,
+) (*alpaca.Order, error) {
+	if f.ReplaceOrderFunc == nil {
+		return nil, notStubbed("ReplaceOrder")
+	}
+	return f.ReplaceOrderFunc(ctx, orderID, req)
+}
+
+func (f *Fake) CancelOrder(orderID string) error {
+	return f.CancelOrderWithContext(context.Background(), orderID)
+}
+
+func (f *Fake) CancelOrderWithContext(ctx context.Context, orderID string) error {
+	if f.CancelOrderFunc == nil {
+		return notStubbed("CancelOrder")
+	}
+	return f.CancelOrderFunc(ctx, orderID)
+}
+
+func (f *Fake) CancelAllOrders() error {
+	return f.CancelAllOrdersWithContext(context.Background())
+}
+
+func (f *Fake) CancelAllOrdersWithContext(ctx context.Context) error {
+	if f.CancelAllOrdersFunc == nil {
+		return notStubbed("CancelAllOrders")
+	}
+	return f.CancelAllOrdersFunc(ctx)
+}
+
+func (f *Fake) GetAssets(req alpaca.GetAssetsRequest) ([]alpaca.Asset, error) {
+	return f.GetAssetsWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetAssetsWithContext(ctx context.Context, req alpaca.GetAssetsRequest) ([]alpaca.Asset, error) {
+	if f.GetAssetsFunc == nil {
+		return nil, notStubbed("GetAssets")
+	}
+	return f.GetAssetsFunc(ctx, req)
+}
+
+func (f *Fake) GetAsset(symbol string) (*alpaca.Asset, error) {
+	return f.GetAssetWithContext(context.Background(), symbol)
+}
+
+func (f *Fake) GetAssetWithContext(ctx context.Context, symbol string) (*alpaca.Asset, error) {
+	if f.GetAssetFunc == nil {
+		return nil, notStubbed("GetAsset")
+	}
+	return f.GetAssetFunc(ctx, symbol)
+}
+
+func (f *Fake) GetOptionContracts(req alpaca.GetOptionContractsRequest) ([]alpaca.OptionContract, error) {
+	return f.GetOptionContractsWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetOptionContractsWithContext(
+	ctx context.Context, req alpaca.GetOptionContractsRequest,
+) ([]alpaca.OptionContract, error) {
+	if f.GetOptionContractsFunc == nil {
+		return nil, notStubbed("GetOptionContracts")
+	}
+	return f.GetOptionContractsFunc(ctx, req)
+}
+
+func (f *Fake) GetOptionContract(symbolOrID string) (*alpaca.OptionContract, error) {
+	return f.GetOptionContractWithContext(context.Background(), symbolOrID)
+}
+
+func (f *Fake) GetOptionContractWithContext(ctx context.Context, symbolOrID string) (*alpaca.OptionContract, error) {
+	if f.GetOptionContractFunc == nil {
+		return nil, notStubbed("GetOptionContract")
+	}
+	return f.GetOptionContractFunc(ctx, symbolOrID)
+}
+
+func (f *Fake) GetAnnouncements(req alpaca.GetAnnouncementsRequest) ([]alpaca.Announcement, error) {
+	return f.GetAnnouncementsWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetAnnouncementsWithContext(
+	ctx context.Context, req alpaca.GetAnnouncementsRequest,
+) ([]alpaca.Announcement, error) {
+	if f.GetAnnouncementsFunc == nil {
+		return nil, notStubbed("GetAnnouncements")
+	}
+	return f.GetAnnouncementsFunc(ctx, req)
+}
+
+func (f *Fake) GetAnnouncement(announcementID string) (*alpaca.Announcement, error) {
+	return f.GetAnnouncementWithContext(context.Background(), announcementID)
+}
+
+func (f *Fake) GetAnnouncementWithContext(ctx context.Context, announcementID string) (*alpaca.Announcement, error) {
+	if f.GetAnnouncementFunc == nil {
+		return nil, notStubbed("GetAnnouncement")
+	}
+	return f.GetAnnouncementFunc(ctx, announcementID)
+}
+
+func (f *Fake) GetWatchlists() ([]alpaca.Watchlist, error) {
+	return f.GetWatchlistsWithContext(context.Background())
+}
+
+func (f *Fake) GetWatchlistsWithContext(ctx context.Context) ([]alpaca.Watchlist, error) {
+	if f.GetWatchlistsFunc == nil {
+		return nil, notStubbed("GetWatchlists")
+	}
+	return f.GetWatchlistsFunc(ctx)
+}
+
+func (f *Fake) CreateWatchlist(req alpaca.CreateWatchlistRequest) (*alpaca.Watchlist, error) {
+	return f.CreateWatchlistWithContext(context.Background(), req)
+}
+
+func (f *Fake) CreateWatchlistWithContext(
+	ctx context.Context, req alpaca.CreateWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	if f.CreateWatchlistFunc == nil {
+		return nil, notStubbed("CreateWatchlist")
+	}
+	return f.CreateWatchlistFunc(ctx, req)
+}
+
+func (f *Fake) GetWatchlist(watchlistID string) (*alpaca.Watchlist, error) {
+	return f.GetWatchlistWithContext(context.Background(), watchlistID)
+}
+
+func (f *Fake) GetWatchlistWithContext(ctx context.Context, watchlistID string) (*alpaca.Watchlist, error) {
+	if f.GetWatchlistFunc == nil {
+		return nil, notStubbed("GetWatchlist")
+	}
+	return f.GetWatchlistFunc(ctx, watchlistID)
+}
+
+func (f *Fake) UpdateWatchlist(watchlistID string, req alpaca.UpdateWatchlistRequest) (*alpaca.Watchlist, error) {
+	return f.UpdateWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+func (f *Fake) UpdateWatchlistWithContext(
+	ctx context.Context, watchlistID string, req alpaca.UpdateWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	if f.UpdateWatchlistFunc == nil {
+		return nil, notStubbed("UpdateWatchlist")
+	}
+	return f.UpdateWatchlistFunc(ctx, watchlistID, req)
+}
+
+func (f *Fake) AddSymbolToWatchlist(
+	watchlistID string, req alpaca.AddSymbolToWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	return f.AddSymbolToWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+func (f *Fake) AddSymbolToWatchlistWithContext(
+	ctx context.Context, watchlistID string, req alpaca.AddSymbolToWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	if f.AddSymbolToWatchlistFunc == nil {
+		return nil, notStubbed("AddSymbolToWatchlist")
+	}
+	return f.AddSymbolToWatchlistFunc(ctx, watchlistID, req)
+}
+
+func (f *Fake) RemoveSymbolFromWatchlist(watchlistID string, req alpaca.RemoveSymbolFromWatchlistRequest) error {
+	return f.RemoveSymbolFromWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+func (f *Fake) RemoveSymbolFromWatchlistWithContext(
+	ctx context.Context, watchlistID string, req alpaca.RemoveSymbolFromWatchlistRequest,
+) error {
+	if f.RemoveSymbolFromWatchlistFunc == nil {
+		return notStubbed("RemoveSymbolFromWatchlist")
+	}
+	return f.RemoveSymbolFromWatchlistFunc(ctx, watchlistID, req)
+}
+
+func (f *Fake) DeleteWatchlist(watchlistID string) error {
+	return f.DeleteWatchlistWithContext(context.Background(), watchlistID)
+}
+
+func (f *Fake) DeleteWatchlistWithContext(ctx context.Context, watchlistID string) error {
+	if f.DeleteWatchlistFunc == nil {
+		return notStubbed("DeleteWatchlist")
+	}
+	return f.DeleteWatchlistFunc(ctx, watchlistID)
+}
+
+func (f *Fake) GetUSTreasuries(req alpaca.GetUSTreasuriesRequest) ([]alpaca.USTreasury, error) {
+	return f.GetUSTreasuriesWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetUSTreasuriesWithContext(
+	ctx context.Context, req alpaca.GetUSTreasuriesRequest,
+) ([]alpaca.USTreasury, error) {
+	if f.GetUSTreasuriesFunc == nil {
+		return nil, notStubbed("GetUSTreasuries")
+	}
+	return f.GetUSTreasuriesFunc(ctx, req)
+}
+
+func (f *Fake) GetUSCorporates(req alpaca.GetUSCorporatesRequest) ([]alpaca.USCorporate, error) {
+	return f.GetUSCorporatesWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetUSCorporatesWithContext(
+	ctx context.Context, req alpaca.GetUSCorporatesRequest,
+) ([]alpaca.USCorporate, error) {
+	if f.GetUSCorporatesFunc == nil {
+		return nil, notStubbed("GetUSCorporates")
+	}
+	return f.GetUSCorporatesFunc(ctx, req)
+}
+
+func (f *Fake) StreamTradeUpdates(
+	ctx context.Context, handler func(alpaca.TradeUpdate), req alpaca.StreamTradeUpdatesRequest,
+) error {
+	if f.StreamTradeUpdatesFunc == nil {
+		return notStubbed("StreamTradeUpdates")
+	}
+	return f.StreamTradeUpdatesFunc(ctx, handler, req)
+}
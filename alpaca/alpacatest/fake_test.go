@@ -0,0 +1,82 @@
+package alpacatest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+type ctxKey struct{}
+
+func TestFake(t *testing.T) {
+	var api alpaca.TradingAPI = &Fake{
+		PlaceOrderFunc: func(ctx context.Context, req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+			assert.NotNil(t, ctx)
+			return &alpaca.Order{ID: "order_id", Symbol: req.Symbol}, nil
+		},
+		GetAccountFunc: func(ctx context.Context) (*alpaca.Account, error) {
+			assert.Equal(t, "value", ctx.Value(ctxKey{}))
+			return &alpaca.Account{ID: "account_id"}, nil
+		},
+	}
+
+	order, err := api.PlaceOrder(alpaca.PlaceOrderRequest{Symbol: "AAPL"})
+	require.NoError(t, err)
+	assert.Equal(t, "AAPL", order.Symbol)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	acct, err := api.GetAccountWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "account_id", acct.ID)
+
+	_, err = api.GetPositions()
+	require.ErrorIs(t, err, ErrNotStubbed)
+	assert.Contains(t, err.Error(), "GetPositions")
+	assert.ErrorIs(t, api.CancelAllOrders(), ErrNotStubbed)
+}
+
+func TestRecorder(t *testing.T) {
+	errNotFound := errors.New("not found")
+	r := NewRecorder(&Fake{
+		PlaceOrderFunc: func(_ context.Context, req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+			return &alpaca.Order{ID: "order_id", Symbol: req.Symbol}, nil
+		},
+		CancelOrderFunc: func(_ context.Context, orderID string) error {
+			if orderID != "order_id" {
+				return errNotFound
+			}
+			return nil
+		},
+	})
+
+	qty := decimal.NewFromInt(1)
+	req := alpaca.PlaceOrderRequest{Symbol: "AAPL", Qty: &qty, Side: alpaca.Buy}
+	order, err := r.PlaceOrderWithContext(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "order_id", order.ID)
+	require.NoError(t, r.CancelOrder("order_id"))
+	require.ErrorIs(t, r.CancelOrder("other"), errNotFound)
+	_, err = r.GetClock()
+	require.ErrorIs(t, err, ErrNotStubbed)
+
+	calls := r.Calls()
+	require.Len(t, calls, 4)
+	assert.Equal(t, Call{Method: "PlaceOrder", Args: []interface{}{req}}, calls[0])
+	assert.Equal(t, "GetClock", calls[3].Method)
+	assert.Empty(t, calls[3].Args)
+	assert.ErrorIs(t, calls[3].Err, ErrNotStubbed)
+
+	cancels := r.CallsTo("CancelOrder")
+	require.Len(t, cancels, 2)
+	assert.Equal(t, []interface{}{"other"}, cancels[1].Args)
+	assert.ErrorIs(t, cancels[1].Err, errNotFound)
+
+	r.Reset()
+	assert.Empty(t, r.Calls())
+}
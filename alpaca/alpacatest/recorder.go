@@ -0,0 +1,467 @@
+package alpacatest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// Call is a method call recorded by Recorder.
+type Call struct {
+	// Method is the name of the method, without the WithContext suffix.
+	Method string
+	// Args are the arguments of the call, except the context.
+	Args []interface{}
+	// Err is the error returned by the call.
+	Err error
+}
+
+// Recorder is an alpaca.TradingAPI that forwards the calls to another alpaca.TradingAPI
+// (typically a Fake) and records them in memory. It's safe for concurrent use.
+type Recorder struct {
+	api alpaca.TradingAPI
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ alpaca.TradingAPI = (*Recorder)(nil)
+
+// NewRecorder returns a Recorder forwarding the calls to api.
+func NewRecorder(api alpaca.TradingAPI) *Recorder {
+	return &Recorder{api: api}
+}
+
+// Calls returns the recorded calls in the order they were made.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls of the given method (without the WithContext suffix).
+func (r *Recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *Recorder) record(method string, err error, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args, Err: err})
+}
+
+func (r *Recorder) GetAccount() (*alpaca.Account, error) {
+	return r.GetAccountWithContext(context.Background())
+}
+
+func (r *Recorder) GetAccountWithContext(ctx context.Context) (*alpaca.Account, error) {
+	res, err := r.api.GetAccountWithContext(ctx)
+	r.record("GetAccount", err)
+	return res, err
+}
+
+func (r *Recorder) GetAccountConfigurations() (*alpaca.AccountConfigurations, error) {
+	return r.GetAccountConfigurationsWithContext(context.Background())
+}
+
+func (r *Recorder) GetAccountConfigurationsWithContext(ctx context.Context) (*alpaca.AccountConfigurations, error) {
+	res, err := r.api.GetAccountConfigurationsWithContext(ctx)
+	r.record("GetAccountConfigurations", err)
+	return res, err
+}
+
+func (r *Recorder) UpdateAccountConfigurations(
+	req alpaca.UpdateAccountConfigurationsRequest,
+) (*alpaca.AccountConfigurations, error) {
+	return r.UpdateAccountConfigurationsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) UpdateAccountConfigurationsWithContext(
+	ctx context.Context, req alpaca.UpdateAccountConfigurationsRequest,
+) (*alpaca.AccountConfigurations, error) {
+	res, err := r.api.UpdateAccountConfigurationsWithContext(ctx, req)
+	r.record("UpdateAccountConfigurations", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetAccountActivities(req alpaca.GetAccountActivitiesRequest) ([]alpaca.AccountActivity, error) {
+	return r.GetAccountActivitiesWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetAccountActivitiesWithContext(
+	ctx context.Context, req alpaca.GetAccountActivitiesRequest,
+) ([]alpaca.AccountActivity, error) {
+	res, err := r.api.GetAccountActivitiesWithContext(ctx, req)
+	r.record("GetAccountActivities", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetAllAccountActivities(req alpaca.GetAccountActivitiesRequest) ([]alpaca.AccountActivity, error) {
+	return r.GetAllAccountActivitiesWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetAllAccountActivitiesWithContext(
+	ctx context.Context, req alpaca.GetAccountActivitiesRequest,
+) ([]alpaca.AccountActivity, error) {
+	res, err := r.api.GetAllAccountActivitiesWithContext(ctx, req)
+	r.record("GetAllAccountActivities", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetPortfolioHistory(req alpaca.GetPortfolioHistoryRequest) (*alpaca.PortfolioHistory, error) {
+	return r.GetPortfolioHistoryWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetPortfolioHistoryWithContext(
+	ctx context.Context, req alpaca.GetPortfolioHistoryRequest,
+) (*alpaca.PortfolioHistory, error) {
+	res, err := r.api.GetPortfolioHistoryWithContext(ctx, req)
+	r.record("GetPortfolioHistory", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetPositions() ([]alpaca.Position, error) {
+	return r.GetPositionsWithContext(context.Background())
+}
+
+func (r *Recorder) GetPositionsWithContext(ctx context.Context) ([]alpaca.Position, error) {
+	res, err := r.api.GetPositionsWithContext(ctx)
+	r.record("GetPositions", err)
+	return res, err
+}
+
+func (r *Recorder) GetPosition(symbol string) (*alpaca.Position, error) {
+	return r.GetPositionWithContext(context.Background(), symbol)
+}
+
+func (r *Recorder) GetPositionWithContext(ctx context.Context, symbol string) (*alpaca.Position, error) {
+	res, err := r.api.GetPositionWithContext(ctx, symbol)
+	r.record("GetPosition", err, symbol)
+	return res, err
+}
+
+func (r *Recorder) CloseAllPositions(req alpaca.CloseAllPositionsRequest) ([]alpaca.Order, error) {
+	return r.CloseAllPositionsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) CloseAllPositionsWithContext(
+	ctx context.Context, req alpaca.CloseAllPositionsRequest,
+) ([]alpaca.Order, error) {
+	res, err := r.api.CloseAllPositionsWithContext(ctx, req)
+	r.record("CloseAllPositions", err, req)
+	return res, err
+}
+
+func (r *Recorder) ClosePosition(symbol string, req alpaca.ClosePositionRequest) (*alpaca.Order, error) {
+	return r.ClosePositionWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) ClosePositionWithContext(
+	ctx context.Context, symbol string, req alpaca.ClosePositionRequest,
+) (*alpaca.Order, error) {
+	res, err := r.api.ClosePositionWithContext(ctx, symbol, req)
+	r.record("ClosePosition", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetClock() (*alpaca.Clock, error) {
+	return r.GetClockWithContext(context.Background())
+}
+
+func (r *Recorder) GetClockWithContext(ctx context.Context) (*alpaca.Clock, error) {
+	res, err := r.api.GetClockWithContext(ctx)
+	r.record("GetClock", err)
+	return res, err
+}
+
+func (r *Recorder) GetCalendar(req alpaca.GetCalendarRequest) ([]alpaca.CalendarDay, error) {
+	return r.GetCalendarWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetCalendarWithContext(
+	ctx context.Context, req alpaca.GetCalendarRequest,
+) ([]alpaca.CalendarDay, error) {
+	res, err := r.api.GetCalendarWithContext(ctx, req)
+	r.record("GetCalendar", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetOrders(req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	return r.GetOrdersWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetOrdersWithContext(ctx context.Context, req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	res, err := r.api.GetOrdersWithContext(ctx, req)
+	r.record("GetOrders", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetAllOrders(req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	return r.GetAllOrdersWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetAllOrdersWithContext(ctx context.Context, req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	res, err := r.api.GetAllOrdersWithContext(ctx, req)
+	r.record("GetAllOrders", err, req)
+	return res, err
+}
+
+func (r *Recorder) PlaceOrder(req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+	return r.PlaceOrderWithContext(context.Background(), req)
+}
+
+func (r *Recorder) PlaceOrderWithContext(ctx context.Context, req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+	res, err := r.api.PlaceOrderWithContext(ctx, req)
+	r.record("PlaceOrder", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetOrder(orderID string) (*alpaca.Order, error) {
+	return r.GetOrderWithContext(context.Background(), orderID)
+}
+
+func (r *Recorder) GetOrderWithContext(ctx context.Context, orderID string) (*alpaca.Order, error) {
+	res, err := r.api.GetOrderWithContext(ctx, orderID)
+	r.record("GetOrder", err, orderID)
+	return res, err
+}
+
+func (r *Recorder) GetOrderByClientOrderID(clientOrderID string) (*alpaca.Order, error) {
+	return r.GetOrderByClientOrderIDWithContext(context.Background(), clientOrderID)
+}
+
+func (r *Recorder) GetOrderByClientOrderIDWithContext(
+	ctx context.Context, clientOrderID string,
+) (*alpaca.Order, error) {
+	res, err := r.api.GetOrderByClientOrderIDWithContext(ctx, clientOrderID)
+	r.record("GetOrderByClientOrderID", err, clientOrderID)
+	return res, err
+}
+
+func (r *Recorder) ReplaceOrder(orderID string, req alpaca.ReplaceOrderRequest) (*alpaca.Order, error) {
+	return r.ReplaceOrderWithContext(context.Background(), orderID, req)
+}
+
+func (r *Recorder) ReplaceOrderWithContext(
+	ctx context.Context, orderID string, req alpaca.ReplaceOrderRequest,
+) (*alpaca.Order, error) {
+	res, err := r.api.ReplaceOrderWithContext(ctx, orderID, req)
+	r.record("ReplaceOrder", err, orderID, req)
+	return res, err
+}
+
+func (r *Recorder) CancelOrder(orderID string) error {
+	return r.CancelOrderWithContext(context.Background(), orderID)
+}
+
+func (r *Recorder) CancelOrderWithContext(ctx context.Context, orderID string) error {
+	err := r.api.CancelOrderWithContext(ctx, orderID)
+	r.record("CancelOrder", err, orderID)
+	return err
+}
+
+func (r *Recorder) CancelAllOrders() error {
+	return r.CancelAllOrdersWithContext(context.Background())
+}
+
+func (r *Recorder) CancelAllOrdersWithContext(ctx context.Context) error {
+	err := r.api.CancelAllOrdersWithContext(ctx)
+	r.record("CancelAllOrders", err)
+	return err
+}
+
+func (r *Recorder) GetAssets(req alpaca.GetAssetsRequest) ([]alpaca.Asset, error) {
+	return r.GetAssetsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetAssetsWithContext(ctx context.Context, req alpaca.GetAssetsRequest) ([]alpaca.Asset, error) {
+	res, err := r.api.GetAssetsWithContext(ctx, req)
+	r.record("GetAssets", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetAsset(symbol string) (*alpaca.Asset, error) {
+	return r.GetAssetWithContext(context.Background(), symbol)
+}
+
+func (r *Recorder) GetAssetWithContext(ctx context.Context, symbol string) (*alpaca.Asset, error) {
+	res, err := r.api.GetAssetWithContext(ctx, symbol)
+	r.record("GetAsset", err, symbol)
+	return res, err
+}
+
+func (r *Recorder) GetOptionContracts(req alpaca.GetOptionContractsRequest) ([]alpaca.OptionContract, error) {
+	return r.GetOptionContractsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetOptionContractsWithContext(
+	ctx context.Context, req alpaca.GetOptionContractsRequest,
+) ([]alpaca.OptionContract, error) {
+	res, err := r.api.GetOptionContractsWithContext(ctx, req)
+	r.record("GetOptionContracts", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetOptionContract(symbolOrID string) (*alpaca.OptionContract, error) {
+	return r.GetOptionContractWithContext(context.Background(), symbolOrID)
+}
+
+func (r *Recorder) GetOptionContractWithContext(
+	ctx context.Context, symbolOrID string,
+) (*alpaca.OptionContract, error) {
+	res, err := r.api.GetOptionContractWithContext(ctx, symbolOrID)
+	r.record("GetOptionContract", err, symbolOrID)
+	return res, err
+}
+
+func (r *Recorder) GetAnnouncements(req alpaca.GetAnnouncementsRequest) ([]alpaca.Announcement, error) {
+	return r.GetAnnouncementsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetAnnouncementsWithContext(
+	ctx context.Context, req alpaca.GetAnnouncementsRequest,
+) ([]alpaca.Announcement, error) {
+	res, err := r.api.GetAnnouncementsWithContext(ctx, req)
+	r.record("GetAnnouncements", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetAnnouncement(announcementID string) (*alpaca.Announcement, error) {
+	return r.GetAnnouncementWithContext(context.Background(), announcementID)
+}
+
+func (r *Recorder) GetAnnouncementWithContext(
+	ctx context.Context, announcementID string,
+) (*alpaca.Announcement, error) {
+	res, err := r.api.GetAnnouncementWithContext(ctx, announcementID)
+	r.record("GetAnnouncement", err, announcementID)
+	return res, err
+}
+
+func (r *Recorder) GetWatchlists() ([]alpaca.Watchlist, error) {
+	return r.GetWatchlistsWithContext(context.Background())
+}
+
+func (r *Recorder) GetWatchlistsWithContext(ctx context.Context) ([]alpaca.Watchlist, error) {
+	res, err := r.api.GetWatchlistsWithContext(ctx)
+	r.record("GetWatchlists", err)
+	return res, err
+}
+
+func (r *Recorder) CreateWatchlist(req alpaca.CreateWatchlistRequest) (*alpaca.Watchlist, error) {
+	return r.CreateWatchlistWithContext(context.Background(), req)
+}
+
+func (r *Recorder) CreateWatchlistWithContext(
+	ctx context.Context, req alpaca.CreateWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	res, err := r.api.CreateWatchlistWithContext(ctx, req)
+	r.record("CreateWatchlist", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetWatchlist(watchlistID string) (*alpaca.Watchlist, error) {
+	return r.GetWatchlistWithContext(context.Background(), watchlistID)
+}
+
+func (r *Recorder) GetWatchlistWithContext(ctx context.Context, watchlistID string) (*alpaca.Watchlist, error) {
+	res, err := r.api.GetWatchlistWithContext(ctx, watchlistID)
+	r.record("GetWatchlist", err, watchlistID)
+	return res, err
+}
+
+func (r *Recorder) UpdateWatchlist(watchlistID string, req alpaca.UpdateWatchlistRequest) (*alpaca.Watchlist, error) {
+	return r.UpdateWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+func (r *Recorder) UpdateWatchlistWithContext(
+	ctx context.Context, watchlistID string, req alpaca.UpdateWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	res, err := r.api.UpdateWatchlistWithContext(ctx, watchlistID, req)
+	r.record("UpdateWatchlist", err, watchlistID, req)
+	return res, err
+}
+
+func (r *Recorder) AddSymbolToWatchlist(
+	watchlistID string, req alpaca.AddSymbolToWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	return r.AddSymbolToWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+func (r *Recorder) AddSymbolToWatchlistWithContext(
+	ctx context.Context, watchlistID string, req alpaca.AddSymbolToWatchlistRequest,
+) (*alpaca.Watchlist, error) {
+	res, err := r.api.AddSymbolToWatchlistWithContext(ctx, watchlistID, req)
+	r.record("AddSymbolToWatchlist", err, watchlistID, req)
+	return res, err
+}
+
+func (r *Recorder) RemoveSymbolFromWatchlist(watchlistID string, req alpaca.RemoveSymbolFromWatchlistRequest) error {
+	return r.RemoveSymbolFromWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+func (r *Recorder) RemoveSymbolFromWatchlistWithContext(
+	ctx context.Context, watchlistID string, req alpaca.RemoveSymbolFromWatchlistRequest,
+) error {
+	err := r.api.RemoveSymbolFromWatchlistWithContext(ctx, watchlistID, req)
+	r.record("RemoveSymbolFromWatchlist", err, watchlistID, req)
+	return err
+}
+
+func (r *Recorder) DeleteWatchlist(watchlistID string) error {
+	return r.DeleteWatchlistWithContext(context.Background(), watchlistID)
+}
+
+func (r *Recorder) DeleteWatchlistWithContext(ctx context.Context, watchlistID string) error {
+	err := r.api.DeleteWatchlistWithContext(ctx, watchlistID)
+	r.record("DeleteWatchlist", err, watchlistID)
+	return err
+}
+
+func (r *Recorder) GetUSTreasuries(req alpaca.GetUSTreasuriesRequest) ([]alpaca.USTreasury, error) {
+	return r.GetUSTreasuriesWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetUSTreasuriesWithContext(
+	ctx context.Context, req alpaca.GetUSTreasuriesRequest,
+) ([]alpaca.USTreasury, error) {
+	res, err := r.api.GetUSTreasuriesWithContext(ctx, req)
+	r.record("GetUSTreasuries", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetUSCorporates(req alpaca.GetUSCorporatesRequest) ([]alpaca.USCorporate, error) {
+	return r.GetUSCorporatesWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetUSCorporatesWithContext(
+	ctx context.Context, req alpaca.GetUSCorporatesRequest,
+) ([]alpaca.USCorporate, error) {
+	res, err := r.api.GetUSCorporatesWithContext(ctx, req)
+	r.record("GetUSCorporates", err, req)
+	return res, err
+}
+
+func (r *Recorder) StreamTradeUpdates(
+	ctx context.Context, handler func(alpaca.TradeUpdate), req alpaca.StreamTradeUpdatesRequest,
+) error {
+	err := r.api.StreamTradeUpdates(ctx, handler, req)
+	r.record("StreamTradeUpdates", err, handler, req)
+	return err
+}
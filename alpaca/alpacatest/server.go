@@ -0,0 +1,593 @@
+package alpacatest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// Server is an in-process fake of the Trading API for integration tests. Pointing
+// ClientOpts.BaseURL at its URL (or using Client) exercises the real client code paths
+// without network access.
+//
+// The server keeps the account, the orders, the positions and the watchlists in memory.
+// Orders are accepted as new, then filled either explicitly by Fill or automatically once
+// the price of their symbol is known (see SetPrice): market orders and marketable limit
+// orders are filled at that price, and stop and stop limit orders are triggered when the
+// price crosses their stop price. Trailing stop orders and the legs of the advanced order
+// classes (bracket, OCO, OTO) are not simulated, they can only be filled with Fill.
+// Rejections and failures (e.g. 429 Too Many Requests) can be scripted with Reject,
+// RejectNextOrder and FailNext.
+//
+// Every state change of an order is sent as a TradeUpdate to the trade updates stream.
+type Server struct {
+	// URL is the base URL of the server, to be used as ClientOpts.BaseURL.
+	URL string
+
+	srv *httptest.Server
+
+	mu              sync.Mutex
+	account         alpaca.Account
+	configs         alpaca.AccountConfigurations
+	cash            decimal.Decimal
+	orders          []*alpaca.Order
+	ordersByID      map[string]*alpaca.Order
+	triggered       map[string]bool
+	positions       map[string]*position
+	prices          map[string]decimal.Decimal
+	assets          []alpaca.Asset
+	clock           *alpaca.Clock
+	calendar        []alpaca.CalendarDay
+	watchlists      []*alpaca.Watchlist
+	optionContracts []alpaca.OptionContract
+	activities      []alpaca.AccountActivity
+	events          []alpaca.TradeUpdate
+	// newEvent is closed (and replaced) when a new event is added
+	newEvent   chan struct{}
+	failures   []int
+	rejections []string
+	requests   []string
+	lastID     int
+}
+
+type position struct {
+	qty           decimal.Decimal
+	avgEntryPrice decimal.Decimal
+}
+
+var (
+	// DefaultCash is the initial cash balance of the account.
+	DefaultCash = decimal.NewFromInt(100_000)
+	// ErrOrderNotOpen is returned by Fill and Reject for orders that can no longer be filled.
+	ErrOrderNotOpen = errors.New("order is not open")
+)
+
+// NewServer starts and returns a new Server. It should be closed when it's no longer used.
+func NewServer() *Server {
+	s := &Server{
+		account: alpaca.Account{
+			ID:              "00000000-0000-4000-8000-000000000000",
+			AccountNumber:   "PA0000000000",
+			Status:          "ACTIVE",
+			CryptoStatus:    "ACTIVE",
+			Currency:        "USD",
+			ShortingEnabled: true,
+			Multiplier:      decimal.NewFromInt(1),
+			CreatedAt:       time.Now().UTC(),
+		},
+		configs:    alpaca.AccountConfigurations{DTBPCheck: "entry", TradeConfirmEmail: "all"},
+		cash:       DefaultCash,
+		ordersByID: make(map[string]*alpaca.Order),
+		triggered:  make(map[string]bool),
+		positions:  make(map[string]*position),
+		prices:     make(map[string]decimal.Decimal),
+		newEvent:   make(chan struct{}),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server and blocks until all outstanding requests have completed.
+func (s *Server) Close() {
+	s.srv.CloseClientConnections()
+	s.srv.Close()
+}
+
+// Client returns a client connected to the server.
+func (s *Server) Client() *alpaca.Client {
+	return alpaca.NewClient(alpaca.ClientOpts{
+		APIKey:    "key",
+		APISecret: "secret",
+		BaseURL:   s.URL,
+	})
+}
+
+// SetCash sets the cash balance of the account.
+func (s *Server) SetCash(cash decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cash = cash
+}
+
+// SetPosition sets the position of the symbol, e.g. to start a test with an existing position.
+// A zero qty removes the position. A negative qty is a short position.
+func (s *Server) SetPosition(symbol string, qty, avgEntryPrice decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if qty.IsZero() {
+		delete(s.positions, symbol)
+		return
+	}
+	s.positions[symbol] = &position{qty: qty, avgEntryPrice: avgEntryPrice}
+}
+
+// SetPrice sets the current price of the symbol, which is used to value the positions
+// and to fill the orders. The open orders of the symbol are filled if they are marketable.
+func (s *Server) SetPrice(symbol string, price decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[symbol] = price
+	for _, o := range s.orders {
+		if o.Symbol == symbol {
+			s.match(o)
+		}
+	}
+}
+
+// AddAsset adds an asset to the ones returned by the assets endpoints.
+func (s *Server) AddAsset(asset alpaca.Asset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assets = append(s.assets, asset)
+}
+
+// AddOptionContract adds a contract to the ones returned by the option contracts endpoints.
+func (s *Server) AddOptionContract(contract alpaca.OptionContract) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.optionContracts = append(s.optionContracts, contract)
+}
+
+// AddActivity adds a (non-trade) account activity, e.g. a dividend. The FILL activities
+// are added automatically when the orders are filled.
+func (s *Server) AddActivity(activity alpaca.AccountActivity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if activity.ID == "" {
+		activity.ID = s.newID()
+	}
+	s.activities = append(s.activities, activity)
+}
+
+// SetClock sets the market clock. By default, the market is open.
+func (s *Server) SetClock(clock alpaca.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = &clock
+}
+
+// SetCalendar sets the market calendar.
+func (s *Server) SetCalendar(calendar []alpaca.CalendarDay) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calendar = calendar
+}
+
+// FailNext makes the next n requests fail with the given HTTP status code. For 429 Too Many
+// Requests, the response also contains the rate limit headers with a Retry-After of 0 seconds.
+func (s *Server) FailNext(n, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, statusCode)
+	}
+}
+
+// RejectNextOrder makes the next order placement fail with 403 Forbidden and the given message,
+// the way the API rejects orders, e.g. for insufficient buying power.
+func (s *Server) RejectNextOrder(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejections = append(s.rejections, message)
+}
+
+// Fill fills qty of the order at price. The order is partially filled if qty is less than
+// its remaining quantity. Orders with a notional value are entirely filled by any qty.
+func (s *Server) Fill(orderID string, qty, price decimal.Decimal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.ordersByID[orderID]
+	if !ok {
+		return fmt.Errorf("order %s not found", orderID)
+	}
+	if !o.Status.IsOpen() {
+		return fmt.Errorf("order %s: %w", orderID, ErrOrderNotOpen)
+	}
+	if !qty.IsPositive() {
+		return fmt.Errorf("invalid fill qty: %s", qty)
+	}
+	if o.Qty != nil && qty.GreaterThan(o.Qty.Sub(o.FilledQty)) {
+		return fmt.Errorf("fill qty %s exceeds the remaining qty of order %s", qty, orderID)
+	}
+	s.fill(o, qty, price)
+	return nil
+}
+
+// Reject rejects an open order asynchronously, after it has been accepted.
+func (s *Server) Reject(orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.ordersByID[orderID]
+	if !ok {
+		return fmt.Errorf("order %s not found", orderID)
+	}
+	if !o.Status.IsOpen() {
+		return fmt.Errorf("order %s: %w", orderID, ErrOrderNotOpen)
+	}
+	now := s.now()
+	o.Status = alpaca.OrderRejected
+	o.FailedAt = &now
+	o.UpdatedAt = now
+	s.emit(alpaca.TradeEventRejected, o, nil)
+	return nil
+}
+
+// Orders returns all the orders, in the order they were submitted.
+func (s *Server) Orders() []alpaca.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orders := make([]alpaca.Order, 0, len(s.orders))
+	for _, o := range s.orders {
+		orders = append(orders, *o)
+	}
+	return orders
+}
+
+// Positions returns the open positions.
+func (s *Server) Positions() []alpaca.Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.positionsView()
+}
+
+// TradeUpdates returns all the trade updates sent so far.
+func (s *Server) TradeUpdates() []alpaca.TradeUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.events)
+}
+
+// Requests returns the requests received by the server as "METHOD /path" strings.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().UTC()
+}
+
+// newID returns a new unique ID in UUID format. The IDs are increasing.
+func (s *Server) newID() string {
+	s.lastID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.lastID)
+}
+
+// placeOrder accepts the order and fills it if it's marketable.
+func (s *Server) placeOrder(req alpaca.PlaceOrderRequest) *alpaca.Order {
+	now := s.now()
+	id := s.newID()
+	o := &alpaca.Order{
+		ID:             id,
+		ClientOrderID:  req.ClientOrderID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		SubmittedAt:    now,
+		AssetID:        s.assetID(req.Symbol),
+		Symbol:         req.Symbol,
+		AssetClass:     alpaca.USEquity,
+		OrderClass:     req.OrderClass,
+		Type:           req.Type,
+		Side:           req.Side,
+		PositionIntent: req.PositionIntent,
+		TimeInForce:    req.TimeInForce,
+		Status:         alpaca.OrderNew,
+		Notional:       req.Notional,
+		Qty:            req.Qty,
+		LimitPrice:     req.LimitPrice,
+		StopPrice:      req.StopPrice,
+		TrailPrice:     req.TrailPrice,
+		TrailPercent:   req.TrailPercent,
+		ExtendedHours:  req.ExtendedHours,
+	}
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = id
+	}
+	if o.OrderClass == "" {
+		o.OrderClass = alpaca.Simple
+	}
+	if isCrypto(o.Symbol) {
+		o.AssetClass = alpaca.Crypto
+	}
+	s.addOrder(o)
+	return o
+}
+
+func (s *Server) addOrder(o *alpaca.Order) {
+	s.orders = append(s.orders, o)
+	s.ordersByID[o.ID] = o
+	s.emit(alpaca.TradeEventNew, o, nil)
+	s.match(o)
+	if o.Status.IsOpen() && (o.TimeInForce == alpaca.IOC || o.TimeInForce == alpaca.FOK) {
+		s.cancel(o)
+	}
+}
+
+func (s *Server) cancel(o *alpaca.Order) {
+	now := s.now()
+	o.Status = alpaca.OrderCanceled
+	o.CanceledAt = &now
+	o.UpdatedAt = now
+	s.emit(alpaca.TradeEventCanceled, o, nil)
+}
+
+// match fills the order at the current price of its symbol if it's marketable.
+func (s *Server) match(o *alpaca.Order) {
+	price, ok := s.prices[o.Symbol]
+	if !ok || !o.Status.IsOpen() {
+		return
+	}
+	buy := o.Side == alpaca.Buy
+	if (o.Type == alpaca.Stop || o.Type == alpaca.StopLimit) && !s.triggered[o.ID] {
+		if o.StopPrice == nil || (buy && price.LessThan(*o.StopPrice)) || (!buy && price.GreaterThan(*o.StopPrice)) {
+			return
+		}
+		s.triggered[o.ID] = true
+	}
+	switch o.Type {
+	case alpaca.Market, alpaca.Stop:
+	case alpaca.Limit, alpaca.StopLimit:
+		if o.LimitPrice == nil || (buy && price.GreaterThan(*o.LimitPrice)) ||
+			(!buy && price.LessThan(*o.LimitPrice)) {
+			return
+		}
+	default:
+		return
+	}
+	var qty decimal.Decimal
+	if o.Qty != nil {
+		qty = o.Qty.Sub(o.FilledQty)
+	} else if o.Notional != nil {
+		qty = o.Notional.Div(price).Truncate(9)
+	}
+	s.fill(o, qty, price)
+}
+
+// fill fills qty of the order at price, updating the position and the cash balance.
+func (s *Server) fill(o *alpaca.Order, qty, price decimal.Decimal) {
+	now := s.now()
+	prevFilled := o.FilledQty
+	o.FilledQty = prevFilled.Add(qty)
+	avg := price
+	if o.FilledAvgPrice != nil {
+		avg = o.FilledAvgPrice.Mul(prevFilled).Add(price.Mul(qty)).Div(o.FilledQty)
+	}
+	o.FilledAvgPrice = &avg
+	o.UpdatedAt = now
+	event := alpaca.TradeEventPartialFill
+	o.Status = alpaca.OrderPartiallyFilled
+	if o.Qty == nil || o.FilledQty.GreaterThanOrEqual(*o.Qty) {
+		event = alpaca.TradeEventFill
+		o.Status = alpaca.OrderFilled
+		o.FilledAt = &now
+	}
+
+	signed := qty
+	if o.Side == alpaca.Sell {
+		signed = qty.Neg()
+	}
+	s.cash = s.cash.Sub(signed.Mul(price))
+	positionQty := s.updatePosition(o.Symbol, signed, price)
+
+	executionID := s.newID()
+	s.activities = append(s.activities, alpaca.AccountActivity{
+		ID:              executionID,
+		ActivityType:    alpaca.ActivityFill,
+		TransactionTime: now,
+		Type:            string(event),
+		Price:           price,
+		Qty:             qty,
+		Side:            string(o.Side),
+		Symbol:          o.Symbol,
+		LeavesQty:       s.leavesQty(o),
+		CumQty:          o.FilledQty,
+		OrderID:         o.ID,
+		OrderStatus:     string(o.Status),
+	})
+	s.emit(event, o, &alpaca.TradeUpdate{
+		ExecutionID: executionID,
+		PositionQty: &positionQty,
+		Price:       &price,
+		Qty:         &qty,
+		Timestamp:   &now,
+	})
+}
+
+func (s *Server) leavesQty(o *alpaca.Order) decimal.Decimal {
+	if o.Qty == nil || !o.Status.IsOpen() {
+		return decimal.Zero
+	}
+	return o.Qty.Sub(o.FilledQty)
+}
+
+// updatePosition adds signed qty at price to the position of the symbol and returns the new position qty.
+func (s *Server) updatePosition(symbol string, signed, price decimal.Decimal) decimal.Decimal {
+	p, ok := s.positions[symbol]
+	if !ok {
+		p = &position{}
+		s.positions[symbol] = p
+	}
+	newQty := p.qty.Add(signed)
+	switch {
+	case p.qty.IsZero() || p.qty.Sign() == signed.Sign():
+		// The position is opened or increased
+		p.avgEntryPrice = p.qty.Abs().Mul(p.avgEntryPrice).Add(signed.Abs().Mul(price)).Div(newQty.Abs())
+	case newQty.Sign() != 0 && newQty.Sign() != p.qty.Sign():
+		// The position is reversed
+		p.avgEntryPrice = price
+	}
+	p.qty = newQty
+	if p.qty.IsZero() {
+		delete(s.positions, symbol)
+	}
+	return newQty
+}
+
+// emit sends a trade update of the order to the stream. For fills, tu contains the execution details.
+func (s *Server) emit(event alpaca.TradeUpdateEvent, o *alpaca.Order, tu *alpaca.TradeUpdate) {
+	if tu == nil {
+		tu = &alpaca.TradeUpdate{}
+	}
+	tu.At = s.now()
+	tu.Event = event
+	tu.EventID = fmt.Sprintf("%026d", len(s.events)+1)
+	tu.Order = *o
+	s.events = append(s.events, *tu)
+	close(s.newEvent)
+	s.newEvent = make(chan struct{})
+}
+
+func (s *Server) price(symbol string, fallback decimal.Decimal) decimal.Decimal {
+	if p, ok := s.prices[symbol]; ok {
+		return p
+	}
+	return fallback
+}
+
+func (s *Server) positionsView() []alpaca.Position {
+	symbols := make([]string, 0, len(s.positions))
+	for symbol := range s.positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	positions := make([]alpaca.Position, 0, len(symbols))
+	for _, symbol := range symbols {
+		positions = append(positions, s.positionView(symbol))
+	}
+	return positions
+}
+
+func (s *Server) positionView(symbol string) alpaca.Position {
+	p := s.positions[symbol]
+	price := s.price(symbol, p.avgEntryPrice)
+	marketValue := p.qty.Mul(price)
+	costBasis := p.qty.Mul(p.avgEntryPrice)
+	unrealizedPL := marketValue.Sub(costBasis)
+	unrealizedPLPC := decimal.Zero
+	if !costBasis.IsZero() {
+		unrealizedPLPC = unrealizedPL.Div(costBasis.Abs())
+	}
+	side := "long"
+	if p.qty.IsNegative() {
+		side = "short"
+	}
+	assetClass := alpaca.USEquity
+	if isCrypto(symbol) {
+		assetClass = alpaca.Crypto
+	}
+	return alpaca.Position{
+		AssetID:        s.assetID(symbol),
+		Symbol:         symbol,
+		AssetClass:     assetClass,
+		Qty:            p.qty,
+		QtyAvailable:   p.qty,
+		AvgEntryPrice:  p.avgEntryPrice,
+		Side:           side,
+		MarketValue:    &marketValue,
+		CostBasis:      costBasis,
+		UnrealizedPL:   &unrealizedPL,
+		UnrealizedPLPC: &unrealizedPLPC,
+		CurrentPrice:   &price,
+	}
+}
+
+func (s *Server) accountView() alpaca.Account {
+	a := s.account
+	var long, short decimal.Decimal
+	for symbol, p := range s.positions {
+		mv := p.qty.Mul(s.price(symbol, p.avgEntryPrice))
+		if mv.IsNegative() {
+			short = short.Add(mv)
+		} else {
+			long = long.Add(mv)
+		}
+	}
+	a.Cash = s.cash
+	a.LongMarketValue = long
+	a.ShortMarketValue = short
+	a.PositionMarketValue = long.Add(short.Abs())
+	a.Equity = s.cash.Add(long).Add(short)
+	a.LastEquity = a.Equity
+	a.PortfolioValue = a.Equity
+	a.BuyingPower = decimal.Max(s.cash, decimal.Zero)
+	a.RegTBuyingPower = a.BuyingPower
+	a.NonMarginBuyingPower = a.BuyingPower
+	a.EffectiveBuyingPower = a.BuyingPower
+	return a
+}
+
+func (s *Server) assetID(symbol string) string {
+	for _, a := range s.assets {
+		if a.Symbol == symbol {
+			return a.ID
+		}
+	}
+	return ""
+}
+
+func (s *Server) asset(symbolOrID string) (alpaca.Asset, bool) {
+	for _, a := range s.assets {
+		if a.Symbol == symbolOrID || a.ID == symbolOrID {
+			return a, true
+		}
+	}
+	return alpaca.Asset{}, false
+}
+
+func isCrypto(symbol string) bool {
+	return strings.Contains(symbol, "/")
+}
+
+// failure returns the scripted failure of the next request, if any.
+func (s *Server) failure() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return 0, false
+	}
+	status := s.failures[0]
+	s.failures = s.failures[1:]
+	return status, true
+}
+
+func writeFailure(w http.ResponseWriter, status int) {
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "0")
+		w.Header().Set("X-RateLimit-Limit", "200")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Unix()))
+	}
+	writeError(w, status, status*100000, http.StatusText(status))
+}
@@ -0,0 +1,789 @@
+package alpacatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, 0, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+// writeError writes an error the way the API does. If code is 0, it's derived from the status.
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	if code == 0 {
+		code = status*100000 + 10000
+	}
+	writeJSON(w, status, map[string]interface{}{"code": code, "message": message})
+}
+
+func notFound(w http.ResponseWriter, what string) {
+	writeError(w, http.StatusNotFound, 0, what+" not found")
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, 0, "method not allowed")
+}
+
+func unprocessable(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusUnprocessableEntity, 0, message)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+	if status, ok := s.failure(); ok {
+		writeFailure(w, status)
+		return
+	}
+	if r.Header.Get("APCA-API-KEY-ID") == "" && r.Header.Get("Authorization") == "" {
+		writeError(w, http.StatusUnauthorized, 0, "request is not authorized")
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/v2/")
+	if !ok {
+		notFound(w, "endpoint")
+		return
+	}
+	parts := strings.Split(path, "/")
+	if path == "events/trades" {
+		s.serveTradeUpdates(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch parts[0] {
+	case "account":
+		s.serveAccount(w, r, parts[1:])
+	case "positions":
+		s.servePositions(w, r, parts[1:])
+	case "orders":
+		s.serveOrders(w, r, parts[1:])
+	case "orders:by_client_order_id":
+		s.serveOrderByClientOrderID(w, r)
+	case "assets":
+		s.serveAssets(w, r, parts[1:])
+	case "clock":
+		s.serveClock(w, r)
+	case "calendar":
+		s.serveCalendar(w, r)
+	case "watchlists":
+		s.serveWatchlists(w, r, parts[1:])
+	case "options":
+		if len(parts) < 2 || parts[1] != "contracts" {
+			notFound(w, "endpoint")
+			return
+		}
+		s.serveOptionContracts(w, r, parts[2:])
+	default:
+		notFound(w, "endpoint")
+	}
+}
+
+func (s *Server) serveAccount(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.accountView())
+	case len(parts) == 1 && parts[0] == "configurations" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.configs)
+	case len(parts) == 1 && parts[0] == "configurations" && r.Method == http.MethodPatch:
+		var req alpaca.UpdateAccountConfigurationsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, 0, err.Error())
+			return
+		}
+		if req.DtbpCheck != "" {
+			s.configs.DTBPCheck = alpaca.DTBPCheck(req.DtbpCheck)
+		}
+		if req.TradeConfirmEmail != "" {
+			s.configs.TradeConfirmEmail = alpaca.TradeConfirmEmail(req.TradeConfirmEmail)
+		}
+		s.configs.NoShorting = req.NoShorting
+		s.configs.TradeSuspendedByUser = req.SuspendTrade
+		writeJSON(w, http.StatusOK, s.configs)
+	case len(parts) == 1 && parts[0] == "activities" && r.Method == http.MethodGet:
+		s.serveActivities(w, r)
+	default:
+		notFound(w, "endpoint")
+	}
+}
+
+func (s *Server) serveActivities(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	after, errAfter := parseTime(q.Get("after"))
+	until, errUntil := parseTime(q.Get("until"))
+	pageSize, errPageSize := parseInt(q.Get("page_size"), 100)
+	if err := firstError(errAfter, errUntil, errPageSize); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	var types []string
+	if t := q.Get("activity_types"); t != "" {
+		types = strings.Split(t, ",")
+	}
+
+	activities := slices.Clone(s.activities)
+	if q.Get("direction") != "asc" {
+		slices.Reverse(activities)
+	}
+	if token := q.Get("page_token"); token != "" {
+		i := slices.IndexFunc(activities, func(a alpaca.AccountActivity) bool { return a.ID == token })
+		activities = activities[i+1:]
+	}
+	resp := []map[string]interface{}{}
+	for _, a := range activities {
+		if len(resp) == pageSize {
+			break
+		}
+		if (types != nil && !slices.Contains(types, string(a.ActivityType))) ||
+			(!after.IsZero() && !a.TransactionTime.After(after)) ||
+			(!until.IsZero() && !a.TransactionTime.Before(until)) {
+			continue
+		}
+		resp = append(resp, activityJSON(a))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// activityJSON returns the JSON object of the activity. The date is omitted if it's not set
+// because the zero civil.Date can not be decoded.
+func activityJSON(a alpaca.AccountActivity) map[string]interface{} {
+	b, _ := json.Marshal(a)
+	var m map[string]interface{}
+	_ = json.Unmarshal(b, &m)
+	if a.Date.IsZero() {
+		delete(m, "date")
+	}
+	return m
+}
+
+func (s *Server) servePositions(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.positionsView())
+		case http.MethodDelete:
+			s.closeAllPositions(w, r)
+		default:
+			methodNotAllowed(w)
+		}
+		return
+	}
+	// Crypto symbols (e.g. BTC/USD) contain a slash
+	symbol := strings.Join(parts, "/")
+	p, ok := s.positions[symbol]
+	if !ok {
+		writeError(w, http.StatusNotFound, 0, "position does not exist")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.positionView(symbol))
+	case http.MethodDelete:
+		qty := p.qty.Abs()
+		if v := r.URL.Query().Get("qty"); v != "" {
+			q, err := decimal.NewFromString(v)
+			if err != nil || !q.IsPositive() || q.GreaterThan(qty) {
+				unprocessable(w, "invalid qty")
+				return
+			}
+			qty = q
+		} else if v := r.URL.Query().Get("percentage"); v != "" {
+			pct, err := decimal.NewFromString(v)
+			if err != nil || !pct.IsPositive() || pct.GreaterThan(decimal.NewFromInt(100)) {
+				unprocessable(w, "invalid percentage")
+				return
+			}
+			qty = qty.Mul(pct).Div(decimal.NewFromInt(100)).Truncate(9)
+		}
+		writeJSON(w, http.StatusOK, s.closePosition(symbol, qty))
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (s *Server) closeAllPositions(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("cancel_orders") == "true" {
+		for _, o := range s.orders {
+			if o.Status.IsOpen() {
+				s.cancel(o)
+			}
+		}
+	}
+	type closeResult struct {
+		Symbol string          `json:"symbol"`
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	}
+	results := []closeResult{}
+	for _, p := range s.positionsView() {
+		o := s.closePosition(p.Symbol, p.Qty.Abs())
+		body, _ := json.Marshal(o)
+		results = append(results, closeResult{Symbol: p.Symbol, Status: http.StatusOK, Body: body})
+	}
+	writeJSON(w, http.StatusMultiStatus, results)
+}
+
+// closePosition places a market order that reduces the position of the symbol by qty.
+func (s *Server) closePosition(symbol string, qty decimal.Decimal) alpaca.Order {
+	side := alpaca.Sell
+	if s.positions[symbol].qty.IsNegative() {
+		side = alpaca.Buy
+	}
+	o := s.placeOrder(alpaca.PlaceOrderRequest{
+		Symbol:      symbol,
+		Qty:         &qty,
+		Side:        side,
+		Type:        alpaca.Market,
+		TimeInForce: alpaca.Day,
+	})
+	return *o
+}
+
+func (s *Server) serveOrders(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			s.getOrders(w, r)
+		case http.MethodPost:
+			s.postOrder(w, r)
+		case http.MethodDelete:
+			s.cancelAllOrders(w)
+		default:
+			methodNotAllowed(w)
+		}
+		return
+	}
+	o, ok := s.ordersByID[parts[0]]
+	if !ok || len(parts) > 1 {
+		notFound(w, "order")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, o)
+	case http.MethodPatch:
+		s.replaceOrder(w, r, o)
+	case http.MethodDelete:
+		if !o.Status.IsOpen() {
+			unprocessable(w, "order is not cancelable")
+			return
+		}
+		s.cancel(o)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (s *Server) getOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	after, errAfter := parseTime(q.Get("after"))
+	until, errUntil := parseTime(q.Get("until"))
+	limit, errLimit := parseInt(q.Get("limit"), 50)
+	if err := firstError(errAfter, errUntil, errLimit); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	limit = min(limit, 500)
+	status := q.Get("status")
+	var symbols []string
+	if v := q.Get("symbols"); v != "" {
+		symbols = strings.Split(v, ",")
+	}
+
+	orders := slices.Clone(s.orders)
+	if q.Get("direction") != "asc" {
+		slices.Reverse(orders)
+	}
+	resp := []*alpaca.Order{}
+	for _, o := range orders {
+		if len(resp) == limit {
+			break
+		}
+		if (status == "closed" && o.Status.IsOpen()) || ((status == "" || status == "open") && !o.Status.IsOpen()) ||
+			(symbols != nil && !slices.Contains(symbols, o.Symbol)) ||
+			(q.Get("side") != "" && string(o.Side) != q.Get("side")) ||
+			(!after.IsZero() && !o.SubmittedAt.After(after)) ||
+			(!until.IsZero() && !o.SubmittedAt.Before(until)) {
+			continue
+		}
+		resp = append(resp, o)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) postOrder(w http.ResponseWriter, r *http.Request) {
+	var req alpaca.PlaceOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	if msg := validateOrder(req); msg != "" {
+		unprocessable(w, msg)
+		return
+	}
+	if req.ClientOrderID != "" && slices.ContainsFunc(s.orders, func(o *alpaca.Order) bool {
+		return o.ClientOrderID == req.ClientOrderID
+	}) {
+		unprocessable(w, "client_order_id must be unique")
+		return
+	}
+	if len(s.rejections) > 0 {
+		msg := s.rejections[0]
+		s.rejections = s.rejections[1:]
+		writeError(w, http.StatusForbidden, 0, msg)
+		return
+	}
+	if req.Side == alpaca.Buy && s.cost(req).GreaterThan(s.accountView().BuyingPower) {
+		writeError(w, http.StatusForbidden, 0, "insufficient buying power")
+		return
+	}
+	o := s.placeOrder(req)
+	writeJSON(w, http.StatusOK, o)
+}
+
+func validateOrder(req alpaca.PlaceOrderRequest) string {
+	switch {
+	case req.Symbol == "":
+		return "symbol is required"
+	case req.Side != alpaca.Buy && req.Side != alpaca.Sell:
+		return "invalid side"
+	case req.Type == "":
+		return "type is required"
+	case req.TimeInForce == "":
+		return "time_in_force is required"
+	case (req.Qty == nil) == (req.Notional == nil):
+		return "qty or notional is required"
+	case (req.Type == alpaca.Limit || req.Type == alpaca.StopLimit) && req.LimitPrice == nil:
+		return "limit_price is required"
+	case (req.Type == alpaca.Stop || req.Type == alpaca.StopLimit) && req.StopPrice == nil:
+		return "stop_price is required"
+	}
+	return ""
+}
+
+// cost estimates the cost of a buy order, or returns zero if it's unknown.
+func (s *Server) cost(req alpaca.PlaceOrderRequest) decimal.Decimal {
+	if req.Notional != nil {
+		return *req.Notional
+	}
+	price, ok := s.prices[req.Symbol]
+	if req.LimitPrice != nil {
+		price, ok = *req.LimitPrice, true
+	}
+	if !ok {
+		return decimal.Zero
+	}
+	return req.Qty.Mul(price)
+}
+
+func (s *Server) cancelAllOrders(w http.ResponseWriter) {
+	type cancelResult struct {
+		ID     string        `json:"id"`
+		Status int           `json:"status"`
+		Body   *alpaca.Order `json:"body"`
+	}
+	results := []cancelResult{}
+	for _, o := range s.orders {
+		if o.Status.IsOpen() {
+			s.cancel(o)
+			results = append(results, cancelResult{ID: o.ID, Status: http.StatusOK, Body: o})
+		}
+	}
+	writeJSON(w, http.StatusMultiStatus, results)
+}
+
+func (s *Server) replaceOrder(w http.ResponseWriter, r *http.Request, old *alpaca.Order) {
+	var req alpaca.ReplaceOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	if !old.Status.IsOpen() {
+		unprocessable(w, "order is not replaceable")
+		return
+	}
+	now := s.now()
+	o := *old
+	o.ID = s.newID()
+	o.ClientOrderID = o.ID
+	if req.ClientOrderID != "" {
+		o.ClientOrderID = req.ClientOrderID
+	}
+	o.CreatedAt, o.UpdatedAt, o.SubmittedAt = now, now, now
+	o.Status = alpaca.OrderNew
+	o.Replaces = &old.ID
+	o.FilledQty = decimal.Zero
+	o.FilledAvgPrice = nil
+	if old.Qty != nil {
+		remaining := old.Qty.Sub(old.FilledQty)
+		o.Qty = &remaining
+	}
+	if req.Qty != nil {
+		o.Qty = req.Qty
+	}
+	if req.LimitPrice != nil {
+		o.LimitPrice = req.LimitPrice
+	}
+	if req.StopPrice != nil {
+		o.StopPrice = req.StopPrice
+	}
+	if req.Trail != nil {
+		if o.TrailPercent != nil {
+			o.TrailPercent = req.Trail
+		} else {
+			o.TrailPrice = req.Trail
+		}
+	}
+	if req.TimeInForce != "" {
+		o.TimeInForce = req.TimeInForce
+	}
+
+	old.Status = alpaca.OrderReplaced
+	old.ReplacedAt = &now
+	old.ReplacedBy = &o.ID
+	old.UpdatedAt = now
+	s.emit(alpaca.TradeEventReplaced, old, nil)
+	s.addOrder(&o)
+	writeJSON(w, http.StatusOK, &o)
+}
+
+func (s *Server) serveOrderByClientOrderID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	id := r.URL.Query().Get("client_order_id")
+	for _, o := range s.orders {
+		if o.ClientOrderID == id {
+			writeJSON(w, http.StatusOK, o)
+			return
+		}
+	}
+	notFound(w, "order")
+}
+
+func (s *Server) serveAssets(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if len(parts) > 0 {
+		a, ok := s.asset(strings.Join(parts, "/"))
+		if !ok {
+			notFound(w, "asset")
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+		return
+	}
+	q := r.URL.Query()
+	assets := []alpaca.Asset{}
+	for _, a := range s.assets {
+		if (q.Get("status") != "" && string(a.Status) != q.Get("status")) ||
+			(q.Get("asset_class") != "" && string(a.Class) != q.Get("asset_class")) ||
+			(q.Get("exchange") != "" && a.Exchange != q.Get("exchange")) {
+			continue
+		}
+		assets = append(assets, a)
+	}
+	writeJSON(w, http.StatusOK, assets)
+}
+
+func (s *Server) serveClock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if s.clock != nil {
+		writeJSON(w, http.StatusOK, s.clock)
+		return
+	}
+	now := s.now()
+	writeJSON(w, http.StatusOK, alpaca.Clock{
+		Timestamp: now,
+		IsOpen:    true,
+		NextOpen:  now.Add(24 * time.Hour),
+		NextClose: now.Add(time.Hour),
+	})
+}
+
+func (s *Server) serveCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	start, end := r.URL.Query().Get("start"), r.URL.Query().Get("end")
+	days := []alpaca.CalendarDay{}
+	for _, d := range s.calendar {
+		// The dates are in YYYY-MM-DD format, so they can be compared as strings
+		if (start != "" && d.Date < start) || (end != "" && d.Date > end) {
+			continue
+		}
+		days = append(days, d)
+	}
+	writeJSON(w, http.StatusOK, days)
+}
+
+func (s *Server) serveWatchlists(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.watchlists)
+		case http.MethodPost:
+			var req alpaca.CreateWatchlistRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+				unprocessable(w, "name is required")
+				return
+			}
+			now := s.now().Format(time.RFC3339Nano)
+			wl := &alpaca.Watchlist{
+				AccountID: s.account.ID,
+				ID:        s.newID(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				Name:      req.Name,
+				Assets:    s.watchlistAssets(req.Symbols),
+			}
+			s.watchlists = append(s.watchlists, wl)
+			writeJSON(w, http.StatusOK, wl)
+		default:
+			methodNotAllowed(w)
+		}
+		return
+	}
+	i := slices.IndexFunc(s.watchlists, func(wl *alpaca.Watchlist) bool { return wl.ID == parts[0] })
+	if i < 0 {
+		notFound(w, "watchlist")
+		return
+	}
+	wl := s.watchlists[i]
+	if len(parts) > 1 {
+		if r.Method != http.MethodDelete {
+			methodNotAllowed(w)
+			return
+		}
+		symbol := strings.Join(parts[1:], "/")
+		wl.Assets = slices.DeleteFunc(wl.Assets, func(a alpaca.Asset) bool { return a.Symbol == symbol })
+		writeJSON(w, http.StatusOK, wl)
+		return
+	}
+	s.serveWatchlist(w, r, i)
+}
+
+func (s *Server) serveWatchlist(w http.ResponseWriter, r *http.Request, i int) {
+	wl := s.watchlists[i]
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, wl)
+	case http.MethodPut:
+		var req alpaca.UpdateWatchlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, 0, err.Error())
+			return
+		}
+		if req.Name != "" {
+			wl.Name = req.Name
+		}
+		wl.Assets = s.watchlistAssets(req.Symbols)
+		wl.UpdatedAt = s.now().Format(time.RFC3339Nano)
+		writeJSON(w, http.StatusOK, wl)
+	case http.MethodPost:
+		var req alpaca.AddSymbolToWatchlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Symbol == "" {
+			unprocessable(w, "symbol is required")
+			return
+		}
+		wl.Assets = append(wl.Assets, s.watchlistAssets([]string{req.Symbol})...)
+		wl.UpdatedAt = s.now().Format(time.RFC3339Nano)
+		writeJSON(w, http.StatusOK, wl)
+	case http.MethodDelete:
+		s.watchlists = slices.Delete(s.watchlists, i, i+1)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// watchlistAssets returns the assets of the symbols. The unknown symbols are returned as bare assets.
+func (s *Server) watchlistAssets(symbols []string) []alpaca.Asset {
+	assets := make([]alpaca.Asset, 0, len(symbols))
+	for _, symbol := range symbols {
+		a, ok := s.asset(symbol)
+		if !ok {
+			a = alpaca.Asset{Symbol: symbol, Status: alpaca.AssetActive, Tradable: true}
+		}
+		assets = append(assets, a)
+	}
+	return assets
+}
+
+func (s *Server) serveOptionContracts(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if len(parts) > 0 {
+		i := slices.IndexFunc(s.optionContracts, func(c alpaca.OptionContract) bool {
+			return c.Symbol == parts[0] || c.ID == parts[0]
+		})
+		if i < 0 {
+			notFound(w, "option contract")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.optionContracts[i])
+		return
+	}
+	q := r.URL.Query()
+	limit, errLimit := parseInt(q.Get("limit"), 100)
+	offset, errOffset := parseInt(q.Get("page_token"), 0)
+	if err := firstError(errLimit, errOffset); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	contracts := []alpaca.OptionContract{}
+	for _, c := range s.optionContracts {
+		if matchOptionContract(c, q.Get) {
+			contracts = append(contracts, c)
+		}
+	}
+	resp := struct {
+		OptionContracts []alpaca.OptionContract `json:"option_contracts"`
+		NextPageToken   *string                 `json:"next_page_token"`
+	}{OptionContracts: []alpaca.OptionContract{}}
+	if offset < len(contracts) {
+		end := min(offset+limit, len(contracts))
+		resp.OptionContracts = contracts[offset:end]
+		if end < len(contracts) {
+			token := strconv.Itoa(end)
+			resp.NextPageToken = &token
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// matchOptionContract returns whether the contract matches the filters of the query.
+func matchOptionContract(c alpaca.OptionContract, param func(string) string) bool {
+	if v := param("underlying_symbols"); v != "" && !slices.Contains(strings.Split(v, ","), c.UnderlyingSymbol) {
+		return false
+	}
+	expiration := c.ExpirationDate.String()
+	strike := c.StrikePrice
+	gte := func(name string) bool {
+		v, err := decimal.NewFromString(param(name))
+		return err != nil || strike.GreaterThanOrEqual(v)
+	}
+	lte := func(name string) bool {
+		v, err := decimal.NewFromString(param(name))
+		return err != nil || strike.LessThanOrEqual(v)
+	}
+	return (param("status") == "" || string(c.Status) == param("status")) &&
+		(param("type") == "" || string(c.Type) == param("type")) &&
+		(param("style") == "" || string(c.Style) == param("style")) &&
+		(param("root_symbol") == "" || (c.RootSymbol != nil && *c.RootSymbol == param("root_symbol"))) &&
+		(param("expiration_date") == "" || expiration == param("expiration_date")) &&
+		(param("expiration_date_gte") == "" || expiration >= param("expiration_date_gte")) &&
+		(param("expiration_date_lte") == "" || expiration <= param("expiration_date_lte")) &&
+		gte("strike_price_gte") && lte("strike_price_lte")
+}
+
+// serveTradeUpdates streams the trade updates as server-sent events. Without since or since_id,
+// only the trade updates happening after the connection are sent. The stream ends after
+// until or until_id if they are set.
+func (s *Server) serveTradeUpdates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, errSince := parseTime(q.Get("since"))
+	until, errUntil := parseTime(q.Get("until"))
+	if err := firstError(errSince, errUntil); err != nil {
+		writeError(w, http.StatusBadRequest, 0, err.Error())
+		return
+	}
+	sinceID, untilID := q.Get("since_id"), q.Get("until_id")
+
+	s.mu.Lock()
+	next := len(s.events)
+	if !since.IsZero() || sinceID != "" {
+		next = 0
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		s.mu.Lock()
+		events := s.events[next:]
+		next = len(s.events)
+		newEvent := s.newEvent
+		s.mu.Unlock()
+
+		for _, tu := range events {
+			if (!until.IsZero() && tu.At.After(until)) || (untilID != "" && tu.EventID > untilID) {
+				return
+			}
+			if (!since.IsZero() && tu.At.Before(since)) || (sinceID != "" && tu.EventID <= sinceID) {
+				continue
+			}
+			b, _ := json.Marshal(tu)
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if untilID != "" && len(events) > 0 && events[len(events)-1].EventID == untilID {
+			return
+		}
+		select {
+		case <-newEvent:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+func parseInt(v string, defaultValue int) (int, error) {
+	if v == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package alpacatest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+func dec(s string) *decimal.Decimal {
+	d := decimal.RequireFromString(s)
+	return &d
+}
+
+func TestServer_Orders(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	o, err := c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol:        "AAPL",
+		Qty:           dec("10"),
+		Side:          alpaca.Buy,
+		Type:          alpaca.Limit,
+		LimitPrice:    dec("100"),
+		TimeInForce:   alpaca.Day,
+		ClientOrderID: "my-order",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderNew, o.Status)
+	assert.Equal(t, "my-order", o.ClientOrderID)
+
+	// The limit is not reached
+	s.SetPrice("AAPL", decimal.NewFromInt(101))
+	got, err := c.GetOrderByClientOrderID("my-order")
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderNew, got.Status)
+
+	require.NoError(t, s.Fill(o.ID, decimal.NewFromInt(4), decimal.NewFromInt(100)))
+	got, err = c.GetOrder(o.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderPartiallyFilled, got.Status)
+	assert.Equal(t, "4", got.FilledQty.String())
+
+	s.SetPrice("AAPL", decimal.NewFromInt(99))
+	got, err = c.GetOrder(o.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderFilled, got.Status)
+	assert.Equal(t, "99.4", got.FilledAvgPrice.String())
+	assert.ErrorIs(t, s.Fill(o.ID, decimal.NewFromInt(1), decimal.NewFromInt(99)), ErrOrderNotOpen)
+
+	p, err := c.GetPosition("AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, "10", p.Qty.String())
+	assert.Equal(t, "99.4", p.AvgEntryPrice.String())
+	assert.Equal(t, "990", p.MarketValue.String())
+
+	acct, err := c.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, "99006", acct.Cash.String())
+	assert.Equal(t, "99996", acct.Equity.String())
+
+	activities, err := c.GetAccountActivities(alpaca.GetAccountActivitiesRequest{
+		ActivityTypes: []string{string(alpaca.ActivityFill)},
+		Direction:     "asc",
+	})
+	require.NoError(t, err)
+	require.Len(t, activities, 2)
+	assert.Equal(t, "partial_fill", activities[0].Type)
+	assert.Equal(t, "6", activities[1].Qty.String())
+
+	open, err := c.GetOrders(alpaca.GetOrdersRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, open)
+	all, err := c.GetOrders(alpaca.GetOrdersRequest{Status: "all", Symbols: []string{"AAPL"}})
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	_, err = c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("1"), Side: alpaca.Sell, Type: alpaca.Market, TimeInForce: alpaca.Day,
+		ClientOrderID: "my-order",
+	})
+	var apiErr *alpaca.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+}
+
+func TestServer_ReplaceAndCancel(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	o, err := c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "MSFT", Qty: dec("5"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("300"),
+		TimeInForce: alpaca.GTC,
+	})
+	require.NoError(t, err)
+	replaced, err := c.ReplaceOrder(o.ID, alpaca.ReplaceOrderRequest{LimitPrice: dec("310")})
+	require.NoError(t, err)
+	assert.Equal(t, o.ID, *replaced.Replaces)
+	assert.Equal(t, "310", replaced.LimitPrice.String())
+	assert.Equal(t, "5", replaced.Qty.String())
+
+	old, err := c.GetOrder(o.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderReplaced, old.Status)
+	assert.Equal(t, replaced.ID, *old.ReplacedBy)
+
+	require.NoError(t, c.CancelOrder(replaced.ID))
+	err = c.CancelOrder(replaced.ID)
+	var apiErr *alpaca.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+
+	events := []alpaca.TradeUpdateEvent{}
+	for _, tu := range s.TradeUpdates() {
+		events = append(events, tu.Event)
+	}
+	assert.Equal(t, []alpaca.TradeUpdateEvent{
+		alpaca.TradeEventNew, alpaca.TradeEventReplaced, alpaca.TradeEventNew, alpaca.TradeEventCanceled,
+	}, events)
+}
+
+func TestServer_StopAndIOC(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+	s.SetPrice("SPY", decimal.NewFromInt(500))
+	s.SetPosition("SPY", decimal.NewFromInt(10), decimal.NewFromInt(450))
+
+	stop, err := c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "SPY", Qty: dec("10"), Side: alpaca.Sell, Type: alpaca.Stop, StopPrice: dec("490"),
+		TimeInForce: alpaca.GTC,
+	})
+	require.NoError(t, err)
+	ioc, err := c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "SPY", Qty: dec("1"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("480"),
+		TimeInForce: alpaca.IOC,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderCanceled, ioc.Status)
+
+	s.SetPrice("SPY", decimal.NewFromInt(495))
+	assert.Len(t, s.Positions(), 1)
+	s.SetPrice("SPY", decimal.NewFromInt(489))
+	assert.Empty(t, s.Positions())
+	got, err := c.GetOrder(stop.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderFilled, got.Status)
+	assert.Equal(t, "489", got.FilledAvgPrice.String())
+}
+
+func TestServer_ClosePositions(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+	s.SetPosition("AAPL", decimal.NewFromInt(10), decimal.NewFromInt(100))
+	s.SetPosition("TSLA", decimal.NewFromInt(-4), decimal.NewFromInt(200))
+	s.SetPrice("AAPL", decimal.NewFromInt(110))
+
+	o, err := c.ClosePosition("AAPL", alpaca.ClosePositionRequest{Percentage: decimal.NewFromInt(50)})
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.Sell, o.Side)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+	p, err := c.GetPosition("AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, "5", p.Qty.String())
+
+	orders, err := c.CloseAllPositions(alpaca.CloseAllPositionsRequest{CancelOrders: true})
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, alpaca.Buy, orders[1].Side)
+	assert.Equal(t, alpaca.OrderNew, orders[1].Status)
+
+	_, err = c.GetPosition("AAPL")
+	var apiErr *alpaca.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestServer_Failures(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	s.FailNext(2, http.StatusTooManyRequests)
+	_, err := c.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /v2/account", "GET /v2/account", "GET /v2/account"}, s.Requests())
+
+	s.FailNext(1, http.StatusInternalServerError)
+	_, err = c.GetClock()
+	var apiErr *alpaca.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+
+	s.RejectNextOrder("account is not allowed to trade")
+	req := alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("1"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	}
+	_, err = c.PlaceOrder(req)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "account is not allowed to trade", apiErr.Message)
+
+	s.SetPrice("AAPL", decimal.NewFromInt(200_000))
+	_, err = c.PlaceOrder(req)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "insufficient buying power", apiErr.Message)
+
+	s.SetPrice("AAPL", decimal.NewFromInt(200))
+	o, err := c.PlaceOrder(req)
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+
+	o, err = c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("1"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("100"),
+		TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Reject(o.ID))
+	o, err = c.GetOrder(o.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderRejected, o.Status)
+}
+
+func TestServer_TradeUpdates(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	updates := make(chan alpaca.TradeUpdate, 10)
+	connected := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.StreamTradeUpdatesInBackground(ctx, func(tu alpaca.TradeUpdate) {
+		updates <- tu
+	}, alpaca.WithConnectCallback(func() { close(connected) }))
+	select {
+	case <-connected:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "stream not connected")
+	}
+
+	o, err := c.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("2"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	s.SetPrice("AAPL", decimal.NewFromInt(150))
+
+	for _, want := range []alpaca.TradeUpdateEvent{alpaca.TradeEventNew, alpaca.TradeEventFill} {
+		select {
+		case tu := <-updates:
+			assert.Equal(t, want, tu.Event)
+			assert.Equal(t, o.ID, tu.Order.ID)
+			if want == alpaca.TradeEventFill {
+				assert.Equal(t, "2", tu.PositionQty.String())
+				assert.Equal(t, "150", tu.Price.String())
+			}
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "trade update not received", want)
+		}
+	}
+
+	// The past trade updates can be replayed
+	var replayed []alpaca.TradeUpdate
+	err = c.StreamTradeUpdates(context.Background(), func(tu alpaca.TradeUpdate) {
+		replayed = append(replayed, tu)
+	}, alpaca.StreamTradeUpdatesRequest{SinceID: "0", UntilID: s.TradeUpdates()[1].EventID})
+	require.NoError(t, err)
+	assert.Len(t, replayed, 2)
+}
+
+func TestServer_ReferenceData(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	s.AddAsset(alpaca.Asset{ID: "aapl-id", Symbol: "AAPL", Class: alpaca.USEquity, Status: alpaca.AssetActive})
+	s.AddAsset(alpaca.Asset{ID: "btc-id", Symbol: "BTC/USD", Class: alpaca.Crypto, Status: alpaca.AssetActive})
+	assets, err := c.GetAssets(alpaca.GetAssetsRequest{AssetClass: string(alpaca.Crypto)})
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	asset, err := c.GetAsset("BTC/USD")
+	require.NoError(t, err)
+	assert.Equal(t, "btc-id", asset.ID)
+
+	clock, err := c.GetClock()
+	require.NoError(t, err)
+	assert.True(t, clock.IsOpen)
+
+	s.SetCalendar([]alpaca.CalendarDay{
+		{Date: "2024-01-02", Open: "09:30", Close: "16:00"},
+		{Date: "2024-01-03", Open: "09:30", Close: "16:00"},
+	})
+	days, err := c.GetCalendar(alpaca.GetCalendarRequest{Start: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Equal(t, []alpaca.CalendarDay{{Date: "2024-01-03", Open: "09:30", Close: "16:00"}}, days)
+
+	wl, err := c.CreateWatchlist(alpaca.CreateWatchlistRequest{Name: "tech", Symbols: []string{"AAPL"}})
+	require.NoError(t, err)
+	wl, err = c.AddSymbolToWatchlist(wl.ID, alpaca.AddSymbolToWatchlistRequest{Symbol: "MSFT"})
+	require.NoError(t, err)
+	assert.Len(t, wl.Assets, 2)
+	require.NoError(t, c.RemoveSymbolFromWatchlist(wl.ID, alpaca.RemoveSymbolFromWatchlistRequest{Symbol: "AAPL"}))
+	wl, err = c.GetWatchlist(wl.ID)
+	require.NoError(t, err)
+	require.Len(t, wl.Assets, 1)
+	assert.Equal(t, "MSFT", wl.Assets[0].Symbol)
+	require.NoError(t, c.DeleteWatchlist(wl.ID))
+	wls, err := c.GetWatchlists()
+	require.NoError(t, err)
+	assert.Empty(t, wls)
+
+	for i, strike := range []string{"100", "110", "120"} {
+		s.AddOptionContract(alpaca.OptionContract{
+			ID:               "contract-" + strike,
+			Symbol:           "AAPL240119C00" + strike + "000",
+			UnderlyingSymbol: "AAPL",
+			Type:             alpaca.OptionTypeCall,
+			ExpirationDate:   civil.Date{Year: 2024, Month: 1, Day: 19 + i},
+			StrikePrice:      decimal.RequireFromString(strike),
+		})
+	}
+	contracts, err := c.GetOptionContracts(alpaca.GetOptionContractsRequest{
+		UnderlyingSymbols: "AAPL",
+		StrikePriceGTE:    decimal.NewFromInt(105),
+		PageLimit:         1,
+	})
+	require.NoError(t, err)
+	require.Len(t, contracts, 2)
+	assert.Equal(t, "contract-120", contracts[1].ID)
+	contract, err := c.GetOptionContract("contract-110")
+	require.NoError(t, err)
+	assert.Equal(t, "AAPL240119C00110000", contract.Symbol)
+}
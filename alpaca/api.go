@@ -0,0 +1,97 @@
+package alpaca
+
+import "context"
+
+// TradingAPI is the method set of the Trading API client, implemented by *Client.
+// Depending on it instead of *Client allows substituting a fake in tests, such as the
+// ones in the alpacatest package.
+//
+// The iterators (IterateOrders, IterateAccountActivities and their WithContext variants) are not
+// part of the interface, since they return concrete iterator types that a fake couldn't
+// construct, nor are the streams (StreamTradeUpdatesInBackground, NewTradeUpdatesClient) and
+// NewAccountState, which hold a connection or a background goroutine and have their own
+// lifecycle. RateLimit isn't part of it either: it doesn't call the API, it reports the rate
+// limit headers of the last response received by the client.
+type TradingAPI interface {
+	GetAccount() (*Account, error)
+	GetAccountWithContext(ctx context.Context) (*Account, error)
+	GetAccountConfigurations() (*AccountConfigurations, error)
+	GetAccountConfigurationsWithContext(ctx context.Context) (*AccountConfigurations, error)
+	UpdateAccountConfigurations(req UpdateAccountConfigurationsRequest) (*AccountConfigurations, error)
+	UpdateAccountConfigurationsWithContext(
+		ctx context.Context, req UpdateAccountConfigurationsRequest,
+	) (*AccountConfigurations, error)
+	GetAccountActivities(req GetAccountActivitiesRequest) ([]AccountActivity, error)
+	GetAccountActivitiesWithContext(ctx context.Context, req GetAccountActivitiesRequest) ([]AccountActivity, error)
+	GetAllAccountActivities(req GetAccountActivitiesRequest) ([]AccountActivity, error)
+	GetAllAccountActivitiesWithContext(ctx context.Context, req GetAccountActivitiesRequest) ([]AccountActivity, error)
+	GetPortfolioHistory(req GetPortfolioHistoryRequest) (*PortfolioHistory, error)
+	GetPortfolioHistoryWithContext(ctx context.Context, req GetPortfolioHistoryRequest) (*PortfolioHistory, error)
+	GetPositions() ([]Position, error)
+	GetPositionsWithContext(ctx context.Context) ([]Position, error)
+	GetPosition(symbol string) (*Position, error)
+	GetPositionWithContext(ctx context.Context, symbol string) (*Position, error)
+	CloseAllPositions(req CloseAllPositionsRequest) ([]Order, error)
+	CloseAllPositionsWithContext(ctx context.Context, req CloseAllPositionsRequest) ([]Order, error)
+	ClosePosition(symbol string, req ClosePositionRequest) (*Order, error)
+	ClosePositionWithContext(ctx context.Context, symbol string, req ClosePositionRequest) (*Order, error)
+	GetClock() (*Clock, error)
+	GetClockWithContext(ctx context.Context) (*Clock, error)
+	GetCalendar(req GetCalendarRequest) ([]CalendarDay, error)
+	GetCalendarWithContext(ctx context.Context, req GetCalendarRequest) ([]CalendarDay, error)
+	GetOrders(req GetOrdersRequest) ([]Order, error)
+	GetOrdersWithContext(ctx context.Context, req GetOrdersRequest) ([]Order, error)
+	GetAllOrders(req GetOrdersRequest) ([]Order, error)
+	GetAllOrdersWithContext(ctx context.Context, req GetOrdersRequest) ([]Order, error)
+	PlaceOrder(req PlaceOrderRequest) (*Order, error)
+	PlaceOrderWithContext(ctx context.Context, req PlaceOrderRequest) (*Order, error)
+	GetOrder(orderID string) (*Order, error)
+	GetOrderWithContext(ctx context.Context, orderID string) (*Order, error)
+	GetOrderByClientOrderID(clientOrderID string) (*Order, error)
+	GetOrderByClientOrderIDWithContext(ctx context.Context, clientOrderID string) (*Order, error)
+	ReplaceOrder(orderID string, req ReplaceOrderRequest) (*Order, error)
+	ReplaceOrderWithContext(ctx context.Context, orderID string, req ReplaceOrderRequest) (*Order, error)
+	CancelOrder(orderID string) error
+	CancelOrderWithContext(ctx context.Context, orderID string) error
+	CancelAllOrders() error
+	CancelAllOrdersWithContext(ctx context.Context) error
+	GetAssets(req GetAssetsRequest) ([]Asset, error)
+	GetAssetsWithContext(ctx context.Context, req GetAssetsRequest) ([]Asset, error)
+	GetAsset(symbol string) (*Asset, error)
+	GetAssetWithContext(ctx context.Context, symbol string) (*Asset, error)
+	GetOptionContracts(req GetOptionContractsRequest) ([]OptionContract, error)
+	GetOptionContractsWithContext(ctx context.Context, req GetOptionContractsRequest) ([]OptionContract, error)
+	GetOptionContract(symbolOrID string) (*OptionContract, error)
+	GetOptionContractWithContext(ctx context.Context, symbolOrID string) (*OptionContract, error)
+	GetAnnouncements(req GetAnnouncementsRequest) ([]Announcement, error)
+	GetAnnouncementsWithContext(ctx context.Context, req GetAnnouncementsRequest) ([]Announcement, error)
+	GetAnnouncement(announcementID string) (*Announcement, error)
+	GetAnnouncementWithContext(ctx context.Context, announcementID string) (*Announcement, error)
+	GetWatchlists() ([]Watchlist, error)
+	GetWatchlistsWithContext(ctx context.Context) ([]Watchlist, error)
+	CreateWatchlist(req CreateWatchlistRequest) (*Watchlist, error)
+	CreateWatchlistWithContext(ctx context.Context, req CreateWatchlistRequest) (*Watchlist, error)
+	GetWatchlist(watchlistID string) (*Watchlist, error)
+	GetWatchlistWithContext(ctx context.Context, watchlistID string) (*Watchlist, error)
+	UpdateWatchlist(watchlistID string, req UpdateWatchlistRequest) (*Watchlist, error)
+	UpdateWatchlistWithContext(
+		ctx context.Context, watchlistID string, req UpdateWatchlistRequest,
+	) (*Watchlist, error)
+	AddSymbolToWatchlist(watchlistID string, req AddSymbolToWatchlistRequest) (*Watchlist, error)
+	AddSymbolToWatchlistWithContext(
+		ctx context.Context, watchlistID string, req AddSymbolToWatchlistRequest,
+	) (*Watchlist, error)
+	RemoveSymbolFromWatchlist(watchlistID string, req RemoveSymbolFromWatchlistRequest) error
+	RemoveSymbolFromWatchlistWithContext(
+		ctx context.Context, watchlistID string, req RemoveSymbolFromWatchlistRequest,
+	) error
+	DeleteWatchlist(watchlistID string) error
+	DeleteWatchlistWithContext(ctx context.Context, watchlistID string) error
+	GetUSTreasuries(req GetUSTreasuriesRequest) ([]USTreasury, error)
+	GetUSTreasuriesWithContext(ctx context.Context, req GetUSTreasuriesRequest) ([]USTreasury, error)
+	GetUSCorporates(req GetUSCorporatesRequest) ([]USCorporate, error)
+	GetUSCorporatesWithContext(ctx context.Context, req GetUSCorporatesRequest) ([]USCorporate, error)
+	StreamTradeUpdates(ctx context.Context, handler func(TradeUpdate), req StreamTradeUpdatesRequest) error
+}
+
+var _ TradingAPI = (*Client)(nil)
@@ -0,0 +1,327 @@
+package alpaca
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// OrderBook is an in-memory view of the open orders of the account, kept up to date by
+// applying the trade updates. An order is removed from the book once it reaches a terminal
+// status. It is safe for concurrent use.
+type OrderBook struct {
+	mu     sync.RWMutex
+	orders map[string]Order
+	// seq is incremented by each applied trade update, and seqs records the last one of each order
+	seq  uint64
+	seqs map[string]uint64
+}
+
+// NewOrderBook returns a new OrderBook containing the open orders among orders.
+func NewOrderBook(orders []Order) *OrderBook {
+	b := &OrderBook{orders: make(map[string]Order), seqs: make(map[string]uint64)}
+	for _, o := range orders {
+		if o.Status.IsOpen() {
+			b.orders[o.ID] = o
+		}
+	}
+	return b
+}
+
+// Apply applies the trade update to the book. The updates older than the order in the book
+// (by their UpdatedAt) are ignored. It reports whether the book changed.
+func (b *OrderBook) Apply(tu TradeUpdate) bool {
+	o := tu.Order
+	if o.ID == "" {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cur, ok := b.orders[o.ID]
+	if ok && cur.UpdatedAt.After(o.UpdatedAt) {
+		return false
+	}
+	if !ok && !o.Status.IsOpen() {
+		return false
+	}
+	b.seq++
+	b.seqs[o.ID] = b.seq
+	if o.Status.IsOpen() {
+		b.orders[o.ID] = o
+	} else {
+		delete(b.orders, o.ID)
+	}
+	return true
+}
+
+// Get returns the open order with the given ID.
+func (b *OrderBook) Get(orderID string) (Order, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	o, ok := b.orders[orderID]
+	return o, ok
+}
+
+// GetByClientOrderID returns the open order with the given client order ID.
+func (b *OrderBook) GetByClientOrderID(clientOrderID string) (Order, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, o := range b.orders {
+		if o.ClientOrderID == clientOrderID {
+			return o, true
+		}
+	}
+	return Order{}, false
+}
+
+// Orders returns the open orders, sorted by submission time.
+func (b *OrderBook) Orders() []Order {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	orders := make([]Order, 0, len(b.orders))
+	for _, o := range b.orders {
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].SubmittedAt.Equal(orders[j].SubmittedAt) {
+			return orders[i].SubmittedAt.Before(orders[j].SubmittedAt)
+		}
+		return orders[i].ID < orders[j].ID
+	})
+	return orders
+}
+
+func (b *OrderBook) version() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.seq
+}
+
+// reconcile replaces the content of the book with the open orders returned by the API and
+// returns the differences. The orders updated since the version were fetched concurrently with
+// the trade updates, so they are only replaced if they match, and never reported as drift.
+func (b *OrderBook) reconcile(remote []Order, since uint64) []OrderDrift {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var drifts []OrderDrift
+	remoteByID := make(map[string]Order, len(remote))
+	for _, r := range remote {
+		remoteByID[r.ID] = r
+		local, ok := b.orders[r.ID]
+		switch {
+		case b.seqs[r.ID] > since:
+			if ok && sameOrder(local, r) {
+				b.orders[r.ID] = r
+			}
+			continue
+		case !ok:
+			r := r
+			drifts = append(drifts, OrderDrift{OrderID: r.ID, Remote: &r})
+		case !sameOrder(local, r):
+			local, r := local, r
+			drifts = append(drifts, OrderDrift{OrderID: r.ID, Local: &local, Remote: &r})
+		}
+		b.orders[r.ID] = r
+	}
+	for id, local := range b.orders {
+		if _, ok := remoteByID[id]; ok || b.seqs[id] > since {
+			continue
+		}
+		local := local
+		drifts = append(drifts, OrderDrift{OrderID: id, Local: &local})
+		delete(b.orders, id)
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].OrderID < drifts[j].OrderID })
+	return drifts
+}
+
+// sameOrder reports whether the state of the orders is the same.
+func sameOrder(a, b Order) bool {
+	return a.Status == b.Status && a.FilledQty.Equal(b.FilledQty) && equalDecimals(a.Qty, b.Qty) &&
+		equalDecimals(a.LimitPrice, b.LimitPrice) && equalDecimals(a.StopPrice, b.StopPrice)
+}
+
+func equalDecimals(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// PositionBook is an in-memory view of the open positions of the account, kept up to date by
+// applying the fills of the trade updates. It is safe for concurrent use.
+//
+// The quantities, the average entry prices and the cost bases are updated by the fills. The
+// positions are valued at the price of their last fill until they are reconciled.
+type PositionBook struct {
+	mu        sync.RWMutex
+	positions map[string]Position
+	seq       uint64
+	seqs      map[string]uint64
+	// fillTimes records the time of the last fill applied to each symbol
+	fillTimes map[string]time.Time
+}
+
+// NewPositionBook returns a new PositionBook containing the positions.
+func NewPositionBook(positions []Position) *PositionBook {
+	b := &PositionBook{
+		positions: make(map[string]Position),
+		seqs:      make(map[string]uint64),
+		fillTimes: make(map[string]time.Time),
+	}
+	for _, p := range positions {
+		b.positions[p.Symbol] = p
+	}
+	return b
+}
+
+// Apply applies the fill or partial fill of the trade update to the position of its symbol.
+// The fills older than the last one applied to the symbol (by their Timestamp, or At if it's
+// missing) are ignored. It reports whether the book changed.
+func (b *PositionBook) Apply(tu TradeUpdate) bool {
+	if (tu.Event != TradeEventFill && tu.Event != TradeEventPartialFill) || tu.PositionQty == nil {
+		return false
+	}
+	symbol := tu.Order.Symbol
+	at := tu.At
+	if tu.Timestamp != nil {
+		at = *tu.Timestamp
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if at.Before(b.fillTimes[symbol]) {
+		return false
+	}
+	if !at.IsZero() {
+		b.fillTimes[symbol] = at
+	}
+	b.seq++
+	b.seqs[symbol] = b.seq
+	qty := *tu.PositionQty
+	if qty.IsZero() {
+		delete(b.positions, symbol)
+		return true
+	}
+	p, ok := b.positions[symbol]
+	if !ok {
+		p = Position{Symbol: symbol, AssetID: tu.Order.AssetID, AssetClass: tu.Order.AssetClass}
+	}
+	price := p.AvgEntryPrice
+	if tu.Price != nil {
+		price = *tu.Price
+	}
+	// The fills are applied by the resulting position qty, so that applying an update twice
+	// doesn't change the qty
+	switch prev := p.Qty; {
+	case prev.IsZero() || prev.Sign() != qty.Sign():
+		p.AvgEntryPrice = price
+	case qty.Abs().GreaterThan(prev.Abs()):
+		p.AvgEntryPrice = prev.Mul(p.AvgEntryPrice).Add(qty.Sub(prev).Mul(price)).Div(qty)
+	}
+	p.Qty = qty
+	p.QtyAvailable = qty
+	p.Side = "long"
+	if qty.IsNegative() {
+		p.Side = "short"
+	}
+	p.CostBasis = qty.Mul(p.AvgEntryPrice)
+	marketValue := qty.Mul(price)
+	unrealizedPL := marketValue.Sub(p.CostBasis)
+	unrealizedPLPC := decimal.Zero
+	if !p.CostBasis.IsZero() {
+		unrealizedPLPC = unrealizedPL.Div(p.CostBasis.Abs())
+	}
+	p.CurrentPrice = &price
+	p.MarketValue = &marketValue
+	p.UnrealizedPL = &unrealizedPL
+	p.UnrealizedPLPC = &unrealizedPLPC
+	b.positions[symbol] = p
+	return true
+}
+
+// Get returns the open position of the symbol.
+func (b *PositionBook) Get(symbol string) (Position, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	p, ok := b.positions[symbol]
+	return p, ok
+}
+
+// Positions returns the open positions, sorted by symbol.
+func (b *PositionBook) Positions() []Position {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	positions := make([]Position, 0, len(b.positions))
+	for _, p := range b.positions {
+		positions = append(positions, p)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions
+}
+
+func (b *PositionBook) version() uint64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.seq
+}
+
+// reconcile replaces the content of the book with the positions returned by the API and
+// returns the differences of qty, like OrderBook.reconcile.
+func (b *PositionBook) reconcile(remote []Position, since uint64) []PositionDrift {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var drifts []PositionDrift
+	remoteBySymbol := make(map[string]Position, len(remote))
+	for _, r := range remote {
+		remoteBySymbol[r.Symbol] = r
+		local := b.positions[r.Symbol]
+		switch {
+		case b.seqs[r.Symbol] > since:
+			if !local.Qty.Equal(r.Qty) {
+				continue
+			}
+		case !local.Qty.Equal(r.Qty):
+			drifts = append(drifts, PositionDrift{Symbol: r.Symbol, LocalQty: local.Qty, RemoteQty: r.Qty})
+		}
+		b.positions[r.Symbol] = r
+	}
+	for symbol, local := range b.positions {
+		if _, ok := remoteBySymbol[symbol]; ok || b.seqs[symbol] > since {
+			continue
+		}
+		drifts = append(drifts, PositionDrift{Symbol: symbol, LocalQty: local.Qty})
+		delete(b.positions, symbol)
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Symbol < drifts[j].Symbol })
+	return drifts
+}
+
+// Drift contains the differences found by a reconciliation between the books and the API.
+type Drift struct {
+	Orders    []OrderDrift
+	Positions []PositionDrift
+}
+
+// IsEmpty reports whether no difference was found.
+func (d Drift) IsEmpty() bool {
+	return len(d.Orders) == 0 && len(d.Positions) == 0
+}
+
+// OrderDrift is an order whose state in the OrderBook differed from the one returned by the API.
+type OrderDrift struct {
+	OrderID string
+	// Local is the order in the book, nil if it was missing.
+	Local *Order
+	// Remote is the order returned by the API, nil if it's no longer open.
+	Remote *Order
+}
+
+// PositionDrift is a position whose qty in the PositionBook differed from the one returned by the API.
+// A missing position has a zero qty.
+type PositionDrift struct {
+	Symbol    string
+	LocalQty  decimal.Decimal
+	RemoteQty decimal.Decimal
+}
@@ -0,0 +1,177 @@
+package alpaca
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var booksT0 = time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+
+func bookOrder(id string, status OrderStatus, filled int64, at time.Duration) Order {
+	qty := decimal.NewFromInt(10)
+	return Order{
+		ID:          id,
+		Symbol:      "AAPL",
+		Status:      status,
+		Qty:         &qty,
+		FilledQty:   decimal.NewFromInt(filled),
+		SubmittedAt: booksT0,
+		UpdatedAt:   booksT0.Add(at),
+	}
+}
+
+func fillUpdate(event TradeUpdateEvent, positionQty, price int64) TradeUpdate {
+	pq, p := decimal.NewFromInt(positionQty), decimal.NewFromInt(price)
+	return TradeUpdate{Event: event, Order: Order{Symbol: "AAPL"}, PositionQty: &pq, Price: &p}
+}
+
+func TestOrderBook_Apply(t *testing.T) {
+	b := NewOrderBook([]Order{bookOrder("1", OrderNew, 0, 0), bookOrder("2", OrderFilled, 10, 0)})
+	assert.Len(t, b.Orders(), 1)
+
+	assert.True(t, b.Apply(TradeUpdate{Event: TradeEventPartialFill, Order: bookOrder("1", OrderPartiallyFilled, 4, 2)}))
+	// An older update is ignored
+	assert.False(t, b.Apply(TradeUpdate{Event: TradeEventNew, Order: bookOrder("1", OrderNew, 0, 1)}))
+	o, ok := b.Get("1")
+	require.True(t, ok)
+	assert.Equal(t, OrderPartiallyFilled, o.Status)
+	assert.Equal(t, "4", o.FilledQty.String())
+
+	assert.True(t, b.Apply(TradeUpdate{Event: TradeEventNew, Order: bookOrder("3", OrderNew, 0, 1)}))
+	third := bookOrder("3", OrderNew, 0, 1)
+	third.ClientOrderID = "c3"
+	b.Apply(TradeUpdate{Event: TradeEventNew, Order: third})
+	o, ok = b.GetByClientOrderID("c3")
+	require.True(t, ok)
+	assert.Equal(t, "3", o.ID)
+	assert.Len(t, b.Orders(), 2)
+
+	// The orders reaching a terminal status are removed
+	assert.True(t, b.Apply(TradeUpdate{Event: TradeEventCanceled, Order: bookOrder("1", OrderCanceled, 4, 3)}))
+	assert.True(t, b.Apply(TradeUpdate{Event: TradeEventReplaced, Order: bookOrder("3", OrderReplaced, 0, 3)}))
+	assert.False(t, b.Apply(TradeUpdate{Event: TradeEventFill, Order: bookOrder("4", OrderFilled, 10, 3)}))
+	assert.Empty(t, b.Orders())
+	_, ok = b.Get("1")
+	assert.False(t, ok)
+}
+
+func TestOrderBook_Reconcile(t *testing.T) {
+	b := NewOrderBook([]Order{
+		bookOrder("same", OrderNew, 0, 0),
+		bookOrder("changed", OrderNew, 0, 0),
+		bookOrder("closed", OrderNew, 0, 0),
+		bookOrder("inflight", OrderNew, 0, 0),
+	})
+	since := b.version()
+	// Updated while the orders were being fetched: not reported
+	b.Apply(TradeUpdate{Event: TradeEventCanceled, Order: bookOrder("inflight", OrderCanceled, 0, 1)})
+
+	drifts := b.reconcile([]Order{
+		bookOrder("same", OrderNew, 0, 0),
+		bookOrder("changed", OrderPartiallyFilled, 5, 1),
+		bookOrder("missing", OrderNew, 0, 0),
+		bookOrder("inflight", OrderNew, 0, 0),
+	}, since)
+	require.Len(t, drifts, 3)
+	assert.Equal(t, "changed", drifts[0].OrderID)
+	assert.Equal(t, OrderNew, drifts[0].Local.Status)
+	assert.Equal(t, OrderPartiallyFilled, drifts[0].Remote.Status)
+	assert.Equal(t, "closed", drifts[1].OrderID)
+	assert.Nil(t, drifts[1].Remote)
+	assert.Equal(t, "missing", drifts[2].OrderID)
+	assert.Nil(t, drifts[2].Local)
+
+	var ids []string
+	for _, o := range b.Orders() {
+		ids = append(ids, o.ID)
+	}
+	assert.Equal(t, []string{"changed", "missing", "same"}, ids)
+}
+
+func TestPositionBook_Apply(t *testing.T) {
+	b := NewPositionBook(nil)
+	assert.False(t, b.Apply(TradeUpdate{Event: TradeEventNew, Order: Order{Symbol: "AAPL"}}))
+
+	require.True(t, b.Apply(fillUpdate(TradeEventPartialFill, 10, 100)))
+	require.True(t, b.Apply(fillUpdate(TradeEventFill, 20, 110)))
+	p, ok := b.Get("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, "20", p.Qty.String())
+	assert.Equal(t, "105", p.AvgEntryPrice.String())
+	assert.Equal(t, "2100", p.CostBasis.String())
+	assert.Equal(t, "2200", p.MarketValue.String())
+	assert.Equal(t, "long", p.Side)
+
+	// Applying the same update twice doesn't change the qty
+	b.Apply(fillUpdate(TradeEventFill, 20, 110))
+	p, _ = b.Get("AAPL")
+	assert.Equal(t, "20", p.Qty.String())
+	assert.Equal(t, "105", p.AvgEntryPrice.String())
+
+	// Reducing keeps the average entry price, reversing resets it
+	b.Apply(fillUpdate(TradeEventFill, 5, 120))
+	p, _ = b.Get("AAPL")
+	assert.Equal(t, "105", p.AvgEntryPrice.String())
+	b.Apply(fillUpdate(TradeEventFill, -5, 90))
+	p, _ = b.Get("AAPL")
+	assert.Equal(t, "90", p.AvgEntryPrice.String())
+	assert.Equal(t, "short", p.Side)
+
+	b.Apply(fillUpdate(TradeEventFill, 0, 95))
+	assert.Empty(t, b.Positions())
+}
+
+func TestPositionBook_Apply_Stale(t *testing.T) {
+	b := NewPositionBook(nil)
+	at := func(tu TradeUpdate, d time.Duration) TradeUpdate {
+		ts := booksT0.Add(d)
+		tu.Timestamp = &ts
+		return tu
+	}
+	require.True(t, b.Apply(at(fillUpdate(TradeEventPartialFill, 10, 100), 2*time.Second)))
+	// A fill delivered late doesn't revert the position
+	assert.False(t, b.Apply(at(fillUpdate(TradeEventPartialFill, 5, 100), time.Second)))
+	p, ok := b.Get("AAPL")
+	require.True(t, ok)
+	assert.Equal(t, "10", p.Qty.String())
+
+	// The fills at the same time are applied
+	require.True(t, b.Apply(at(fillUpdate(TradeEventFill, 20, 110), 2*time.Second)))
+	p, _ = b.Get("AAPL")
+	assert.Equal(t, "20", p.Qty.String())
+}
+
+func TestPositionBook_Reconcile(t *testing.T) {
+	b := NewPositionBook([]Position{
+		{Symbol: "AAPL", Qty: decimal.NewFromInt(10)},
+		{Symbol: "MSFT", Qty: decimal.NewFromInt(5)},
+		{Symbol: "TSLA", Qty: decimal.NewFromInt(1)},
+	})
+	since := b.version()
+	b.Apply(TradeUpdate{
+		Event: TradeEventFill, Order: Order{Symbol: "TSLA"},
+		PositionQty: decimalPtr(decimal.NewFromInt(2)), Price: decimalPtr(decimal.NewFromInt(200)),
+	})
+
+	drifts := b.reconcile([]Position{
+		{Symbol: "AAPL", Qty: decimal.NewFromInt(10)},
+		{Symbol: "NVDA", Qty: decimal.NewFromInt(3)},
+		{Symbol: "TSLA", Qty: decimal.NewFromInt(1)},
+	}, since)
+	assert.Equal(t, []PositionDrift{
+		{Symbol: "MSFT", LocalQty: decimal.NewFromInt(5)},
+		{Symbol: "NVDA", RemoteQty: decimal.NewFromInt(3)},
+	}, drifts)
+	p, ok := b.Get("TSLA")
+	require.True(t, ok)
+	assert.Equal(t, "2", p.Qty.String())
+	assert.Len(t, b.Positions(), 3)
+}
+
+func decimalPtr(d decimal.Decimal) *decimal.Decimal {
+	return &d
+}
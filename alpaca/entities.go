@@ -74,7 +74,7 @@ type Order struct {
 	Side           Side             `json:"side"`
 	PositionIntent PositionIntent   `json:"position_intent"`
 	TimeInForce    TimeInForce      `json:"time_in_force"`
-	Status         string           `json:"status"`
+	Status         OrderStatus      `json:"status"`
 	Notional       *decimal.Decimal `json:"notional"`
 	Qty            *decimal.Decimal `json:"qty"`
 	FilledQty      decimal.Decimal  `json:"filled_qty"`
@@ -174,7 +174,7 @@ type AccountConfigurations struct {
 
 type AccountActivity struct {
 	ID              string          `json:"id"`
-	ActivityType    string          `json:"activity_type"`
+	ActivityType    ActivityType    `json:"activity_type"`
 	TransactionTime time.Time       `json:"transaction_time"`
 	Type            string          `json:"type"`
 	Price           decimal.Decimal `json:"price"`
@@ -195,6 +195,47 @@ type AccountActivity struct {
 //easyjson:json
 type accountSlice []AccountActivity
 
+type ActivityType string
+
+const (
+	ActivityFill                    ActivityType = "FILL"
+	ActivityTransaction             ActivityType = "TRANS"
+	ActivityMisc                    ActivityType = "MISC"
+	ActivityACATCash                ActivityType = "ACATC"
+	ActivityACATSecurities          ActivityType = "ACATS"
+	ActivityCryptoFee               ActivityType = "CFEE"
+	ActivityCashDeposit             ActivityType = "CSD"
+	ActivityCashWithdrawal          ActivityType = "CSW"
+	ActivityDividend                ActivityType = "DIV"
+	ActivityDividendCapGainLong     ActivityType = "DIVCGL"
+	ActivityDividendCapGainShort    ActivityType = "DIVCGS"
+	ActivityDividendFee             ActivityType = "DIVFEE"
+	ActivityDividendForeignTax      ActivityType = "DIVFT"
+	ActivityDividendNRAWithheld     ActivityType = "DIVNRA"
+	ActivityDividendReturnOfCapital ActivityType = "DIVROC"
+	ActivityDividendTefraWithheld   ActivityType = "DIVTW"
+	ActivityDividendTaxExempt       ActivityType = "DIVTXEX"
+	ActivityFee                     ActivityType = "FEE"
+	ActivityInterest                ActivityType = "INT"
+	ActivityInterestNRAWithheld     ActivityType = "INTNRA"
+	ActivityInterestTefraWithheld   ActivityType = "INTTW"
+	ActivityJournal                 ActivityType = "JNL"
+	ActivityJournalCash             ActivityType = "JNLC"
+	ActivityJournalStock            ActivityType = "JNLS"
+	ActivityMergerAcquisition       ActivityType = "MA"
+	ActivityNameChange              ActivityType = "NC"
+	ActivityOptionAssignment        ActivityType = "OPASN"
+	ActivityOptionExpiration        ActivityType = "OPEXP"
+	ActivityOptionExercise          ActivityType = "OPXRC"
+	ActivityPassThruCharge          ActivityType = "PTC"
+	ActivityPassThruRebate          ActivityType = "PTR"
+	ActivityReorg                   ActivityType = "REORG"
+	ActivitySymbolChange            ActivityType = "SC"
+	ActivityStockSpinoff            ActivityType = "SSO"
+	ActivityStockSplit              ActivityType = "SSP"
+	ActivitySplit                   ActivityType = "SPLIT"
+)
+
 type PortfolioHistory struct {
 	BaseValue     decimal.Decimal   `json:"base_value"`
 	Equity        []decimal.Decimal `json:"equity"`
@@ -240,6 +281,28 @@ const (
 	MLeg    OrderClass = "mleg"
 )
 
+type OrderStatus string
+
+const (
+	OrderNew                OrderStatus = "new"
+	OrderPartiallyFilled    OrderStatus = "partially_filled"
+	OrderFilled             OrderStatus = "filled"
+	OrderDoneForDay         OrderStatus = "done_for_day"
+	OrderCanceled           OrderStatus = "canceled"
+	OrderExpired            OrderStatus = "expired"
+	OrderReplaced           OrderStatus = "replaced"
+	OrderPendingCancel      OrderStatus = "pending_cancel"
+	OrderPendingReplace     OrderStatus = "pending_replace"
+	OrderAccepted           OrderStatus = "accepted"
+	OrderPendingNew         OrderStatus = "pending_new"
+	OrderAcceptedForBidding OrderStatus = "accepted_for_bidding"
+	OrderStopped            OrderStatus = "stopped"
+	OrderRejected           OrderStatus = "rejected"
+	OrderSuspended          OrderStatus = "suspended"
+	OrderCalculated         OrderStatus = "calculated"
+	OrderHeld               OrderStatus = "held"
+)
+
 type TimeInForce string
 
 const (
@@ -282,7 +345,7 @@ const (
 
 type TradeUpdate struct {
 	At          time.Time        `json:"at"`
-	Event       string           `json:"event"`
+	Event       TradeUpdateEvent `json:"event"`
 	EventID     string           `json:"event_id"`
 	ExecutionID string           `json:"execution_id"`
 	Order       Order            `json:"order"`
@@ -292,6 +355,27 @@ type TradeUpdate struct {
 	Timestamp   *time.Time       `json:"timestamp"`
 }
 
+type TradeUpdateEvent string
+
+const (
+	TradeEventNew                  TradeUpdateEvent = "new"
+	TradeEventFill                 TradeUpdateEvent = "fill"
+	TradeEventPartialFill          TradeUpdateEvent = "partial_fill"
+	TradeEventCanceled             TradeUpdateEvent = "canceled"
+	TradeEventExpired              TradeUpdateEvent = "expired"
+	TradeEventDoneForDay           TradeUpdateEvent = "done_for_day"
+	TradeEventReplaced             TradeUpdateEvent = "replaced"
+	TradeEventRejected             TradeUpdateEvent = "rejected"
+	TradeEventPendingNew           TradeUpdateEvent = "pending_new"
+	TradeEventStopped              TradeUpdateEvent = "stopped"
+	TradeEventPendingCancel        TradeUpdateEvent = "pending_cancel"
+	TradeEventPendingReplace       TradeUpdateEvent = "pending_replace"
+	TradeEventCalculated           TradeUpdateEvent = "calculated"
+	TradeEventSuspended            TradeUpdateEvent = "suspended"
+	TradeEventOrderReplaceRejected TradeUpdateEvent = "order_replace_rejected"
+	TradeEventOrderCancelRejected  TradeUpdateEvent = "order_cancel_rejected"
+)
+
 type DateType string
 
 const (
@@ -2201,7 +2201,7 @@ func easyjson3e8ab7adDecodeGithubComAlpacahqAlpacaTradeApiGoV3Alpaca16(in *jlexe
 				in.AddError((out.At).UnmarshalJSON(data))
 			}
 		case "event":
-			out.Event = string(in.String())
+			out.Event = TradeUpdateEvent(in.String())
 		case "event_id":
 			out.EventID = string(in.String())
 		case "execution_id":
@@ -3097,7 +3097,7 @@ func easyjson3e8ab7adDecodeGithubComAlpacahqAlpacaTradeApiGoV3Alpaca20(in *jlexe
 		case "time_in_force":
 			out.TimeInForce = TimeInForce(in.String())
 		case "status":
-			out.Status = string(in.String())
+			out.Status = OrderStatus(in.String())
 		case "notional":
 			if in.IsNull() {
 				in.Skip()
@@ -4723,7 +4723,7 @@ func easyjson3e8ab7adDecodeGithubComAlpacahqAlpacaTradeApiGoV3Alpaca30(in *jlexe
 		case "id":
 			out.ID = string(in.String())
 		case "activity_type":
-			out.ActivityType = string(in.String())
+			out.ActivityType = ActivityType(in.String())
 		case "transaction_time":
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.TransactionTime).UnmarshalJSON(data))
@@ -0,0 +1,103 @@
+package alpaca
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrOrderNotPlaced is returned by PlaceOrder when IdempotentOrders is enabled
+	// and the order has definitely not been placed. The underlying error is wrapped too.
+	ErrOrderNotPlaced = errors.New("order not placed")
+	// ErrOrderStatusUnknown is returned by PlaceOrder when IdempotentOrders is enabled
+	// and it could not be determined whether the order has been placed, e.g. because
+	// the API was unreachable for a long time. The order can be looked up later by its
+	// client order ID.
+	ErrOrderStatusUnknown = errors.New("order status unknown")
+)
+
+// reconcileTimeout limits how long placeOrderIdempotent tries to find out
+// whether an order has been placed after an ambiguous failure.
+const reconcileTimeout = time.Minute
+
+// placeOrderIdempotent places req making sure that it's placed at most once.
+// It relies on the server rejecting orders with duplicate client order IDs.
+func (c *Client) placeOrderIdempotent(ctx context.Context, req PlaceOrderRequest) (*Order, error) {
+	if req.ClientOrderID == "" {
+		id, err := newClientOrderID()
+		if err != nil {
+			return nil, err
+		}
+		req.ClientOrderID = id
+	}
+	order, err := c.placeOrder(ctx, req)
+	if err == nil {
+		return order, nil
+	}
+	if isRejected(err) {
+		return nil, fmt.Errorf("%w: %w", ErrOrderNotPlaced, err)
+	}
+	// The request may or may not have reached the server. The reconciliation must not
+	// be interrupted by the cancellation of ctx, otherwise the caller would be left
+	// without an answer, but the order is only resent if ctx is still active.
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reconcileTimeout)
+	defer cancel()
+	return c.reconcileOrder(rctx, req, ctx.Err() == nil, err)
+}
+
+// reconcileOrder finds out whether req has been placed after placing it failed with err.
+// If the order doesn't exist and resend is true, it's sent again: this is safe because
+// if the original request reaches the server later, it's rejected as a duplicate.
+func (c *Client) reconcileOrder(ctx context.Context, req PlaceOrderRequest, resend bool, err error) (*Order, error) {
+	attempts := c.opts.RetryPolicy.MaxRetries + 1
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if sleepErr := sleepContext(ctx, c.opts.RetryPolicy.Backoff(i-1, nil)); sleepErr != nil {
+				break
+			}
+		}
+		order, lookupErr := c.GetOrderByClientOrderIDWithContext(ctx, req.ClientOrderID)
+		switch {
+		case lookupErr == nil:
+			return order, nil
+		case !isNotFound(lookupErr):
+			continue
+		case isRejected(err):
+			// The resent order was rejected and the original doesn't exist either.
+			return nil, fmt.Errorf("%w: %w", ErrOrderNotPlaced, err)
+		case !resend || i == attempts-1:
+			continue
+		}
+		if order, err = c.placeOrder(ctx, req); err == nil {
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("%w (client order ID %s): %w", ErrOrderStatusUnknown, req.ClientOrderID, err)
+}
+
+// isRejected reports whether err means that the server received and rejected the request.
+func isRejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// newClientOrderID generates a random (version 4) UUID.
+func newClientOrderID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,156 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOrderServer accepts each client order ID only once, like the real API.
+type fakeOrderServer struct {
+	mu     sync.Mutex
+	orders map[string]bool
+	posts  int
+	// fail decides whether the n-th order request should fail, and whether
+	// the order should be placed nevertheless.
+	fail          func(n int) (fail bool, place bool)
+	lookupFails   bool
+	rejectResends bool
+}
+
+func (s *fakeOrderServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/orders":
+		s.posts++
+		var req PlaceOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if s.rejectResends && s.posts > 1 {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"code":40310000,"message":"insufficient buying power"}`)
+			return
+		}
+		if s.orders[req.ClientOrderID] {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"code":40010001,"message":"client_order_id must be unique"}`)
+			return
+		}
+		fail, place := false, true
+		if s.fail != nil {
+			fail, place = s.fail(s.posts)
+		}
+		if place {
+			s.orders[req.ClientOrderID] = true
+		}
+		if fail {
+			// Simulate a network failure
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		fmt.Fprintf(w, `{"id":"order_id","client_order_id":%q}`, req.ClientOrderID)
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/orders:by_client_order_id":
+		id := r.URL.Query().Get("client_order_id")
+		switch {
+		case s.lookupFails:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"message":"service unavailable"}`)
+		case s.orders[id]:
+			fmt.Fprintf(w, `{"id":"order_id","client_order_id":%q}`, id)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":40410000,"message":"order not found"}`)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newIdempotentTestClient(t *testing.T, s *fakeOrderServer) *Client {
+	s.orders = map[string]bool{}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return NewClient(ClientOpts{
+		BaseURL:          ts.URL,
+		RetryDelay:       time.Millisecond,
+		IdempotentOrders: true,
+	})
+}
+
+func TestPlaceOrderIdempotent_GeneratesClientOrderID(t *testing.T) {
+	s := &fakeOrderServer{}
+	c := newIdempotentTestClient(t, s)
+	order, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL"})
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		order.ClientOrderID)
+
+	order, err = c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", ClientOrderID: "custom"})
+	require.NoError(t, err)
+	assert.Equal(t, "custom", order.ClientOrderID)
+}
+
+func TestPlaceOrderIdempotent_PlacedDespiteFailure(t *testing.T) {
+	s := &fakeOrderServer{fail: func(int) (bool, bool) { return true, true }}
+	c := newIdempotentTestClient(t, s)
+	order, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", ClientOrderID: "id1"})
+	require.NoError(t, err)
+	assert.Equal(t, "id1", order.ClientOrderID)
+	assert.Equal(t, 1, s.posts)
+}
+
+func TestPlaceOrderIdempotent_Resend(t *testing.T) {
+	s := &fakeOrderServer{fail: func(n int) (bool, bool) { return n == 1, n > 1 }}
+	c := newIdempotentTestClient(t, s)
+	order, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", ClientOrderID: "id1"})
+	require.NoError(t, err)
+	assert.Equal(t, "id1", order.ClientOrderID)
+	assert.Equal(t, 2, s.posts)
+	assert.Len(t, s.orders, 1)
+}
+
+func TestPlaceOrderIdempotent_Rejected(t *testing.T) {
+	s := &fakeOrderServer{}
+	c := newIdempotentTestClient(t, s)
+	s.orders["id1"] = true
+	_, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", ClientOrderID: "id1"})
+	require.ErrorIs(t, err, ErrOrderNotPlaced)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+}
+
+func TestPlaceOrderIdempotent_ResendRejected(t *testing.T) {
+	s := &fakeOrderServer{
+		fail:          func(int) (bool, bool) { return true, false },
+		rejectResends: true,
+	}
+	c := newIdempotentTestClient(t, s)
+	_, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", ClientOrderID: "id1"})
+	require.ErrorIs(t, err, ErrOrderNotPlaced)
+	assert.Contains(t, err.Error(), "insufficient buying power")
+	assert.Equal(t, 2, s.posts)
+}
+
+func TestPlaceOrderIdempotent_StatusUnknown(t *testing.T) {
+	s := &fakeOrderServer{fail: func(int) (bool, bool) { return true, false }, lookupFails: true}
+	c := newIdempotentTestClient(t, s)
+	_, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", ClientOrderID: "id1"})
+	require.ErrorIs(t, err, ErrOrderStatusUnknown)
+	assert.NotErrorIs(t, err, ErrOrderNotPlaced)
+	assert.Equal(t, 1, s.posts)
+}
@@ -0,0 +1,134 @@
+package alpaca
+
+import (
+	"fmt"
+	"sync"
+)
+
+// IsTerminal reports whether the order reached a final status: no further
+// updates are expected for it.
+func (s OrderStatus) IsTerminal() bool {
+	switch s {
+	case OrderFilled, OrderCanceled, OrderExpired, OrderReplaced, OrderRejected:
+		return true
+	}
+	return false
+}
+
+// IsOpen reports whether the order is still working or may still be filled.
+func (s OrderStatus) IsOpen() bool {
+	switch s {
+	case OrderNew, OrderPartiallyFilled, OrderDoneForDay, OrderPendingCancel, OrderPendingReplace,
+		OrderAccepted, OrderPendingNew, OrderAcceptedForBidding, OrderStopped, OrderSuspended,
+		OrderCalculated, OrderHeld:
+		return true
+	}
+	return false
+}
+
+// CanCancel reports whether an order with this status can be canceled.
+func (s OrderStatus) CanCancel() bool {
+	return s.IsOpen() && s != OrderPendingCancel
+}
+
+// CanReplace reports whether an order with this status can be replaced.
+func (s OrderStatus) CanReplace() bool {
+	switch s {
+	case OrderNew, OrderPartiallyFilled, OrderAccepted, OrderHeld:
+		return true
+	}
+	return false
+}
+
+// OrderStatus returns the status of the order after the event. It returns false
+// for the events after which the status depends on the order, e.g. order_cancel_rejected,
+// which restores the status the order had before the cancel request.
+func (e TradeUpdateEvent) OrderStatus() (OrderStatus, bool) {
+	switch e {
+	case TradeEventNew:
+		return OrderNew, true
+	case TradeEventFill:
+		return OrderFilled, true
+	case TradeEventPartialFill:
+		return OrderPartiallyFilled, true
+	case TradeEventCanceled, TradeEventExpired, TradeEventDoneForDay, TradeEventReplaced,
+		TradeEventRejected, TradeEventPendingNew, TradeEventStopped, TradeEventPendingCancel,
+		TradeEventPendingReplace, TradeEventCalculated, TradeEventSuspended:
+		return OrderStatus(e), true
+	}
+	return "", false
+}
+
+// ValidTransition reports whether event may happen to an order with the given status.
+// An empty status means that the status of the order is unknown, in which case
+// any event is valid.
+func ValidTransition(from OrderStatus, event TradeUpdateEvent) bool {
+	if from == "" {
+		return true
+	}
+	if from.IsTerminal() {
+		return false
+	}
+	// An order can't become new again once it has been (partially) filled
+	isNew := event == TradeEventNew || event == TradeEventPendingNew
+	return !isNew || from != OrderPartiallyFilled
+}
+
+// TransitionError is returned by TradeUpdateValidator for an impossible trade update.
+type TransitionError struct {
+	OrderID string
+	From    OrderStatus
+	Event   TradeUpdateEvent
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order %s: unexpected %s event in %s status", e.OrderID, e.Event, e.From)
+}
+
+// TradeUpdateValidator tracks the status of the orders from the trade updates
+// (e.g. received by StreamTradeUpdates) and flags the impossible sequences, such as
+// a fill after a cancellation. It's safe for concurrent use.
+type TradeUpdateValidator struct {
+	mu       sync.Mutex
+	statuses map[string]OrderStatus
+}
+
+// NewTradeUpdateValidator creates an empty TradeUpdateValidator.
+func NewTradeUpdateValidator() *TradeUpdateValidator {
+	return &TradeUpdateValidator{statuses: make(map[string]OrderStatus)}
+}
+
+// Validate checks whether tu is a valid transition from the last known status of
+// its order, and records the new status: the one implied by the event, or else the status
+// of the order in tu. For an invalid transition, it returns a *TransitionError and the
+// recorded status is not changed.
+func (v *TradeUpdateValidator) Validate(tu TradeUpdate) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	from := v.statuses[tu.Order.ID]
+	if !ValidTransition(from, tu.Event) {
+		return &TransitionError{OrderID: tu.Order.ID, From: from, Event: tu.Event}
+	}
+	if to, ok := tu.Event.OrderStatus(); ok {
+		v.statuses[tu.Order.ID] = to
+	} else if tu.Order.Status != "" {
+		v.statuses[tu.Order.ID] = tu.Order.Status
+	}
+	return nil
+}
+
+// Status returns the last known status of the order.
+func (v *TradeUpdateValidator) Status(orderID string) (OrderStatus, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.statuses[orderID]
+	return s, ok
+}
+
+// Forget removes the order from the validator, e.g. after it reached a terminal status
+// and no more updates are expected for it.
+func (v *TradeUpdateValidator) Forget(orderID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.statuses, orderID)
+}
@@ -0,0 +1,90 @@
+package alpaca
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderStatus(t *testing.T) {
+	for _, s := range []OrderStatus{OrderFilled, OrderCanceled, OrderExpired, OrderReplaced, OrderRejected} {
+		assert.True(t, s.IsTerminal(), s)
+		assert.False(t, s.IsOpen(), s)
+		assert.False(t, s.CanCancel(), s)
+		assert.False(t, s.CanReplace(), s)
+	}
+	for _, s := range []OrderStatus{OrderNew, OrderPartiallyFilled, OrderAccepted, OrderHeld} {
+		assert.False(t, s.IsTerminal(), s)
+		assert.True(t, s.IsOpen(), s)
+		assert.True(t, s.CanCancel(), s)
+		assert.True(t, s.CanReplace(), s)
+	}
+	assert.True(t, OrderPendingCancel.IsOpen())
+	assert.False(t, OrderPendingCancel.CanCancel())
+	assert.False(t, OrderPendingReplace.CanReplace())
+	assert.True(t, OrderPendingReplace.CanCancel())
+	assert.False(t, OrderStatus("unknown").IsOpen())
+	assert.False(t, OrderStatus("unknown").IsTerminal())
+}
+
+func TestTradeUpdateEvent_OrderStatus(t *testing.T) {
+	s, ok := TradeEventFill.OrderStatus()
+	require.True(t, ok)
+	assert.Equal(t, OrderFilled, s)
+	s, ok = TradeEventPartialFill.OrderStatus()
+	require.True(t, ok)
+	assert.Equal(t, OrderPartiallyFilled, s)
+	s, ok = TradeEventPendingCancel.OrderStatus()
+	require.True(t, ok)
+	assert.Equal(t, OrderPendingCancel, s)
+	_, ok = TradeEventOrderCancelRejected.OrderStatus()
+	assert.False(t, ok)
+}
+
+func TestTradeUpdateValidator(t *testing.T) {
+	update := func(orderID string, event TradeUpdateEvent) TradeUpdate {
+		return TradeUpdate{Event: event, Order: Order{ID: orderID}}
+	}
+	v := NewTradeUpdateValidator()
+	require.NoError(t, v.Validate(update("o1", TradeEventPendingNew)))
+	require.NoError(t, v.Validate(update("o1", TradeEventNew)))
+	require.NoError(t, v.Validate(update("o1", TradeEventPartialFill)))
+	require.NoError(t, v.Validate(update("o1", TradeEventPendingCancel)))
+	require.NoError(t, v.Validate(update("o1", TradeEventCanceled)))
+
+	err := v.Validate(update("o1", TradeEventFill))
+	var tErr *TransitionError
+	require.ErrorAs(t, err, &tErr)
+	assert.Equal(t, TransitionError{OrderID: "o1", From: OrderCanceled, Event: TradeEventFill}, *tErr)
+	assert.Equal(t, "order o1: unexpected fill event in canceled status", err.Error())
+	s, _ := v.Status("o1")
+	assert.Equal(t, OrderCanceled, s)
+
+	// The first update of an order is always valid
+	require.NoError(t, v.Validate(update("o2", TradeEventPartialFill)))
+	require.Error(t, v.Validate(update("o2", TradeEventNew)))
+	require.NoError(t, v.Validate(update("o2", TradeEventFill)))
+	require.Error(t, v.Validate(update("o2", TradeEventFill)))
+
+	v.Forget("o2")
+	_, ok := v.Status("o2")
+	assert.False(t, ok)
+}
+
+func TestTradeUpdateValidator_CancelRejected(t *testing.T) {
+	v := NewTradeUpdateValidator()
+	require.NoError(t, v.Validate(TradeUpdate{Event: TradeEventNew, Order: Order{ID: "o1"}}))
+	require.NoError(t, v.Validate(TradeUpdate{Event: TradeEventPendingCancel, Order: Order{ID: "o1"}}))
+	// The order is open again after the cancel is rejected, with the status of the update
+	require.NoError(t, v.Validate(TradeUpdate{
+		Event: TradeEventOrderCancelRejected, Order: Order{ID: "o1", Status: OrderNew},
+	}))
+	s, ok := v.Status("o1")
+	require.True(t, ok)
+	assert.Equal(t, OrderNew, s)
+	assert.True(t, s.CanCancel())
+	require.NoError(t, v.Validate(TradeUpdate{Event: TradeEventFill, Order: Order{ID: "o1"}}))
+	s, _ = v.Status("o1")
+	assert.Equal(t, OrderFilled, s)
+}
@@ -0,0 +1,249 @@
+package alpaca
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxOrdersPageSize is the maximum number of orders returned by a single GetOrders call.
+const maxOrdersPageSize = 500
+
+// OrderIterator iterates over all the orders matching a GetOrdersRequest,
+// fetching them page by page. Use it like a bufio.Scanner:
+//
+//	it := client.IterateOrders(req)
+//	for it.Next() {
+//		order := it.Order()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+type OrderIterator struct {
+	c     *Client
+	ctx   context.Context
+	req   GetOrdersRequest
+	asc   bool
+	after time.Time
+	until time.Time
+	// seen contains the IDs of the already returned orders that may be returned again
+	// in the next page, because they were submitted in the same second as the cursor.
+	seen  map[string]time.Time
+	page  []Order
+	order Order
+	done  bool
+	err   error
+}
+
+// IterateOrders returns an iterator over all the orders matching req. Unlike GetOrders,
+// it isn't limited to a single page of orders: it follows the After or Until cursor
+// (depending on Direction) until all the orders have been returned. req.Limit is used
+// as the page size and defaults to the maximum allowed by the API. Nested orders are
+// returned according to req.Nested, with their legs included in the parent order.
+func (c *Client) IterateOrders(req GetOrdersRequest) *OrderIterator {
+	return c.IterateOrdersWithContext(context.Background(), req)
+}
+
+// IterateOrdersWithContext returns an iterator over all the orders matching req, whose
+// requests use ctx. See IterateOrders for details.
+func (c *Client) IterateOrdersWithContext(ctx context.Context, req GetOrdersRequest) *OrderIterator {
+	if req.Limit <= 0 || req.Limit > maxOrdersPageSize {
+		req.Limit = maxOrdersPageSize
+	}
+	return &OrderIterator{
+		c:     c,
+		ctx:   ctx,
+		req:   req,
+		asc:   strings.EqualFold(req.Direction, "asc"),
+		after: req.After,
+		until: req.Until,
+		seen:  make(map[string]time.Time),
+	}
+}
+
+// Next advances the iterator to the next order, which will then be available through the
+// Order method. It returns false when there are no more orders or when an error occurred.
+func (it *OrderIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	it.order, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Order returns the current order.
+func (it *OrderIterator) Order() Order {
+	return it.order
+}
+
+// Err returns the first error encountered by the iterator.
+func (it *OrderIterator) Err() error {
+	return it.err
+}
+
+func (it *OrderIterator) fetch() {
+	orders, err := it.c.GetOrdersWithContext(it.ctx, it.req)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if len(orders) < it.req.Limit {
+		it.done = true
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	// The cursor is the submission time of the oldest (or, in ascending order, the newest)
+	// order of the page. The API's after and until parameters are exclusive and have second
+	// precision, so in order not to miss orders submitted in the same second as the cursor,
+	// the next page starts one second further, and the orders returned again are skipped.
+	cursor := orders[0].SubmittedAt
+	for _, o := range orders[1:] {
+		if (it.asc && o.SubmittedAt.After(cursor)) || (!it.asc && o.SubmittedAt.Before(cursor)) {
+			cursor = o.SubmittedAt
+		}
+	}
+	cursor = cursor.Truncate(time.Second)
+
+	it.page = make([]Order, 0, len(orders))
+	for _, o := range orders {
+		if _, ok := it.seen[o.ID]; !ok {
+			it.page = append(it.page, o)
+			it.seen[o.ID] = o.SubmittedAt
+		}
+	}
+	for id, t := range it.seen {
+		if t.Before(cursor.Add(-time.Second)) || !t.Before(cursor.Add(2*time.Second)) {
+			delete(it.seen, id)
+		}
+	}
+	if len(it.page) == 0 && !it.done {
+		it.err = fmt.Errorf("more than %d orders submitted around %s, increase the page size",
+			it.req.Limit, cursor.Format(time.RFC3339))
+		return
+	}
+
+	if it.asc {
+		it.req.After = cursor.Add(-time.Second)
+		if it.req.After.Before(it.after) {
+			it.req.After = it.after
+		}
+	} else {
+		it.req.Until = cursor.Add(time.Second)
+		if !it.until.IsZero() && it.req.Until.After(it.until) {
+			it.req.Until = it.until
+		}
+	}
+}
+
+// GetAllOrders returns all the orders matching req, following the pagination cursors.
+// See IterateOrders for details.
+func (c *Client) GetAllOrders(req GetOrdersRequest) ([]Order, error) {
+	return c.GetAllOrdersWithContext(context.Background(), req)
+}
+
+// GetAllOrdersWithContext returns all the orders matching req, following the pagination cursors.
+// See IterateOrders for details.
+func (c *Client) GetAllOrdersWithContext(ctx context.Context, req GetOrdersRequest) ([]Order, error) {
+	var orders []Order
+	it := c.IterateOrdersWithContext(ctx, req)
+	for it.Next() {
+		orders = append(orders, it.Order())
+	}
+	return orders, it.Err()
+}
+
+// maxActivitiesPageSize is the maximum number of activities returned by a single GetAccountActivities call.
+const maxActivitiesPageSize = 100
+
+// ActivityIterator iterates over all the account activities matching a GetAccountActivitiesRequest,
+// fetching them page by page. It's used the same way as OrderIterator.
+type ActivityIterator struct {
+	c        *Client
+	ctx      context.Context
+	req      GetAccountActivitiesRequest
+	page     []AccountActivity
+	activity AccountActivity
+	done     bool
+	err      error
+}
+
+// IterateAccountActivities returns an iterator over all the account activities matching req,
+// following the page tokens until the last page. req.PageSize is used as the page size and
+// defaults to the maximum allowed by the API. If req.PageToken is set, the iteration starts after it.
+func (c *Client) IterateAccountActivities(req GetAccountActivitiesRequest) *ActivityIterator {
+	return c.IterateAccountActivitiesWithContext(context.Background(), req)
+}
+
+// IterateAccountActivitiesWithContext returns an iterator over all the account activities
+// matching req, whose requests use ctx. See IterateAccountActivities for details.
+func (c *Client) IterateAccountActivitiesWithContext(
+	ctx context.Context, req GetAccountActivitiesRequest,
+) *ActivityIterator {
+	if req.PageSize <= 0 || req.PageSize > maxActivitiesPageSize {
+		req.PageSize = maxActivitiesPageSize
+	}
+	return &ActivityIterator{c: c, ctx: ctx, req: req}
+}
+
+// Next advances the iterator to the next activity, which will then be available through the
+// Activity method. It returns false when there are no more activities or when an error occurred.
+func (it *ActivityIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		activities, err := it.c.GetAccountActivitiesWithContext(it.ctx, it.req)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if len(activities) < it.req.PageSize {
+			it.done = true
+		}
+		if len(activities) > 0 {
+			it.req.PageToken = activities[len(activities)-1].ID
+		}
+		it.page = activities
+	}
+	it.activity, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Activity returns the current activity.
+func (it *ActivityIterator) Activity() AccountActivity {
+	return it.activity
+}
+
+// PageToken returns the page token that can be used to resume the iteration after the current activity.
+func (it *ActivityIterator) PageToken() string {
+	return it.activity.ID
+}
+
+// Err returns the first error encountered by the iterator.
+func (it *ActivityIterator) Err() error {
+	return it.err
+}
+
+// GetAllAccountActivities returns all the account activities matching req, following the page tokens.
+func (c *Client) GetAllAccountActivities(req GetAccountActivitiesRequest) ([]AccountActivity, error) {
+	return c.GetAllAccountActivitiesWithContext(context.Background(), req)
+}
+
+// GetAllAccountActivitiesWithContext returns all the account activities matching req, following the page tokens.
+func (c *Client) GetAllAccountActivitiesWithContext(
+	ctx context.Context, req GetAccountActivitiesRequest,
+) ([]AccountActivity, error) {
+	var activities []AccountActivity
+	it := c.IterateAccountActivitiesWithContext(ctx, req)
+	for it.Next() {
+		activities = append(activities, it.Activity())
+	}
+	return activities, it.Err()
+}
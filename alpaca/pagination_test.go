@@ -0,0 +1,228 @@
+package alpaca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOrdersServer serves orders like the real API: sorted by submission time,
+// filtered by the exclusive after and until parameters.
+func newOrdersServer(t *testing.T, orders []Order, calls *int) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		q := r.URL.Query()
+		assert.Equal(t, "true", q.Get("nested"))
+		limit, err := strconv.Atoi(q.Get("limit"))
+		require.NoError(t, err)
+		var after, until time.Time
+		if s := q.Get("after"); s != "" {
+			after, err = time.Parse(time.RFC3339, s)
+			require.NoError(t, err)
+		}
+		if s := q.Get("until"); s != "" {
+			until, err = time.Parse(time.RFC3339, s)
+			require.NoError(t, err)
+		}
+		var resp []Order
+		for _, o := range orders {
+			if (!after.IsZero() && !o.SubmittedAt.After(after)) || (!until.IsZero() && !o.SubmittedAt.Before(until)) {
+				continue
+			}
+			resp = append(resp, o)
+		}
+		sort.SliceStable(resp, func(i, j int) bool {
+			if q.Get("direction") == "asc" {
+				return resp[i].SubmittedAt.Before(resp[j].SubmittedAt)
+			}
+			return resp[i].SubmittedAt.After(resp[j].SubmittedAt)
+		})
+		if len(resp) > limit {
+			resp = resp[:limit]
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func testOrders() []Order {
+	base := time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)
+	var orders []Order
+	// Multiple orders in the same second, some with the exact same timestamp
+	offsets := []time.Duration{
+		0, 100 * time.Millisecond, 100 * time.Millisecond, 900 * time.Millisecond,
+		time.Second, time.Second, time.Second + time.Nanosecond,
+		5 * time.Second, time.Minute, time.Minute, time.Minute, time.Minute, time.Hour,
+	}
+	for i, d := range offsets {
+		orders = append(orders, Order{ID: fmt.Sprintf("order%02d", i), SubmittedAt: base.Add(d)})
+	}
+	return orders
+}
+
+func orderIDs(orders []Order) []string {
+	ids := make([]string, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	return ids
+}
+
+func TestGetAllOrders(t *testing.T) {
+	orders := testOrders()
+	for _, direction := range []string{"", "asc", "desc"} {
+		// The page size must be larger than the number of orders submitted
+		// in the same second (or, in ascending order, in two consecutive seconds).
+		limits := []int{5, 6, 13, 0}
+		if direction == "asc" {
+			limits = []int{7, 8, 13, 0}
+		}
+		for _, limit := range limits {
+			t.Run(fmt.Sprintf("%s_%d", direction, limit), func(t *testing.T) {
+				calls := 0
+				ts := newOrdersServer(t, orders, &calls)
+				c := NewClient(ClientOpts{BaseURL: ts.URL})
+				got, err := c.GetAllOrders(GetOrdersRequest{
+					Status:    "all",
+					Limit:     limit,
+					Direction: direction,
+					Nested:    true,
+				})
+				require.NoError(t, err)
+				assert.ElementsMatch(t, orderIDs(orders), orderIDs(got))
+				for i := 1; i < len(got); i++ {
+					if direction == "asc" {
+						assert.False(t, got[i].SubmittedAt.Before(got[i-1].SubmittedAt))
+					} else {
+						assert.False(t, got[i].SubmittedAt.After(got[i-1].SubmittedAt))
+					}
+				}
+				if limit == 0 {
+					assert.Equal(t, 1, calls)
+				}
+			})
+		}
+	}
+}
+
+func TestIterateOrders_Bounds(t *testing.T) {
+	orders := testOrders()
+	calls := 0
+	ts := newOrdersServer(t, orders, &calls)
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+
+	got, err := c.GetAllOrders(GetOrdersRequest{
+		Limit:  5,
+		After:  orders[0].SubmittedAt,  // exclusive
+		Until:  orders[12].SubmittedAt, // exclusive
+		Nested: true,
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, orderIDs(orders[1:12]), orderIDs(got))
+
+	got, err = c.GetAllOrders(GetOrdersRequest{
+		Limit:     7,
+		After:     orders[0].SubmittedAt,
+		Until:     orders[12].SubmittedAt,
+		Direction: "asc",
+		Nested:    true,
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, orderIDs(orders[1:12]), orderIDs(got))
+}
+
+func TestIterateOrders_TooManyTies(t *testing.T) {
+	orders := testOrders()
+	calls := 0
+	ts := newOrdersServer(t, orders, &calls)
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+
+	it := c.IterateOrders(GetOrdersRequest{Limit: 2, Direction: "asc", Nested: true})
+	var got []Order
+	for it.Next() {
+		got = append(got, it.Order())
+	}
+	require.Error(t, it.Err())
+	assert.Contains(t, it.Err().Error(), "more than 2 orders submitted around 2024-03-01T14:30:00Z")
+	assert.Len(t, got, 2)
+	assert.False(t, it.Next())
+}
+
+func TestIterateOrders_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"unauthorized"}`)
+	}))
+	defer ts.Close()
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	it := c.IterateOrdersWithContext(context.Background(), GetOrdersRequest{})
+	assert.False(t, it.Next())
+	var apiErr *APIError
+	require.ErrorAs(t, it.Err(), &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestIterateAccountActivities(t *testing.T) {
+	var activities []AccountActivity
+	for i := 0; i < 7; i++ {
+		activities = append(activities, AccountActivity{ID: fmt.Sprintf("activity%d", i), ActivityType: ActivityFill})
+	}
+	var tokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/account/activities", r.URL.Path)
+		assert.Equal(t, "FILL", r.URL.Query().Get("activity_types"))
+		pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+		require.NoError(t, err)
+		token := r.URL.Query().Get("page_token")
+		tokens = append(tokens, token)
+		start := 0
+		for i, a := range activities {
+			if a.ID == token {
+				start = i + 1
+			}
+		}
+		var page []map[string]string
+		for _, a := range activities[start:min(start+pageSize, len(activities))] {
+			page = append(page, map[string]string{"id": a.ID, "activity_type": string(a.ActivityType)})
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(page))
+	}))
+	defer ts.Close()
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+
+	got, err := c.GetAllAccountActivities(GetAccountActivitiesRequest{
+		ActivityTypes: []string{string(ActivityFill)},
+		PageSize:      3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, activities, got)
+	assert.Equal(t, []string{"", "activity2", "activity5"}, tokens)
+
+	// Stop early and resume from the page token
+	tokens = nil
+	it := c.IterateAccountActivities(GetAccountActivitiesRequest{
+		ActivityTypes: []string{string(ActivityFill)},
+		PageSize:      7,
+	})
+	require.True(t, it.Next())
+	assert.Equal(t, "activity0", it.Activity().ID)
+	assert.Equal(t, "activity0", it.PageToken())
+	got, err = c.GetAllAccountActivities(GetAccountActivitiesRequest{
+		ActivityTypes: []string{string(ActivityFill)},
+		PageSize:      7,
+		PageToken:     it.PageToken(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, activities[1:], got)
+	assert.Equal(t, []string{"", "activity0"}, tokens)
+}
@@ -0,0 +1,159 @@
+package alpaca
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is the rate limit state reported by the server in the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset response headers.
+type RateLimit struct {
+	// Limit is the maximum number of requests allowed in the current window.
+	Limit int
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+	// Reset is when the current window ends. It's zero if the server didn't send it.
+	Reset time.Time
+}
+
+// RateLimitFromHeader parses the rate limit headers of a response.
+// It returns false if the headers are missing or invalid.
+func RateLimitFromHeader(h http.Header) (RateLimit, bool) {
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	rl := RateLimit{Limit: limit, Remaining: remaining}
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+	return rl, true
+}
+
+// RateLimiter is a client-side token bucket rate limiter. The same RateLimiter
+// can be set in the ClientOpts of multiple clients (including marketdata clients)
+// that share the same rate limit, e.g. because they use the same API key.
+//
+// Besides its own token bucket, the limiter also follows the rate limit state
+// reported by the server: when the server reports that there are no requests
+// left in the current window, requests are held back until the window resets.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // tokens per second
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	state       *RateLimit
+	now         func() time.Time
+}
+
+// NewRateLimiter creates a RateLimiter that allows limit requests per interval,
+// e.g. NewRateLimiter(200, time.Minute). Up to limit requests can be sent in a burst.
+// It panics if limit or interval is not positive.
+func NewRateLimiter(limit int, interval time.Duration) *RateLimiter {
+	if limit <= 0 {
+		panic("alpaca: non-positive limit for NewRateLimiter")
+	}
+	if interval <= 0 {
+		panic("alpaca: non-positive interval for NewRateLimiter")
+	}
+	return &RateLimiter{
+		rate:   float64(limit) / interval.Seconds(),
+		burst:  float64(limit),
+		tokens: float64(limit),
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a request is allowed to be sent or ctx is done.
+// A nil RateLimiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := l.now()
+	l.refill(now)
+	// The token is reserved immediately, even if the bucket is empty,
+	// so that waiting requests are served in order.
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if d := l.pausedUntil.Sub(now); d > delay {
+		delay = d
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Rate returns the number of requests per second allowed by the limiter in the long run. It
+// returns 0 on a nil RateLimiter.
+func (l *RateLimiter) Rate() float64 {
+	if l == nil {
+		return 0
+	}
+	return l.rate
+}
+
+// Observe updates the limiter with the rate limit state reported by the server.
+// It's a no-op on a nil RateLimiter.
+func (l *RateLimiter) Observe(rl RateLimit) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.refill(now)
+	if float64(rl.Remaining) < l.tokens {
+		l.tokens = float64(rl.Remaining)
+	}
+	if rl.Remaining <= 0 && rl.Reset.After(l.pausedUntil) {
+		l.pausedUntil = rl.Reset
+	}
+	l.state = &rl
+}
+
+// State returns the most recent rate limit state passed to Observe.
+// It returns false if no state has been observed yet or on a nil RateLimiter.
+func (l *RateLimiter) State() (RateLimit, bool) {
+	if l == nil {
+		return RateLimit{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.state == nil {
+		return RateLimit{}, false
+	}
+	return *l.state, true
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
@@ -0,0 +1,125 @@
+package alpaca
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitFromHeader(t *testing.T) {
+	h := http.Header{}
+	_, ok := RateLimitFromHeader(h)
+	assert.False(t, ok)
+
+	h.Set("X-RateLimit-Limit", "200")
+	h.Set("X-RateLimit-Remaining", "invalid")
+	_, ok = RateLimitFromHeader(h)
+	assert.False(t, ok)
+
+	h.Set("X-RateLimit-Remaining", "199")
+	rl, ok := RateLimitFromHeader(h)
+	require.True(t, ok)
+	assert.Equal(t, RateLimit{Limit: 200, Remaining: 199}, rl)
+
+	h.Set("X-RateLimit-Reset", "1700000000")
+	rl, ok = RateLimitFromHeader(h)
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(1700000000, 0), rl.Reset)
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(2, time.Hour)
+	l.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	require.NoError(t, l.Wait(ctx))
+	require.NoError(t, l.Wait(ctx))
+
+	// The bucket is empty, the next token arrives in 30 minutes
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	// The canceled request gave back its reservation
+	assert.InDelta(t, 0, l.tokens, 1e-9)
+
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, l.Wait(context.Background()))
+}
+
+func TestRateLimiter_Observe(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(100, time.Second)
+	l.now = func() time.Time { return now }
+
+	_, ok := l.State()
+	assert.False(t, ok)
+
+	rl := RateLimit{Limit: 200, Remaining: 0, Reset: now.Add(time.Hour)}
+	l.Observe(rl)
+	state, ok := l.State()
+	require.True(t, ok)
+	assert.Equal(t, rl, state)
+
+	// The server reported that there are no requests left until the reset
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, l.Wait(context.Background()))
+}
+
+func TestRateLimiter_Nil(t *testing.T) {
+	var l *RateLimiter
+	require.NoError(t, l.Wait(context.Background()))
+	l.Observe(RateLimit{})
+	_, ok := l.State()
+	assert.False(t, ok)
+	assert.Zero(t, l.Rate())
+}
+
+func TestRateLimiter_Rate(t *testing.T) {
+	assert.InDelta(t, 2.5, NewRateLimiter(150, time.Minute).Rate(), 1e-9)
+}
+
+func TestNewRateLimiter_Invalid(t *testing.T) {
+	assert.Panics(t, func() { NewRateLimiter(0, time.Minute) })
+	assert.Panics(t, func() { NewRateLimiter(200, 0) })
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	remaining := 200
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		remaining--
+		w.Header().Set("X-RateLimit-Limit", "200")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		fmt.Fprint(w, `{"id":"account_id"}`)
+	}))
+	defer ts.Close()
+
+	limiter := NewRateLimiter(200, time.Minute)
+	c := NewClient(ClientOpts{BaseURL: ts.URL, RateLimiter: limiter})
+	_, ok := c.RateLimit()
+	assert.False(t, ok)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetAccount()
+		require.NoError(t, err)
+	}
+	want := RateLimit{Limit: 200, Remaining: 197, Reset: time.Unix(1700000000, 0)}
+	rl, ok := c.RateLimit()
+	require.True(t, ok)
+	assert.Equal(t, want, rl)
+	state, ok := limiter.State()
+	require.True(t, ok)
+	assert.Equal(t, want, state)
+}
@@ -2,6 +2,7 @@ package alpaca
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/civil"
@@ -28,6 +30,22 @@ type ClientOpts struct {
 	BaseURL      string
 	RetryLimit   int
 	RetryDelay   time.Duration
+	// RetryPolicy configures how failed requests are retried. If nil, requests that
+	// failed with 429 Too Many Requests are retried RetryLimit times, waiting
+	// RetryDelay between the attempts.
+	RetryPolicy *RetryPolicy
+	// IdempotentOrders makes PlaceOrder safe against placing the same order twice. A random
+	// ClientOrderID is generated for requests without one, and if the outcome of a request
+	// is unknown (e.g. because of a timeout), the order is looked up by its ClientOrderID
+	// before returning. PlaceOrder then either returns the placed order or an error
+	// wrapping ErrOrderNotPlaced (or ErrOrderStatusUnknown if the API remained unreachable).
+	IdempotentOrders bool
+	// ValidateOrders makes PlaceOrder and ReplaceOrder validate the requests before sending them.
+	// Invalid requests are rejected with a *ValidationError without calling the API.
+	ValidateOrders bool
+	// RateLimiter throttles the requests sent by the client. It can be shared between
+	// multiple clients that are subject to the same rate limit. If nil, requests are not throttled.
+	RateLimiter *RateLimiter
 	// HTTPClient to be used for each http request.
 	HTTPClient *http.Client
 }
@@ -36,6 +54,7 @@ type ClientOpts struct {
 type Client struct {
 	opts       ClientOpts
 	httpClient *http.Client
+	rateLimit  atomic.Pointer[RateLimit]
 
 	do func(c *Client, req *http.Request) (*http.Response, error)
 }
@@ -64,6 +83,14 @@ func NewClient(opts ClientOpts) *Client {
 	if opts.RetryDelay == 0 {
 		opts.RetryDelay = time.Second
 	}
+	if opts.RetryPolicy == nil {
+		opts.RetryPolicy = &RetryPolicy{
+			MaxRetries:        opts.RetryLimit,
+			BaseDelay:         opts.RetryDelay,
+			MaxDelay:          opts.RetryDelay,
+			RetryableStatuses: []int{http.StatusTooManyRequests},
+		}
+	}
 	httpClient := opts.HTTPClient
 	if httpClient == nil {
 		httpClient = &http.Client{
@@ -85,7 +112,17 @@ const (
 	apiVersion = "v2"
 )
 
-func defaultDo(c *Client, req *http.Request) (*http.Response, error) {
+// RateLimit returns the rate limit state reported by the server in the most recent response.
+// It returns false if no response with rate limit headers has been received yet.
+func (c *Client) RateLimit() (RateLimit, bool) {
+	if rl := c.rateLimit.Load(); rl != nil {
+		return *rl, true
+	}
+	return RateLimit{}, false
+}
+
+// setHeaders sets the user agent and the authentication headers of req.
+func (c *Client) setHeaders(req *http.Request) {
 	req.Header.Set("User-Agent", Version())
 
 	switch {
@@ -97,21 +134,40 @@ func defaultDo(c *Client, req *http.Request) (*http.Response, error) {
 		req.Header.Set("APCA-API-KEY-ID", c.opts.APIKey)
 		req.Header.Set("APCA-API-SECRET-KEY", c.opts.APISecret)
 	}
+}
+
+func defaultDo(c *Client, req *http.Request) (*http.Response, error) {
+	c.setHeaders(req)
 
 	var resp *http.Response
 	var err error
 	for i := 0; ; i++ {
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
+		if err = c.opts.RateLimiter.Wait(req.Context()); err != nil {
 			return nil, err
 		}
-		if resp.StatusCode != http.StatusTooManyRequests {
-			break
+		resp, err = c.httpClient.Do(req)
+		if resp != nil {
+			if rl, ok := RateLimitFromHeader(resp.Header); ok {
+				c.rateLimit.Store(&rl)
+				c.opts.RateLimiter.Observe(rl)
+			}
 		}
-		if i >= c.opts.RetryLimit {
+		if !c.opts.RetryPolicy.ShouldRetry(req, resp, err, i) {
 			break
 		}
-		time.Sleep(c.opts.RetryDelay)
+		delay := c.opts.RetryPolicy.Backoff(i, resp)
+		if resp != nil {
+			closeResp(resp)
+		}
+		if err = sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if err = rewindBody(req); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if err = verify(resp); err != nil {
@@ -123,12 +179,17 @@ func defaultDo(c *Client, req *http.Request) (*http.Response, error) {
 
 // GetAccount returns the user's account information.
 func (c *Client) GetAccount() (*Account, error) {
+	return c.GetAccountWithContext(context.Background())
+}
+
+// GetAccountWithContext returns the user's account information.
+func (c *Client) GetAccountWithContext(ctx context.Context) (*Account, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/account", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -143,12 +204,17 @@ func (c *Client) GetAccount() (*Account, error) {
 
 // GetAccountConfigurations returns the current account configurations
 func (c *Client) GetAccountConfigurations() (*AccountConfigurations, error) {
+	return c.GetAccountConfigurationsWithContext(context.Background())
+}
+
+// GetAccountConfigurationsWithContext returns the current account configurations
+func (c *Client) GetAccountConfigurationsWithContext(ctx context.Context) (*AccountConfigurations, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/account/configurations", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -171,12 +237,19 @@ type UpdateAccountConfigurationsRequest struct {
 
 // UpdateAccountConfigurations updates the account configs.
 func (c *Client) UpdateAccountConfigurations(req UpdateAccountConfigurationsRequest) (*AccountConfigurations, error) {
+	return c.UpdateAccountConfigurationsWithContext(context.Background(), req)
+}
+
+// UpdateAccountConfigurationsWithContext updates the account configs.
+func (c *Client) UpdateAccountConfigurationsWithContext(
+	ctx context.Context, req UpdateAccountConfigurationsRequest,
+) (*AccountConfigurations, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/account/configurations", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.patch(u, req)
+	resp, err := c.patch(ctx, u, req)
 	if err != nil {
 		return nil, err
 	}
@@ -202,6 +275,13 @@ type GetAccountActivitiesRequest struct {
 
 // GetAccountActivities returns the account activities.
 func (c *Client) GetAccountActivities(req GetAccountActivitiesRequest) ([]AccountActivity, error) {
+	return c.GetAccountActivitiesWithContext(context.Background(), req)
+}
+
+// GetAccountActivitiesWithContext returns the account activities.
+func (c *Client) GetAccountActivitiesWithContext(
+	ctx context.Context, req GetAccountActivitiesRequest,
+) ([]AccountActivity, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/account/activities", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -234,7 +314,7 @@ func (c *Client) GetAccountActivities(req GetAccountActivitiesRequest) ([]Accoun
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -256,6 +336,13 @@ type GetPortfolioHistoryRequest struct {
 
 // GetPortfolioHistory returns the portfolio history.
 func (c *Client) GetPortfolioHistory(req GetPortfolioHistoryRequest) (*PortfolioHistory, error) {
+	return c.GetPortfolioHistoryWithContext(context.Background(), req)
+}
+
+// GetPortfolioHistoryWithContext returns the portfolio history.
+func (c *Client) GetPortfolioHistoryWithContext(
+	ctx context.Context, req GetPortfolioHistoryRequest,
+) (*PortfolioHistory, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/account/portfolio/history", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -274,7 +361,7 @@ func (c *Client) GetPortfolioHistory(req GetPortfolioHistoryRequest) (*Portfolio
 	query.Set("extended_hours", strconv.FormatBool(req.ExtendedHours))
 	u.RawQuery = query.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -289,12 +376,17 @@ func (c *Client) GetPortfolioHistory(req GetPortfolioHistoryRequest) (*Portfolio
 
 // GetPositions returns the account's open positions.
 func (c *Client) GetPositions() ([]Position, error) {
+	return c.GetPositionsWithContext(context.Background())
+}
+
+// GetPositionsWithContext returns the account's open positions.
+func (c *Client) GetPositionsWithContext(ctx context.Context) ([]Position, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/positions", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -309,6 +401,11 @@ func (c *Client) GetPositions() ([]Position, error) {
 
 // GetPosition returns the account's position for the provided symbol.
 func (c *Client) GetPosition(symbol string) (*Position, error) {
+	return c.GetPositionWithContext(context.Background(), symbol)
+}
+
+// GetPositionWithContext returns the account's position for the provided symbol.
+func (c *Client) GetPositionWithContext(ctx context.Context, symbol string) (*Position, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/positions/%s", c.opts.BaseURL, apiVersion, symbol))
 	if err != nil {
 		return nil, err
@@ -318,7 +415,7 @@ func (c *Client) GetPosition(symbol string) (*Position, error) {
 	q.Set("symbol", symbol)
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -339,6 +436,13 @@ type CloseAllPositionsRequest struct {
 // It returns the list of orders that were created to close the positions.
 // If errors occur while closing some of the positions, the errors will also be returned (possibly among orders)
 func (c *Client) CloseAllPositions(req CloseAllPositionsRequest) ([]Order, error) {
+	return c.CloseAllPositionsWithContext(context.Background(), req)
+}
+
+// CloseAllPositionsWithContext liquidates all open positions at market price.
+// It returns the list of orders that were created to close the positions.
+// If errors occur while closing some of the positions, the errors will also be returned (possibly among orders)
+func (c *Client) CloseAllPositionsWithContext(ctx context.Context, req CloseAllPositionsRequest) ([]Order, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/positions", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -348,7 +452,7 @@ func (c *Client) CloseAllPositions(req CloseAllPositionsRequest) ([]Order, error
 	q.Set("cancel_orders", strconv.FormatBool(req.CancelOrders))
 	u.RawQuery = q.Encode()
 
-	resp, err := c.delete(u)
+	resp, err := c.delete(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -395,6 +499,13 @@ type ClosePositionRequest struct {
 
 // ClosePosition liquidates the position for the given symbol at market price.
 func (c *Client) ClosePosition(symbol string, req ClosePositionRequest) (*Order, error) {
+	return c.ClosePositionWithContext(context.Background(), symbol, req)
+}
+
+// ClosePositionWithContext liquidates the position for the given symbol at market price.
+func (c *Client) ClosePositionWithContext(
+	ctx context.Context, symbol string, req ClosePositionRequest,
+) (*Order, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/positions/%s", c.opts.BaseURL, apiVersion, symbol))
 	if err != nil {
 		return nil, err
@@ -409,7 +520,7 @@ func (c *Client) ClosePosition(symbol string, req ClosePositionRequest) (*Order,
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.delete(u)
+	resp, err := c.delete(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -424,12 +535,17 @@ func (c *Client) ClosePosition(symbol string, req ClosePositionRequest) (*Order,
 
 // GetClock returns the current market clock.
 func (c *Client) GetClock() (*Clock, error) {
+	return c.GetClockWithContext(context.Background())
+}
+
+// GetClockWithContext returns the current market clock.
+func (c *Client) GetClockWithContext(ctx context.Context) (*Clock, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/clock", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -449,6 +565,11 @@ type GetCalendarRequest struct {
 
 // GetCalendar returns the market calendar.
 func (c *Client) GetCalendar(req GetCalendarRequest) ([]CalendarDay, error) {
+	return c.GetCalendarWithContext(context.Background(), req)
+}
+
+// GetCalendarWithContext returns the market calendar.
+func (c *Client) GetCalendarWithContext(ctx context.Context, req GetCalendarRequest) ([]CalendarDay, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/calendar", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -463,7 +584,7 @@ func (c *Client) GetCalendar(req GetCalendarRequest) ([]CalendarDay, error) {
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -490,6 +611,11 @@ type GetOrdersRequest struct {
 
 // GetOrders returns the list of orders for an account.
 func (c *Client) GetOrders(req GetOrdersRequest) ([]Order, error) {
+	return c.GetOrdersWithContext(context.Background(), req)
+}
+
+// GetOrdersWithContext returns the list of orders for an account.
+func (c *Client) GetOrdersWithContext(ctx context.Context, req GetOrdersRequest) ([]Order, error) {
 	urlString := fmt.Sprintf("%s/%s/orders", c.opts.BaseURL, apiVersion)
 
 	u, err := url.Parse(urlString)
@@ -522,7 +648,7 @@ func (c *Client) GetOrders(req GetOrdersRequest) ([]Order, error) {
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -573,12 +699,29 @@ type StopLoss struct {
 
 // PlaceOrder submits an order request to buy or sell an asset.
 func (c *Client) PlaceOrder(req PlaceOrderRequest) (*Order, error) {
+	return c.PlaceOrderWithContext(context.Background(), req)
+}
+
+// PlaceOrderWithContext submits an order request to buy or sell an asset.
+func (c *Client) PlaceOrderWithContext(ctx context.Context, req PlaceOrderRequest) (*Order, error) {
+	if c.opts.ValidateOrders {
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.opts.IdempotentOrders {
+		return c.placeOrderIdempotent(ctx, req)
+	}
+	return c.placeOrder(ctx, req)
+}
+
+func (c *Client) placeOrder(ctx context.Context, req PlaceOrderRequest) (*Order, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/orders", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.post(u, req)
+	resp, err := c.post(ctx, u, req)
 	if err != nil {
 		return nil, err
 	}
@@ -593,12 +736,17 @@ func (c *Client) PlaceOrder(req PlaceOrderRequest) (*Order, error) {
 
 // GetOrder submits a request to get an order by the order ID.
 func (c *Client) GetOrder(orderID string) (*Order, error) {
+	return c.GetOrderWithContext(context.Background(), orderID)
+}
+
+// GetOrderWithContext submits a request to get an order by the order ID.
+func (c *Client) GetOrderWithContext(ctx context.Context, orderID string) (*Order, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/orders/%s", c.opts.BaseURL, apiVersion, orderID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -613,6 +761,11 @@ func (c *Client) GetOrder(orderID string) (*Order, error) {
 
 // GetOrderByClientOrderID submits a request to get an order by the client order ID.
 func (c *Client) GetOrderByClientOrderID(clientOrderID string) (*Order, error) {
+	return c.GetOrderByClientOrderIDWithContext(context.Background(), clientOrderID)
+}
+
+// GetOrderByClientOrderIDWithContext submits a request to get an order by the client order ID.
+func (c *Client) GetOrderByClientOrderIDWithContext(ctx context.Context, clientOrderID string) (*Order, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/orders:by_client_order_id", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -622,7 +775,7 @@ func (c *Client) GetOrderByClientOrderID(clientOrderID string) (*Order, error) {
 	q.Set("client_order_id", clientOrderID)
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -646,12 +799,22 @@ type ReplaceOrderRequest struct {
 
 // ReplaceOrder submits a request to replace an order by id
 func (c *Client) ReplaceOrder(orderID string, req ReplaceOrderRequest) (*Order, error) {
+	return c.ReplaceOrderWithContext(context.Background(), orderID, req)
+}
+
+// ReplaceOrderWithContext submits a request to replace an order by id
+func (c *Client) ReplaceOrderWithContext(ctx context.Context, orderID string, req ReplaceOrderRequest) (*Order, error) {
+	if c.opts.ValidateOrders {
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	u, err := url.Parse(fmt.Sprintf("%s/%s/orders/%s", c.opts.BaseURL, apiVersion, orderID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.patch(u, req)
+	resp, err := c.patch(ctx, u, req)
 	if err != nil {
 		return nil, err
 	}
@@ -666,12 +829,17 @@ func (c *Client) ReplaceOrder(orderID string, req ReplaceOrderRequest) (*Order,
 
 // CancelOrder submits a request to cancel an open order.
 func (c *Client) CancelOrder(orderID string) error {
+	return c.CancelOrderWithContext(context.Background(), orderID)
+}
+
+// CancelOrderWithContext submits a request to cancel an open order.
+func (c *Client) CancelOrderWithContext(ctx context.Context, orderID string) error {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/orders/%s", c.opts.BaseURL, apiVersion, orderID))
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.delete(u)
+	resp, err := c.delete(ctx, u)
 	if err != nil {
 		return err
 	}
@@ -681,12 +849,17 @@ func (c *Client) CancelOrder(orderID string) error {
 
 // CancelAllOrders submits a request to cancel all orders.
 func (c *Client) CancelAllOrders() error {
+	return c.CancelAllOrdersWithContext(context.Background())
+}
+
+// CancelAllOrdersWithContext submits a request to cancel all orders.
+func (c *Client) CancelAllOrdersWithContext(ctx context.Context) error {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/orders", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.delete(u)
+	resp, err := c.delete(ctx, u)
 	if err != nil {
 		return err
 	}
@@ -701,6 +874,11 @@ type GetAssetsRequest struct {
 
 // GetAssets returns the list of assets.
 func (c *Client) GetAssets(req GetAssetsRequest) ([]Asset, error) {
+	return c.GetAssetsWithContext(context.Background(), req)
+}
+
+// GetAssetsWithContext returns the list of assets.
+func (c *Client) GetAssetsWithContext(ctx context.Context, req GetAssetsRequest) ([]Asset, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/assets", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -718,7 +896,7 @@ func (c *Client) GetAssets(req GetAssetsRequest) ([]Asset, error) {
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -733,12 +911,17 @@ func (c *Client) GetAssets(req GetAssetsRequest) ([]Asset, error) {
 
 // GetAsset returns an asset for the given symbol.
 func (c *Client) GetAsset(symbol string) (*Asset, error) {
+	return c.GetAssetWithContext(context.Background(), symbol)
+}
+
+// GetAssetWithContext returns an asset for the given symbol.
+func (c *Client) GetAssetWithContext(ctx context.Context, symbol string) (*Asset, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/assets/%v", c.opts.BaseURL, apiVersion, symbol))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -794,6 +977,13 @@ type GetOptionContractsRequest struct {
 
 // GetOptionContracts returns the list of Option Contracts.
 func (c *Client) GetOptionContracts(req GetOptionContractsRequest) ([]OptionContract, error) {
+	return c.GetOptionContractsWithContext(context.Background(), req)
+}
+
+// GetOptionContractsWithContext returns the list of Option Contracts.
+func (c *Client) GetOptionContractsWithContext(
+	ctx context.Context, req GetOptionContractsRequest,
+) ([]OptionContract, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/options/contracts", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -857,7 +1047,7 @@ func (c *Client) GetOptionContracts(req GetOptionContractsRequest) ([]OptionCont
 
 		u.RawQuery = q.Encode()
 
-		resp, err := c.get(u)
+		resp, err := c.get(ctx, u)
 		if err != nil {
 			return nil, err
 		}
@@ -882,12 +1072,17 @@ func (c *Client) GetOptionContracts(req GetOptionContractsRequest) ([]OptionCont
 
 // GetOptionContract returns an option contract by symbol or contract ID.
 func (c *Client) GetOptionContract(symbolOrID string) (*OptionContract, error) {
+	return c.GetOptionContractWithContext(context.Background(), symbolOrID)
+}
+
+// GetOptionContractWithContext returns an option contract by symbol or contract ID.
+func (c *Client) GetOptionContractWithContext(ctx context.Context, symbolOrID string) (*OptionContract, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/options/contracts/%v", c.opts.BaseURL, apiVersion, symbolOrID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -909,7 +1104,13 @@ type GetAnnouncementsRequest struct {
 	DateType DateType  `json:"date_type"`
 }
 
+// GetAnnouncements returns the corporate action announcements matching the request.
 func (c *Client) GetAnnouncements(req GetAnnouncementsRequest) ([]Announcement, error) {
+	return c.GetAnnouncementsWithContext(context.Background(), req)
+}
+
+// GetAnnouncementsWithContext returns the corporate action announcements matching the request.
+func (c *Client) GetAnnouncementsWithContext(ctx context.Context, req GetAnnouncementsRequest) ([]Announcement, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/corporate_actions/announcements", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -936,7 +1137,7 @@ func (c *Client) GetAnnouncements(req GetAnnouncementsRequest) ([]Announcement,
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -950,14 +1151,20 @@ func (c *Client) GetAnnouncements(req GetAnnouncementsRequest) ([]Announcement,
 	return announcements, nil
 }
 
+// GetAnnouncement returns the corporate action announcement with the given ID.
 func (c *Client) GetAnnouncement(announcementID string) (*Announcement, error) {
+	return c.GetAnnouncementWithContext(context.Background(), announcementID)
+}
+
+// GetAnnouncementWithContext returns the corporate action announcement with the given ID.
+func (c *Client) GetAnnouncementWithContext(ctx context.Context, announcementID string) (*Announcement, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/corporate_actions/announcements/%s",
 		c.opts.BaseURL, apiVersion, announcementID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -970,14 +1177,19 @@ func (c *Client) GetAnnouncement(announcementID string) (*Announcement, error) {
 	return &announcement, nil
 }
 
-// GetAccount returns the user's account information.
+// GetWatchlists returns the watchlists of the account.
 func (c *Client) GetWatchlists() ([]Watchlist, error) {
+	return c.GetWatchlistsWithContext(context.Background())
+}
+
+// GetWatchlistsWithContext returns the watchlists of the account.
+func (c *Client) GetWatchlistsWithContext(ctx context.Context) ([]Watchlist, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/watchlists", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -990,13 +1202,19 @@ func (c *Client) GetWatchlists() ([]Watchlist, error) {
 	return watchlists, nil
 }
 
+// CreateWatchlist creates a watchlist.
 func (c *Client) CreateWatchlist(req CreateWatchlistRequest) (*Watchlist, error) {
+	return c.CreateWatchlistWithContext(context.Background(), req)
+}
+
+// CreateWatchlistWithContext creates a watchlist.
+func (c *Client) CreateWatchlistWithContext(ctx context.Context, req CreateWatchlistRequest) (*Watchlist, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/watchlists", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.post(u, req)
+	resp, err := c.post(ctx, u, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1009,13 +1227,19 @@ func (c *Client) CreateWatchlist(req CreateWatchlistRequest) (*Watchlist, error)
 	return watchlist, nil
 }
 
+// GetWatchlist returns the watchlist with the given ID.
 func (c *Client) GetWatchlist(watchlistID string) (*Watchlist, error) {
+	return c.GetWatchlistWithContext(context.Background(), watchlistID)
+}
+
+// GetWatchlistWithContext returns the watchlist with the given ID.
+func (c *Client) GetWatchlistWithContext(ctx context.Context, watchlistID string) (*Watchlist, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/watchlists/%s", c.opts.BaseURL, apiVersion, watchlistID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1028,13 +1252,21 @@ func (c *Client) GetWatchlist(watchlistID string) (*Watchlist, error) {
 	return watchlist, nil
 }
 
+// UpdateWatchlist updates the name and the symbols of the watchlist.
 func (c *Client) UpdateWatchlist(watchlistID string, req UpdateWatchlistRequest) (*Watchlist, error) {
+	return c.UpdateWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+// UpdateWatchlistWithContext updates the name and the symbols of the watchlist.
+func (c *Client) UpdateWatchlistWithContext(
+	ctx context.Context, watchlistID string, req UpdateWatchlistRequest,
+) (*Watchlist, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/watchlists/%s", c.opts.BaseURL, apiVersion, watchlistID))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.put(u, req)
+	resp, err := c.put(ctx, u, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1049,7 +1281,15 @@ func (c *Client) UpdateWatchlist(watchlistID string, req UpdateWatchlistRequest)
 
 var ErrSymbolMissing = errors.New("symbol missing from request")
 
+// AddSymbolToWatchlist adds a symbol to the watchlist.
 func (c *Client) AddSymbolToWatchlist(watchlistID string, req AddSymbolToWatchlistRequest) (*Watchlist, error) {
+	return c.AddSymbolToWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+// AddSymbolToWatchlistWithContext adds a symbol to the watchlist.
+func (c *Client) AddSymbolToWatchlistWithContext(
+	ctx context.Context, watchlistID string, req AddSymbolToWatchlistRequest,
+) (*Watchlist, error) {
 	if req.Symbol == "" {
 		return nil, ErrSymbolMissing
 	}
@@ -1059,7 +1299,7 @@ func (c *Client) AddSymbolToWatchlist(watchlistID string, req AddSymbolToWatchli
 		return nil, err
 	}
 
-	resp, err := c.post(u, req)
+	resp, err := c.post(ctx, u, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1072,7 +1312,15 @@ func (c *Client) AddSymbolToWatchlist(watchlistID string, req AddSymbolToWatchli
 	return watchlist, nil
 }
 
+// RemoveSymbolFromWatchlist removes a symbol from the watchlist.
 func (c *Client) RemoveSymbolFromWatchlist(watchlistID string, req RemoveSymbolFromWatchlistRequest) error {
+	return c.RemoveSymbolFromWatchlistWithContext(context.Background(), watchlistID, req)
+}
+
+// RemoveSymbolFromWatchlistWithContext removes a symbol from the watchlist.
+func (c *Client) RemoveSymbolFromWatchlistWithContext(
+	ctx context.Context, watchlistID string, req RemoveSymbolFromWatchlistRequest,
+) error {
 	if req.Symbol == "" {
 		return ErrSymbolMissing
 	}
@@ -1082,7 +1330,7 @@ func (c *Client) RemoveSymbolFromWatchlist(watchlistID string, req RemoveSymbolF
 		return err
 	}
 
-	resp, err := c.delete(u)
+	resp, err := c.delete(ctx, u)
 	if err != nil {
 		return err
 	}
@@ -1090,13 +1338,19 @@ func (c *Client) RemoveSymbolFromWatchlist(watchlistID string, req RemoveSymbolF
 	return nil
 }
 
+// DeleteWatchlist deletes the watchlist.
 func (c *Client) DeleteWatchlist(watchlistID string) error {
+	return c.DeleteWatchlistWithContext(context.Background(), watchlistID)
+}
+
+// DeleteWatchlistWithContext deletes the watchlist.
+func (c *Client) DeleteWatchlistWithContext(ctx context.Context, watchlistID string) error {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/watchlists/%s", c.opts.BaseURL, apiVersion, watchlistID))
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.delete(u)
+	resp, err := c.delete(ctx, u)
 	if err != nil {
 		return err
 	}
@@ -1110,175 +1364,400 @@ func GetAccount() (*Account, error) {
 	return DefaultClient.GetAccount()
 }
 
+// GetAccountWithContext returns the user's account information
+// using the default Alpaca client.
+func GetAccountWithContext(ctx context.Context) (*Account, error) {
+	return DefaultClient.GetAccountWithContext(ctx)
+}
+
 // GetAccountConfigurations returns the current account configurations
 func GetAccountConfigurations() (*AccountConfigurations, error) {
 	return DefaultClient.GetAccountConfigurations()
 }
 
+// GetAccountConfigurationsWithContext returns the current account configurations
+func GetAccountConfigurationsWithContext(ctx context.Context) (*AccountConfigurations, error) {
+	return DefaultClient.GetAccountConfigurationsWithContext(ctx)
+}
+
 // UpdateAccountConfigurations updates the account configs.
 func UpdateAccountConfigurations(req UpdateAccountConfigurationsRequest) (*AccountConfigurations, error) {
 	return DefaultClient.UpdateAccountConfigurations(req)
 }
 
+// UpdateAccountConfigurationsWithContext updates the account configs.
+func UpdateAccountConfigurationsWithContext(
+	ctx context.Context, req UpdateAccountConfigurationsRequest,
+) (*AccountConfigurations, error) {
+	return DefaultClient.UpdateAccountConfigurationsWithContext(ctx, req)
+}
+
 // GetAccountActivities returns the account activities.
 func GetAccountActivities(req GetAccountActivitiesRequest) ([]AccountActivity, error) {
 	return DefaultClient.GetAccountActivities(req)
 }
 
+// GetAccountActivitiesWithContext returns the account activities.
+func GetAccountActivitiesWithContext(ctx context.Context, req GetAccountActivitiesRequest) ([]AccountActivity, error) {
+	return DefaultClient.GetAccountActivitiesWithContext(ctx, req)
+}
+
+// IterateAccountActivities returns an iterator over all the account activities matching req.
+func IterateAccountActivities(req GetAccountActivitiesRequest) *ActivityIterator {
+	return DefaultClient.IterateAccountActivities(req)
+}
+
+// IterateAccountActivitiesWithContext returns an iterator over all the account activities matching req.
+func IterateAccountActivitiesWithContext(ctx context.Context, req GetAccountActivitiesRequest) *ActivityIterator {
+	return DefaultClient.IterateAccountActivitiesWithContext(ctx, req)
+}
+
+// GetAllAccountActivities returns all the account activities matching req, following the page tokens.
+func GetAllAccountActivities(req GetAccountActivitiesRequest) ([]AccountActivity, error) {
+	return DefaultClient.GetAllAccountActivities(req)
+}
+
+// GetAllAccountActivitiesWithContext returns all the account activities matching req, following the page tokens.
+func GetAllAccountActivitiesWithContext(
+	ctx context.Context, req GetAccountActivitiesRequest,
+) ([]AccountActivity, error) {
+	return DefaultClient.GetAllAccountActivitiesWithContext(ctx, req)
+}
+
 // GetPortfolioHistory returns the portfolio history.
 func GetPortfolioHistory(req GetPortfolioHistoryRequest) (*PortfolioHistory, error) {
 	return DefaultClient.GetPortfolioHistory(req)
 }
 
+// GetPortfolioHistoryWithContext returns the portfolio history.
+func GetPortfolioHistoryWithContext(ctx context.Context, req GetPortfolioHistoryRequest) (*PortfolioHistory, error) {
+	return DefaultClient.GetPortfolioHistoryWithContext(ctx, req)
+}
+
 // GetPositions lists the account's open positions.
 func GetPositions() ([]Position, error) {
 	return DefaultClient.GetPositions()
 }
 
+// GetPositionsWithContext lists the account's open positions.
+func GetPositionsWithContext(ctx context.Context) ([]Position, error) {
+	return DefaultClient.GetPositionsWithContext(ctx)
+}
+
 // GetPosition returns the account's position for the provided symbol.
 func GetPosition(symbol string) (*Position, error) {
 	return DefaultClient.GetPosition(symbol)
 }
 
+// GetPositionWithContext returns the account's position for the provided symbol.
+func GetPositionWithContext(ctx context.Context, symbol string) (*Position, error) {
+	return DefaultClient.GetPositionWithContext(ctx, symbol)
+}
+
 // CloseAllPositions liquidates all open positions at market price.
 func CloseAllPositions(req CloseAllPositionsRequest) ([]Order, error) {
 	return DefaultClient.CloseAllPositions(req)
 }
 
+// CloseAllPositionsWithContext liquidates all open positions at market price.
+func CloseAllPositionsWithContext(ctx context.Context, req CloseAllPositionsRequest) ([]Order, error) {
+	return DefaultClient.CloseAllPositionsWithContext(ctx, req)
+}
+
 // ClosePosition liquidates the position for the given symbol at market price.
 func ClosePosition(symbol string, req ClosePositionRequest) (*Order, error) {
 	return DefaultClient.ClosePosition(symbol, req)
 }
 
+// ClosePositionWithContext liquidates the position for the given symbol at market price.
+func ClosePositionWithContext(ctx context.Context, symbol string, req ClosePositionRequest) (*Order, error) {
+	return DefaultClient.ClosePositionWithContext(ctx, symbol, req)
+}
+
 // GetClock returns the current market clock.
 func GetClock() (*Clock, error) {
 	return DefaultClient.GetClock()
 }
 
+// GetClockWithContext returns the current market clock.
+func GetClockWithContext(ctx context.Context) (*Clock, error) {
+	return DefaultClient.GetClockWithContext(ctx)
+}
+
 // GetCalendar returns the market calendar.
 func GetCalendar(req GetCalendarRequest) ([]CalendarDay, error) {
 	return DefaultClient.GetCalendar(req)
 }
 
+// GetCalendarWithContext returns the market calendar.
+func GetCalendarWithContext(ctx context.Context, req GetCalendarRequest) ([]CalendarDay, error) {
+	return DefaultClient.GetCalendarWithContext(ctx, req)
+}
+
 // GetOrders returns the list of orders for an account.
 func GetOrders(req GetOrdersRequest) ([]Order, error) {
 	return DefaultClient.GetOrders(req)
 }
 
+// GetOrdersWithContext returns the list of orders for an account.
+func GetOrdersWithContext(ctx context.Context, req GetOrdersRequest) ([]Order, error) {
+	return DefaultClient.GetOrdersWithContext(ctx, req)
+}
+
+// IterateOrders returns an iterator over all the orders matching req.
+func IterateOrders(req GetOrdersRequest) *OrderIterator {
+	return DefaultClient.IterateOrders(req)
+}
+
+// IterateOrdersWithContext returns an iterator over all the orders matching req.
+func IterateOrdersWithContext(ctx context.Context, req GetOrdersRequest) *OrderIterator {
+	return DefaultClient.IterateOrdersWithContext(ctx, req)
+}
+
+// GetAllOrders returns all the orders matching req, following the pagination cursors.
+func GetAllOrders(req GetOrdersRequest) ([]Order, error) {
+	return DefaultClient.GetAllOrders(req)
+}
+
+// GetAllOrdersWithContext returns all the orders matching req, following the pagination cursors.
+func GetAllOrdersWithContext(ctx context.Context, req GetOrdersRequest) ([]Order, error) {
+	return DefaultClient.GetAllOrdersWithContext(ctx, req)
+}
+
 // PlaceOrder submits an order request to buy or sell an asset.
 func PlaceOrder(req PlaceOrderRequest) (*Order, error) {
 	return DefaultClient.PlaceOrder(req)
 }
 
+// PlaceOrderWithContext submits an order request to buy or sell an asset.
+func PlaceOrderWithContext(ctx context.Context, req PlaceOrderRequest) (*Order, error) {
+	return DefaultClient.PlaceOrderWithContext(ctx, req)
+}
+
 // GetOrder submits a request to get an order by the order ID.
 func GetOrder(orderID string) (*Order, error) {
 	return DefaultClient.GetOrder(orderID)
 }
 
+// GetOrderWithContext submits a request to get an order by the order ID.
+func GetOrderWithContext(ctx context.Context, orderID string) (*Order, error) {
+	return DefaultClient.GetOrderWithContext(ctx, orderID)
+}
+
 // GetOrderByClientOrderID submits a request to get an order by the client order ID.
 func GetOrderByClientOrderID(clientOrderID string) (*Order, error) {
 	return DefaultClient.GetOrderByClientOrderID(clientOrderID)
 }
 
+// GetOrderByClientOrderIDWithContext submits a request to get an order by the client order ID.
+func GetOrderByClientOrderIDWithContext(ctx context.Context, clientOrderID string) (*Order, error) {
+	return DefaultClient.GetOrderByClientOrderIDWithContext(ctx, clientOrderID)
+}
+
 // ReplaceOrder submits a request to replace an order by id
 func ReplaceOrder(orderID string, req ReplaceOrderRequest) (*Order, error) {
 	return DefaultClient.ReplaceOrder(orderID, req)
 }
 
+// ReplaceOrderWithContext submits a request to replace an order by id
+func ReplaceOrderWithContext(ctx context.Context, orderID string, req ReplaceOrderRequest) (*Order, error) {
+	return DefaultClient.ReplaceOrderWithContext(ctx, orderID, req)
+}
+
 // CancelOrder submits a request to cancel an open order.
 func CancelOrder(orderID string) error {
 	return DefaultClient.CancelOrder(orderID)
 }
 
+// CancelOrderWithContext submits a request to cancel an open order.
+func CancelOrderWithContext(ctx context.Context, orderID string) error {
+	return DefaultClient.CancelOrderWithContext(ctx, orderID)
+}
+
 // CancelAllOrders submits a request to cancel all orders.
 func CancelAllOrders() error {
 	return DefaultClient.CancelAllOrders()
 }
 
+// CancelAllOrdersWithContext submits a request to cancel all orders.
+func CancelAllOrdersWithContext(ctx context.Context) error {
+	return DefaultClient.CancelAllOrdersWithContext(ctx)
+}
+
 // GetAssets returns the list of assets.
 func GetAssets(req GetAssetsRequest) ([]Asset, error) {
 	return DefaultClient.GetAssets(req)
 }
 
+// GetAssetsWithContext returns the list of assets.
+func GetAssetsWithContext(ctx context.Context, req GetAssetsRequest) ([]Asset, error) {
+	return DefaultClient.GetAssetsWithContext(ctx, req)
+}
+
 // GetAsset returns an asset for the given symbol.
 func GetAsset(symbol string) (*Asset, error) {
 	return DefaultClient.GetAsset(symbol)
 }
 
+// GetAssetWithContext returns an asset for the given symbol.
+func GetAssetWithContext(ctx context.Context, symbol string) (*Asset, error) {
+	return DefaultClient.GetAssetWithContext(ctx, symbol)
+}
+
 // GetOptionContracts returns the list of Option Contracts.
 func GetOptionContracts(req GetOptionContractsRequest) ([]OptionContract, error) {
 	return DefaultClient.GetOptionContracts(req)
 }
 
+// GetOptionContractsWithContext returns the list of Option Contracts.
+func GetOptionContractsWithContext(ctx context.Context, req GetOptionContractsRequest) ([]OptionContract, error) {
+	return DefaultClient.GetOptionContractsWithContext(ctx, req)
+}
+
 // GetOptionContract returns an option contract by symbol or contract ID.
 func GetOptionContract(symbolOrID string) (*OptionContract, error) {
 	return DefaultClient.GetOptionContract(symbolOrID)
 }
 
+// GetOptionContractWithContext returns an option contract by symbol or contract ID.
+func GetOptionContractWithContext(ctx context.Context, symbolOrID string) (*OptionContract, error) {
+	return DefaultClient.GetOptionContractWithContext(ctx, symbolOrID)
+}
+
 // GetAnnouncements returns a list of announcements
 // with the default Alpaca client.
 func GetAnnouncements(req GetAnnouncementsRequest) ([]Announcement, error) {
 	return DefaultClient.GetAnnouncements(req)
 }
 
+// GetAnnouncementsWithContext returns a list of announcements
+// with the default Alpaca client.
+func GetAnnouncementsWithContext(ctx context.Context, req GetAnnouncementsRequest) ([]Announcement, error) {
+	return DefaultClient.GetAnnouncementsWithContext(ctx, req)
+}
+
 // GetAnnouncement returns a single announcement
 // with the default Alpaca client.
 func GetAnnouncement(announcementID string) (*Announcement, error) {
 	return DefaultClient.GetAnnouncement(announcementID)
 }
 
+// GetAnnouncementWithContext returns a single announcement
+// with the default Alpaca client.
+func GetAnnouncementWithContext(ctx context.Context, announcementID string) (*Announcement, error) {
+	return DefaultClient.GetAnnouncementWithContext(ctx, announcementID)
+}
+
 // GetWatchlists returns a list of watchlists
 // with the default Alpaca client.
 func GetWatchlists() ([]Watchlist, error) {
 	return DefaultClient.GetWatchlists()
 }
 
+// GetWatchlistsWithContext returns a list of watchlists
+// with the default Alpaca client.
+func GetWatchlistsWithContext(ctx context.Context) ([]Watchlist, error) {
+	return DefaultClient.GetWatchlistsWithContext(ctx)
+}
+
 // CreateWatchlist creates a new watchlist
 // with the default Alpaca client.
 func CreateWatchlist(req CreateWatchlistRequest) (*Watchlist, error) {
 	return DefaultClient.CreateWatchlist(req)
 }
 
+// CreateWatchlistWithContext creates a new watchlist
+// with the default Alpaca client.
+func CreateWatchlistWithContext(ctx context.Context, req CreateWatchlistRequest) (*Watchlist, error) {
+	return DefaultClient.CreateWatchlistWithContext(ctx, req)
+}
+
 // GetWatchlist returns a single watchlist by getting the watchlist id
 // with the default Alpaca client.
 func GetWatchlist(watchlistID string) (*Watchlist, error) {
 	return DefaultClient.GetWatchlist(watchlistID)
 }
 
+// GetWatchlistWithContext returns a single watchlist by getting the watchlist id
+// with the default Alpaca client.
+func GetWatchlistWithContext(ctx context.Context, watchlistID string) (*Watchlist, error) {
+	return DefaultClient.GetWatchlistWithContext(ctx, watchlistID)
+}
+
 // UpdateWatchlist updates a watchlist by getting the watchlist id
 // with the default Alpaca client.
 func UpdateWatchlist(watchlistID string, req UpdateWatchlistRequest) (*Watchlist, error) {
 	return DefaultClient.UpdateWatchlist(watchlistID, req)
 }
 
+// UpdateWatchlistWithContext updates a watchlist by getting the watchlist id
+// with the default Alpaca client.
+func UpdateWatchlistWithContext(
+	ctx context.Context, watchlistID string, req UpdateWatchlistRequest,
+) (*Watchlist, error) {
+	return DefaultClient.UpdateWatchlistWithContext(ctx, watchlistID, req)
+}
+
 // DeleteWatchlist deletes a watchlist by getting the watchlist id
 // with the default Alpaca client.
 func DeleteWatchlist(watchlistID string) error {
 	return DefaultClient.DeleteWatchlist(watchlistID)
 }
 
+// DeleteWatchlistWithContext deletes a watchlist by getting the watchlist id
+// with the default Alpaca client.
+func DeleteWatchlistWithContext(ctx context.Context, watchlistID string) error {
+	return DefaultClient.DeleteWatchlistWithContext(ctx, watchlistID)
+}
+
 // AddSymbolToWatchlist adds an asset to a watchlist by getting the watchlist id
 // with the default Alpaca client.
 func AddSymbolToWatchlist(watchlistID string, req AddSymbolToWatchlistRequest) (*Watchlist, error) {
 	return DefaultClient.AddSymbolToWatchlist(watchlistID, req)
 }
 
+// AddSymbolToWatchlistWithContext adds an asset to a watchlist by getting the watchlist id
+// with the default Alpaca client.
+func AddSymbolToWatchlistWithContext(
+	ctx context.Context, watchlistID string, req AddSymbolToWatchlistRequest,
+) (*Watchlist, error) {
+	return DefaultClient.AddSymbolToWatchlistWithContext(ctx, watchlistID, req)
+}
+
 // RemoveSymbolFromWatchlist removes an asset from a watchlist by getting the watchlist id
 // with the default Alpaca client.
 func RemoveSymbolFromWatchlist(watchlistID string, req RemoveSymbolFromWatchlistRequest) error {
 	return DefaultClient.RemoveSymbolFromWatchlist(watchlistID, req)
 }
 
+// RemoveSymbolFromWatchlistWithContext removes an asset from a watchlist by getting the watchlist id
+// with the default Alpaca client.
+func RemoveSymbolFromWatchlistWithContext(
+	ctx context.Context, watchlistID string, req RemoveSymbolFromWatchlistRequest,
+) error {
+	return DefaultClient.RemoveSymbolFromWatchlistWithContext(ctx, watchlistID, req)
+}
+
 // GetUSTreasuries returns the available US Treasury securities.
 func GetUSTreasuries(req GetUSTreasuriesRequest) ([]USTreasury, error) {
 	return DefaultClient.GetUSTreasuries(req)
 }
 
+// GetUSTreasuriesWithContext returns the available US Treasury securities.
+func GetUSTreasuriesWithContext(ctx context.Context, req GetUSTreasuriesRequest) ([]USTreasury, error) {
+	return DefaultClient.GetUSTreasuriesWithContext(ctx, req)
+}
+
 // GetUSCorporates returns the available US Corporate bonds.
 func GetUSCorporates(req GetUSCorporatesRequest) ([]USCorporate, error) {
 	return DefaultClient.GetUSCorporates(req)
 }
 
+// GetUSCorporatesWithContext returns the available US Corporate bonds.
+func GetUSCorporatesWithContext(ctx context.Context, req GetUSCorporatesRequest) ([]USCorporate, error) {
+	return DefaultClient.GetUSCorporatesWithContext(ctx, req)
+}
+
 type GetUSTreasuriesRequest struct {
 	Subtype    TreasurySubtype
 	BondStatus BondStatus
@@ -1288,6 +1767,11 @@ type GetUSTreasuriesRequest struct {
 
 // GetUSTreasuries returns the available US Treasury securities.
 func (c *Client) GetUSTreasuries(req GetUSTreasuriesRequest) ([]USTreasury, error) {
+	return c.GetUSTreasuriesWithContext(context.Background(), req)
+}
+
+// GetUSTreasuriesWithContext returns the available US Treasury securities.
+func (c *Client) GetUSTreasuriesWithContext(ctx context.Context, req GetUSTreasuriesRequest) ([]USTreasury, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/assets/fixed_income/us_treasuries", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -1308,7 +1792,7 @@ func (c *Client) GetUSTreasuries(req GetUSTreasuriesRequest) ([]USTreasury, erro
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1330,6 +1814,11 @@ type GetUSCorporatesRequest struct {
 
 // GetUSCorporates returns the available US Corporate bonds.
 func (c *Client) GetUSCorporates(req GetUSCorporatesRequest) ([]USCorporate, error) {
+	return c.GetUSCorporatesWithContext(context.Background(), req)
+}
+
+// GetUSCorporatesWithContext returns the available US Corporate bonds.
+func (c *Client) GetUSCorporatesWithContext(ctx context.Context, req GetUSCorporatesRequest) ([]USCorporate, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/assets/fixed_income/us_corporates", c.opts.BaseURL, apiVersion))
 	if err != nil {
 		return nil, err
@@ -1350,7 +1839,7 @@ func (c *Client) GetUSCorporates(req GetUSCorporatesRequest) ([]USCorporate, err
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1363,8 +1852,8 @@ func (c *Client) GetUSCorporates(req GetUSCorporatesRequest) ([]USCorporate, err
 	return result.USCorporates, nil
 }
 
-func (c *Client) get(u *url.URL) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+func (c *Client) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1372,13 +1861,13 @@ func (c *Client) get(u *url.URL) (*http.Response, error) {
 	return c.do(c, req)
 }
 
-func (c *Client) post(u *url.URL, data interface{}) (*http.Response, error) {
+func (c *Client) post(ctx context.Context, u *url.URL, data interface{}) (*http.Response, error) {
 	buf, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(buf))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(buf))
 	if err != nil {
 		return nil, err
 	}
@@ -1386,13 +1875,13 @@ func (c *Client) post(u *url.URL, data interface{}) (*http.Response, error) {
 	return c.do(c, req)
 }
 
-func (c *Client) put(u *url.URL, data interface{}) (*http.Response, error) {
+func (c *Client) put(ctx context.Context, u *url.URL, data interface{}) (*http.Response, error) {
 	buf, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(buf))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(buf))
 	if err != nil {
 		return nil, err
 	}
@@ -1400,13 +1889,13 @@ func (c *Client) put(u *url.URL, data interface{}) (*http.Response, error) {
 	return c.do(c, req)
 }
 
-func (c *Client) patch(u *url.URL, data interface{}) (*http.Response, error) {
+func (c *Client) patch(ctx context.Context, u *url.URL, data interface{}) (*http.Response, error) {
 	buf, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPatch, u.String(), bytes.NewReader(buf))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, u.String(), bytes.NewReader(buf))
 	if err != nil {
 		return nil, err
 	}
@@ -1414,8 +1903,8 @@ func (c *Client) patch(u *url.URL, data interface{}) (*http.Response, error) {
 	return c.do(c, req)
 }
 
-func (c *Client) delete(u *url.URL) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+func (c *Client) delete(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package alpaca
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -114,6 +115,26 @@ func TestDefaultDo_TooManyRetries(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestDefaultDo_ContextCanceledWhileRetrying(t *testing.T) {
+	called := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called++
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+	c := NewClient(ClientOpts{
+		BaseURL:    ts.URL,
+		RetryDelay: time.Hour,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetAccountWithContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Minute)
+	assert.Equal(t, 1, called)
+}
+
 func TestDefaultDo_Error(t *testing.T) {
 	resp := `{"code":1234567,"message":"custom error message","other_field":"x"}`
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -159,6 +180,27 @@ func TestGetAccount(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestGetAccountWithContext(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	c.do = func(_ *Client, req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "value", req.Context().Value(ctxKey{}))
+		return &http.Response{
+			Body: genBody(Account{ID: "some_id"}),
+		}, nil
+	}
+	acct, err := c.GetAccountWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "some_id", acct.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c = NewClient(ClientOpts{BaseURL: "http://127.0.0.1:1"})
+	_, err = c.GetAccountWithContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
 func TestGetPositions(t *testing.T) {
 	c := DefaultClient
 
@@ -1262,7 +1304,7 @@ func TestGetAccountActivities(t *testing.T) {
 	assert.Len(t, activities, 3)
 	activity1 := activities[0]
 	assert.Equal(t, civil.Date{Year: 2019, Month: 8, Day: 1}, activity1.Date)
-	assert.Equal(t, "DIV", activity1.ActivityType)
+	assert.Equal(t, ActivityDividend, activity1.ActivityType)
 	assert.Equal(t, "20190801011955195::5f596936-6f23-4cef-bdf1-3806aae57dbf", activity1.ID)
 	assert.True(t, decimal.NewFromFloat(1.02).Equal(activity1.NetAmount))
 	assert.Equal(t, "T", activity1.Symbol)
@@ -1271,7 +1313,7 @@ func TestGetAccountActivities(t *testing.T) {
 	assert.Equal(t, "executed", activity1.Status)
 	activity2 := activities[1]
 	assert.Equal(t, civil.Date{Year: 2019, Month: 8, Day: 1}, activity2.Date)
-	assert.Equal(t, "DIV", activity2.ActivityType)
+	assert.Equal(t, ActivityDividend, activity2.ActivityType)
 	assert.Equal(t, "20190801011955195::5f596936-6f23-4cef-bdf1-3806aae57dbd", activity2.ID)
 	assert.True(t, decimal.NewFromInt(5).Equal(activity2.NetAmount))
 	assert.Equal(t, "AAPL", activity2.Symbol)
@@ -1279,7 +1321,7 @@ func TestGetAccountActivities(t *testing.T) {
 	assert.Equal(t, decimal.NewFromInt(100), activity2.PerShareAmount)
 	assert.Equal(t, "executed", activity2.Status)
 	activity3 := activities[2]
-	assert.Equal(t, "FILL", activity3.ActivityType)
+	assert.Equal(t, ActivityFill, activity3.ActivityType)
 	assert.Equal(t, "20240624093004214::18a82342-245e-4e8a-9703-87ae38d9b629", activity3.ID)
 	assert.Equal(t, "2024-06-24T13:30:04.214535Z", activity3.TransactionTime.Format("2006-01-02T15:04:05.999999Z"))
 	assert.Equal(t, "partial_fill", activity3.Type)
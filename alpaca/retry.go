@@ -0,0 +1,182 @@
+package alpaca
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand" //nolint:depguard // math/rand/v2 requires go1.22, jitter doesn't need a better source
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures when and how failed requests are retried.
+//
+// Requests with idempotent methods (GET, PUT, DELETE) are retried when the response
+// status is in RetryableStatuses or when RetryableError reports the transport error
+// as retryable. Requests with non-idempotent methods (POST, PATCH), e.g. placing an
+// order, are only retried after a 429 Too Many Requests response, because in that case
+// the server guarantees that the request has not been processed. Any other failure of
+// a non-idempotent request is returned to the caller, since the request may have
+// already taken effect.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries after the initial attempt.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry. It is doubled for each consecutive retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff. Zero means no cap.
+	// Set it to BaseDelay to retry with a constant delay.
+	MaxDelay time.Duration
+	// Jitter randomly shortens each computed delay by up to this fraction (between 0 and 1),
+	// so that multiple clients hitting the same error don't retry in lockstep.
+	Jitter float64
+	// RetryableStatuses contains the HTTP status codes that should be retried.
+	RetryableStatuses []int
+	// RetryableError reports whether a transport error (e.g. a connection reset) should be retried.
+	// If nil, transport errors are never retried.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with exponential backoff and jitter that retries
+// rate limit errors, gateway errors and transient network errors.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries: 5,
+		BaseDelay:  250 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+		Jitter:     0.2,
+		RetryableStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableError: IsTransientError,
+	}
+}
+
+// ShouldRetry reports whether req should be sent again after the given attempt (starting from 0)
+// resulted in resp or err.
+func (p *RetryPolicy) ShouldRetry(req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if attempt >= p.MaxRetries || req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isIdempotent(req) && p.RetryableError != nil && p.RetryableError(err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return slices.Contains(p.RetryableStatuses, resp.StatusCode)
+	}
+	return isIdempotent(req) && slices.Contains(p.RetryableStatuses, resp.StatusCode)
+}
+
+// Backoff returns how long to wait before the next attempt when the given attempt
+// (starting from 0) resulted in resp. The delay requested by the server in the Retry-After
+// or the X-RateLimit-Reset headers takes precedence over the computed exponential backoff.
+func (p *RetryPolicy) Backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := serverRetryDelay(resp, time.Now()); ok {
+			return d
+		}
+	}
+	d := p.BaseDelay
+	for i := 0; i < attempt && d < math.MaxInt64/2; i++ {
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(math.Min(p.Jitter, 1) * rand.Float64() * float64(d)) //nolint:gosec // not security sensitive
+	}
+	return d
+}
+
+// serverRetryDelay returns the delay the server asked for in its response, if any.
+func serverRetryDelay(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil {
+			return max(time.Duration(secs)*time.Second, 0), true
+		}
+		if t, err := http.ParseTime(s); err == nil {
+			return max(t.Sub(now), 0), true
+		}
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if s := resp.Header.Get("X-RateLimit-Reset"); s != "" {
+		if reset, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+	return 0, false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// IsTransientError reports whether err is a network error that is likely to go away
+// when the request is retried, e.g. a connection reset or a timeout. The permanent
+// failures, such as an unknown host or a failed TLS handshake, are not transient.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	// Every error returned by http.Client is wrapped in a *url.Error
+	// that implements net.Error itself, so we have to look inside.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// sleepContext pauses the current goroutine for at least d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rewindBody prepares the body of req to be sent again.
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
@@ -0,0 +1,165 @@
+package alpaca
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(0, nil))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(1, nil))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(2, nil))
+	assert.Equal(t, 800*time.Millisecond, p.Backoff(3, nil))
+	assert.Equal(t, time.Second, p.Backoff(4, nil))
+	assert.Equal(t, time.Second, p.Backoff(1000, nil))
+
+	p.MaxDelay = 0
+	assert.Equal(t, 1600*time.Millisecond, p.Backoff(4, nil))
+	assert.Positive(t, p.Backoff(1000, nil))
+}
+
+func TestRetryPolicy_BackoffJitter(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(i, nil)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+	}
+}
+
+func TestRetryPolicy_BackoffServerDelay(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "3")
+	assert.Equal(t, 3*time.Second, p.Backoff(0, resp))
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	d := p.Backoff(0, resp)
+	assert.Greater(t, d, 58*time.Second)
+	assert.LessOrEqual(t, d, time.Minute)
+
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Duration(0), p.Backoff(0, resp))
+
+	resp.Header.Set("Retry-After", "invalid")
+	assert.Equal(t, time.Millisecond, p.Backoff(0, resp))
+
+	// X-RateLimit-Reset is only considered for 429 responses
+	resp.Header.Del("Retry-After")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(10*time.Second).Unix(), 10))
+	assert.Equal(t, time.Millisecond, p.Backoff(0, resp))
+	resp.StatusCode = http.StatusTooManyRequests
+	d = p.Backoff(0, resp)
+	assert.Greater(t, d, 8*time.Second)
+	assert.LessOrEqual(t, d, 10*time.Second)
+}
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy()
+	get, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	require.NoError(t, err)
+	post, err := http.NewRequest(http.MethodPost, "https://example.com", nil)
+	require.NoError(t, err)
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	connReset := fmt.Errorf("read: %w", syscall.ECONNRESET)
+
+	assert.True(t, p.ShouldRetry(get, status(http.StatusTooManyRequests), nil, 0))
+	assert.True(t, p.ShouldRetry(get, status(http.StatusServiceUnavailable), nil, 0))
+	assert.True(t, p.ShouldRetry(get, nil, connReset, 0))
+	assert.False(t, p.ShouldRetry(get, status(http.StatusInternalServerError), nil, 0))
+	assert.False(t, p.ShouldRetry(get, status(http.StatusTooManyRequests), nil, p.MaxRetries))
+
+	assert.True(t, p.ShouldRetry(post, status(http.StatusTooManyRequests), nil, 0))
+	assert.False(t, p.ShouldRetry(post, status(http.StatusServiceUnavailable), nil, 0))
+	assert.False(t, p.ShouldRetry(post, nil, connReset, 0))
+
+	p.RetryableError = nil
+	assert.False(t, p.ShouldRetry(get, nil, connReset, 0))
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, IsTransientError(nil))
+	assert.False(t, IsTransientError(errors.New("something")))
+	assert.True(t, IsTransientError(io.ErrUnexpectedEOF))
+	assert.True(t, IsTransientError(fmt.Errorf("dial: %w", syscall.ECONNREFUSED)))
+
+	_, err := http.Get("http://127.0.0.1:0") //nolint:noctx // test
+	require.Error(t, err)
+	assert.True(t, IsTransientError(err))
+
+	// Timeouts and temporary DNS failures are retried, but not the unknown hosts
+	assert.True(t, IsTransientError(&url.Error{Op: "Get", Err: &net.DNSError{IsTimeout: true}}))
+	assert.True(t, IsTransientError(&net.DNSError{IsTemporary: true}))
+	assert.False(t, IsTransientError(&url.Error{Op: "Get", Err: &net.DNSError{Name: "nope", IsNotFound: true}}))
+	assert.True(t, IsTransientError(&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}))
+	assert.False(t, IsTransientError(&net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")}))
+}
+
+func TestDefaultDo_RetryPostBody(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req PlaceOrderRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "AAPL", req.Symbol)
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"id":"order_id"}`)
+	}))
+	defer ts.Close()
+	c := NewClient(ClientOpts{BaseURL: ts.URL, RetryPolicy: DefaultRetryPolicy()})
+	order, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL"})
+	require.NoError(t, err)
+	assert.Equal(t, "order_id", order.ID)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestDefaultDo_CustomRetryPolicy(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"id":"account_id"}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	_, err := c.GetAccount()
+	require.Error(t, err)
+	assert.EqualValues(t, 1, calls.Load())
+
+	calls.Store(0)
+	c = NewClient(ClientOpts{
+		BaseURL: ts.URL,
+		RetryPolicy: &RetryPolicy{
+			MaxRetries:        2,
+			BaseDelay:         time.Millisecond,
+			RetryableStatuses: []int{http.StatusBadGateway},
+		},
+	})
+	acct, err := c.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, "account_id", acct.ID)
+	assert.EqualValues(t, 3, calls.Load())
+}
@@ -0,0 +1,66 @@
+package alpaca
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// sseEvent is an event of a server-sent events stream.
+type sseEvent struct {
+	// Name is the event type, empty for the default "message" type.
+	Name string
+	ID   string
+	Data []byte
+}
+
+// sseReader parses a server-sent events stream as defined by
+// https://html.spec.whatwg.org/multipage/server-sent-events.html.
+type sseReader struct {
+	r *bufio.Reader
+	// onLine is called for every line read, including comments (heartbeats).
+	onLine func()
+}
+
+func newSSEReader(r io.Reader, onLine func()) *sseReader {
+	return &sseReader{r: bufio.NewReader(r), onLine: onLine}
+}
+
+// Next returns the next event of the stream. Incomplete events at the end of the stream are discarded.
+func (s *sseReader) Next() (sseEvent, error) {
+	var ev sseEvent
+	var data [][]byte
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		if s.onLine != nil {
+			s.onLine()
+		}
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		if len(line) == 0 {
+			// A blank line dispatches the event
+			if len(data) == 0 {
+				ev = sseEvent{}
+				continue
+			}
+			ev.Data = bytes.Join(data, []byte("\n"))
+			return ev, nil
+		}
+		if line[0] == ':' {
+			// Comment, e.g. a heartbeat
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			ev.Name = string(value)
+		case "data":
+			data = append(data, value)
+		case "id":
+			ev.ID = string(value)
+		}
+	}
+}
@@ -1,17 +1,14 @@
 package alpaca
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
-	"net"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
@@ -20,21 +17,33 @@ type StreamTradeUpdatesRequest struct {
 	Until   time.Time
 	SinceID string
 	UntilID string
+	// IdleTimeout makes the stream fail with ErrStreamIdle if nothing (neither a trade update
+	// nor a heartbeat) is received for this long. Zero means no idle timeout.
+	IdleTimeout time.Duration
 }
 
+// ErrStreamIdle is returned by StreamTradeUpdates if nothing has been received
+// from the server for longer than the idle timeout.
+var ErrStreamIdle = errors.New("no message received within the idle timeout")
+
 // StreamTradeUpdates streams the trade updates of the account.
+//
+// The stream uses the transport and the credentials of the client, but not the Timeout
+// of its HTTPClient, since the stream is long-lived. Use IdleTimeout to detect dead connections.
 func (c *Client) StreamTradeUpdates(
 	ctx context.Context, handler func(TradeUpdate), req StreamTradeUpdatesRequest,
 ) error {
-	transport := http.Transport{
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			d := net.Dialer{Timeout: 5 * time.Second}
-			return d.DialContext(ctx, network, addr)
-		},
-	}
-	client := http.Client{
-		Transport: &transport,
-	}
+	return c.streamTradeUpdates(ctx, handler, req, nil, nil)
+}
+
+// streamTradeUpdates streams the trade updates of the account,
+// calling onConnect (if not nil) once the connection has been established.
+// The events that are not valid trade updates are passed to onInvalid with their
+// event ID (empty if unknown), or end the stream with an error if it's nil.
+func (c *Client) streamTradeUpdates(
+	ctx context.Context, handler func(TradeUpdate), req StreamTradeUpdatesRequest,
+	onConnect func(), onInvalid func(eventID string, err error),
+) error {
 	u, err := url.Parse(c.opts.BaseURL + "/v2/events/trades")
 	if err != nil {
 		return err
@@ -55,70 +64,264 @@ func (c *Client) StreamTradeUpdates(
 	}
 
 	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return err
 	}
-	if c.opts.OAuth != "" {
-		request.Header.Set("Authorization", "Bearer "+c.opts.OAuth)
-	} else {
-		request.Header.Set("APCA-API-KEY-ID", c.opts.APIKey)
-		request.Header.Set("APCA-API-SECRET-KEY", c.opts.APISecret)
+	c.setHeaders(request)
+	request.Header.Set("Accept", "text/event-stream")
+	if err := c.opts.RateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	// The idle timer covers the connection too
+	onLine := func() {}
+	if req.IdleTimeout > 0 {
+		idleTimer := time.AfterFunc(req.IdleTimeout, func() { cancel(ErrStreamIdle) })
+		defer idleTimer.Stop()
+		onLine = func() { idleTimer.Reset(req.IdleTimeout) }
 	}
 
+	// The client's timeout covers the whole request including reading the body,
+	// so it can't be applied to a stream.
+	client := *c.httpClient
+	client.Timeout = 0
 	resp, err := client.Do(request)
 	if err != nil {
-		return err
+		return streamError(ctx, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("%s (HTTP %d)", body, resp.StatusCode)
+		return APIErrorFromResponse(resp)
+	}
+	if onConnect != nil {
+		onConnect()
 	}
 
-	reader := bufio.NewReader(resp.Body)
+	events := newSSEReader(resp.Body, onLine)
 	for {
-		msg, err := reader.ReadBytes('\n')
+		ev, err := events.Next()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				return nil
 			}
-			return err
+			return streamError(ctx, err)
 		}
-		const dataPrefix = "data: "
-		if !bytes.HasPrefix(msg, []byte(dataPrefix)) {
-			continue
-		}
-		msg = msg[(len(dataPrefix)):]
+		// Every event carries a trade update, regardless of its name
 		var tu TradeUpdate
-		if err := json.Unmarshal(msg, &tu); err != nil {
-			return err
+		if err := json.Unmarshal(ev.Data, &tu); err != nil {
+			err = fmt.Errorf("alpaca: invalid trade update %q: %w", ev.Data, err)
+			if onInvalid == nil {
+				return err
+			}
+			onInvalid(invalidEventID(ev), err)
+			continue
 		}
 		handler(tu)
 	}
 }
 
+// invalidEventID returns the event ID of an event that is not a valid trade update: its
+// event_id if its data is still valid JSON, or else its SSE ID.
+func invalidEventID(ev sseEvent) string {
+	var data struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(ev.Data, &data); err == nil && data.EventID != "" {
+		return data.EventID
+	}
+	return ev.ID
+}
+
+// streamError returns ErrStreamIdle instead of err if the stream was interrupted by the idle timer.
+func streamError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrStreamIdle) {
+		return cause
+	}
+	return err
+}
+
+// TradeUpdatesSubscription is a trade updates stream running in the background,
+// started by StreamTradeUpdatesInBackground.
+type TradeUpdatesSubscription struct {
+	terminated chan error
+
+	mu          sync.Mutex
+	startedAt   time.Time
+	lastEventID string
+	lastAt      time.Time
+	recent      recentIDs
+}
+
+// Terminated returns a channel that the subscription sends an error to when it has terminated.
+// The error is nil if the context was canceled. The channel is also closed upon termination.
+func (s *TradeUpdatesSubscription) Terminated() <-chan error {
+	return s.terminated
+}
+
+// LastEventID returns the event ID of the last trade update passed to the handler.
+// It can be used to resume streaming later with WithSinceID.
+func (s *TradeUpdatesSubscription) LastEventID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastEventID
+}
+
 // StreamTradeUpdatesInBackground streams the trade updates of the account.
 // It runs in the background and keeps calling the handler function for each trade update
-// until the context is cancelled. If an error happens it logs it and retries immediately.
-func (c *Client) StreamTradeUpdatesInBackground(ctx context.Context, handler func(TradeUpdate)) {
+// until the context is cancelled. If the connection is lost, it reconnects with exponential
+// backoff and resumes right after the last received trade update, skipping the duplicates.
+// The events that are not valid trade updates are logged and skipped.
+func (c *Client) StreamTradeUpdatesInBackground(
+	ctx context.Context, handler func(TradeUpdate), opts ...StreamOption,
+) *TradeUpdatesSubscription {
+	o := defaultStreamOptions()
+	for _, opt := range opts {
+		opt.applyStream(&o)
+	}
+	s := &TradeUpdatesSubscription{
+		terminated:  make(chan error, 1),
+		startedAt:   time.Now(),
+		lastEventID: o.sinceID,
+		recent:      newRecentIDs(recentEventIDsSize),
+	}
 	go func() {
-		var lastMessage time.Time
-		for {
-			req := StreamTradeUpdatesRequest{}
-			if !lastMessage.IsZero() {
-				req.Since = lastMessage.Add(time.Nanosecond)
-			}
-			err := c.StreamTradeUpdates(ctx, func(tu TradeUpdate) {
-				lastMessage = tu.At
+		s.terminated <- s.run(ctx, c, handler, o)
+		close(s.terminated)
+	}()
+	return s
+}
+
+// recentEventIDsSize is the number of recent event IDs remembered to detect duplicates.
+const recentEventIDsSize = 1000
+
+func (s *TradeUpdatesSubscription) run(
+	ctx context.Context, c *Client, handler func(TradeUpdate), o streamOptions,
+) error {
+	backoff := RetryPolicy{BaseDelay: o.reconnectDelay, MaxDelay: o.maxReconnectDelay, Jitter: 0.2}
+	failedAttemptsInARow := 0
+	for {
+		connected := false
+		err := c.streamTradeUpdates(ctx, func(tu TradeUpdate) {
+			if s.accept(tu) {
 				handler(tu)
-			}, req)
-			if err == nil || errors.Is(err, context.Canceled) {
-				return
 			}
-			log.Printf("alpaca stream trade updates error: %v", err)
+		}, s.resumeRequest(o.idleTimeout), func() {
+			connected = true
+			failedAttemptsInARow = 0
+			o.logger.Infof("alpaca: trade updates stream connected")
+			if o.connectCallback != nil {
+				o.connectCallback()
+			}
+		}, func(eventID string, err error) {
+			// The invalid event is skipped, so that it isn't received again after a reconnection
+			o.logger.Errorf("alpaca: skipping trade update event %q: %v", eventID, err)
+			s.skip(eventID)
+		})
+		if connected && o.disconnectCallback != nil {
+			o.disconnectCallback()
 		}
-	}()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if isIrrecoverable(err) {
+			o.logger.Errorf("alpaca: trade updates stream irrecoverable error: %v", err)
+			return err
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		failedAttemptsInARow++
+		if o.reconnectLimit != 0 && failedAttemptsInARow > o.reconnectLimit {
+			o.logger.Errorf("alpaca: trade updates stream max reconnect limit has been reached, last error: %v", err)
+			return fmt.Errorf("max reconnect limit has been reached, last error: %w", err)
+		}
+		delay := backoff.Backoff(failedAttemptsInARow-1, nil)
+		o.logger.Warnf("alpaca: trade updates stream error: %v, reconnecting in %s (attempt %d)",
+			err, delay, failedAttemptsInARow)
+		if sleepContext(ctx, delay) != nil {
+			return nil
+		}
+	}
+}
+
+// accept records tu as the last trade update and reports whether it should be
+// passed to the handler, i.e. whether it is not a duplicate.
+func (s *TradeUpdatesSubscription) accept(tu TradeUpdate) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tu.EventID != "" {
+		if !s.recent.add(tu.EventID) {
+			return false
+		}
+		s.lastEventID = tu.EventID
+	}
+	s.lastAt = tu.At
+	return true
+}
+
+// skip records the event ID of an event that is not passed to the handler as the last one.
+func (s *TradeUpdatesSubscription) skip(eventID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if eventID != "" && s.recent.add(eventID) {
+		s.lastEventID = eventID
+	}
+}
+
+// resumeRequest returns the request that continues the stream after the last trade update,
+// or from the start of the subscription if there was none, so that the updates sent while
+// disconnected are not lost.
+func (s *TradeUpdatesSubscription) resumeRequest(idleTimeout time.Duration) StreamTradeUpdatesRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req := StreamTradeUpdatesRequest{IdleTimeout: idleTimeout}
+	switch {
+	case s.lastEventID != "":
+		req.SinceID = s.lastEventID
+	case !s.lastAt.IsZero():
+		req.Since = s.lastAt.Add(time.Nanosecond)
+	default:
+		req.Since = s.startedAt
+	}
+	return req
+}
+
+// isIrrecoverable reports whether err means that reconnecting won't help, e.g. invalid credentials.
+func isIrrecoverable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusBadRequest &&
+		apiErr.StatusCode < http.StatusInternalServerError && apiErr.StatusCode != http.StatusTooManyRequests
+}
+
+// recentIDs is a fixed size set of the most recently added IDs.
+type recentIDs struct {
+	ids   map[string]struct{}
+	order []string
+	next  int
+}
+
+func newRecentIDs(size int) recentIDs {
+	return recentIDs{
+		ids:   make(map[string]struct{}, size),
+		order: make([]string, size),
+	}
+}
+
+// add adds id to the set, evicting the oldest ID if the set is full.
+// It returns false if id was already in the set.
+func (r *recentIDs) add(id string) bool {
+	if _, ok := r.ids[id]; ok {
+		return false
+	}
+	delete(r.ids, r.order[r.next])
+	r.order[r.next] = id
+	r.next = (r.next + 1) % len(r.order)
+	r.ids[id] = struct{}{}
+	return true
 }
 
 // StreamTradeUpdates streams the trade updates of the account. It blocks and keeps calling the handler
@@ -129,7 +332,10 @@ func StreamTradeUpdates(ctx context.Context, handler func(TradeUpdate), req Stre
 
 // StreamTradeUpdatesInBackground streams the trade updates of the account.
 // It runs in the background and keeps calling the handler function for each trade update
-// until the context is cancelled. If an error happens it logs it and retries immediately.
-func StreamTradeUpdatesInBackground(ctx context.Context, handler func(TradeUpdate)) {
-	DefaultClient.StreamTradeUpdatesInBackground(ctx, handler)
+// until the context is cancelled. If the connection is lost, it reconnects with exponential
+// backoff and resumes right after the last received trade update, skipping the duplicates.
+func StreamTradeUpdatesInBackground(
+	ctx context.Context, handler func(TradeUpdate), opts ...StreamOption,
+) *TradeUpdatesSubscription {
+	return DefaultClient.StreamTradeUpdatesInBackground(ctx, handler, opts...)
 }
@@ -0,0 +1,96 @@
+package alpaca
+
+import (
+	"time"
+)
+
+// StreamOption is a configuration option for StreamTradeUpdatesInBackground and TradeUpdatesClient.
+type StreamOption interface {
+	applyStream(*streamOptions)
+}
+
+type streamOptions struct {
+	logger             Logger
+	reconnectLimit     int
+	reconnectDelay     time.Duration
+	maxReconnectDelay  time.Duration
+	connectCallback    func()
+	disconnectCallback func()
+	sinceID            string
+	idleTimeout        time.Duration
+}
+
+func defaultStreamOptions() streamOptions {
+	return streamOptions{
+		logger:            ErrorOnlyLogger(),
+		reconnectLimit:    0,
+		reconnectDelay:    150 * time.Millisecond,
+		maxReconnectDelay: 30 * time.Second,
+	}
+}
+
+type funcStreamOption struct {
+	f func(*streamOptions)
+}
+
+func (fo *funcStreamOption) applyStream(o *streamOptions) {
+	fo.f(o)
+}
+
+func newFuncStreamOption(f func(*streamOptions)) *funcStreamOption {
+	return &funcStreamOption{
+		f: f,
+	}
+}
+
+// WithLogger configures the logger. By default only the errors are logged.
+func WithLogger(logger Logger) StreamOption {
+	return newFuncStreamOption(func(o *streamOptions) {
+		o.logger = logger
+	})
+}
+
+// WithReconnectSettings configures how many consecutive connection errors should be accepted
+// and the delay before the first reconnection attempt. The delay is doubled after each
+// consecutive error, up to 30 seconds. limit = 0 means the stream will try reconnecting
+// indefinitely unless it runs into an irrecoverable error (such as invalid credentials).
+// By default, StreamTradeUpdatesInBackground reconnects indefinitely and TradeUpdatesClient
+// gives up after 20 consecutive errors.
+func WithReconnectSettings(limit int, delay time.Duration) StreamOption {
+	return newFuncStreamOption(func(o *streamOptions) {
+		o.reconnectLimit = limit
+		o.reconnectDelay = delay
+	})
+}
+
+// WithConnectCallback runs the callback function every time the stream (re)connects.
+func WithConnectCallback(callback func()) StreamOption {
+	return newFuncStreamOption(func(o *streamOptions) {
+		o.connectCallback = callback
+	})
+}
+
+// WithDisconnectCallback runs the callback function every time the stream disconnects.
+func WithDisconnectCallback(callback func()) StreamOption {
+	return newFuncStreamOption(func(o *streamOptions) {
+		o.disconnectCallback = callback
+	})
+}
+
+// WithSinceID makes the stream start right after the trade update with the given event ID,
+// e.g. the last event processed before a restart (see TradeUpdatesSubscription.LastEventID).
+// By default, the stream starts with the trade updates happening after it's started.
+// It's not supported by TradeUpdatesClient.
+func WithSinceID(eventID string) StreamOption {
+	return newFuncStreamOption(func(o *streamOptions) {
+		o.sinceID = eventID
+	})
+}
+
+// WithIdleTimeout makes the stream reconnect if nothing (neither a trade update nor
+// a heartbeat) is received for the given duration. By default there is no idle timeout.
+func WithIdleTimeout(timeout time.Duration) StreamOption {
+	return newFuncStreamOption(func(o *streamOptions) {
+		o.idleTimeout = timeout
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -55,3 +57,291 @@ func TestStreamTradeUpdates(t *testing.T) {
 	}))
 	require.NoError(t, ctx.Err())
 }
+
+type recordingTransport struct {
+	requests atomic.Int32
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestStreamTradeUpdates_HTTPClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "broker_key", key)
+		assert.Equal(t, "broker_secret", secret)
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, ": heartbeat\n\n")
+		flusher.Flush()
+		// longer than the client's timeout
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, `data: {"event_id":"01"}`+"\n\n")
+	}))
+	defer ts.Close()
+
+	transport := &recordingTransport{}
+	c := NewClient(ClientOpts{
+		BaseURL:      ts.URL,
+		BrokerKey:    "broker_key",
+		BrokerSecret: "broker_secret",
+		HTTPClient:   &http.Client{Transport: transport, Timeout: 50 * time.Millisecond},
+	})
+	var ids []string
+	err := c.StreamTradeUpdates(context.Background(), func(tu TradeUpdate) {
+		ids = append(ids, tu.EventID)
+	}, StreamTradeUpdatesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01"}, ids)
+	assert.EqualValues(t, 1, transport.requests.Load())
+}
+
+func TestStreamTradeUpdates_SSEFraming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, ": comment\r\n")
+		fmt.Fprint(w, "event: trade_update\r\nid: 01\r\ndata: {\"event_id\":\r\ndata: \"01\"}\r\n\r\n")
+		fmt.Fprint(w, "\n\n")
+		fmt.Fprint(w, "data:{\"event_id\":\"02\"}\n\n")
+		// incomplete event
+		fmt.Fprint(w, "data: {\"event_id\":\"03\"}\n")
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	var ids []string
+	err := c.StreamTradeUpdates(context.Background(), func(tu TradeUpdate) {
+		ids = append(ids, tu.EventID)
+	}, StreamTradeUpdatesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01", "02"}, ids)
+}
+
+func TestStreamTradeUpdates_IdleTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		// heartbeats keep the stream alive
+		for i := 0; i < 3; i++ {
+			fmt.Fprint(w, ":\n")
+			flusher.Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+		fmt.Fprint(w, `data: {"event_id":"01"}`+"\n\n")
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var ids []string
+	err := c.StreamTradeUpdates(ctx, func(tu TradeUpdate) {
+		ids = append(ids, tu.EventID)
+	}, StreamTradeUpdatesRequest{IdleTimeout: 150 * time.Millisecond})
+	require.ErrorIs(t, err, ErrStreamIdle)
+	assert.Equal(t, []string{"01"}, ids)
+	assert.NoError(t, ctx.Err())
+}
+
+func TestStreamTradeUpdatesInBackground(t *testing.T) {
+	var mu sync.Mutex
+	var sinceIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sinceIDs = append(sinceIDs, r.URL.Query().Get("since_id"))
+		conn := len(sinceIDs)
+		mu.Unlock()
+		flusher := w.(http.Flusher)
+		switch conn {
+		case 1:
+			fmt.Fprint(w, `data: {"event_id":"01","event":"new"}`+"\n\n")
+			fmt.Fprint(w, `data: {"event_id":"02","event":"partial_fill"}`+"\n\n")
+			flusher.Flush()
+			// the connection is closed
+		case 2:
+			// temporary error
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			// the last event is sent again
+			fmt.Fprint(w, `data: {"event_id":"02","event":"partial_fill"}`+"\n\n")
+			fmt.Fprint(w, `data: {"event_id":"03","event":"fill"}`+"\n\n")
+			flusher.Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var connects, disconnects atomic.Int32
+	received := make(chan TradeUpdate, 10)
+	sub := c.StreamTradeUpdatesInBackground(ctx, func(tu TradeUpdate) {
+		received <- tu
+	},
+		WithReconnectSettings(0, time.Millisecond),
+		WithConnectCallback(func() { connects.Add(1) }),
+		WithDisconnectCallback(func() { disconnects.Add(1) }),
+	)
+
+	for _, want := range []string{"01", "02", "03"} {
+		select {
+		case tu := <-received:
+			assert.Equal(t, want, tu.EventID)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "no trade update received")
+		}
+	}
+	assert.Equal(t, "03", sub.LastEventID())
+	assert.EqualValues(t, 2, connects.Load())
+	assert.EqualValues(t, 1, disconnects.Load())
+	mu.Lock()
+	assert.Equal(t, []string{"", "02", "02"}, sinceIDs)
+	mu.Unlock()
+
+	cancel()
+	select {
+	case err, ok := <-sub.Terminated():
+		assert.True(t, ok)
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "not terminated")
+	}
+	_, ok := <-sub.Terminated()
+	assert.False(t, ok)
+	assert.Empty(t, received)
+}
+
+func TestStreamTradeUpdatesInBackground_DroppedBeforeFirstEvent(t *testing.T) {
+	var mu sync.Mutex
+	var since []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		since = append(since, r.URL.Query().Get("since"))
+		conn := len(since)
+		mu.Unlock()
+		if conn == 1 {
+			// the connection is closed before any event
+			return
+		}
+		fmt.Fprint(w, `data: {"event_id":"01","event":"new"}`+"\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	received := make(chan TradeUpdate, 10)
+	c.StreamTradeUpdatesInBackground(ctx, func(tu TradeUpdate) { received <- tu },
+		WithReconnectSettings(0, time.Millisecond))
+
+	select {
+	case tu := <-received:
+		assert.Equal(t, "01", tu.EventID)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "no trade update received")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, since, 2)
+	// The stream resumes from the start of the subscription, not from the reconnection
+	assert.Equal(t, since[0], since[1])
+	resumedFrom, err := time.Parse(time.RFC3339Nano, since[1])
+	require.NoError(t, err)
+	assert.WithinDuration(t, start, resumedFrom, time.Second)
+}
+
+func TestStreamTradeUpdatesInBackground_InvalidEvent(t *testing.T) {
+	var mu sync.Mutex
+	var sinceIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sinceIDs = append(sinceIDs, r.URL.Query().Get("since_id"))
+		conn := len(sinceIDs)
+		mu.Unlock()
+		if conn == 1 {
+			fmt.Fprint(w, `data: {"event_id":"01","event":"new"}`+"\n\n")
+			fmt.Fprint(w, `data: {"event_id":"02","event":"fill","at":"invalid"}`+"\n\n")
+			fmt.Fprint(w, `data: {"event_id":"03","event":"fill"}`+"\n\n")
+			// the connection is closed after an invalid event
+			fmt.Fprint(w, "id: 04\ndata: {\n\n")
+			return
+		}
+		fmt.Fprint(w, `data: {"event_id":"05","event":"new"}`+"\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan TradeUpdate, 10)
+	sub := c.StreamTradeUpdatesInBackground(ctx, func(tu TradeUpdate) { received <- tu },
+		WithReconnectSettings(0, time.Millisecond))
+
+	// The invalid events are skipped
+	for _, want := range []string{"01", "03", "05"} {
+		select {
+		case tu := <-received:
+			assert.Equal(t, want, tu.EventID)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "no trade update received")
+		}
+	}
+	assert.Equal(t, "05", sub.LastEventID())
+	mu.Lock()
+	defer mu.Unlock()
+	// The stream resumes after the last invalid event
+	assert.Equal(t, []string{"", "04"}, sinceIDs)
+}
+
+func TestStreamTradeUpdatesInBackground_Terminated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since_id") == "unauthorized" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":40110000,"message":"request is not authorized"}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+
+	sub := c.StreamTradeUpdatesInBackground(context.Background(), func(TradeUpdate) {},
+		WithSinceID("unauthorized"), WithLogger(ErrorOnlyLogger()))
+	select {
+	case err := <-sub.Terminated():
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "not terminated")
+	}
+
+	sub = c.StreamTradeUpdatesInBackground(context.Background(), func(TradeUpdate) {},
+		WithReconnectSettings(3, time.Millisecond))
+	select {
+	case err := <-sub.Terminated():
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "max reconnect limit has been reached")
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "not terminated")
+	}
+}
+
+func TestRecentIDs(t *testing.T) {
+	r := newRecentIDs(2)
+	assert.True(t, r.add("a"))
+	assert.True(t, r.add("b"))
+	assert.False(t, r.add("a"))
+	assert.True(t, r.add("c"))
+	assert.False(t, r.add("b"))
+	assert.True(t, r.add("a"))
+}
@@ -0,0 +1,213 @@
+package alpaca
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxClientOrderIDLength is the maximum length of a client order ID accepted by the API.
+const maxClientOrderIDLength = 128
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	// Field is the name of the invalid field, e.g. "TakeProfit" or "Legs[1].RatioQty".
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned by the Validate methods of the requests.
+// It contains every problem found in the request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Field returns the problem found with the given field.
+// It returns false if the field is valid.
+func (e *ValidationError) Field(name string) (FieldError, bool) {
+	for _, f := range e.Fields {
+		if f.Field == name {
+			return f, true
+		}
+	}
+	return FieldError{}, false
+}
+
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) positive(field string, d *decimal.Decimal) {
+	if d != nil && !d.IsPositive() {
+		v.add(field, "must be positive")
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// isCryptoSymbol reports whether symbol is a crypto pair, e.g. BTC/USD.
+func isCryptoSymbol(symbol string) bool {
+	return strings.Contains(symbol, "/")
+}
+
+var cryptoTimeInForces = []TimeInForce{GTC, IOC}
+
+// Validate checks the request for problems that would make the API reject it, without sending it.
+// If problems are found, a *ValidationError is returned.
+func (r PlaceOrderRequest) Validate() error {
+	var v validator
+	if r.OrderClass != MLeg && r.Symbol == "" {
+		v.add("Symbol", "is required")
+	}
+	if r.OrderClass != MLeg && r.Side == "" {
+		v.add("Side", "is required")
+	}
+	if r.Type == "" {
+		v.add("Type", "is required")
+	}
+	if r.TimeInForce == "" {
+		v.add("TimeInForce", "is required")
+	}
+	r.validateQty(&v)
+	r.validatePrices(&v)
+	r.validateOrderClass(&v)
+	if r.ExtendedHours && (r.Type != Limit || r.TimeInForce != Day) {
+		v.add("ExtendedHours", "is only supported for limit orders with day time in force")
+	}
+	if isCryptoSymbol(r.Symbol) && r.TimeInForce != "" && !slices.Contains(cryptoTimeInForces, r.TimeInForce) {
+		v.add("TimeInForce", "%q is not supported for crypto orders, use gtc or ioc", r.TimeInForce)
+	}
+	if len(r.ClientOrderID) > maxClientOrderIDLength {
+		v.add("ClientOrderID", "must be at most %d characters long", maxClientOrderIDLength)
+	}
+	return v.err()
+}
+
+func (r PlaceOrderRequest) validateQty(v *validator) {
+	switch {
+	case r.Qty != nil && r.Notional != nil:
+		v.add("Notional", "cannot be set together with Qty")
+	case r.Qty == nil && r.Notional == nil:
+		v.add("Qty", "either Qty or Notional is required")
+	}
+	v.positive("Qty", r.Qty)
+	v.positive("Notional", r.Notional)
+	if r.Notional != nil && r.Type != "" && r.Type != Market {
+		v.add("Notional", "is only supported for market orders")
+	}
+}
+
+func (r PlaceOrderRequest) validatePrices(v *validator) {
+	needsLimit := r.Type == Limit || r.Type == StopLimit
+	needsStop := r.Type == Stop || r.Type == StopLimit
+	switch {
+	case needsLimit && r.LimitPrice == nil:
+		v.add("LimitPrice", "is required for %s orders", r.Type)
+	case !needsLimit && r.LimitPrice != nil && r.Type != "":
+		v.add("LimitPrice", "is not supported for %s orders", r.Type)
+	}
+	switch {
+	case needsStop && r.StopPrice == nil:
+		v.add("StopPrice", "is required for %s orders", r.Type)
+	case !needsStop && r.StopPrice != nil && r.Type != "":
+		v.add("StopPrice", "is not supported for %s orders", r.Type)
+	}
+	if r.Type == TrailingStop && (r.TrailPrice == nil) == (r.TrailPercent == nil) {
+		v.add("TrailPrice", "exactly one of TrailPrice and TrailPercent is required for trailing_stop orders")
+	}
+	if r.Type != TrailingStop && r.Type != "" && (r.TrailPrice != nil || r.TrailPercent != nil) {
+		v.add("TrailPrice", "TrailPrice and TrailPercent are only supported for trailing_stop orders")
+	}
+	v.positive("LimitPrice", r.LimitPrice)
+	v.positive("StopPrice", r.StopPrice)
+	v.positive("TrailPrice", r.TrailPrice)
+	v.positive("TrailPercent", r.TrailPercent)
+}
+
+func (r PlaceOrderRequest) validateOrderClass(v *validator) {
+	switch r.OrderClass {
+	case Bracket:
+		if r.TakeProfit == nil {
+			v.add("TakeProfit", "is required for bracket orders")
+		}
+		if r.StopLoss == nil {
+			v.add("StopLoss", "is required for bracket orders")
+		}
+	case OTO:
+		if (r.TakeProfit == nil) == (r.StopLoss == nil) {
+			v.add("TakeProfit", "exactly one of TakeProfit and StopLoss is required for oto orders")
+		}
+	case MLeg:
+		if len(r.Legs) == 0 {
+			v.add("Legs", "is required for mleg orders")
+		}
+		for i, leg := range r.Legs {
+			if leg.Symbol == "" {
+				v.add(fmt.Sprintf("Legs[%d].Symbol", i), "is required")
+			}
+			if leg.Side == "" {
+				v.add(fmt.Sprintf("Legs[%d].Side", i), "is required")
+			}
+			if !leg.RatioQty.IsPositive() {
+				v.add(fmt.Sprintf("Legs[%d].RatioQty", i), "must be positive")
+			}
+		}
+	}
+	if r.OrderClass != MLeg && len(r.Legs) > 0 {
+		v.add("Legs", "is only supported for mleg orders")
+	}
+	if r.TakeProfit != nil {
+		if r.TakeProfit.LimitPrice == nil {
+			v.add("TakeProfit.LimitPrice", "is required")
+		}
+		v.positive("TakeProfit.LimitPrice", r.TakeProfit.LimitPrice)
+	}
+	if r.StopLoss != nil {
+		if r.StopLoss.StopPrice == nil {
+			v.add("StopLoss.StopPrice", "is required")
+		}
+		v.positive("StopLoss.LimitPrice", r.StopLoss.LimitPrice)
+		v.positive("StopLoss.StopPrice", r.StopLoss.StopPrice)
+	}
+}
+
+// Validate checks the request for problems that would make the API reject it, without sending it.
+// If problems are found, a *ValidationError is returned.
+func (r ReplaceOrderRequest) Validate() error {
+	var v validator
+	if r.Qty == nil && r.LimitPrice == nil && r.StopPrice == nil && r.Trail == nil &&
+		r.TimeInForce == "" && r.ClientOrderID == "" {
+		v.add("Qty", "at least one field must be set")
+	}
+	v.positive("Qty", r.Qty)
+	v.positive("LimitPrice", r.LimitPrice)
+	v.positive("StopPrice", r.StopPrice)
+	v.positive("Trail", r.Trail)
+	if len(r.ClientOrderID) > maxClientOrderIDLength {
+		v.add("ClientOrderID", "must be at most %d characters long", maxClientOrderIDLength)
+	}
+	return v.err()
+}
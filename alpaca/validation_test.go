@@ -0,0 +1,223 @@
+package alpaca
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceOrderRequest_Validate(t *testing.T) {
+	valid := func(modify func(r *PlaceOrderRequest)) PlaceOrderRequest {
+		r := PlaceOrderRequest{
+			Symbol:      "AAPL",
+			Qty:         deciP("10"),
+			Side:        Buy,
+			Type:        Limit,
+			TimeInForce: Day,
+			LimitPrice:  deciP("150"),
+		}
+		if modify != nil {
+			modify(&r)
+		}
+		return r
+	}
+	mleg := PlaceOrderRequest{
+		Qty:         deciP("1"),
+		Type:        Market,
+		TimeInForce: Day,
+		OrderClass:  MLeg,
+		Legs: []Leg{
+			{Symbol: "AAPL250620C00100000", Side: Buy, RatioQty: decimal.NewFromInt(1)},
+			{Symbol: "AAPL250620C00110000", Side: Sell, RatioQty: decimal.NewFromInt(1)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		req    PlaceOrderRequest
+		fields []string
+	}{
+		{name: "valid", req: valid(nil)},
+		{name: "valid mleg", req: mleg},
+		{
+			name: "valid bracket",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.OrderClass = Bracket
+				r.TakeProfit = &TakeProfit{LimitPrice: deciP("160")}
+				r.StopLoss = &StopLoss{StopPrice: deciP("140")}
+			}),
+		},
+		{
+			name:   "empty",
+			req:    PlaceOrderRequest{},
+			fields: []string{"Symbol", "Side", "Type", "TimeInForce", "Qty"},
+		},
+		{
+			name:   "bracket without take profit and stop loss",
+			req:    valid(func(r *PlaceOrderRequest) { r.OrderClass = Bracket }),
+			fields: []string{"TakeProfit", "StopLoss"},
+		},
+		{
+			name: "bracket without stop price",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.OrderClass = Bracket
+				r.TakeProfit = &TakeProfit{LimitPrice: deciP("160")}
+				r.StopLoss = &StopLoss{LimitPrice: deciP("139")}
+			}),
+			fields: []string{"StopLoss.StopPrice"},
+		},
+		{
+			name: "qty and notional",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.Type = Market
+				r.LimitPrice = nil
+				r.Notional = deciP("1000")
+			}),
+			fields: []string{"Notional"},
+		},
+		{
+			name:   "notional with limit order",
+			req:    valid(func(r *PlaceOrderRequest) { r.Qty, r.Notional = nil, deciP("1000") }),
+			fields: []string{"Notional"},
+		},
+		{
+			name:   "negative qty",
+			req:    valid(func(r *PlaceOrderRequest) { r.Qty = deciP("-1") }),
+			fields: []string{"Qty"},
+		},
+		{
+			name: "extended hours with market order",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.Type = Market
+				r.LimitPrice = nil
+				r.ExtendedHours = true
+			}),
+			fields: []string{"ExtendedHours"},
+		},
+		{
+			name: "extended hours with gtc order",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.TimeInForce = GTC
+				r.ExtendedHours = true
+			}),
+			fields: []string{"ExtendedHours"},
+		},
+		{
+			name:   "crypto with day time in force",
+			req:    valid(func(r *PlaceOrderRequest) { r.Symbol = "BTC/USD" }),
+			fields: []string{"TimeInForce"},
+		},
+		{
+			name: "crypto with gtc time in force",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.Symbol = "BTC/USD"
+				r.TimeInForce = GTC
+			}),
+		},
+		{
+			name:   "limit without limit price",
+			req:    valid(func(r *PlaceOrderRequest) { r.LimitPrice = nil }),
+			fields: []string{"LimitPrice"},
+		},
+		{
+			name:   "stop limit without stop price",
+			req:    valid(func(r *PlaceOrderRequest) { r.Type = StopLimit }),
+			fields: []string{"StopPrice"},
+		},
+		{
+			name: "trailing stop with both trail price and percent",
+			req: valid(func(r *PlaceOrderRequest) {
+				r.Type = TrailingStop
+				r.LimitPrice = nil
+				r.TrailPrice = deciP("1")
+				r.TrailPercent = deciP("1")
+			}),
+			fields: []string{"TrailPrice"},
+		},
+		{
+			name: "mleg without legs",
+			req: func() PlaceOrderRequest {
+				r := mleg
+				r.Legs = nil
+				return r
+			}(),
+			fields: []string{"Legs"},
+		},
+		{
+			name: "mleg with invalid leg",
+			req: func() PlaceOrderRequest {
+				r := mleg
+				r.Legs = []Leg{mleg.Legs[0], {Side: Sell}}
+				return r
+			}(),
+			fields: []string{"Legs[1].Symbol", "Legs[1].RatioQty"},
+		},
+		{
+			name:   "legs without mleg",
+			req:    valid(func(r *PlaceOrderRequest) { r.Legs = mleg.Legs }),
+			fields: []string{"Legs"},
+		},
+		{
+			name:   "long client order id",
+			req:    valid(func(r *PlaceOrderRequest) { r.ClientOrderID = strings.Repeat("x", 129) }),
+			fields: []string{"ClientOrderID"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if len(tt.fields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var vErr *ValidationError
+			require.ErrorAs(t, err, &vErr)
+			var fields []string
+			for _, f := range vErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+			for _, field := range tt.fields {
+				_, ok := vErr.Field(field)
+				assert.True(t, ok)
+			}
+		})
+	}
+}
+
+func TestReplaceOrderRequest_Validate(t *testing.T) {
+	assert.NoError(t, ReplaceOrderRequest{LimitPrice: deciP("150")}.Validate())
+
+	err := ReplaceOrderRequest{}.Validate()
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	require.Len(t, vErr.Fields, 1)
+
+	err = ReplaceOrderRequest{Qty: deciP("0"), StopPrice: deciP("-1")}.Validate()
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []FieldError{
+		{Field: "Qty", Message: "must be positive"},
+		{Field: "StopPrice", Message: "must be positive"},
+	}, vErr.Fields)
+	assert.Equal(t, "invalid request: Qty: must be positive; StopPrice: must be positive", err.Error())
+}
+
+func TestPlaceOrder_ValidateOrders(t *testing.T) {
+	c := NewClient(ClientOpts{ValidateOrders: true})
+	c.do = func(_ *Client, _ *http.Request) (*http.Response, error) {
+		t.Fatal("the request should not have been sent")
+		return nil, nil
+	}
+	_, err := c.PlaceOrder(PlaceOrderRequest{Symbol: "AAPL", OrderClass: Bracket})
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	_, ok := vErr.Field("TakeProfit")
+	assert.True(t, ok)
+
+	_, err = c.ReplaceOrder("order_id", ReplaceOrderRequest{})
+	require.ErrorAs(t, err, &vErr)
+}
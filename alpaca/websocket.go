@@ -0,0 +1,357 @@
+package alpaca
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+var (
+	// ErrStreamUnauthorized is returned by TradeUpdatesClient if the server rejected the credentials.
+	ErrStreamUnauthorized = errors.New("unauthorized")
+	// ErrConnectCalledMultipleTimes is returned when Connect has been called multiple times on a single client.
+	ErrConnectCalledMultipleTimes = errors.New("tried to call Connect multiple times")
+)
+
+var (
+	wsInitTimeout = 5 * time.Second  // Time allowed for authenticating and subscribing
+	wsWriteWait   = 5 * time.Second  // Time allowed to write a message to the server
+	wsPongWait    = 5 * time.Second  // Time allowed to read the next pong message from the server
+	wsPingPeriod  = 10 * time.Second // Send pings to the server with this period
+)
+
+const tradeUpdatesStream = "trade_updates"
+
+// wsReconnectLimit is the default number of consecutive failed connection attempts after which
+// a TradeUpdatesClient terminates, the same as the market data streams.
+const wsReconnectLimit = 20
+
+// TradeUpdatesClient streams the trade updates of the account over the websocket
+// stream (/stream). It's an alternative to StreamTradeUpdates, which uses server-sent events.
+//
+// After constructing, Connect must be called. Connect keeps the connection alive and
+// reestablishes it until the configured number of consecutive failed attempts is reached.
+// Unlike StreamTradeUpdatesInBackground, the trade updates happening while the client is
+// reconnecting are not resent by the server, so WithSinceID has no effect.
+//
+// Terminated returns a channel that the client sends an error to when it has terminated.
+// A client can not be reused once it has terminated!
+type TradeUpdatesClient struct {
+	baseURL    string
+	auth       map[string]string
+	httpClient *http.Client
+	handler    func(TradeUpdate)
+	opts       streamOptions
+
+	connectOnce sync.Once
+	terminated  chan error
+}
+
+// NewTradeUpdatesClient returns a new TradeUpdatesClient that uses the base URL and
+// the credentials (API key and secret, or OAuth token) of the client, and calls handler
+// for each trade update. Broker API credentials are not supported by the websocket stream.
+// The handshakes use the HTTP client of the client, whose Timeout only bounds the handshake.
+// Unless WithReconnectSettings is set, the client gives up after 20 consecutive failed
+// connection attempts.
+func (c *Client) NewTradeUpdatesClient(handler func(TradeUpdate), opts ...StreamOption) *TradeUpdatesClient {
+	o := defaultStreamOptions()
+	o.reconnectLimit = wsReconnectLimit
+	for _, opt := range opts {
+		opt.applyStream(&o)
+	}
+	auth := map[string]string{"key_id": c.opts.APIKey, "secret_key": c.opts.APISecret}
+	if c.opts.OAuth != "" {
+		auth = map[string]string{"oauth_token": c.opts.OAuth}
+	}
+	return &TradeUpdatesClient{
+		baseURL:    c.opts.BaseURL,
+		auth:       auth,
+		httpClient: c.httpClient,
+		handler:    handler,
+		opts:       o,
+		terminated: make(chan error, 1),
+	}
+}
+
+func websocketURL(baseURL string) (url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return url.URL{}, err
+	}
+	scheme := "wss"
+	if u.Scheme == "http" || u.Scheme == "ws" {
+		scheme = "ws"
+	}
+	return url.URL{Scheme: scheme, Host: u.Host, Path: u.Path + "/stream"}, nil
+}
+
+// Connect establishes a connection and **reestablishes it when errors occur**
+// as long as the configured number of retries has not been exceeded.
+//
+// It blocks until the connection has been established for the first time (or it failed to do so).
+// The client terminates when ctx is canceled.
+//
+// **Should only be called once!**
+func (tc *TradeUpdatesClient) Connect(ctx context.Context) error {
+	err := ErrConnectCalledMultipleTimes
+	tc.connectOnce.Do(func() {
+		var u url.URL
+		if u, err = websocketURL(tc.baseURL); err != nil {
+			tc.terminated <- err
+			close(tc.terminated)
+			return
+		}
+		initialResult := make(chan error)
+		go tc.maintainConnection(ctx, u, initialResult)
+		err = <-initialResult
+	})
+	return err
+}
+
+// Terminated returns a channel that the client sends an error to when it has terminated.
+// The error is nil if the context was canceled. The channel is also closed upon termination.
+func (tc *TradeUpdatesClient) Terminated() <-chan error {
+	return tc.terminated
+}
+
+// maintainConnection connects to the stream and reconnects with exponential backoff
+// after the connection is lost. The result of the first connection is sent to initialResult,
+// the later errors to tc.terminated.
+func (tc *TradeUpdatesClient) maintainConnection(ctx context.Context, u url.URL, initialResult chan<- error) {
+	o := tc.opts
+	backoff := RetryPolicy{BaseDelay: o.reconnectDelay, MaxDelay: o.maxReconnectDelay, Jitter: 0.2}
+	connectedAtLeastOnce := false
+	terminate := func(err error) {
+		if !connectedAtLeastOnce {
+			initialResult <- err
+		}
+		tc.terminated <- err
+		close(tc.terminated)
+	}
+
+	failedAttemptsInARow := 0
+	for {
+		conn, err := tc.connect(ctx, u)
+		if err == nil {
+			o.logger.Infof("alpaca: trade updates websocket connected")
+			if !connectedAtLeastOnce {
+				connectedAtLeastOnce = true
+				initialResult <- nil
+			}
+			failedAttemptsInARow = 0
+			if o.connectCallback != nil {
+				o.connectCallback()
+			}
+			err = tc.readTradeUpdates(ctx, conn)
+			conn.Close(websocket.StatusNormalClosure, "")
+			if o.disconnectCallback != nil {
+				o.disconnectCallback()
+			}
+		}
+		if ctx.Err() != nil {
+			if !connectedAtLeastOnce {
+				err = fmt.Errorf("cancelled before connection could be established, last error: %w", err)
+			} else {
+				err = nil
+			}
+			terminate(err)
+			return
+		}
+		if isIrrecoverable(err) || errors.Is(err, ErrStreamUnauthorized) {
+			o.logger.Errorf("alpaca: trade updates websocket irrecoverable error: %v", err)
+			terminate(err)
+			return
+		}
+		failedAttemptsInARow++
+		if o.reconnectLimit != 0 && failedAttemptsInARow > o.reconnectLimit {
+			o.logger.Errorf("alpaca: trade updates websocket max reconnect limit has been reached, last error: %v", err)
+			terminate(fmt.Errorf("max reconnect limit has been reached, last error: %w", err))
+			return
+		}
+		delay := backoff.Backoff(failedAttemptsInARow-1, nil)
+		o.logger.Warnf("alpaca: trade updates websocket error: %v, reconnecting in %s (attempt %d)",
+			err, delay, failedAttemptsInARow)
+		// The cancellation is handled at the beginning of the next iteration
+		_ = sleepContext(ctx, delay)
+	}
+}
+
+// connect dials the stream, authenticates and starts listening to the trade updates.
+func (tc *TradeUpdatesClient) connect(ctx context.Context, u url.URL) (*websocket.Conn, error) {
+	initCtx, cancel := context.WithTimeout(ctx, wsInitTimeout)
+	defer cancel()
+	header := http.Header{}
+	header.Set("User-Agent", Version())
+	conn, resp, err := websocket.Dial(initCtx, u.String(), &websocket.DialOptions{
+		HTTPClient: tc.httpClient,
+		HTTPHeader: header,
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, handshakeError(resp)
+		}
+		return nil, fmt.Errorf("websocket dial: %w", err)
+	}
+	conn.SetReadLimit(-1)
+
+	if err := tc.authenticate(initCtx, conn); err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		return nil, err
+	}
+	if err := tc.listen(initCtx, conn); err != nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshakeError returns the error of the rejected websocket handshake.
+func handshakeError(resp *http.Response) error {
+	err := APIErrorFromResponse(resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+}
+
+type wsMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func (tc *TradeUpdatesClient) authenticate(ctx context.Context, conn *websocket.Conn) error {
+	if err := writeJSON(ctx, conn, map[string]interface{}{"action": "authenticate", "data": tc.auth}); err != nil {
+		return fmt.Errorf("failed to write auth: %w", err)
+	}
+	var resp struct {
+		Status string `json:"status"`
+		Action string `json:"action"`
+	}
+	if err := readJSON(ctx, conn, "authorization", &resp); err != nil {
+		return fmt.Errorf("failed to read auth response: %w", err)
+	}
+	if resp.Status != "authorized" {
+		return fmt.Errorf("%w: %s", ErrStreamUnauthorized, resp.Status)
+	}
+	return nil
+}
+
+func (tc *TradeUpdatesClient) listen(ctx context.Context, conn *websocket.Conn) error {
+	msg := map[string]interface{}{
+		"action": "listen",
+		"data":   map[string][]string{"streams": {tradeUpdatesStream}},
+	}
+	if err := writeJSON(ctx, conn, msg); err != nil {
+		return fmt.Errorf("failed to write listen: %w", err)
+	}
+	var resp struct {
+		Streams []string `json:"streams"`
+	}
+	if err := readJSON(ctx, conn, "listening", &resp); err != nil {
+		return fmt.Errorf("failed to read listen response: %w", err)
+	}
+	for _, s := range resp.Streams {
+		if s == tradeUpdatesStream {
+			return nil
+		}
+	}
+	return fmt.Errorf("not listening to %s, streams: %v", tradeUpdatesStream, resp.Streams)
+}
+
+// readTradeUpdates calls the handler for each trade update until the connection is lost.
+func (tc *TradeUpdatesClient) readTradeUpdates(ctx context.Context, conn *websocket.Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// The pings are answered while the connection is read
+		ticker := time.NewTicker(wsPingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pingCtx, cancelPing := context.WithTimeout(ctx, wsPongWait)
+				err := conn.Ping(pingCtx)
+				cancelPing()
+				if err != nil {
+					if ctx.Err() == nil {
+						tc.opts.logger.Warnf("alpaca: trade updates websocket ping failed, error: %v", err)
+					}
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		msg, err := tc.read(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if msg.Stream != tradeUpdatesStream {
+			continue
+		}
+		var tu TradeUpdate
+		if err := json.Unmarshal(msg.Data, &tu); err != nil {
+			return err
+		}
+		tc.handler(tu)
+	}
+}
+
+// read reads the next message, applying the idle timeout if configured.
+func (tc *TradeUpdatesClient) read(ctx context.Context, conn *websocket.Conn) (wsMessage, error) {
+	if tc.opts.idleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, tc.opts.idleTimeout, ErrStreamIdle)
+		defer cancel()
+	}
+	var msg wsMessage
+	// Both text and binary (e.g. on paper) messages contain JSON
+	_, b, err := conn.Read(ctx)
+	if err != nil {
+		return msg, streamError(ctx, err)
+	}
+	return msg, json.Unmarshal(b, &msg)
+}
+
+// readJSON reads the next message which must belong to stream and decodes its data into v.
+func readJSON(ctx context.Context, conn *websocket.Conn, stream string, v interface{}) error {
+	_, b, err := conn.Read(ctx)
+	if err != nil {
+		return err
+	}
+	var msg wsMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	if msg.Stream != stream {
+		return fmt.Errorf("unexpected message from %q stream: %s", msg.Stream, msg.Data)
+	}
+	return json.Unmarshal(msg.Data, v)
+}
+
+func writeJSON(ctx context.Context, conn *websocket.Conn, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	writeCtx, cancel := context.WithTimeout(ctx, wsWriteWait)
+	defer cancel()
+	return conn.Write(writeCtx, websocket.MessageText, b)
+}
+
+// NewTradeUpdatesClient returns a new TradeUpdatesClient using the default client.
+func NewTradeUpdatesClient(handler func(TradeUpdate), opts ...StreamOption) *TradeUpdatesClient {
+	return DefaultClient.NewTradeUpdatesClient(handler, opts...)
+}
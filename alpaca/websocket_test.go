@@ -0,0 +1,251 @@
+package alpaca
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wsTestServer struct {
+	t           *testing.T
+	connections atomic.Int32
+	// serve is called after the authentication and the subscription with the number of the connection
+	serve func(ctx context.Context, conn *websocket.Conn, n int)
+}
+
+func (s *wsTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !assert.Equal(s.t, "/stream", r.URL.Path) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	conn, err := websocket.Accept(w, r, nil)
+	if !assert.NoError(s.t, err) {
+		return
+	}
+	defer conn.CloseNow()
+	ctx := r.Context()
+
+	var auth struct {
+		Action string            `json:"action"`
+		Data   map[string]string `json:"data"`
+	}
+	if !s.read(ctx, conn, &auth) {
+		return
+	}
+	assert.Equal(s.t, "authenticate", auth.Action)
+	status := "authorized"
+	if auth.Data["key_id"] != "key" || auth.Data["secret_key"] != "secret" {
+		status = "unauthorized"
+	}
+	s.write(ctx, conn, websocket.MessageText,
+		`{"stream":"authorization","data":{"status":"`+status+`","action":"authenticate"}}`)
+	if status != "authorized" {
+		return
+	}
+
+	var listen struct {
+		Action string `json:"action"`
+		Data   struct {
+			Streams []string `json:"streams"`
+		} `json:"data"`
+	}
+	if !s.read(ctx, conn, &listen) {
+		return
+	}
+	assert.Equal(s.t, "listen", listen.Action)
+	assert.Equal(s.t, []string{"trade_updates"}, listen.Data.Streams)
+	s.write(ctx, conn, websocket.MessageText, `{"stream":"listening","data":{"streams":["trade_updates"]}}`)
+
+	s.serve(ctx, conn, int(s.connections.Add(1)))
+}
+
+func (s *wsTestServer) read(ctx context.Context, conn *websocket.Conn, v interface{}) bool {
+	_, b, err := conn.Read(ctx)
+	return assert.NoError(s.t, err) && assert.NoError(s.t, json.Unmarshal(b, v))
+}
+
+func (s *wsTestServer) write(ctx context.Context, conn *websocket.Conn, typ websocket.MessageType, msg string) {
+	assert.NoError(s.t, conn.Write(ctx, typ, []byte(msg)))
+}
+
+func TestTradeUpdatesClient(t *testing.T) {
+	s := &wsTestServer{t: t}
+	s.serve = func(ctx context.Context, conn *websocket.Conn, n int) {
+		switch n {
+		case 1:
+			s.write(ctx, conn, websocket.MessageText,
+				`{"stream":"trade_updates","data":{"event":"new","event_id":"01","order":{"id":"o1"}}}`)
+			// the connection is lost
+		default:
+			// paper sends binary messages
+			s.write(ctx, conn, websocket.MessageBinary,
+				`{"stream":"trade_updates","data":{"event":"fill","event_id":"02","order":{"id":"o1"}}}`)
+			<-ctx.Done()
+		}
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL, APIKey: "key", APISecret: "secret"})
+	updates := make(chan TradeUpdate, 10)
+	var connects, disconnects atomic.Int32
+	tc := c.NewTradeUpdatesClient(func(tu TradeUpdate) {
+		updates <- tu
+	},
+		WithReconnectSettings(0, time.Millisecond),
+		WithConnectCallback(func() { connects.Add(1) }),
+		WithDisconnectCallback(func() { disconnects.Add(1) }),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, tc.Connect(ctx))
+	assert.ErrorIs(t, tc.Connect(ctx), ErrConnectCalledMultipleTimes)
+
+	for _, want := range []TradeUpdateEvent{TradeEventNew, TradeEventFill} {
+		select {
+		case tu := <-updates:
+			assert.Equal(t, want, tu.Event)
+			assert.Equal(t, "o1", tu.Order.ID)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "trade update not received")
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-tc.Terminated():
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "client not terminated")
+	}
+	assert.EqualValues(t, 2, connects.Load())
+	assert.EqualValues(t, 2, disconnects.Load())
+}
+
+func TestTradeUpdatesClient_Unauthorized(t *testing.T) {
+	ts := httptest.NewServer(&wsTestServer{t: t})
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL, APIKey: "key", APISecret: "wrong"})
+	tc := c.NewTradeUpdatesClient(func(TradeUpdate) {}, WithReconnectSettings(0, time.Millisecond))
+	err := tc.Connect(context.Background())
+	require.ErrorIs(t, err, ErrStreamUnauthorized)
+	assert.ErrorIs(t, <-tc.Terminated(), ErrStreamUnauthorized)
+}
+
+func TestTradeUpdatesClient_HandshakeRejected(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":40310000,"message":"forbidden"}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	tc := c.NewTradeUpdatesClient(func(TradeUpdate) {}, WithReconnectSettings(0, time.Millisecond))
+	err := tc.Connect(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.EqualValues(t, 1, requests.Load())
+}
+
+func TestTradeUpdatesClient_ReconnectLimit(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL})
+	tc := c.NewTradeUpdatesClient(func(TradeUpdate) {}, WithReconnectSettings(2, time.Millisecond))
+	err := tc.Connect(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max reconnect limit has been reached")
+	assert.EqualValues(t, 3, requests.Load())
+}
+
+func TestTradeUpdatesClient_HTTPClient(t *testing.T) {
+	s := &wsTestServer{t: t}
+	s.serve = func(ctx context.Context, conn *websocket.Conn, _ int) {
+		// longer than the client's timeout
+		time.Sleep(100 * time.Millisecond)
+		s.write(ctx, conn, websocket.MessageText,
+			`{"stream":"trade_updates","data":{"event":"new","event_id":"01","order":{"id":"o1"}}}`)
+		<-ctx.Done()
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	transport := &recordingTransport{}
+	c := NewClient(ClientOpts{
+		BaseURL: ts.URL, APIKey: "key", APISecret: "secret",
+		HTTPClient: &http.Client{Transport: transport, Timeout: 50 * time.Millisecond},
+	})
+	updates := make(chan TradeUpdate, 10)
+	tc := c.NewTradeUpdatesClient(func(tu TradeUpdate) { updates <- tu })
+	assert.Equal(t, wsReconnectLimit, tc.opts.reconnectLimit)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, tc.Connect(ctx))
+	select {
+	case tu := <-updates:
+		assert.Equal(t, "01", tu.EventID)
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "trade update not received")
+	}
+	assert.EqualValues(t, 1, transport.requests.Load())
+}
+
+func TestTradeUpdatesClient_IdleTimeout(t *testing.T) {
+	s := &wsTestServer{t: t}
+	s.serve = func(ctx context.Context, _ *websocket.Conn, _ int) {
+		// nothing is sent, the client should reconnect
+		<-ctx.Done()
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := NewClient(ClientOpts{BaseURL: ts.URL, APIKey: "key", APISecret: "secret"})
+	reconnected := make(chan struct{})
+	var connects atomic.Int32
+	tc := c.NewTradeUpdatesClient(func(TradeUpdate) {},
+		WithIdleTimeout(50*time.Millisecond),
+		WithReconnectSettings(0, time.Millisecond),
+		WithConnectCallback(func() {
+			if connects.Add(1) == 2 {
+				close(reconnected)
+			}
+		}),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, tc.Connect(ctx))
+	select {
+	case <-reconnected:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "client did not reconnect")
+	}
+}
+
+func TestWebsocketURL(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://paper-api.alpaca.markets": "wss://paper-api.alpaca.markets/stream",
+		"http://localhost:8080":            "ws://localhost:8080/stream",
+		"http://localhost:8080/proxy":      "ws://localhost:8080/proxy/stream",
+	} {
+		u, err := websocketURL(base)
+		require.NoError(t, err)
+		assert.Equal(t, want, u.String())
+	}
+}
@@ -0,0 +1,265 @@
+// Package backtest replays historical market data into the same handlers as the ones of the
+// market data stream, with the orders of the strategy filled by a paper.Broker, to evaluate
+// a strategy on history.
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/paper"
+)
+
+// DataSource provides the historical market data of a backtest. It is implemented by
+// *marketdata.Client and by any marketdata.MarketDataAPI, e.g. one serving the data from a
+// local cache.
+type DataSource interface {
+	GetMultiBarsWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+	) (map[string][]marketdata.Bar, error)
+	GetMultiTradesWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetTradesRequest,
+	) (map[string][]marketdata.Trade, error)
+	GetMultiQuotesWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetQuotesRequest,
+	) (map[string][]marketdata.Quote, error)
+}
+
+// Opts contains the options of a Backtest.
+type Opts struct {
+	// Source provides the market data. It's required.
+	Source DataSource
+	// Start is the inclusive beginning of the replayed interval.
+	Start time.Time
+	// End is the inclusive end of the replayed interval.
+	End time.Time
+	// TimeFrame is the aggregation size of the bars. Defaults to one minute.
+	TimeFrame marketdata.TimeFrame
+	// Adjustment tells if the bars should be adjusted for corporate actions.
+	Adjustment marketdata.Adjustment
+	// Feed is the source of the data: sip or iex.
+	Feed marketdata.Feed
+	// Sessions makes the backtest close the trading session (see paper.Broker.MarketClose)
+	// whenever the replayed data moves to a new day in New York time, and open the next one,
+	// so that the day orders expire. It should only be set when replaying intraday data.
+	Sessions bool
+	// Broker contains the options of the simulated broker, e.g. the initial cash, the
+	// slippage and the commissions.
+	Broker paper.BrokerOpts
+}
+
+// Backtest replays historical market data into the handlers of a strategy. The handlers
+// place their orders on Broker, which fills them against the market data replayed after
+// they are placed, like they would be on the next events of the live stream.
+//
+// A Backtest can only be run once, and it's not safe for concurrent use.
+type Backtest struct {
+	opts   Opts
+	broker *paper.Broker
+
+	barHandler   func(stream.Bar)
+	tradeHandler func(stream.Trade)
+	quoteHandler func(stream.Quote)
+	barSymbols   []string
+	tradeSymbols []string
+	quoteSymbols []string
+	onOpen       func()
+	onClose      func()
+
+	trades []Trade
+}
+
+// New creates a new Backtest.
+func New(opts Opts) *Backtest {
+	if opts.TimeFrame.N == 0 {
+		opts.TimeFrame = marketdata.OneMin
+	}
+	bt := &Backtest{
+		opts:   opts,
+		broker: paper.NewBroker(opts.Broker),
+	}
+	bt.broker.SubscribeTradeUpdates(bt.recordTrade)
+	return bt
+}
+
+// Broker returns the simulated broker the strategy places its orders on.
+func (bt *Backtest) Broker() *paper.Broker {
+	return bt.broker
+}
+
+// SubscribeToBars replays the bars of the symbols into handler. Like for the stream, there is
+// a single bar handler: the last one set is used for all the subscribed symbols.
+func (bt *Backtest) SubscribeToBars(handler func(stream.Bar), symbols ...string) {
+	bt.barHandler = handler
+	bt.barSymbols = append(bt.barSymbols, symbols...)
+}
+
+// SubscribeToTrades replays the trades of the symbols into handler. Like for the stream, there is
+// a single trade handler: the last one set is used for all the subscribed symbols.
+func (bt *Backtest) SubscribeToTrades(handler func(stream.Trade), symbols ...string) {
+	bt.tradeHandler = handler
+	bt.tradeSymbols = append(bt.tradeSymbols, symbols...)
+}
+
+// SubscribeToQuotes replays the quotes of the symbols into handler. Like for the stream, there is
+// a single quote handler: the last one set is used for all the subscribed symbols.
+func (bt *Backtest) SubscribeToQuotes(handler func(stream.Quote), symbols ...string) {
+	bt.quoteHandler = handler
+	bt.quoteSymbols = append(bt.quoteSymbols, symbols...)
+}
+
+// SubscribeToTradeUpdates registers handler to be called for each trade update of the orders.
+func (bt *Backtest) SubscribeToTradeUpdates(handler func(alpaca.TradeUpdate)) {
+	bt.broker.SubscribeTradeUpdates(handler)
+}
+
+// SubscribeToSessions registers onOpen and onClose to be called when the trading sessions open
+// and close, after the broker. They are only called if Opts.Sessions is set. Either may be nil.
+func (bt *Backtest) SubscribeToSessions(onOpen, onClose func()) {
+	bt.onOpen = onOpen
+	bt.onClose = onClose
+}
+
+// Run loads the market data of the subscribed symbols and replays it in timestamp order.
+// Each event is processed by the broker first, filling the orders placed on the previous
+// events, then passed to the handler. The bars are replayed at their end (their timestamp
+// plus their timeframe), when the stream would send them.
+func (bt *Backtest) Run(ctx context.Context) (*Result, error) {
+	if bt.opts.Source == nil {
+		return nil, errors.New("backtest: no data source")
+	}
+	events, err := bt.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var newYork *time.Location
+	if bt.opts.Sessions {
+		if newYork, err = time.LoadLocation("America/New_York"); err != nil {
+			return nil, fmt.Errorf("backtest: failed to load the New York time zone: %w", err)
+		}
+	}
+	result := &Result{}
+	var day string
+	for i, e := range events {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if bt.opts.Sessions {
+			if d := e.time.In(newYork).Format(time.DateOnly); d != day {
+				if day != "" {
+					bt.marketClose()
+				}
+				bt.marketOpen()
+				day = d
+			}
+		}
+		bt.replay(e)
+		if i == len(events)-1 || !events[i+1].time.Equal(e.time) {
+			point, err := bt.equity(e.time)
+			if err != nil {
+				return nil, err
+			}
+			result.Equity = append(result.Equity, point)
+		}
+	}
+	if bt.opts.Sessions && day != "" {
+		bt.marketClose()
+	}
+	result.Trades = bt.trades
+	return result, nil
+}
+
+func (bt *Backtest) marketOpen() {
+	bt.broker.MarketOpen()
+	if bt.onOpen != nil {
+		bt.onOpen()
+	}
+}
+
+func (bt *Backtest) marketClose() {
+	bt.broker.MarketClose()
+	if bt.onClose != nil {
+		bt.onClose()
+	}
+}
+
+func (bt *Backtest) replay(e event) {
+	switch {
+	case e.bar != nil:
+		bt.broker.ProcessBar(e.symbol, *e.bar)
+		// The bar is passed to the strategy at its end
+		bt.broker.AdvanceClock(e.time)
+		bt.barHandler(stream.Bar{
+			Symbol:     e.symbol,
+			Open:       e.bar.Open,
+			High:       e.bar.High,
+			Low:        e.bar.Low,
+			Close:      e.bar.Close,
+			Volume:     e.bar.Volume,
+			Timestamp:  e.bar.Timestamp,
+			TradeCount: e.bar.TradeCount,
+			VWAP:       e.bar.VWAP,
+		})
+	case e.trade != nil:
+		bt.broker.ProcessTrade(e.symbol, *e.trade)
+		bt.tradeHandler(stream.Trade{
+			ID:         e.trade.ID,
+			Symbol:     e.symbol,
+			Exchange:   e.trade.Exchange,
+			Price:      e.trade.Price,
+			Size:       e.trade.Size,
+			Timestamp:  e.trade.Timestamp,
+			Conditions: e.trade.Conditions,
+			Tape:       e.trade.Tape,
+		})
+	case e.quote != nil:
+		bt.broker.ProcessQuote(e.symbol, *e.quote)
+		bt.quoteHandler(stream.Quote{
+			Symbol:      e.symbol,
+			BidExchange: e.quote.BidExchange,
+			BidPrice:    e.quote.BidPrice,
+			BidSize:     e.quote.BidSize,
+			AskExchange: e.quote.AskExchange,
+			AskPrice:    e.quote.AskPrice,
+			AskSize:     e.quote.AskSize,
+			Timestamp:   e.quote.Timestamp,
+			Conditions:  e.quote.Conditions,
+			Tape:        e.quote.Tape,
+		})
+	}
+}
+
+func (bt *Backtest) equity(t time.Time) (EquityPoint, error) {
+	acct, err := bt.broker.GetAccount()
+	if err != nil {
+		return EquityPoint{}, err
+	}
+	return EquityPoint{Time: t, Equity: acct.Equity, Cash: acct.Cash}, nil
+}
+
+func (bt *Backtest) recordTrade(tu alpaca.TradeUpdate) {
+	if tu.Event != alpaca.TradeEventFill && tu.Event != alpaca.TradeEventPartialFill {
+		return
+	}
+	commission := decimal.Zero
+	if bt.opts.Broker.Commission != nil {
+		commission = bt.opts.Broker.Commission(tu.Order.Side, *tu.Qty, *tu.Price)
+	}
+	bt.trades = append(bt.trades, Trade{
+		Time:        *tu.Timestamp,
+		OrderID:     tu.Order.ID,
+		Symbol:      tu.Order.Symbol,
+		Side:        tu.Order.Side,
+		Qty:         *tu.Qty,
+		Price:       *tu.Price,
+		Commission:  commission,
+		PositionQty: *tu.PositionQty,
+	})
+}
@@ -0,0 +1,264 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/marketdatatest"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/paper"
+)
+
+var t0 = time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+
+func dec(i int64) *decimal.Decimal {
+	d := decimal.NewFromInt(i)
+	return &d
+}
+
+func minute(m int) time.Time {
+	return t0.Add(time.Duration(m) * time.Minute)
+}
+
+func flatBar(m int, price float64) marketdata.Bar {
+	return marketdata.Bar{Timestamp: minute(m), Open: price, High: price, Low: price, Close: price, Volume: 1000}
+}
+
+func barsSource(bars map[string][]marketdata.Bar) *marketdatatest.Fake {
+	return &marketdatatest.Fake{
+		GetMultiBarsFunc: func(
+			_ context.Context, _ []string, _ marketdata.GetBarsRequest,
+		) (map[string][]marketdata.Bar, error) {
+			return bars, nil
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	var gotReq marketdata.GetBarsRequest
+	source := barsSource(map[string][]marketdata.Bar{
+		"AAPL": {flatBar(0, 100), flatBar(1, 110), flatBar(2, 120), flatBar(3, 90)},
+	})
+	getBars := source.GetMultiBarsFunc
+	source.GetMultiBarsFunc = func(
+		ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+	) (map[string][]marketdata.Bar, error) {
+		gotReq = req
+		return getBars(ctx, symbols, req)
+	}
+	bt := New(Opts{
+		Source: source,
+		Start:  minute(0),
+		End:    minute(3),
+		Feed:   marketdata.IEX,
+		Broker: paper.BrokerOpts{
+			Cash:       decimal.NewFromInt(10_000),
+			Commission: paper.PerShareCommission(decimal.NewFromInt(1), decimal.Zero),
+		},
+	})
+	var bars []stream.Bar
+	bt.SubscribeToBars(func(b stream.Bar) {
+		bars = append(bars, b)
+		var side alpaca.Side
+		switch len(bars) {
+		case 1:
+			side = alpaca.Buy
+		case 3:
+			side = alpaca.Sell
+		default:
+			return
+		}
+		_, err := bt.Broker().PlaceOrder(alpaca.PlaceOrderRequest{
+			Symbol: b.Symbol, Qty: dec(10), Side: side,
+			Type: alpaca.Market, TimeInForce: alpaca.Day,
+		})
+		assert.NoError(t, err)
+	}, "AAPL")
+
+	res, err := bt.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, marketdata.OneMin, gotReq.TimeFrame)
+	assert.Equal(t, marketdata.IEX, gotReq.Feed)
+	assert.Equal(t, minute(0), gotReq.Start)
+	require.Len(t, bars, 4)
+	assert.Equal(t, "AAPL", bars[0].Symbol)
+	assert.Equal(t, 120.0, bars[2].Close)
+
+	// The orders are filled at the open of the bar following the one they were placed on
+	require.Len(t, res.Trades, 2)
+	assert.Equal(t, alpaca.Buy, res.Trades[0].Side)
+	assert.Equal(t, "110", res.Trades[0].Price.String())
+	assert.Equal(t, "10", res.Trades[0].PositionQty.String())
+	assert.Equal(t, alpaca.Sell, res.Trades[1].Side)
+	assert.Equal(t, "90", res.Trades[1].Price.String())
+	assert.Equal(t, "0", res.Trades[1].PositionQty.String())
+	assert.Equal(t, "20", res.Commissions().String())
+
+	require.Len(t, res.Equity, 4)
+	// The bars are replayed at their end
+	assert.Equal(t, minute(1), res.Equity[0].Time)
+	expected := []string{"10000", "9990", "10090", "9780"}
+	for i, p := range res.Equity {
+		assert.Equal(t, expected[i], p.Equity.String(), i)
+	}
+	assert.Equal(t, "-0.022", res.Return().String())
+	assert.Equal(t, "0.0307234886", res.MaxDrawdown().StringFixed(10))
+}
+
+func TestRun_TradeInsideBar(t *testing.T) {
+	source := barsSource(map[string][]marketdata.Bar{
+		"AAPL": {flatBar(0, 100), flatBar(1, 110)},
+	})
+	source.GetMultiTradesFunc = func(
+		_ context.Context, _ []string, _ marketdata.GetTradesRequest,
+	) (map[string][]marketdata.Trade, error) {
+		return map[string][]marketdata.Trade{
+			"AAPL": {{Timestamp: minute(0).Add(30 * time.Second), Price: 105, Size: 100}},
+		}, nil
+	}
+	bt := New(Opts{Source: source, Start: minute(0), End: minute(1), Broker: paper.BrokerOpts{
+		Cash: decimal.NewFromInt(10_000),
+	}})
+	bt.SubscribeToBars(func(stream.Bar) {}, "AAPL")
+	bt.SubscribeToTrades(func(tr stream.Trade) {
+		_, err := bt.Broker().PlaceOrder(alpaca.PlaceOrderRequest{
+			Symbol: tr.Symbol, Qty: dec(10), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+		})
+		assert.NoError(t, err)
+	}, "AAPL")
+
+	res, err := bt.Run(context.Background())
+	require.NoError(t, err)
+	// The order placed in the middle of the first bar isn't filled at its open, but at the open
+	// of the next one
+	require.Len(t, res.Trades, 1)
+	assert.Equal(t, "110", res.Trades[0].Price.String())
+}
+
+func TestRun_BarClock(t *testing.T) {
+	source := barsSource(map[string][]marketdata.Bar{
+		"AAPL": {flatBar(0, 100), flatBar(1, 110)},
+		"MSFT": {flatBar(0, 400), flatBar(1, 410)},
+	})
+	bt := New(Opts{Source: source, Broker: paper.BrokerOpts{Cash: decimal.NewFromInt(10_000)}})
+	var submittedAt time.Time
+	bt.SubscribeToBars(func(b stream.Bar) {
+		if b.Symbol != "AAPL" || !b.Timestamp.Equal(minute(0)) {
+			return
+		}
+		assert.Equal(t, minute(1), bt.Broker().Now())
+		o, err := bt.Broker().PlaceOrder(alpaca.PlaceOrderRequest{
+			Symbol: "MSFT", Qty: dec(1), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+		})
+		require.NoError(t, err)
+		submittedAt = o.SubmittedAt
+	}, "AAPL", "MSFT")
+
+	res, err := bt.Run(context.Background())
+	require.NoError(t, err)
+	// The order placed at the end of the first bars isn't filled at the open of the first MSFT bar
+	assert.Equal(t, minute(1), submittedAt)
+	require.Len(t, res.Trades, 1)
+	assert.Equal(t, "410", res.Trades[0].Price.String())
+}
+
+func TestRun_EventOrder(t *testing.T) {
+	source := barsSource(map[string][]marketdata.Bar{
+		"MSFT": {flatBar(0, 400)},
+		"AAPL": {flatBar(0, 100)},
+	})
+	source.GetMultiTradesFunc = func(
+		_ context.Context, _ []string, _ marketdata.GetTradesRequest,
+	) (map[string][]marketdata.Trade, error) {
+		return map[string][]marketdata.Trade{
+			"AAPL": {
+				{Timestamp: minute(0).Add(30 * time.Second), Price: 101, Size: 5},
+				{Timestamp: minute(1), Price: 102, Size: 5},
+			},
+		}, nil
+	}
+	source.GetMultiQuotesFunc = func(
+		_ context.Context, _ []string, _ marketdata.GetQuotesRequest,
+	) (map[string][]marketdata.Quote, error) {
+		return nil, errors.New("not subscribed")
+	}
+	bt := New(Opts{Source: source})
+	var got []string
+	bt.SubscribeToBars(func(b stream.Bar) {
+		got = append(got, "bar "+b.Symbol+" "+b.Timestamp.Format(time.TimeOnly))
+	}, "AAPL", "MSFT")
+	bt.SubscribeToTrades(func(tr stream.Trade) {
+		got = append(got, "trade "+tr.Symbol+" "+tr.Timestamp.Format(time.TimeOnly))
+	}, "AAPL")
+
+	res, err := bt.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"trade AAPL 14:30:30",
+		"trade AAPL 14:31:00",
+		"bar AAPL 14:30:00",
+		"bar MSFT 14:30:00",
+	}, got)
+	assert.Len(t, res.Equity, 2)
+}
+
+func TestRun_Sessions(t *testing.T) {
+	nextDay := flatBar(24*60, 100)
+	source := barsSource(map[string][]marketdata.Bar{
+		"AAPL": {flatBar(0, 100), nextDay},
+	})
+	bt := New(Opts{Source: source, Sessions: true})
+	var orderID string
+	bt.SubscribeToBars(func(b stream.Bar) {
+		if orderID != "" {
+			return
+		}
+		o, err := bt.Broker().PlaceOrder(alpaca.PlaceOrderRequest{
+			Symbol: b.Symbol, Qty: dec(1), Side: alpaca.Buy,
+			Type: alpaca.Limit, LimitPrice: dec(90),
+			TimeInForce: alpaca.Day,
+		})
+		require.NoError(t, err)
+		orderID = o.ID
+	}, "AAPL")
+	var events []string
+	bt.SubscribeToTradeUpdates(func(tu alpaca.TradeUpdate) {
+		events = append(events, string(tu.Event))
+	})
+	bt.SubscribeToSessions(func() {
+		events = append(events, "open")
+	}, func() {
+		events = append(events, "close")
+	})
+
+	res, err := bt.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, res.Trades)
+	assert.Equal(t, []string{"open", "new", "expired", "close", "open", "close"}, events)
+}
+
+func TestRun_Errors(t *testing.T) {
+	_, err := New(Opts{}).Run(context.Background())
+	require.Error(t, err)
+
+	source := &marketdatatest.Fake{}
+	bt := New(Opts{Source: source})
+	bt.SubscribeToBars(func(stream.Bar) {}, "AAPL")
+	_, err = bt.Run(context.Background())
+	require.ErrorIs(t, err, marketdatatest.ErrNotStubbed)
+
+	bt = New(Opts{Source: barsSource(map[string][]marketdata.Bar{"AAPL": {flatBar(0, 100)}})})
+	bt.SubscribeToBars(func(stream.Bar) {}, "AAPL")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = bt.Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
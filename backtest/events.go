@@ -0,0 +1,137 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// event is a replayed market data event. Exactly one of bar, trade and quote is set.
+type event struct {
+	time   time.Time
+	symbol string
+	bar    *marketdata.Bar
+	trade  *marketdata.Trade
+	quote  *marketdata.Quote
+}
+
+// kind orders the events with the same timestamp: the quotes first, then the trades and the bars.
+func (e *event) kind() int {
+	switch {
+	case e.quote != nil:
+		return 0
+	case e.trade != nil:
+		return 1
+	}
+	return 2
+}
+
+// load fetches the market data of the subscriptions and returns it as events sorted by time.
+func (bt *Backtest) load(ctx context.Context) ([]event, error) {
+	var events []event
+	for _, load := range []func(context.Context) ([]event, error){bt.loadBars, bt.loadTrades, bt.loadQuotes} {
+		ee, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ee...)
+	}
+	// The symbols break the ties so that the replay is deterministic, regardless of the map iteration order.
+	// The events of a symbol with the same timestamp keep their order.
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := &events[i], &events[j]
+		if !a.time.Equal(b.time) {
+			return a.time.Before(b.time)
+		}
+		if a.kind() != b.kind() {
+			return a.kind() < b.kind()
+		}
+		return a.symbol < b.symbol
+	})
+	return events, nil
+}
+
+// barEnd returns the end of the bar starting at t.
+func barEnd(t time.Time, tf marketdata.TimeFrame) time.Time {
+	switch tf.Unit {
+	case marketdata.Min:
+		return t.Add(time.Duration(tf.N) * time.Minute)
+	case marketdata.Hour:
+		return t.Add(time.Duration(tf.N) * time.Hour)
+	case marketdata.Day:
+		return t.AddDate(0, 0, tf.N)
+	case marketdata.Week:
+		return t.AddDate(0, 0, 7*tf.N)
+	case marketdata.Month:
+		return t.AddDate(0, tf.N, 0)
+	}
+	return t
+}
+
+func (bt *Backtest) loadBars(ctx context.Context) ([]event, error) {
+	if bt.barHandler == nil || len(bt.barSymbols) == 0 {
+		return nil, nil
+	}
+	bars, err := bt.opts.Source.GetMultiBarsWithContext(ctx, bt.barSymbols, marketdata.GetBarsRequest{
+		TimeFrame:  bt.opts.TimeFrame,
+		Adjustment: bt.opts.Adjustment,
+		Start:      bt.opts.Start,
+		End:        bt.opts.End,
+		Feed:       bt.opts.Feed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("backtest: failed to get bars: %w", err)
+	}
+	var events []event
+	for symbol, bb := range bars {
+		for i := range bb {
+			events = append(events, event{time: barEnd(bb[i].Timestamp, bt.opts.TimeFrame), symbol: symbol, bar: &bb[i]})
+		}
+	}
+	return events, nil
+}
+
+func (bt *Backtest) loadTrades(ctx context.Context) ([]event, error) {
+	if bt.tradeHandler == nil || len(bt.tradeSymbols) == 0 {
+		return nil, nil
+	}
+	trades, err := bt.opts.Source.GetMultiTradesWithContext(ctx, bt.tradeSymbols, marketdata.GetTradesRequest{
+		Start: bt.opts.Start,
+		End:   bt.opts.End,
+		Feed:  bt.opts.Feed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("backtest: failed to get trades: %w", err)
+	}
+	var events []event
+	for symbol, tt := range trades {
+		for i := range tt {
+			events = append(events, event{time: tt[i].Timestamp, symbol: symbol, trade: &tt[i]})
+		}
+	}
+	return events, nil
+}
+
+func (bt *Backtest) loadQuotes(ctx context.Context) ([]event, error) {
+	if bt.quoteHandler == nil || len(bt.quoteSymbols) == 0 {
+		return nil, nil
+	}
+	quotes, err := bt.opts.Source.GetMultiQuotesWithContext(ctx, bt.quoteSymbols, marketdata.GetQuotesRequest{
+		Start: bt.opts.Start,
+		End:   bt.opts.End,
+		Feed:  bt.opts.Feed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("backtest: failed to get quotes: %w", err)
+	}
+	var events []event
+	for symbol, qq := range quotes {
+		for i := range qq {
+			events = append(events, event{time: qq[i].Timestamp, symbol: symbol, quote: &qq[i]})
+		}
+	}
+	return events, nil
+}
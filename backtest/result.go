@@ -0,0 +1,72 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// Result is the outcome of a backtest.
+type Result struct {
+	// Equity is the equity curve: the equity of the account after each replayed timestamp.
+	Equity []EquityPoint
+	// Trades is the trade log: the fills of the orders, in the order they happened.
+	Trades []Trade
+}
+
+// EquityPoint is a point of the equity curve.
+type EquityPoint struct {
+	Time   time.Time
+	Equity decimal.Decimal
+	Cash   decimal.Decimal
+}
+
+// Trade is a fill of an order.
+type Trade struct {
+	Time       time.Time
+	OrderID    string
+	Symbol     string
+	Side       alpaca.Side
+	Qty        decimal.Decimal
+	Price      decimal.Decimal
+	Commission decimal.Decimal
+	// PositionQty is the position of the symbol after the fill.
+	PositionQty decimal.Decimal
+}
+
+// Return returns the total return over the backtest, as a fraction of the initial equity
+// (e.g. 0.05 for 5%). The initial equity is the one after the first replayed timestamp.
+func (r *Result) Return() decimal.Decimal {
+	if len(r.Equity) == 0 || r.Equity[0].Equity.IsZero() {
+		return decimal.Zero
+	}
+	first, last := r.Equity[0].Equity, r.Equity[len(r.Equity)-1].Equity
+	return last.Sub(first).Div(first)
+}
+
+// MaxDrawdown returns the largest decline of the equity from a previous peak, as a fraction
+// of the peak (e.g. 0.1 for 10%).
+func (r *Result) MaxDrawdown() decimal.Decimal {
+	maxDrawdown := decimal.Zero
+	var peak decimal.Decimal
+	for i, p := range r.Equity {
+		if i == 0 || p.Equity.GreaterThan(peak) {
+			peak = p.Equity
+		}
+		if peak.IsPositive() {
+			maxDrawdown = decimal.Max(maxDrawdown, peak.Sub(p.Equity).Div(peak))
+		}
+	}
+	return maxDrawdown
+}
+
+// Commissions returns the total of the commissions paid.
+func (r *Result) Commissions() decimal.Decimal {
+	total := decimal.Zero
+	for _, t := range r.Trades {
+		total = total.Add(t.Commission)
+	}
+	return total
+}
@@ -164,7 +164,7 @@ func handleTradeUpdates(tu alpaca.TradeUpdate) {
 	eventType := tu.Event
 	oid := tu.Order.ID
 
-	if eventType == "fill" || eventType == "partial_fill" {
+	if eventType == alpaca.TradeEventFill || eventType == alpaca.TradeEventPartialFill {
 		// Our position size has changed
 		pos, err := alpacaClient.client.GetPosition(alpacaClient.stock)
 		if err != nil {
@@ -174,15 +174,15 @@ func handleTradeUpdates(tu alpaca.TradeUpdate) {
 		}
 
 		fmt.Printf("New position size due to order fill: %d\n", alpacaClient.position)
-		if eventType == "fill" && alpacaClient.currOrder == oid {
+		if eventType == alpaca.TradeEventFill && alpacaClient.currOrder == oid {
 			alpacaClient.currOrder = ""
 		}
-	} else if eventType == "rejected" || eventType == "canceled" {
+	} else if eventType == alpaca.TradeEventRejected || eventType == alpaca.TradeEventCanceled {
 		if alpacaClient.currOrder == oid {
 			// Our last order should be removed
 			alpacaClient.currOrder = ""
 		}
-	} else if eventType == "new" {
+	} else if eventType == alpaca.TradeEventNew {
 		alpacaClient.currOrder = oid
 	} else {
 		fmt.Printf("Unexpected order event type %s received\n", eventType)
@@ -0,0 +1,210 @@
+package marketdata
+
+import "context"
+
+// MarketDataAPI is the method set of the market data client, implemented by *Client.
+// Depending on it instead of *Client allows substituting a fake in tests, such as the
+// ones in the marketdatatest package.
+//
+// The page iterators (IterateTrades, IterateBars, etc.) are not part of the interface, since
+// they return a concrete PageIterator that a fake couldn't construct.
+type MarketDataAPI interface {
+	GetOptionTrades(symbol string, req GetOptionTradesRequest) ([]OptionTrade, error)
+	GetOptionTradesWithContext(ctx context.Context, symbol string, req GetOptionTradesRequest) ([]OptionTrade, error)
+	GetOptionMultiTrades(symbols []string, req GetOptionTradesRequest) (map[string][]OptionTrade, error)
+	GetOptionMultiTradesWithContext(
+		ctx context.Context, symbols []string, req GetOptionTradesRequest,
+	) (map[string][]OptionTrade, error)
+	GetOptionBars(symbol string, req GetOptionBarsRequest) ([]OptionBar, error)
+	GetOptionBarsWithContext(ctx context.Context, symbol string, req GetOptionBarsRequest) ([]OptionBar, error)
+	GetMultiOptionBars(symbols []string, req GetOptionBarsRequest) (map[string][]OptionBar, error)
+	GetMultiOptionBarsWithContext(
+		ctx context.Context, symbols []string, req GetOptionBarsRequest,
+	) (map[string][]OptionBar, error)
+	GetLatestOptionTrade(symbol string, req GetLatestOptionTradeRequest) (*OptionTrade, error)
+	GetLatestOptionTradeWithContext(
+		ctx context.Context, symbol string, req GetLatestOptionTradeRequest,
+	) (*OptionTrade, error)
+	GetLatestOptionTrades(symbols []string, req GetLatestOptionTradeRequest) (map[string]OptionTrade, error)
+	GetLatestOptionTradesWithContext(
+		ctx context.Context, symbols []string, req GetLatestOptionTradeRequest,
+	) (map[string]OptionTrade, error)
+	GetLatestOptionQuote(symbol string, req GetLatestOptionQuoteRequest) (*OptionQuote, error)
+	GetLatestOptionQuoteWithContext(
+		ctx context.Context, symbol string, req GetLatestOptionQuoteRequest,
+	) (*OptionQuote, error)
+	GetLatestOptionQuotes(symbols []string, req GetLatestOptionQuoteRequest) (map[string]OptionQuote, error)
+	GetLatestOptionQuotesWithContext(
+		ctx context.Context, symbols []string, req GetLatestOptionQuoteRequest,
+	) (map[string]OptionQuote, error)
+	GetOptionSnapshot(symbol string, req GetOptionSnapshotRequest) (*OptionSnapshot, error)
+	GetOptionSnapshotWithContext(
+		ctx context.Context, symbol string, req GetOptionSnapshotRequest,
+	) (*OptionSnapshot, error)
+	GetOptionSnapshots(symbols []string, req GetOptionSnapshotRequest) (map[string]OptionSnapshot, error)
+	GetOptionSnapshotsWithContext(
+		ctx context.Context, symbols []string, req GetOptionSnapshotRequest,
+	) (map[string]OptionSnapshot, error)
+	GetOptionChain(underlyingSymbol string, req GetOptionChainRequest) (map[string]OptionSnapshot, error)
+	GetOptionChainWithContext(
+		ctx context.Context, underlyingSymbol string, req GetOptionChainRequest,
+	) (map[string]OptionSnapshot, error)
+	GetTrades(symbol string, req GetTradesRequest) ([]Trade, error)
+	GetTradesWithContext(ctx context.Context, symbol string, req GetTradesRequest) ([]Trade, error)
+	GetMultiTrades(symbols []string, req GetTradesRequest) (map[string][]Trade, error)
+	GetMultiTradesWithContext(ctx context.Context, symbols []string, req GetTradesRequest) (map[string][]Trade, error)
+	GetQuotes(symbol string, req GetQuotesRequest) ([]Quote, error)
+	GetQuotesWithContext(ctx context.Context, symbol string, req GetQuotesRequest) ([]Quote, error)
+	GetMultiQuotes(symbols []string, req GetQuotesRequest) (map[string][]Quote, error)
+	GetMultiQuotesWithContext(ctx context.Context, symbols []string, req GetQuotesRequest) (map[string][]Quote, error)
+	GetBars(symbol string, req GetBarsRequest) ([]Bar, error)
+	GetBarsWithContext(ctx context.Context, symbol string, req GetBarsRequest) ([]Bar, error)
+	GetMultiBars(symbols []string, req GetBarsRequest) (map[string][]Bar, error)
+	GetMultiBarsWithContext(ctx context.Context, symbols []string, req GetBarsRequest) (map[string][]Bar, error)
+	GetAuctions(symbol string, req GetAuctionsRequest) ([]DailyAuctions, error)
+	GetAuctionsWithContext(ctx context.Context, symbol string, req GetAuctionsRequest) ([]DailyAuctions, error)
+	GetMultiAuctions(symbols []string, req GetAuctionsRequest) (map[string][]DailyAuctions, error)
+	GetMultiAuctionsWithContext(
+		ctx context.Context, symbols []string, req GetAuctionsRequest,
+	) (map[string][]DailyAuctions, error)
+	GetLatestBar(symbol string, req GetLatestBarRequest) (*Bar, error)
+	GetLatestBarWithContext(ctx context.Context, symbol string, req GetLatestBarRequest) (*Bar, error)
+	GetLatestBars(symbols []string, req GetLatestBarRequest) (map[string]Bar, error)
+	GetLatestBarsWithContext(ctx context.Context, symbols []string, req GetLatestBarRequest) (map[string]Bar, error)
+	GetLatestTrade(symbol string, req GetLatestTradeRequest) (*Trade, error)
+	GetLatestTradeWithContext(ctx context.Context, symbol string, req GetLatestTradeRequest) (*Trade, error)
+	GetLatestTrades(symbols []string, req GetLatestTradeRequest) (map[string]Trade, error)
+	GetLatestTradesWithContext(
+		ctx context.Context, symbols []string, req GetLatestTradeRequest,
+	) (map[string]Trade, error)
+	GetLatestQuote(symbol string, req GetLatestQuoteRequest) (*Quote, error)
+	GetLatestQuoteWithContext(ctx context.Context, symbol string, req GetLatestQuoteRequest) (*Quote, error)
+	GetLatestQuotes(symbols []string, req GetLatestQuoteRequest) (map[string]Quote, error)
+	GetLatestQuotesWithContext(
+		ctx context.Context, symbols []string, req GetLatestQuoteRequest,
+	) (map[string]Quote, error)
+	GetSnapshot(symbol string, req GetSnapshotRequest) (*Snapshot, error)
+	GetSnapshotWithContext(ctx context.Context, symbol string, req GetSnapshotRequest) (*Snapshot, error)
+	GetSnapshots(symbols []string, req GetSnapshotRequest) (map[string]*Snapshot, error)
+	GetSnapshotsWithContext(
+		ctx context.Context, symbols []string, req GetSnapshotRequest,
+	) (map[string]*Snapshot, error)
+	GetCryptoTrades(symbol string, req GetCryptoTradesRequest) ([]CryptoTrade, error)
+	GetCryptoTradesWithContext(ctx context.Context, symbol string, req GetCryptoTradesRequest) ([]CryptoTrade, error)
+	GetCryptoMultiTrades(symbols []string, req GetCryptoTradesRequest) (map[string][]CryptoTrade, error)
+	GetCryptoMultiTradesWithContext(
+		ctx context.Context, symbols []string, req GetCryptoTradesRequest,
+	) (map[string][]CryptoTrade, error)
+	GetCryptoQuotes(symbol string, req GetCryptoQuotesRequest) ([]CryptoQuote, error)
+	GetCryptoQuotesWithContext(ctx context.Context, symbol string, req GetCryptoQuotesRequest) ([]CryptoQuote, error)
+	GetCryptoMultiQuotes(symbols []string, req GetCryptoQuotesRequest) (map[string][]CryptoQuote, error)
+	GetCryptoMultiQuotesWithContext(
+		ctx context.Context, symbols []string, req GetCryptoQuotesRequest,
+	) (map[string][]CryptoQuote, error)
+	GetCryptoBars(symbol string, req GetCryptoBarsRequest) ([]CryptoBar, error)
+	GetCryptoBarsWithContext(ctx context.Context, symbol string, req GetCryptoBarsRequest) ([]CryptoBar, error)
+	GetCryptoMultiBars(symbols []string, req GetCryptoBarsRequest) (map[string][]CryptoBar, error)
+	GetCryptoMultiBarsWithContext(
+		ctx context.Context, symbols []string, req GetCryptoBarsRequest,
+	) (map[string][]CryptoBar, error)
+	GetLatestCryptoBar(symbol string, req GetLatestCryptoBarRequest) (*CryptoBar, error)
+	GetLatestCryptoBarWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoBarRequest,
+	) (*CryptoBar, error)
+	GetLatestCryptoPerpBar(symbol string, req GetLatestCryptoBarRequest) (*CryptoPerpBar, error)
+	GetLatestCryptoPerpBarWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoBarRequest,
+	) (*CryptoPerpBar, error)
+	GetLatestCryptoPerpBars(symbols []string, req GetLatestCryptoBarRequest) (map[string]CryptoPerpBar, error)
+	GetLatestCryptoPerpBarsWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoBarRequest,
+	) (map[string]CryptoPerpBar, error)
+	GetLatestCryptoBars(symbols []string, req GetLatestCryptoBarRequest) (map[string]CryptoBar, error)
+	GetLatestCryptoBarsWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoBarRequest,
+	) (map[string]CryptoBar, error)
+	GetLatestCryptoTrade(symbol string, req GetLatestCryptoTradeRequest) (*CryptoTrade, error)
+	GetLatestCryptoTradeWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoTradeRequest,
+	) (*CryptoTrade, error)
+	GetLatestCryptoPerpTrade(symbol string, req GetLatestCryptoTradeRequest) (*CryptoPerpTrade, error)
+	GetLatestCryptoPerpTradeWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoTradeRequest,
+	) (*CryptoPerpTrade, error)
+	GetLatestCryptoPerpTrades(symbols []string, req GetLatestCryptoTradeRequest) (map[string]CryptoPerpTrade, error)
+	GetLatestCryptoPerpTradesWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoTradeRequest,
+	) (map[string]CryptoPerpTrade, error)
+	GetLatestCryptoTrades(symbols []string, req GetLatestCryptoTradeRequest) (map[string]CryptoTrade, error)
+	GetLatestCryptoTradesWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoTradeRequest,
+	) (map[string]CryptoTrade, error)
+	GetLatestCryptoQuote(symbol string, req GetLatestCryptoQuoteRequest) (*CryptoQuote, error)
+	GetLatestCryptoQuoteWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoQuoteRequest,
+	) (*CryptoQuote, error)
+	GetLatestCryptoPerpQuote(symbol string, req GetLatestCryptoQuoteRequest) (*CryptoPerpQuote, error)
+	GetLatestCryptoPerpQuoteWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoQuoteRequest,
+	) (*CryptoPerpQuote, error)
+	GetLatestCryptoPerpQuotes(symbols []string, req GetLatestCryptoQuoteRequest) (map[string]CryptoPerpQuote, error)
+	GetLatestCryptoPerpQuotesWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoQuoteRequest,
+	) (map[string]CryptoPerpQuote, error)
+	GetLatestCryptoQuotes(symbols []string, req GetLatestCryptoQuoteRequest) (map[string]CryptoQuote, error)
+	GetLatestCryptoQuotesWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoQuoteRequest,
+	) (map[string]CryptoQuote, error)
+	GetLatestCryptoPerpPricing(symbol string, req GetLatestCryptoPerpPricingRequest) (*CryptoPerpPricing, error)
+	GetLatestCryptoPerpPricingWithContext(
+		ctx context.Context, symbol string, req GetLatestCryptoPerpPricingRequest,
+	) (*CryptoPerpPricing, error)
+	GetLatestCryptoPerpPricingData(
+		symbols []string, req GetLatestCryptoPerpPricingRequest,
+	) (map[string]CryptoPerpPricing, error)
+	GetLatestCryptoPerpPricingDataWithContext(
+		ctx context.Context, symbols []string, req GetLatestCryptoPerpPricingRequest,
+	) (map[string]CryptoPerpPricing, error)
+	GetCryptoSnapshot(symbol string, req GetCryptoSnapshotRequest) (*CryptoSnapshot, error)
+	GetCryptoSnapshotWithContext(
+		ctx context.Context, symbol string, req GetCryptoSnapshotRequest,
+	) (*CryptoSnapshot, error)
+	GetCryptoSnapshots(symbols []string, req GetCryptoSnapshotRequest) (map[string]CryptoSnapshot, error)
+	GetCryptoSnapshotsWithContext(
+		ctx context.Context, symbols []string, req GetCryptoSnapshotRequest,
+	) (map[string]CryptoSnapshot, error)
+	GetNews(req GetNewsRequest) ([]News, error)
+	GetNewsWithContext(ctx context.Context, req GetNewsRequest) ([]News, error)
+	GetCorporateActions(req GetCorporateActionsRequest) (CorporateActions, error)
+	GetCorporateActionsWithContext(ctx context.Context, req GetCorporateActionsRequest) (CorporateActions, error)
+	GetFixedIncomeLatestPrice(isin string) (*FixedIncomePrice, error)
+	GetFixedIncomeLatestPriceWithContext(ctx context.Context, isin string) (*FixedIncomePrice, error)
+	GetFixedIncomeLatestPrices(isins []string) (map[string]FixedIncomePrice, error)
+	GetFixedIncomeLatestPricesWithContext(ctx context.Context, isins []string) (map[string]FixedIncomePrice, error)
+	DownloadTrades(
+		ctx context.Context, symbols []string, req GetTradesRequest, opts DownloadOpts,
+	) (map[string][]Trade, error)
+	DownloadQuotes(
+		ctx context.Context, symbols []string, req GetQuotesRequest, opts DownloadOpts,
+	) (map[string][]Quote, error)
+	DownloadBars(
+		ctx context.Context, symbols []string, req GetBarsRequest, opts DownloadOpts,
+	) (map[string][]Bar, error)
+	DownloadCryptoTrades(
+		ctx context.Context, symbols []string, req GetCryptoTradesRequest, opts DownloadOpts,
+	) (map[string][]CryptoTrade, error)
+	DownloadCryptoQuotes(
+		ctx context.Context, symbols []string, req GetCryptoQuotesRequest, opts DownloadOpts,
+	) (map[string][]CryptoQuote, error)
+	DownloadCryptoBars(
+		ctx context.Context, symbols []string, req GetCryptoBarsRequest, opts DownloadOpts,
+	) (map[string][]CryptoBar, error)
+	DownloadOptionTrades(
+		ctx context.Context, symbols []string, req GetOptionTradesRequest, opts DownloadOpts,
+	) (map[string][]OptionTrade, error)
+	DownloadOptionBars(
+		ctx context.Context, symbols []string, req GetOptionBarsRequest, opts DownloadOpts,
+	) (map[string][]OptionBar, error)
+}
+
+var _ MarketDataAPI = (*Client)(nil)
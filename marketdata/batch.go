@@ -0,0 +1,154 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxSymbolsPerRequest is the maximum number of symbols of a request if
+// ClientOpts.MaxSymbolsPerRequest is not set.
+const DefaultMaxSymbolsPerRequest = 200
+
+// BatchError is the error of a batch of symbols that failed, when the symbols of a multi-symbol
+// request were split into batches.
+type BatchError struct {
+	Symbols []string
+	Err     error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("marketdata: failed to get the batch of %s: %v", formatSymbols(e.Symbols), e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// formatSymbols formats the symbols of an error message, abbreviated if there are many.
+func formatSymbols(symbols []string) string {
+	if len(symbols) > 3 {
+		return fmt.Sprintf("%s and %d other symbols", symbols[0], len(symbols)-1)
+	}
+	return strings.Join(symbols, ",")
+}
+
+// symbolBatches splits the symbols into batches of at most MaxSymbolsPerRequest symbols.
+func (c *Client) symbolBatches(symbols []string) [][]string {
+	size := c.opts.MaxSymbolsPerRequest
+	if size <= 0 || len(symbols) <= size {
+		return [][]string{symbols}
+	}
+	batches := make([][]string, 0, (len(symbols)+size-1)/size)
+	for i := 0; i < len(symbols); i += size {
+		batches = append(batches, symbols[i:min(i+size, len(symbols))])
+	}
+	return batches
+}
+
+// getBatches calls get with each batch, SymbolBatchConcurrency batches at a time, and merges the
+// results. The first batch that fails cancels the others.
+func getBatches[V any](
+	ctx context.Context, c *Client, batches [][]string,
+	get func(ctx context.Context, symbols []string) (map[string]V, error),
+) (map[string]V, error) {
+	results := make([]map[string]V, len(batches))
+	err := runParallel(ctx, len(batches), c.opts.SymbolBatchConcurrency, func(ctx context.Context, i int) error {
+		r, err := get(ctx, batches[i])
+		if err != nil {
+			return &BatchError{Symbols: batches[i], Err: err}
+		}
+		results[i] = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[string]V)
+	for _, r := range results {
+		for symbol, v := range r {
+			merged[symbol] = v
+		}
+	}
+	return merged, nil
+}
+
+// getPagedBatches is getBatches for the paginated requests. With a TotalLimit, the batches are
+// requested one at a time, each one with the part of the limit left by the previous ones.
+// count returns the number of records of a symbol.
+func getPagedBatches[V any](
+	ctx context.Context, c *Client, batches [][]string, pr pageRequest, count func(v V) int,
+	get func(ctx context.Context, symbols []string, totalLimit int) (map[string]V, error),
+) (map[string]V, error) {
+	if pr.PageToken != "" {
+		return nil, errors.New("marketdata: PageToken can't be used when the symbols are split into batches")
+	}
+	if pr.TotalLimit == 0 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]V, error) {
+			return get(ctx, symbols, 0)
+		})
+	}
+	merged := make(map[string]V)
+	remaining := pr.TotalLimit
+	for _, symbols := range batches {
+		r, err := get(ctx, symbols, remaining)
+		if err != nil {
+			return nil, &BatchError{Symbols: symbols, Err: err}
+		}
+		for symbol, v := range r {
+			merged[symbol] = v
+			remaining -= count(v)
+		}
+		if remaining <= 0 {
+			break
+		}
+	}
+	return merged, nil
+}
+
+func recordCount[T any](records []T) int {
+	return len(records)
+}
+
+// runParallel calls f with the indexes from 0 to n-1, with at most concurrency calls at the same
+// time. It returns the first error, which cancels the context of the other calls.
+func runParallel(ctx context.Context, n, concurrency int, f func(ctx context.Context, i int) error) error {
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	next := make(chan int)
+	for w := 0; w < min(max(concurrency, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := f(workerCtx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case next <- i:
+		case <-workerCtx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,131 @@
+package marketdata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSymbols(n int) []string {
+	symbols := make([]string, n)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%03d", i)
+	}
+	return symbols
+}
+
+// latestQuotes serves a quote for each requested symbol, and records the requested symbols.
+func latestQuotes(mu *sync.Mutex, requested *[]string) func(_ *Client, req *http.Request) (*http.Response, error) {
+	return func(_ *Client, req *http.Request) (*http.Response, error) {
+		symbols := req.URL.Query().Get("symbols")
+		mu.Lock()
+		*requested = append(*requested, symbols)
+		mu.Unlock()
+		if strings.Contains(symbols, "FAIL") {
+			return nil, errors.New("bad gateway")
+		}
+		quotes := make([]string, 0)
+		for _, symbol := range strings.Split(symbols, ",") {
+			quotes = append(quotes, fmt.Sprintf(`"%s":{"bp":1}`, symbol))
+		}
+		resp := fmt.Sprintf(`{"quotes":{%s}}`, strings.Join(quotes, ","))
+		return &http.Response{Body: io.NopCloser(strings.NewReader(resp))}, nil
+	}
+}
+
+func TestSymbolBatches(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	c := NewClient(ClientOpts{})
+	c.do = latestQuotes(&mu, &requested)
+	symbols := testSymbols(450)
+
+	quotes, err := c.GetLatestQuotes(symbols, GetLatestQuoteRequest{})
+	require.NoError(t, err)
+	assert.Len(t, quotes, 450)
+	require.Len(t, requested, 3)
+	assert.Equal(t, strings.Join(symbols[:200], ","), requested[0])
+	assert.Equal(t, strings.Join(symbols[400:], ","), requested[2])
+
+	// In parallel
+	requested = nil
+	c = NewClient(ClientOpts{MaxSymbolsPerRequest: 10, SymbolBatchConcurrency: 4})
+	c.do = latestQuotes(&mu, &requested)
+	quotes, err = c.GetLatestQuotes(symbols, GetLatestQuoteRequest{})
+	require.NoError(t, err)
+	assert.Len(t, quotes, 450)
+	assert.Len(t, requested, 45)
+
+	// Disabled
+	requested = nil
+	c = NewClient(ClientOpts{MaxSymbolsPerRequest: -1})
+	c.do = latestQuotes(&mu, &requested)
+	_, err = c.GetLatestQuotes(symbols, GetLatestQuoteRequest{})
+	require.NoError(t, err)
+	assert.Len(t, requested, 1)
+}
+
+func TestSymbolBatches_Error(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	c := NewClient(ClientOpts{MaxSymbolsPerRequest: 2})
+	c.do = latestQuotes(&mu, &requested)
+
+	_, err := c.GetLatestQuotes([]string{"AAPL", "MSFT", "FAIL", "TSLA", "NVDA"}, GetLatestQuoteRequest{})
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{"FAIL", "TSLA"}, batchErr.Symbols)
+	assert.Equal(t, "marketdata: failed to get the batch of FAIL,TSLA: bad gateway", err.Error())
+	// The batches after the failed one are not requested
+	assert.Len(t, requested, 2)
+
+	// Without batches, the error is returned as is
+	_, err = c.GetLatestQuotes([]string{"FAIL"}, GetLatestQuoteRequest{})
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &batchErr))
+
+	_, err = c.GetMultiBars([]string{"AAPL", "MSFT", "TSLA"}, GetBarsRequest{PageToken: "next"})
+	require.Error(t, err)
+}
+
+func TestSymbolBatches_TotalLimit(t *testing.T) {
+	c := NewClient(ClientOpts{MaxSymbolsPerRequest: 2})
+	var limits []string
+	c.do = func(_ *Client, req *http.Request) (*http.Response, error) {
+		q := req.URL.Query()
+		limits = append(limits, q.Get("symbols")+" "+q.Get("limit"))
+		// Two bars per symbol, within the limit
+		remaining, _ := strconv.Atoi(q.Get("limit"))
+		bars := make([]string, 0)
+		for _, symbol := range strings.Split(q.Get("symbols"), ",") {
+			switch {
+			case remaining >= 2:
+				bars = append(bars, fmt.Sprintf(`"%s":[{"c":1},{"c":2}]`, symbol))
+			case remaining == 1:
+				bars = append(bars, fmt.Sprintf(`"%s":[{"c":1}]`, symbol))
+			}
+			remaining -= 2
+		}
+		resp := fmt.Sprintf(`{"bars":{%s},"next_page_token":null}`, strings.Join(bars, ","))
+		return &http.Response{Body: io.NopCloser(strings.NewReader(resp))}, nil
+	}
+
+	bars, err := c.GetMultiBars([]string{"AAPL", "MSFT", "NVDA", "TSLA", "XOM"}, GetBarsRequest{TotalLimit: 6})
+	require.NoError(t, err)
+	assert.Len(t, bars, 3)
+	assert.Len(t, bars["NVDA"], 2)
+	// The limit left by the previous batches is passed to the next one
+	assert.Equal(t, []string{"AAPL,MSFT 6", "NVDA,TSLA 2"}, limits)
+}
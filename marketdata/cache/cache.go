@@ -0,0 +1,467 @@
+// Package cache provides a persistent cache of historical market data on the local disk, in front
+// of a marketdata.Client: the repeated requests only fetch the time ranges that are not cached
+// yet.
+//
+// The data is stored in plain files under a directory, an entry per symbol and set of request
+// parameters (see Key), with an index of the cached time ranges and the records split into
+// gzipped JSON files by day, month or year. The recent data that may still change, such as the
+// bar of the current session, is returned but not cached, so it's fetched again by the next
+// requests until it's final. The adjusted bars, which change with each new corporate action,
+// are not cached either.
+//
+// A Cache is safe for concurrent use, but a cache directory must not be shared by several Caches
+// at the same time.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// DefaultSettleDelay is the settle delay if Opts.SettleDelay is not set.
+const DefaultSettleDelay = 15 * time.Minute
+
+// Source fetches the data missing from the cache. It's implemented by *marketdata.Client.
+type Source interface {
+	GetMultiBarsWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+	) (map[string][]marketdata.Bar, error)
+	GetMultiTradesWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetTradesRequest,
+	) (map[string][]marketdata.Trade, error)
+	GetMultiQuotesWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetQuotesRequest,
+	) (map[string][]marketdata.Quote, error)
+	GetMultiAuctionsWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetAuctionsRequest,
+	) (map[string][]marketdata.DailyAuctions, error)
+	GetCorporateActionsWithContext(
+		ctx context.Context, req marketdata.GetCorporateActionsRequest,
+	) (marketdata.CorporateActions, error)
+}
+
+// Opts contains the options of a Cache.
+type Opts struct {
+	// Dir is the directory of the cache, created if it doesn't exist. It's required.
+	Dir string
+	// Source fetches the data missing from the cache. Defaults to marketdata.DefaultClient.
+	Source Source
+	// SettleDelay is how long the data takes to be final after its time, e.g. because of the
+	// delay of the feed or of the late trades. The more recent data is not cached. Defaults
+	// to DefaultSettleDelay.
+	SettleDelay time.Duration
+}
+
+// Cache is a persistent cache of historical market data.
+type Cache struct {
+	opts Opts
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// New returns a Cache storing its data in opts.Dir.
+func New(opts Opts) (*Cache, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("cache: the directory is required")
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache: failed to create the directory: %w", err)
+	}
+	if opts.Source == nil {
+		opts.Source = marketdata.DefaultClient
+	}
+	if opts.SettleDelay == 0 {
+		opts.SettleDelay = DefaultSettleDelay
+	}
+	return &Cache{opts: opts, now: time.Now}, nil
+}
+
+func (c *Cache) dir(k Key) string {
+	return filepath.Join(c.opts.Dir, k.path())
+}
+
+// requestRange returns the time range of a request with an inclusive end, which defaults to now.
+func (c *Cache) requestRange(start, end time.Time, totalLimit int, pageToken string) (Range, error) {
+	if start.IsZero() {
+		return Range{}, errors.New("cache: Start is required")
+	}
+	if totalLimit != 0 || pageToken != "" {
+		return Range{}, errors.New("cache: TotalLimit and PageToken are not supported")
+	}
+	if end.IsZero() {
+		end = c.now()
+	} else {
+		end = end.Add(time.Nanosecond)
+	}
+	return Range{Start: start.UTC(), End: end.UTC()}, nil
+}
+
+// GetBars returns the bars of the symbols like marketdata.Client.GetMultiBars, fetching only the
+// ones that are not cached. Only the raw bars are cached: the adjusted ones change with each new
+// corporate action, so their requests are passed to the source.
+func (c *Cache) GetBars(
+	ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+) (map[string][]marketdata.Bar, error) {
+	if req.Adjustment != "" && req.Adjustment != marketdata.AdjustmentRaw {
+		return c.opts.Source.GetMultiBarsWithContext(ctx, symbols, req)
+	}
+	r, err := c.requestRange(req.Start, req.End, req.TotalLimit, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	s := series[marketdata.Bar]{
+		time:   func(b marketdata.Bar) time.Time { return b.Timestamp },
+		period: monthly,
+		settle: timeFrameDuration(req.TimeFrame),
+	}
+	keys := make([]Key, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = BarsKey(symbol, req)
+	}
+	bars, err := get(ctx, c, s, keys, r, func(ctx context.Context, symbols []string, r Range) (
+		map[string][]marketdata.Bar, error,
+	) {
+		req := req
+		req.Start, req.End, req.Sort = r.Start, r.End.Add(-time.Nanosecond), marketdata.SortAsc
+		return c.opts.Source.GetMultiBarsWithContext(ctx, symbols, req)
+	})
+	return sortRecords(bars, req.Sort), err
+}
+
+// GetTrades returns the trades of the symbols like marketdata.Client.GetMultiTrades, fetching
+// only the ones that are not cached.
+func (c *Cache) GetTrades(
+	ctx context.Context, symbols []string, req marketdata.GetTradesRequest,
+) (map[string][]marketdata.Trade, error) {
+	r, err := c.requestRange(req.Start, req.End, req.TotalLimit, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	s := series[marketdata.Trade]{
+		time:   func(t marketdata.Trade) time.Time { return t.Timestamp },
+		period: daily,
+	}
+	keys := make([]Key, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = TradesKey(symbol, req)
+	}
+	trades, err := get(ctx, c, s, keys, r, func(ctx context.Context, symbols []string, r Range) (
+		map[string][]marketdata.Trade, error,
+	) {
+		req := req
+		req.Start, req.End, req.Sort = r.Start, r.End.Add(-time.Nanosecond), marketdata.SortAsc
+		return c.opts.Source.GetMultiTradesWithContext(ctx, symbols, req)
+	})
+	return sortRecords(trades, req.Sort), err
+}
+
+// GetQuotes returns the quotes of the symbols like marketdata.Client.GetMultiQuotes, fetching
+// only the ones that are not cached.
+func (c *Cache) GetQuotes(
+	ctx context.Context, symbols []string, req marketdata.GetQuotesRequest,
+) (map[string][]marketdata.Quote, error) {
+	r, err := c.requestRange(req.Start, req.End, req.TotalLimit, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	s := series[marketdata.Quote]{
+		time:   func(q marketdata.Quote) time.Time { return q.Timestamp },
+		period: daily,
+	}
+	keys := make([]Key, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = QuotesKey(symbol, req)
+	}
+	quotes, err := get(ctx, c, s, keys, r, func(ctx context.Context, symbols []string, r Range) (
+		map[string][]marketdata.Quote, error,
+	) {
+		req := req
+		req.Start, req.End, req.Sort = r.Start, r.End.Add(-time.Nanosecond), marketdata.SortAsc
+		return c.opts.Source.GetMultiQuotesWithContext(ctx, symbols, req)
+	})
+	return sortRecords(quotes, req.Sort), err
+}
+
+// GetAuctions returns the auctions of the symbols like marketdata.Client.GetMultiAuctions,
+// fetching only the ones that are not cached. The auctions are cached by whole UTC days: the time
+// range of the request is extended to the days it overlaps.
+func (c *Cache) GetAuctions(
+	ctx context.Context, symbols []string, req marketdata.GetAuctionsRequest,
+) (map[string][]marketdata.DailyAuctions, error) {
+	r, err := c.requestRange(req.Start, req.End, req.TotalLimit, "")
+	if err != nil {
+		return nil, err
+	}
+	r = Range{Start: daily.start(r.Start), End: daily.next(daily.start(r.End.Add(-time.Nanosecond)))}
+	s := series[marketdata.DailyAuctions]{
+		time:   func(a marketdata.DailyAuctions) time.Time { return a.Date.In(time.UTC) },
+		period: yearly,
+		settle: 24 * time.Hour,
+	}
+	keys := make([]Key, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = AuctionsKey(symbol, req)
+	}
+	auctions, err := get(ctx, c, s, keys, r, func(ctx context.Context, symbols []string, r Range) (
+		map[string][]marketdata.DailyAuctions, error,
+	) {
+		req := req
+		req.Start, req.End, req.Sort = r.Start, r.End.Add(-time.Nanosecond), marketdata.SortAsc
+		return c.opts.Source.GetMultiAuctionsWithContext(ctx, symbols, req)
+	})
+	return sortRecords(auctions, req.Sort), err
+}
+
+// GetCorporateActions returns the corporate actions of each symbol like
+// marketdata.Client.GetCorporateActions, fetching only the ones that are not cached. The
+// symbols of req are ignored, and the corporate actions are fetched one symbol at a time. They
+// are cached by process date, on which the API filters them.
+func (c *Cache) GetCorporateActions(
+	ctx context.Context, symbols []string, req marketdata.GetCorporateActionsRequest,
+) (map[string]marketdata.CorporateActions, error) {
+	if req.Start.IsZero() {
+		return nil, errors.New("cache: Start is required")
+	}
+	if req.TotalLimit != 0 {
+		return nil, errors.New("cache: TotalLimit is not supported")
+	}
+	end := req.End
+	if end.IsZero() {
+		end = civil.DateOf(c.now().UTC())
+	}
+	r := Range{Start: req.Start.In(time.UTC), End: end.AddDays(1).In(time.UTC)}
+	s := series[corporateAction]{
+		time:   func(ca corporateAction) time.Time { return ca.Date.In(time.UTC) },
+		period: yearly,
+		settle: 24 * time.Hour,
+	}
+	keys := make([]Key, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = CorporateActionsKey(symbol, req)
+	}
+	actions, err := get(ctx, c, s, keys, r, func(ctx context.Context, symbols []string, r Range) (
+		map[string][]corporateAction, error,
+	) {
+		actions := make(map[string][]corporateAction, len(symbols))
+		for _, symbol := range symbols {
+			req := req
+			req.Symbols = []string{symbol}
+			req.Start, req.End = civil.DateOf(r.Start), civil.DateOf(r.End.Add(-time.Nanosecond))
+			cas, err := c.opts.Source.GetCorporateActionsWithContext(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			if actions[symbol], err = flattenCorporateActions(cas); err != nil {
+				return nil, err
+			}
+		}
+		return actions, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]marketdata.CorporateActions, len(actions))
+	for symbol, a := range actions {
+		if result[symbol], err = groupCorporateActions(a); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// corporateAction is a corporate action of any type, as stored in the cache.
+type corporateAction struct {
+	// Type is the field of its type in the JSON of marketdata.CorporateActions, e.g.
+	// "cash_dividends".
+	Type string          `json:"type"`
+	Date civil.Date      `json:"process_date"`
+	Data json.RawMessage `json:"data"`
+}
+
+func flattenCorporateActions(cas marketdata.CorporateActions) ([]corporateAction, error) {
+	b, err := json.Marshal(cas)
+	if err != nil {
+		return nil, err
+	}
+	var byType map[string][]json.RawMessage
+	if err := json.Unmarshal(b, &byType); err != nil {
+		return nil, err
+	}
+	var actions []corporateAction
+	for typ, records := range byType {
+		for _, data := range records {
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return nil, err
+			}
+			var date civil.Date
+			if err := json.Unmarshal(fields["process_date"], &date); err != nil {
+				return nil, err
+			}
+			// The zero dates are marshalled but can't be unmarshalled: they are left out
+			for name, value := range fields {
+				if string(value) == `"0000-00-00"` {
+					delete(fields, name)
+				}
+			}
+			if data, err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
+			actions = append(actions, corporateAction{Type: typ, Date: date, Data: data})
+		}
+	}
+	return actions, nil
+}
+
+func groupCorporateActions(actions []corporateAction) (marketdata.CorporateActions, error) {
+	byType := make(map[string][]json.RawMessage)
+	for _, ca := range actions {
+		byType[ca.Type] = append(byType[ca.Type], ca.Data)
+	}
+	var cas marketdata.CorporateActions
+	b, err := json.Marshal(byType)
+	if err != nil {
+		return cas, err
+	}
+	err = json.Unmarshal(b, &cas)
+	return cas, err
+}
+
+// timeFrameDuration returns the longest duration of the bars of the timeframe.
+func timeFrameDuration(tf marketdata.TimeFrame) time.Duration {
+	if tf.N == 0 {
+		tf = marketdata.OneDay
+	}
+	unit := 24 * time.Hour
+	switch tf.Unit {
+	case marketdata.Min:
+		unit = time.Minute
+	case marketdata.Hour:
+		unit = time.Hour
+	case marketdata.Week:
+		unit = 7 * 24 * time.Hour
+	case marketdata.Month:
+		unit = 31 * 24 * time.Hour
+	}
+	return time.Duration(tf.N) * unit
+}
+
+// sortRecords sorts the records in descending order if requested. They are in ascending order
+// otherwise.
+func sortRecords[T any](records map[string][]T, order marketdata.Sort) map[string][]T {
+	if order != marketdata.SortDesc {
+		return records
+	}
+	for _, r := range records {
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+	}
+	return records
+}
+
+// Entry is a cache entry, the cached data of a Key.
+type Entry struct {
+	Key Key
+	// Covered are the cached time ranges, sorted.
+	Covered []Range
+	// Size is the size of the files of the entry, in bytes.
+	Size int64
+}
+
+// Entries returns the entries of the cache, sorted by kind, parameters and symbol.
+func (c *Cache) Entries() ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var entries []Entry
+	err := filepath.WalkDir(c.opts.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != indexFile {
+			return err
+		}
+		dir := filepath.Dir(path)
+		rel, err := filepath.Rel(c.opts.Dir, dir)
+		if err != nil {
+			return err
+		}
+		e := Entry{}
+		if e.Key, err = parseKey(rel); err != nil {
+			return err
+		}
+		idx, err := readIndex(dir)
+		if err != nil {
+			return err
+		}
+		e.Covered = idx.Covered
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if info, err := f.Info(); err == nil {
+				e.Size += info.Size()
+			}
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cache: failed to list the entries: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Key.path() < entries[j].Key.path() })
+	return entries, nil
+}
+
+// Invalidate forgets the cached data of the key in r, which is fetched again by the next
+// request. A zero Start or End leaves the range unbounded on that side, and a zero Range
+// removes the entry.
+func (c *Cache) Invalidate(k Key, r Range) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir := c.dir(k)
+	if r.Start.IsZero() && r.End.IsZero() {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("cache: failed to remove the entry: %w", err)
+		}
+		return nil
+	}
+	if r.End.IsZero() {
+		r.End = farFuture
+	}
+	idx, err := readIndex(dir)
+	if err != nil || len(idx.Covered) == 0 {
+		return err
+	}
+	idx.Covered = removeRange(idx.Covered, r)
+	return writeIndex(dir, idx)
+}
+
+// farFuture is after the time of any record.
+var farFuture = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Clear removes all the entries of the cache.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files, err := os.ReadDir(c.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("cache: failed to clear: %w", err)
+	}
+	for _, f := range files {
+		if err := os.RemoveAll(filepath.Join(c.opts.Dir, f.Name())); err != nil {
+			return fmt.Errorf("cache: failed to clear: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/marketdatatest"
+)
+
+var testNow = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// dailyBars serves a bar per day at midnight UTC until now, and records the requested ranges.
+func dailyBars(requested *[]Range) *marketdatatest.Fake {
+	return &marketdatatest.Fake{
+		GetMultiBarsFunc: func(_ context.Context, symbols []string, req marketdata.GetBarsRequest) (
+			map[string][]marketdata.Bar, error,
+		) {
+			*requested = append(*requested, Range{Start: req.Start, End: req.End})
+			bars := make(map[string][]marketdata.Bar)
+			for _, symbol := range symbols {
+				day := daily.start(req.Start.Add(-time.Nanosecond)).AddDate(0, 0, 1)
+				for ; !day.After(req.End) && day.Before(testNow); day = day.AddDate(0, 0, 1) {
+					bars[symbol] = append(bars[symbol], marketdata.Bar{Timestamp: day, Close: float64(day.Day())})
+				}
+			}
+			return bars, nil
+		},
+	}
+}
+
+func newTestCache(t *testing.T, dir string, source Source) *Cache {
+	c, err := New(Opts{Dir: dir, Source: source})
+	require.NoError(t, err)
+	c.now = func() time.Time { return testNow }
+	return c
+}
+
+func TestGetBars(t *testing.T) {
+	var requested []Range
+	dir := t.TempDir()
+	c := newTestCache(t, dir, dailyBars(&requested))
+	ctx := context.Background()
+	symbols := []string{"AAPL", "MSFT"}
+
+	bars, err := c.GetBars(ctx, symbols, marketdata.GetBarsRequest{Start: date(1, 1), End: date(1, 31)})
+	require.NoError(t, err)
+	assert.Len(t, bars["AAPL"], 31)
+	assert.Len(t, bars["MSFT"], 31)
+	require.Len(t, requested, 1)
+
+	// Only the missing range is fetched, for both symbols together
+	requested = nil
+	bars, err = c.GetBars(ctx, symbols, marketdata.GetBarsRequest{Start: date(1, 15), End: date(2, 15)})
+	require.NoError(t, err)
+	require.Len(t, bars["AAPL"], 32)
+	assert.Equal(t, date(1, 15), bars["AAPL"][0].Timestamp)
+	assert.Equal(t, date(2, 15), bars["AAPL"][31].Timestamp)
+	assert.Equal(t, []Range{{Start: date(1, 31).Add(time.Nanosecond), End: date(2, 15)}}, requested)
+
+	// The data persists in the files
+	requested = nil
+	c = newTestCache(t, dir, dailyBars(&requested))
+	bars, err = c.GetBars(ctx, symbols, marketdata.GetBarsRequest{
+		Start: date(1, 10), End: date(1, 20), Sort: marketdata.SortDesc,
+	})
+	require.NoError(t, err)
+	require.Len(t, bars["MSFT"], 11)
+	assert.Equal(t, date(1, 20), bars["MSFT"][0].Timestamp)
+	assert.Empty(t, requested)
+
+	// Another timeframe is another entry
+	_, err = c.GetBars(ctx, symbols, marketdata.GetBarsRequest{
+		TimeFrame: marketdata.OneHour, Start: date(1, 10), End: date(1, 20),
+	})
+	require.NoError(t, err)
+	assert.Len(t, requested, 1)
+
+	// The adjusted bars are not cached
+	requested = nil
+	for i := 0; i < 2; i++ {
+		bars, err = c.GetBars(ctx, symbols, marketdata.GetBarsRequest{
+			Start: date(1, 10), End: date(1, 20), Adjustment: marketdata.AdjustmentSplit,
+		})
+		require.NoError(t, err)
+		assert.Len(t, bars["AAPL"], 11)
+	}
+	assert.Len(t, requested, 2)
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.Equal(t, string(marketdata.AdjustmentRaw), e.Key.Adjustment)
+	}
+}
+
+func TestGetBars_Recent(t *testing.T) {
+	var requested []Range
+	c := newTestCache(t, t.TempDir(), dailyBars(&requested))
+	ctx := context.Background()
+
+	bars, err := c.GetBars(ctx, []string{"AAPL"}, marketdata.GetBarsRequest{Start: date(3, 1)})
+	require.NoError(t, err)
+	assert.Len(t, bars["AAPL"], 10)
+
+	// The bar of today may still change: it's fetched again
+	requested = nil
+	bars, err = c.GetBars(ctx, []string{"AAPL"}, marketdata.GetBarsRequest{Start: date(3, 1)})
+	require.NoError(t, err)
+	assert.Len(t, bars["AAPL"], 10)
+	cutoff := testNow.Add(-24*time.Hour - DefaultSettleDelay)
+	assert.Equal(t, []Range{{Start: cutoff, End: testNow.Add(-time.Nanosecond)}}, requested)
+
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, BarsKey("AAPL", marketdata.GetBarsRequest{}), entries[0].Key)
+	assert.Equal(t, []Range{{Start: date(3, 1), End: cutoff}}, entries[0].Covered)
+	assert.Positive(t, entries[0].Size)
+}
+
+func TestGetBars_Concurrent(t *testing.T) {
+	var (
+		requested []Range
+		mu        sync.Mutex
+	)
+	bars := dailyBars(&requested)
+	fetching, release := make(chan struct{}), make(chan struct{})
+	c := newTestCache(t, t.TempDir(), &marketdatatest.Fake{
+		GetMultiBarsFunc: func(_ context.Context, symbols []string, req marketdata.GetBarsRequest) (
+			map[string][]marketdata.Bar, error,
+		) {
+			if symbols[0] == "AAPL" {
+				close(fetching)
+				<-release
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return bars.GetMultiBars(symbols, req)
+		},
+	})
+	ctx := context.Background()
+	req := marketdata.GetBarsRequest{Start: date(1, 1), End: date(1, 31)}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetBars(ctx, []string{"AAPL"}, req)
+		done <- err
+	}()
+	<-fetching
+	// The cache isn't locked while AAPL is fetched
+	msft, err := c.GetBars(ctx, []string{"MSFT"}, req)
+	require.NoError(t, err)
+	assert.Len(t, msft["MSFT"], 31)
+	_, err = c.Entries()
+	require.NoError(t, err)
+
+	close(release)
+	require.NoError(t, <-done)
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestInvalidate(t *testing.T) {
+	var requested []Range
+	c := newTestCache(t, t.TempDir(), dailyBars(&requested))
+	ctx := context.Background()
+	req := marketdata.GetBarsRequest{Feed: marketdata.IEX, Start: date(1, 1), End: date(1, 31)}
+	_, err := c.GetBars(ctx, []string{"AAPL", "MSFT"}, req)
+	require.NoError(t, err)
+
+	k := BarsKey("AAPL", req)
+	require.NoError(t, c.Invalidate(k, Range{Start: date(1, 10), End: date(1, 20)}))
+	requested = nil
+	bars, err := c.GetBars(ctx, []string{"AAPL", "MSFT"}, req)
+	require.NoError(t, err)
+	assert.Len(t, bars["AAPL"], 31)
+	assert.Equal(t, []Range{{Start: date(1, 10), End: date(1, 20).Add(-time.Nanosecond)}}, requested)
+
+	require.NoError(t, c.Invalidate(k, Range{}))
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "MSFT", entries[0].Key.Symbol)
+	assert.Equal(t, "iex", entries[0].Key.Feed)
+
+	require.NoError(t, c.Clear())
+	entries, err = c.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestGetCorporateActions(t *testing.T) {
+	var requested []string
+	source := &marketdatatest.Fake{
+		GetCorporateActionsFunc: func(_ context.Context, req marketdata.GetCorporateActionsRequest) (
+			marketdata.CorporateActions, error,
+		) {
+			requested = append(requested, req.Symbols[0]+" "+req.Start.String()+" "+req.End.String())
+			return marketdata.CorporateActions{
+				CashDividends: []marketdata.CashDividend{
+					{Symbol: req.Symbols[0], Rate: 0.25, ProcessDate: civil.Date{Year: 2024, Month: 2, Day: 16}},
+				},
+				ForwardSplits: []marketdata.ForwardSplit{
+					{Symbol: req.Symbols[0], NewRate: 4, ProcessDate: civil.Date{Year: 2024, Month: 3, Day: 9}},
+				},
+			}, nil
+		},
+	}
+	c := newTestCache(t, t.TempDir(), source)
+	ctx := context.Background()
+	req := marketdata.GetCorporateActionsRequest{Start: civil.Date{Year: 2024, Month: 1, Day: 1}}
+
+	actions, err := c.GetCorporateActions(ctx, []string{"AAPL", "MSFT"}, req)
+	require.NoError(t, err)
+	require.Len(t, actions["MSFT"].CashDividends, 1)
+	assert.Equal(t, 0.25, actions["MSFT"].CashDividends[0].Rate)
+	assert.Len(t, actions["MSFT"].ForwardSplits, 1)
+	assert.Equal(t, []string{"AAPL 2024-01-01 2024-03-10", "MSFT 2024-01-01 2024-03-10"}, requested)
+
+	// The corporate actions of the last day are not final yet
+	requested = nil
+	actions, err = c.GetCorporateActions(ctx, []string{"AAPL"}, req)
+	require.NoError(t, err)
+	assert.Len(t, actions["AAPL"].CashDividends, 1)
+	assert.Len(t, actions["AAPL"].ForwardSplits, 1)
+	assert.Equal(t, []string{"AAPL 2024-03-09 2024-03-10"}, requested)
+}
+
+func TestUnsupportedRequests(t *testing.T) {
+	c := newTestCache(t, t.TempDir(), &marketdatatest.Fake{})
+	ctx := context.Background()
+
+	_, err := c.GetTrades(ctx, []string{"AAPL"}, marketdata.GetTradesRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Start is required")
+	_, err = c.GetQuotes(ctx, []string{"AAPL"}, marketdata.GetQuotesRequest{Start: date(1, 1), TotalLimit: 10})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+
+	// The errors of the source are returned
+	_, err = c.GetAuctions(ctx, []string{"AAPL"}, marketdata.GetAuctionsRequest{Start: date(1, 1)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache: failed to fetch AAPL")
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
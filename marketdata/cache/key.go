@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// Kind is the kind of the data of a cache entry.
+type Kind string
+
+// List of kinds
+const (
+	Bars             Kind = "bars"
+	Trades           Kind = "trades"
+	Quotes           Kind = "quotes"
+	Auctions         Kind = "auctions"
+	CorporateActions Kind = "corporate_actions"
+)
+
+// Key identifies the entry of a symbol in the cache: the requests of the symbol that only differ
+// by their time range share the same entry. The fields that don't apply to the kind are empty.
+type Key struct {
+	Kind       Kind
+	Symbol     string
+	Feed       string
+	TimeFrame  string
+	Adjustment string
+	Currency   string
+	AsOf       string
+	// Types are the comma-separated types of the corporate actions, sorted.
+	Types string
+}
+
+// BarsKey returns the key of the bars of the symbol requested with req. The Cache only stores
+// the raw bars (see Cache.GetBars).
+func BarsKey(symbol string, req marketdata.GetBarsRequest) Key {
+	timeframe := marketdata.OneDay
+	if req.TimeFrame.N != 0 {
+		timeframe = req.TimeFrame
+	}
+	adjustment := marketdata.AdjustmentRaw
+	if req.Adjustment != "" {
+		adjustment = req.Adjustment
+	}
+	return Key{
+		Kind:       Bars,
+		Symbol:     symbol,
+		Feed:       req.Feed,
+		TimeFrame:  timeframe.String(),
+		Adjustment: string(adjustment),
+		Currency:   req.Currency,
+		AsOf:       req.AsOf,
+	}
+}
+
+// TradesKey returns the key of the trades of the symbol requested with req.
+func TradesKey(symbol string, req marketdata.GetTradesRequest) Key {
+	return Key{Kind: Trades, Symbol: symbol, Feed: req.Feed, Currency: req.Currency, AsOf: req.AsOf}
+}
+
+// QuotesKey returns the key of the quotes of the symbol requested with req.
+func QuotesKey(symbol string, req marketdata.GetQuotesRequest) Key {
+	return Key{Kind: Quotes, Symbol: symbol, Feed: req.Feed, Currency: req.Currency, AsOf: req.AsOf}
+}
+
+// AuctionsKey returns the key of the auctions of the symbol requested with req.
+func AuctionsKey(symbol string, req marketdata.GetAuctionsRequest) Key {
+	return Key{Kind: Auctions, Symbol: symbol, Currency: req.Currency, AsOf: req.AsOf}
+}
+
+// CorporateActionsKey returns the key of the corporate actions of the symbol requested with req.
+func CorporateActionsKey(symbol string, req marketdata.GetCorporateActionsRequest) Key {
+	types := append([]string(nil), req.Types...)
+	sort.Strings(types)
+	return Key{Kind: CorporateActions, Symbol: symbol, Types: strings.Join(types, ",")}
+}
+
+// params returns the parameters of the key, other than its kind and symbol.
+func (k Key) params() url.Values {
+	q := url.Values{}
+	for name, value := range map[string]string{
+		"feed":       k.Feed,
+		"timeframe":  k.TimeFrame,
+		"adjustment": k.Adjustment,
+		"currency":   k.Currency,
+		"asof":       k.AsOf,
+		"types":      k.Types,
+	} {
+		if value != "" {
+			q.Set(name, value)
+		}
+	}
+	return q
+}
+
+// noParams is the directory name of the keys without parameters.
+const noParams = "_"
+
+// path returns the directory of the entry, relative to the directory of the cache:
+// kind/params/symbol, with the parameters and the symbol escaped.
+func (k Key) path() string {
+	params := k.params().Encode()
+	if params == "" {
+		params = noParams
+	}
+	return filepath.Join(string(k.Kind), params, url.QueryEscape(k.Symbol))
+}
+
+// parseKey parses the path of an entry, relative to the directory of the cache.
+func parseKey(path string) (Key, error) {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	if len(parts) != 3 {
+		return Key{}, fmt.Errorf("cache: invalid entry path %q", path)
+	}
+	symbol, err := url.QueryUnescape(parts[2])
+	if err != nil {
+		return Key{}, fmt.Errorf("cache: invalid entry path %q: %w", path, err)
+	}
+	k := Key{Kind: Kind(parts[0]), Symbol: symbol}
+	if parts[1] == noParams {
+		return k, nil
+	}
+	q, err := url.ParseQuery(parts[1])
+	if err != nil {
+		return Key{}, fmt.Errorf("cache: invalid entry path %q: %w", path, err)
+	}
+	k.Feed = q.Get("feed")
+	k.TimeFrame = q.Get("timeframe")
+	k.Adjustment = q.Get("adjustment")
+	k.Currency = q.Get("currency")
+	k.AsOf = q.Get("asof")
+	k.Types = q.Get("types")
+	return k, nil
+}
@@ -0,0 +1,396 @@
+package cache
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Range is the half-open time range [Start, End).
+type Range struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (r Range) empty() bool {
+	return !r.Start.Before(r.End)
+}
+
+// missing returns the parts of r that are not in the covered ranges, which must be sorted and
+// disjoint.
+func missing(covered []Range, r Range) []Range {
+	var gaps []Range
+	from := r.Start
+	for _, c := range covered {
+		if !c.End.After(from) {
+			continue
+		}
+		if !c.Start.Before(r.End) {
+			break
+		}
+		if c.Start.After(from) {
+			gaps = append(gaps, Range{Start: from, End: c.Start})
+		}
+		from = c.End
+	}
+	if from.Before(r.End) {
+		gaps = append(gaps, Range{Start: from, End: r.End})
+	}
+	return gaps
+}
+
+// addRange adds r to the covered ranges, merging the ranges that overlap or touch.
+func addRange(covered []Range, r Range) []Range {
+	all := append(append([]Range(nil), covered...), r)
+	sort.Slice(all, func(i, j int) bool { return all[i].Start.Before(all[j].Start) })
+	merged := all[:1]
+	for _, c := range all[1:] {
+		last := &merged[len(merged)-1]
+		if c.Start.After(last.End) {
+			merged = append(merged, c)
+		} else if c.End.After(last.End) {
+			last.End = c.End
+		}
+	}
+	return merged
+}
+
+// removeRange removes r from the covered ranges.
+func removeRange(covered []Range, r Range) []Range {
+	var kept []Range
+	for _, c := range covered {
+		if before := (Range{Start: c.Start, End: minTime(c.End, r.Start)}); !before.empty() {
+			kept = append(kept, before)
+		}
+		if after := (Range{Start: maxTime(c.Start, r.End), End: c.End}); !after.empty() {
+			kept = append(kept, after)
+		}
+	}
+	return kept
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// period is the time span of the records of a partition file.
+type period int
+
+const (
+	daily period = iota
+	monthly
+	yearly
+)
+
+func (p period) start(t time.Time) time.Time {
+	t = t.UTC()
+	switch p {
+	case daily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func (p period) next(t time.Time) time.Time {
+	switch p {
+	case daily:
+		return t.AddDate(0, 0, 1)
+	case monthly:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(1, 0, 0)
+	}
+}
+
+func (p period) file(t time.Time) string {
+	layout := map[period]string{daily: "2006-01-02", monthly: "2006-01", yearly: "2006"}[p]
+	return t.Format(layout) + ".json.gz"
+}
+
+// partitions calls f with each partition overlapping r and the part of r in it.
+func (p period) partitions(r Range, f func(file string, r Range) error) error {
+	for start := p.start(r.Start); start.Before(r.End); start = p.next(start) {
+		part := Range{Start: maxTime(start, r.Start), End: minTime(p.next(start), r.End)}
+		if err := f(p.file(start), part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// series describes how the records of a kind are stored.
+type series[T any] struct {
+	// time returns the time of a record.
+	time func(T) time.Time
+	// period is the time span of the partition files.
+	period period
+	// settle is how long after its time a record is final, e.g. the duration of a bar.
+	settle time.Duration
+}
+
+func (s series[T]) filter(records []T, r Range) []T {
+	var kept []T
+	for _, rec := range records {
+		if t := s.time(rec); !t.Before(r.Start) && t.Before(r.End) {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// index is the content of the index file of an entry.
+type index struct {
+	// Covered are the time ranges whose records are all stored, sorted and disjoint.
+	Covered []Range `json:"covered"`
+}
+
+const indexFile = "index.json"
+
+func readIndex(dir string) (index, error) {
+	var idx index
+	b, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return idx, fmt.Errorf("cache: failed to read the index: %w", err)
+	}
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return idx, fmt.Errorf("cache: failed to parse the index of %s: %w", dir, err)
+	}
+	return idx, nil
+}
+
+func writeIndex(dir string, idx index) error {
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, indexFile), func(f *os.File) error {
+		_, err := f.Write(b)
+		return err
+	})
+}
+
+func readPartition[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache: failed to read a partition: %w", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("cache: failed to read %s: %w", path, err)
+	}
+	var records []T
+	if err := json.NewDecoder(zr).Decode(&records); err != nil {
+		return nil, fmt.Errorf("cache: failed to parse %s: %w", path, err)
+	}
+	return records, nil
+}
+
+func writePartition[T any](path string, records []T) error {
+	if len(records) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("cache: failed to remove a partition: %w", err)
+		}
+		return nil
+	}
+	return writeFile(path, func(f *os.File) error {
+		zw := gzip.NewWriter(f)
+		if err := json.NewEncoder(zw).Encode(records); err != nil {
+			return err
+		}
+		return zw.Close()
+	})
+}
+
+// writeFile writes a file atomically: it's written to a temporary file first, which then
+// replaces the file, so that the readers never see a partially written file.
+func writeFile(path string, write func(f *os.File) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cache: failed to create the entry directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("cache: failed to create a file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("cache: failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cache: failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("cache: failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// store replaces the records of the entry in r with the given ones.
+func store[T any](dir string, s series[T], r Range, records []T) error {
+	return s.period.partitions(r, func(file string, part Range) error {
+		path := filepath.Join(dir, file)
+		existing, err := readPartition[T](path)
+		if err != nil {
+			return err
+		}
+		kept := s.filter(records, part)
+		for _, rec := range existing {
+			if t := s.time(rec); t.Before(part.Start) || !t.Before(part.End) {
+				kept = append(kept, rec)
+			}
+		}
+		sort.SliceStable(kept, func(i, j int) bool { return s.time(kept[i]).Before(s.time(kept[j])) })
+		return writePartition(path, kept)
+	})
+}
+
+// load returns the stored records of the entry in r.
+func load[T any](dir string, s series[T], r Range) ([]T, error) {
+	var records []T
+	err := s.period.partitions(r, func(file string, part Range) error {
+		stored, err := readPartition[T](filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		records = append(records, s.filter(stored, part)...)
+		return nil
+	})
+	return records, err
+}
+
+// fetchFunc fetches the records of the symbols in r.
+type fetchFunc[T any] func(ctx context.Context, symbols []string, r Range) (map[string][]T, error)
+
+// gap is a range missing from the entries of keys.
+type gap struct {
+	r    Range
+	keys []Key
+}
+
+// get returns the records of the keys in r. The missing ranges are fetched, the symbols missing
+// the same range together, and stored, except the part that is not final yet. The cache is only
+// locked while reading and writing the entries, not while fetching.
+func get[T any](
+	ctx context.Context, c *Cache, s series[T], keys []Key, r Range, fetch fetchFunc[T],
+) (map[string][]T, error) {
+	records, gaps, err := loadCovered(c, s, keys, r)
+	if err != nil {
+		return nil, err
+	}
+	// The records from the cutoff on may still change, they are returned but not stored
+	cutoff := c.now().Add(-c.opts.SettleDelay - s.settle)
+	for _, g := range gaps {
+		symbols := make([]string, len(g.keys))
+		for i, k := range g.keys {
+			symbols[i] = k.Symbol
+		}
+		fetched, err := fetch(ctx, symbols, g.r)
+		if err != nil {
+			return nil, fmt.Errorf("cache: failed to fetch %s from %s to %s: %w", strings.Join(symbols, ","),
+				g.r.Start.Format(time.RFC3339), g.r.End.Format(time.RFC3339), err)
+		}
+		final := Range{Start: g.r.Start, End: minTime(g.r.End, cutoff)}
+		for _, k := range g.keys {
+			records[k] = append(records[k], s.filter(fetched[k.Symbol], g.r)...)
+			if final.empty() {
+				continue
+			}
+			if err := save(c, s, k, final, fetched[k.Symbol]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := make(map[string][]T, len(keys))
+	for _, k := range keys {
+		if kept := records[k]; len(kept) > 0 {
+			sort.SliceStable(kept, func(i, j int) bool { return s.time(kept[i]).Before(s.time(kept[j])) })
+			result[k.Symbol] = kept
+		}
+	}
+	return result, nil
+}
+
+// loadCovered returns the stored records of the keys in r, and the gaps of r to fetch sorted by
+// their start.
+func loadCovered[T any](c *Cache, s series[T], keys []Key, r Range) (map[Key][]T, []*gap, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var gaps []*gap
+	byRange := make(map[string]*gap)
+	records := make(map[Key][]T, len(keys))
+	for _, k := range keys {
+		idx, err := readIndex(c.dir(k))
+		if err != nil {
+			return nil, nil, err
+		}
+		// The partitions may contain records of the invalidated ranges
+		for _, covered := range idx.Covered {
+			part := Range{Start: maxTime(covered.Start, r.Start), End: minTime(covered.End, r.End)}
+			if part.empty() {
+				continue
+			}
+			stored, err := load(c.dir(k), s, part)
+			if err != nil {
+				return nil, nil, err
+			}
+			records[k] = append(records[k], stored...)
+		}
+		for _, m := range missing(idx.Covered, r) {
+			id := m.Start.String() + "/" + m.End.String()
+			if byRange[id] == nil {
+				byRange[id] = &gap{r: m}
+				gaps = append(gaps, byRange[id])
+			}
+			byRange[id].keys = append(byRange[id].keys, k)
+		}
+	}
+	sort.SliceStable(gaps, func(i, j int) bool { return gaps[i].r.Start.Before(gaps[j].r.Start) })
+	return records, gaps, nil
+}
+
+// save stores the records of the entry of k in r and adds r to its index, which is read again
+// since it may have changed while the records were fetched.
+func save[T any](c *Cache, s series[T], k Key, r Range, records []T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir := c.dir(k)
+	idx, err := readIndex(dir)
+	if err != nil {
+		return err
+	}
+	if err := store(dir, s, r, records); err != nil {
+		return err
+	}
+	idx.Covered = addRange(idx.Covered, r)
+	return writeIndex(dir, idx)
+}
@@ -0,0 +1,365 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// Default values of the DownloadOpts.
+const (
+	DefaultDownloadConcurrency = 4
+	DefaultSymbolsPerShard     = 100
+	DefaultShardRetries        = 3
+)
+
+// downloadRequestDuration is the expected duration of a page request. With a RateLimiter, the
+// default concurrency is the number of requests it allows during that time, at most
+// maxDownloadConcurrency: more workers would only wait for the limiter.
+const (
+	downloadRequestDuration = 500 * time.Millisecond
+	maxDownloadConcurrency  = 32
+)
+
+// DownloadOpts contains the options of the parallel downloads, such as DownloadBars.
+type DownloadOpts struct {
+	// SymbolsPerShard is the maximum number of symbols of a shard. Defaults to
+	// DefaultSymbolsPerShard.
+	SymbolsPerShard int
+	// ShardDuration is the length of the time range of a shard: the [Start, End] range of the
+	// request is split into consecutive ranges of that length, which requires Start to be set
+	// (End defaults to now). If zero, the time range is not split.
+	ShardDuration time.Duration
+	// Concurrency is the maximum number of shards downloaded at the same time. If the client
+	// has a RateLimiter, it defaults to the number of requests it allows per half second (from
+	// 1 to 32), otherwise to DefaultDownloadConcurrency. Every request still waits for the
+	// RateLimiter, so the shards share the rate limit whatever the concurrency.
+	Concurrency int
+	// Retries is the number of times a failed shard is retried, resuming from its last
+	// downloaded page. Defaults to DefaultShardRetries, a negative value disables the retries.
+	// The shards are retried on top of the retries of the client's RetryPolicy.
+	Retries int
+	// RetryDelay is the delay before retrying a failed shard. Defaults to the RetryDelay of the
+	// client.
+	RetryDelay time.Duration
+}
+
+// ShardError is the error of a shard that failed to download, after its retries.
+type ShardError struct {
+	Symbols []string
+	// Start and End are the time range of the shard, zero if the request had none.
+	Start time.Time
+	End   time.Time
+	Err   error
+}
+
+func (e *ShardError) Error() string {
+	var timeRange string
+	if !e.Start.IsZero() {
+		timeRange += " from " + e.Start.Format(time.RFC3339Nano)
+	}
+	if !e.End.IsZero() {
+		timeRange += " to " + e.End.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("marketdata: failed to download %s%s: %v", formatSymbols(e.Symbols), timeRange, e.Err)
+}
+
+func (e *ShardError) Unwrap() error {
+	return e.Err
+}
+
+// shard is a part of a download, with a subset of the symbols and of the time range.
+type shard struct {
+	symbols []string
+	start   time.Time
+	end     time.Time
+}
+
+// downloadRequest contains the parameters of a request that matter to the sharding.
+type downloadRequest struct {
+	Start      time.Time
+	End        time.Time
+	Sort       Sort
+	TotalLimit int
+	PageToken  string
+}
+
+// DownloadTrades returns the trades for the given symbols like GetMultiTrades, but splits the
+// request into shards of symbols and time ranges that are downloaded concurrently.
+func (c *Client) DownloadTrades(
+	ctx context.Context, symbols []string, req GetTradesRequest, opts DownloadOpts,
+) (map[string][]Trade, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(ctx context.Context, s shard, token string) *PageIterator[Trade] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateTrades(ctx, s.symbols, r)
+	})
+}
+
+// DownloadQuotes returns the quotes for the given symbols like GetMultiQuotes, but splits the
+// request into shards of symbols and time ranges that are downloaded concurrently.
+func (c *Client) DownloadQuotes(
+	ctx context.Context, symbols []string, req GetQuotesRequest, opts DownloadOpts,
+) (map[string][]Quote, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(ctx context.Context, s shard, token string) *PageIterator[Quote] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateQuotes(ctx, s.symbols, r)
+	})
+}
+
+// DownloadBars returns the bars for the given symbols like GetMultiBars, but splits the
+// request into shards of symbols and time ranges that are downloaded concurrently, e.g. to
+// download years of minute bars of hundreds of symbols:
+//
+//	bars, err := client.DownloadBars(ctx, symbols, marketdata.GetBarsRequest{
+//		TimeFrame: marketdata.OneMin,
+//		Start:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+//		End:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+//	}, marketdata.DownloadOpts{ShardDuration: 30 * 24 * time.Hour})
+//
+// The bars of each symbol are merged in timestamp order, in the direction of req.Sort. A shard
+// that fails is retried on its own, and the download fails with a *ShardError if it still fails
+// after its retries. TotalLimit and PageToken are not supported.
+func (c *Client) DownloadBars(
+	ctx context.Context, symbols []string, req GetBarsRequest, opts DownloadOpts,
+) (map[string][]Bar, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(ctx context.Context, s shard, token string) *PageIterator[Bar] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateBars(ctx, s.symbols, r)
+	})
+}
+
+// DownloadCryptoTrades returns the crypto trades for the given symbols like
+// GetCryptoMultiTrades, but splits the request into shards of symbols and time ranges that are
+// downloaded concurrently.
+func (c *Client) DownloadCryptoTrades(
+	ctx context.Context, symbols []string, req GetCryptoTradesRequest, opts DownloadOpts,
+) (map[string][]CryptoTrade, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(
+		ctx context.Context, s shard, token string,
+	) *PageIterator[CryptoTrade] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateCryptoTrades(ctx, s.symbols, r)
+	})
+}
+
+// DownloadCryptoQuotes returns the crypto quotes for the given symbols like
+// GetCryptoMultiQuotes, but splits the request into shards of symbols and time ranges that are
+// downloaded concurrently.
+func (c *Client) DownloadCryptoQuotes(
+	ctx context.Context, symbols []string, req GetCryptoQuotesRequest, opts DownloadOpts,
+) (map[string][]CryptoQuote, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(
+		ctx context.Context, s shard, token string,
+	) *PageIterator[CryptoQuote] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateCryptoQuotes(ctx, s.symbols, r)
+	})
+}
+
+// DownloadCryptoBars returns the crypto bars for the given symbols like GetCryptoMultiBars,
+// but splits the request into shards of symbols and time ranges that are downloaded
+// concurrently.
+func (c *Client) DownloadCryptoBars(
+	ctx context.Context, symbols []string, req GetCryptoBarsRequest, opts DownloadOpts,
+) (map[string][]CryptoBar, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(
+		ctx context.Context, s shard, token string,
+	) *PageIterator[CryptoBar] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateCryptoBars(ctx, s.symbols, r)
+	})
+}
+
+// DownloadOptionTrades returns the option trades for the given symbols like
+// GetOptionMultiTrades, but splits the request into shards of symbols and time ranges that are
+// downloaded concurrently.
+func (c *Client) DownloadOptionTrades(
+	ctx context.Context, symbols []string, req GetOptionTradesRequest, opts DownloadOpts,
+) (map[string][]OptionTrade, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(
+		ctx context.Context, s shard, token string,
+	) *PageIterator[OptionTrade] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateOptionTrades(ctx, s.symbols, r)
+	})
+}
+
+// DownloadOptionBars returns the option bars for the given symbols like GetMultiOptionBars,
+// but splits the request into shards of symbols and time ranges that are downloaded
+// concurrently.
+func (c *Client) DownloadOptionBars(
+	ctx context.Context, symbols []string, req GetOptionBarsRequest, opts DownloadOpts,
+) (map[string][]OptionBar, error) {
+	dr := downloadRequest{req.Start, req.End, req.Sort, req.TotalLimit, req.PageToken}
+	return download(ctx, c, symbols, dr, opts, func(
+		ctx context.Context, s shard, token string,
+	) *PageIterator[OptionBar] {
+		r := req
+		r.Start, r.End, r.PageToken = s.start, s.end, token
+		return c.IterateOptionBars(ctx, s.symbols, r)
+	})
+}
+
+// iterateShard returns the iterator over the pages of a shard, starting from the page token.
+type iterateShard[T any] func(ctx context.Context, s shard, token string) *PageIterator[T]
+
+func download[T any](
+	ctx context.Context, c *Client, symbols []string, req downloadRequest, opts DownloadOpts, iterate iterateShard[T],
+) (map[string][]T, error) {
+	if req.TotalLimit != 0 || req.PageToken != "" {
+		return nil, errors.New("marketdata: TotalLimit and PageToken are not supported by the downloads")
+	}
+	opts = c.downloadDefaults(opts)
+	shards, err := splitShards(symbols, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	results, err := downloadShards(ctx, shards, opts, iterate)
+	if err != nil {
+		return nil, err
+	}
+	// The shards are in ascending time order, and a symbol is in a single shard per time range
+	if req.Sort == SortDesc {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
+	records := make(map[string][]T, len(symbols))
+	for _, r := range results {
+		for symbol, s := range r {
+			records[symbol] = append(records[symbol], s...)
+		}
+	}
+	return records, nil
+}
+
+func (c *Client) downloadDefaults(opts DownloadOpts) DownloadOpts {
+	if opts.SymbolsPerShard <= 0 {
+		opts.SymbolsPerShard = DefaultSymbolsPerShard
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultDownloadConcurrency
+		if rate := c.opts.RateLimiter.Rate(); rate > 0 {
+			n := int(math.Ceil(rate * downloadRequestDuration.Seconds()))
+			opts.Concurrency = min(max(n, 1), maxDownloadConcurrency)
+		}
+	}
+	if opts.Retries == 0 {
+		opts.Retries = DefaultShardRetries
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = c.opts.RetryDelay
+	}
+	return opts
+}
+
+// splitShards splits the symbols and the time range of the request into shards, in ascending
+// time order.
+func splitShards(symbols []string, req downloadRequest, opts DownloadOpts) ([]shard, error) {
+	type timeRange struct{ start, end time.Time }
+	ranges := []timeRange{{req.Start, req.End}}
+	if opts.ShardDuration > 0 {
+		if req.Start.IsZero() {
+			return nil, errors.New("marketdata: Start is required to split the time range")
+		}
+		end := req.End
+		if end.IsZero() {
+			end = time.Now()
+		}
+		ranges = ranges[:0]
+		for start := req.Start; !start.After(end); start = start.Add(opts.ShardDuration) {
+			// Both ends are inclusive, so the ranges must not share their bounds
+			r := timeRange{start, start.Add(opts.ShardDuration - time.Nanosecond)}
+			if r.end.After(end) {
+				r.end = end
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	shards := make([]shard, 0, len(ranges)*((len(symbols)+opts.SymbolsPerShard-1)/opts.SymbolsPerShard))
+	for _, r := range ranges {
+		for i := 0; i < len(symbols); i += opts.SymbolsPerShard {
+			shards = append(shards, shard{
+				symbols: symbols[i:min(i+opts.SymbolsPerShard, len(symbols))],
+				start:   r.start,
+				end:     r.end,
+			})
+		}
+	}
+	return shards, nil
+}
+
+// downloadShards downloads the shards with opts.Concurrency workers. The first shard that fails
+// cancels the others.
+func downloadShards[T any](
+	ctx context.Context, shards []shard, opts DownloadOpts, iterate iterateShard[T],
+) ([]map[string][]T, error) {
+	results := make([]map[string][]T, len(shards))
+	err := runParallel(ctx, len(shards), opts.Concurrency, func(ctx context.Context, i int) error {
+		records, err := downloadShard(ctx, shards[i], opts, iterate)
+		results[i] = records
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// downloadShard downloads the pages of a shard. After a failure, the shard is resumed from the
+// page that failed.
+func downloadShard[T any](
+	ctx context.Context, s shard, opts DownloadOpts, iterate iterateShard[T],
+) (map[string][]T, error) {
+	records := make(map[string][]T, len(s.symbols))
+	token := ""
+	for attempt := 0; ; attempt++ {
+		it := iterate(ctx, s, token)
+		for it.Next() {
+			for symbol, r := range it.Page() {
+				records[symbol] = append(records[symbol], r...)
+			}
+			token = it.NextPageToken()
+		}
+		err := it.Err()
+		if err == nil {
+			return records, nil
+		}
+		if attempt >= opts.Retries || !isRetryableShardError(ctx, err) {
+			return nil, &ShardError{Symbols: s.symbols, Start: s.start, End: s.end, Err: err}
+		}
+		if err := sleepContext(ctx, opts.RetryDelay); err != nil {
+			return nil, &ShardError{Symbols: s.symbols, Start: s.start, End: s.end, Err: err}
+		}
+	}
+}
+
+// isRetryableShardError reports whether retrying a shard that failed with err may help, i.e.
+// unless the download is cancelled or the request is invalid.
+func isRetryableShardError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *alpaca.APIError
+	return !errors.As(err, &apiErr) || apiErr.StatusCode >= http.StatusInternalServerError ||
+		apiErr.StatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,171 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// shardedBars serves two pages per request with a bar per symbol on the start and end days of the
+// request. fail is called before serving a request, and can make it fail.
+func shardedBars(fail func(req *http.Request) error) func(_ *Client, req *http.Request) (*http.Response, error) {
+	return func(_ *Client, req *http.Request) (*http.Response, error) {
+		if err := fail(req); err != nil {
+			return nil, err
+		}
+		q := req.URL.Query()
+		t := q.Get("start")
+		next := `"next"`
+		if q.Get("page_token") == "next" {
+			end, _ := time.Parse(time.RFC3339Nano, q.Get("end"))
+			t = end.Truncate(24 * time.Hour).Format(time.RFC3339)
+			next = "null"
+		}
+		bars := make([]string, 0)
+		for _, symbol := range strings.Split(q.Get("symbols"), ",") {
+			bars = append(bars, fmt.Sprintf(`"%s":[{"t":"%s","c":1}]`, symbol, t))
+		}
+		resp := fmt.Sprintf(`{"bars":{%s},"next_page_token":%s}`, strings.Join(bars, ","), next)
+		return &http.Response{Body: io.NopCloser(strings.NewReader(resp))}, nil
+	}
+}
+
+func barTimes(bars []Bar) []string {
+	times := make([]string, len(bars))
+	for i, b := range bars {
+		times[i] = b.Timestamp.Format(time.DateOnly)
+	}
+	return times
+}
+
+func TestDownloadBars(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+	c.do = shardedBars(func(req *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		q := req.URL.Query()
+		key := q.Get("symbols") + " " + q.Get("start") + " " + q.Get("page_token")
+		requests[key]++
+		// The second page of a shard fails once
+		if key == "MSFT 2024-01-03T00:00:00Z next" && requests[key] == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+
+	req := GetBarsRequest{
+		TimeFrame: OneDay,
+		Start:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+	}
+	opts := DownloadOpts{SymbolsPerShard: 2, ShardDuration: 48 * time.Hour, Concurrency: 3, RetryDelay: time.Millisecond}
+	bars, err := c.DownloadBars(context.Background(), []string{"AAPL", "AMZN", "MSFT"}, req, opts)
+	require.NoError(t, err)
+	require.Len(t, bars, 3)
+	want := []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05", "2024-01-05"}
+	for _, symbol := range []string{"AAPL", "AMZN", "MSFT"} {
+		assert.Equal(t, want, barTimes(bars[symbol]), symbol)
+	}
+	// 2 symbol shards * 3 time shards * 2 pages, and the failed page is retried on its own
+	assert.Len(t, requests, 12)
+	assert.Equal(t, 2, requests["MSFT 2024-01-03T00:00:00Z next"])
+	assert.Equal(t, 1, requests["MSFT 2024-01-03T00:00:00Z "])
+
+	req.Sort = SortDesc
+	bars, err = c.DownloadBars(context.Background(), []string{"AAPL"}, req, opts)
+	require.NoError(t, err)
+	// The shards are merged in descending order
+	assert.Equal(t, []string{"2024-01-05", "2024-01-05", "2024-01-03", "2024-01-04", "2024-01-01", "2024-01-02"},
+		barTimes(bars["AAPL"]))
+}
+
+func TestDownloadBars_NoSharding(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	var requests int
+	c.do = shardedBars(func(*http.Request) error {
+		requests++
+		return nil
+	})
+	bars, err := c.DownloadBars(context.Background(), []string{"AAPL", "MSFT"}, GetBarsRequest{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+	}, DownloadOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"2024-01-01", "2024-01-05"}, barTimes(bars["MSFT"]))
+}
+
+func TestDownloadDefaults_Concurrency(t *testing.T) {
+	assert.Equal(t, DefaultDownloadConcurrency, NewClient(ClientOpts{}).downloadDefaults(DownloadOpts{}).Concurrency)
+	// The concurrency follows the rate limit: 200 requests per minute allow 2 requests per half second
+	c := NewClient(ClientOpts{RateLimiter: alpaca.NewRateLimiter(200, time.Minute)})
+	assert.Equal(t, 2, c.downloadDefaults(DownloadOpts{}).Concurrency)
+	assert.Equal(t, 8, c.downloadDefaults(DownloadOpts{Concurrency: 8}).Concurrency)
+	c = NewClient(ClientOpts{RateLimiter: alpaca.NewRateLimiter(10000, time.Minute)})
+	assert.Equal(t, maxDownloadConcurrency, c.downloadDefaults(DownloadOpts{}).Concurrency)
+}
+
+func TestDownloadBars_Errors(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	ctx := context.Background()
+	symbols := []string{"AAPL", "AMZN", "MSFT", "NVDA", "TSLA"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := c.DownloadBars(ctx, symbols, GetBarsRequest{TotalLimit: 10}, DownloadOpts{})
+	require.Error(t, err)
+	_, err = c.DownloadBars(ctx, symbols, GetBarsRequest{}, DownloadOpts{ShardDuration: time.Hour})
+	require.Error(t, err)
+
+	// Invalid requests are not retried
+	var mu sync.Mutex
+	requests := 0
+	c.do = shardedBars(func(*http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		return &alpaca.APIError{StatusCode: http.StatusBadRequest, Message: "invalid symbol"}
+	})
+	_, err = c.DownloadBars(ctx, symbols, GetBarsRequest{Start: start}, DownloadOpts{Concurrency: 1})
+	var shardErr *ShardError
+	require.ErrorAs(t, err, &shardErr)
+	assert.Equal(t, symbols, shardErr.Symbols)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, "marketdata: failed to download AAPL and 4 other symbols from 2024-01-01T00:00:00Z: "+
+		"invalid symbol (HTTP 400)", err.Error())
+
+	// The other errors are retried until the retries are exhausted
+	requests = 0
+	c.do = shardedBars(func(req *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if req.URL.Query().Get("symbols") == "TSLA" {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	_, err = c.DownloadBars(ctx, symbols, GetBarsRequest{Start: start}, DownloadOpts{
+		SymbolsPerShard: 4,
+		Concurrency:     1,
+		Retries:         2,
+		RetryDelay:      time.Millisecond,
+	})
+	require.ErrorAs(t, err, &shardErr)
+	assert.Equal(t, []string{"TSLA"}, shardErr.Symbols)
+	assert.Equal(t, 2+3, requests)
+}
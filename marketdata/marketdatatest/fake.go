@@ -0,0 +1,1032 @@
+// Package marketdatatest provides test doubles for the market data API client: Fake, whose methods
+// can be stubbed one by one, and Recorder, which records the calls made to another
+// marketdata.MarketDataAPI.
+package marketdatatest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// ErrNotStubbed is returned by the methods of Fake that have not been stubbed.
+var ErrNotStubbed = errors.New("method not stubbed")
+
+func notStubbed(method string) error {
+	return fmt.Errorf("marketdatatest: %s: %w", method, ErrNotStubbed)
+}
+
+// Fake is a marketdata.MarketDataAPI whose methods call the corresponding function fields, e.g.
+// GetBars and GetBarsWithContext both call GetBarsFunc. The methods whose
+// function is not set return an error wrapping ErrNotStubbed. The methods without
+// a context pass context.Background() to the function.
+type Fake struct {
+	GetOptionTradesFunc func(
+		ctx context.Context, symbol string, req marketdata.GetOptionTradesRequest,
+	) ([]marketdata.OptionTrade, error)
+	GetOptionMultiTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetOptionTradesRequest,
+	) (map[string][]marketdata.OptionTrade, error)
+	GetOptionBarsFunc func(
+		ctx context.Context, symbol string, req marketdata.GetOptionBarsRequest,
+	) ([]marketdata.OptionBar, error)
+	GetMultiOptionBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetOptionBarsRequest,
+	) (map[string][]marketdata.OptionBar, error)
+	GetLatestOptionTradeFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestOptionTradeRequest,
+	) (*marketdata.OptionTrade, error)
+	GetLatestOptionTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestOptionTradeRequest,
+	) (map[string]marketdata.OptionTrade, error)
+	GetLatestOptionQuoteFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestOptionQuoteRequest,
+	) (*marketdata.OptionQuote, error)
+	GetLatestOptionQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestOptionQuoteRequest,
+	) (map[string]marketdata.OptionQuote, error)
+	GetOptionSnapshotFunc func(
+		ctx context.Context, symbol string, req marketdata.GetOptionSnapshotRequest,
+	) (*marketdata.OptionSnapshot, error)
+	GetOptionSnapshotsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetOptionSnapshotRequest,
+	) (map[string]marketdata.OptionSnapshot, error)
+	GetOptionChainFunc func(
+		ctx context.Context, underlyingSymbol string, req marketdata.GetOptionChainRequest,
+	) (map[string]marketdata.OptionSnapshot, error)
+	GetTradesFunc func(
+		ctx context.Context, symbol string, req marketdata.GetTradesRequest,
+	) ([]marketdata.Trade, error)
+	GetMultiTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetTradesRequest,
+	) (map[string][]marketdata.Trade, error)
+	GetQuotesFunc func(
+		ctx context.Context, symbol string, req marketdata.GetQuotesRequest,
+	) ([]marketdata.Quote, error)
+	GetMultiQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetQuotesRequest,
+	) (map[string][]marketdata.Quote, error)
+	GetBarsFunc      func(ctx context.Context, symbol string, req marketdata.GetBarsRequest) ([]marketdata.Bar, error)
+	GetMultiBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+	) (map[string][]marketdata.Bar, error)
+	GetAuctionsFunc func(
+		ctx context.Context, symbol string, req marketdata.GetAuctionsRequest,
+	) ([]marketdata.DailyAuctions, error)
+	GetMultiAuctionsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetAuctionsRequest,
+	) (map[string][]marketdata.DailyAuctions, error)
+	GetLatestBarFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestBarRequest,
+	) (*marketdata.Bar, error)
+	GetLatestBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestBarRequest,
+	) (map[string]marketdata.Bar, error)
+	GetLatestTradeFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestTradeRequest,
+	) (*marketdata.Trade, error)
+	GetLatestTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestTradeRequest,
+	) (map[string]marketdata.Trade, error)
+	GetLatestQuoteFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestQuoteRequest,
+	) (*marketdata.Quote, error)
+	GetLatestQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestQuoteRequest,
+	) (map[string]marketdata.Quote, error)
+	GetSnapshotFunc func(
+		ctx context.Context, symbol string, req marketdata.GetSnapshotRequest,
+	) (*marketdata.Snapshot, error)
+	GetSnapshotsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetSnapshotRequest,
+	) (map[string]*marketdata.Snapshot, error)
+	GetCryptoTradesFunc func(
+		ctx context.Context, symbol string, req marketdata.GetCryptoTradesRequest,
+	) ([]marketdata.CryptoTrade, error)
+	GetCryptoMultiTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoTradesRequest,
+	) (map[string][]marketdata.CryptoTrade, error)
+	GetCryptoQuotesFunc func(
+		ctx context.Context, symbol string, req marketdata.GetCryptoQuotesRequest,
+	) ([]marketdata.CryptoQuote, error)
+	GetCryptoMultiQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoQuotesRequest,
+	) (map[string][]marketdata.CryptoQuote, error)
+	GetCryptoBarsFunc func(
+		ctx context.Context, symbol string, req marketdata.GetCryptoBarsRequest,
+	) ([]marketdata.CryptoBar, error)
+	GetCryptoMultiBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoBarsRequest,
+	) (map[string][]marketdata.CryptoBar, error)
+	GetLatestCryptoBarFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoBarRequest,
+	) (*marketdata.CryptoBar, error)
+	GetLatestCryptoPerpBarFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoBarRequest,
+	) (*marketdata.CryptoPerpBar, error)
+	GetLatestCryptoPerpBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoBarRequest,
+	) (map[string]marketdata.CryptoPerpBar, error)
+	GetLatestCryptoBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoBarRequest,
+	) (map[string]marketdata.CryptoBar, error)
+	GetLatestCryptoTradeFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoTradeRequest,
+	) (*marketdata.CryptoTrade, error)
+	GetLatestCryptoPerpTradeFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoTradeRequest,
+	) (*marketdata.CryptoPerpTrade, error)
+	GetLatestCryptoPerpTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+	) (map[string]marketdata.CryptoPerpTrade, error)
+	GetLatestCryptoTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+	) (map[string]marketdata.CryptoTrade, error)
+	GetLatestCryptoQuoteFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+	) (*marketdata.CryptoQuote, error)
+	GetLatestCryptoPerpQuoteFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+	) (*marketdata.CryptoPerpQuote, error)
+	GetLatestCryptoPerpQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+	) (map[string]marketdata.CryptoPerpQuote, error)
+	GetLatestCryptoQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+	) (map[string]marketdata.CryptoQuote, error)
+	GetLatestCryptoPerpPricingFunc func(
+		ctx context.Context, symbol string, req marketdata.GetLatestCryptoPerpPricingRequest,
+	) (*marketdata.CryptoPerpPricing, error)
+	GetLatestCryptoPerpPricingDataFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetLatestCryptoPerpPricingRequest,
+	) (map[string]marketdata.CryptoPerpPricing, error)
+	GetCryptoSnapshotFunc func(
+		ctx context.Context, symbol string, req marketdata.GetCryptoSnapshotRequest,
+	) (*marketdata.CryptoSnapshot, error)
+	GetCryptoSnapshotsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoSnapshotRequest,
+	) (map[string]marketdata.CryptoSnapshot, error)
+	GetNewsFunc             func(ctx context.Context, req marketdata.GetNewsRequest) ([]marketdata.News, error)
+	GetCorporateActionsFunc func(
+		ctx context.Context, req marketdata.GetCorporateActionsRequest,
+	) (marketdata.CorporateActions, error)
+	GetFixedIncomeLatestPriceFunc  func(ctx context.Context, isin string) (*marketdata.FixedIncomePrice, error)
+	GetFixedIncomeLatestPricesFunc func(
+		ctx context.Context, isins []string,
+	) (map[string]marketdata.FixedIncomePrice, error)
+	DownloadTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetTradesRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.Trade, error)
+	DownloadQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetQuotesRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.Quote, error)
+	DownloadBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetBarsRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.Bar, error)
+	DownloadCryptoTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoTradesRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.CryptoTrade, error)
+	DownloadCryptoQuotesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoQuotesRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.CryptoQuote, error)
+	DownloadCryptoBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetCryptoBarsRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.CryptoBar, error)
+	DownloadOptionTradesFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetOptionTradesRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.OptionTrade, error)
+	DownloadOptionBarsFunc func(
+		ctx context.Context, symbols []string, req marketdata.GetOptionBarsRequest, opts marketdata.DownloadOpts,
+	) (map[string][]marketdata.OptionBar, error)
+}
+
+var _ marketdata.MarketDataAPI = (*Fake)(nil)
+
+func (f *Fake) GetOptionTrades(symbol string, req marketdata.GetOptionTradesRequest) ([]marketdata.OptionTrade, error) {
+	return f.GetOptionTradesWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetOptionTradesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetOptionTradesRequest,
+) ([]marketdata.OptionTrade, error) {
+	if f.GetOptionTradesFunc == nil {
+		return nil, notStubbed("GetOptionTrades")
+	}
+	return f.GetOptionTradesFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetOptionMultiTrades(
+	symbols []string, req marketdata.GetOptionTradesRequest,
+) (map[string][]marketdata.OptionTrade, error) {
+	return f.GetOptionMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetOptionMultiTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetOptionTradesRequest,
+) (map[string][]marketdata.OptionTrade, error) {
+	if f.GetOptionMultiTradesFunc == nil {
+		return nil, notStubbed("GetOptionMultiTrades")
+	}
+	return f.GetOptionMultiTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetOptionBars(symbol string, req marketdata.GetOptionBarsRequest) ([]marketdata.OptionBar, error) {
+	return f.GetOptionBarsWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetOptionBarsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetOptionBarsRequest,
+) ([]marketdata.OptionBar, error) {
+	if f.GetOptionBarsFunc == nil {
+		return nil, notStubbed("GetOptionBars")
+	}
+	return f.GetOptionBarsFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetMultiOptionBars(
+	symbols []string, req marketdata.GetOptionBarsRequest,
+) (map[string][]marketdata.OptionBar, error) {
+	return f.GetMultiOptionBarsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetMultiOptionBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetOptionBarsRequest,
+) (map[string][]marketdata.OptionBar, error) {
+	if f.GetMultiOptionBarsFunc == nil {
+		return nil, notStubbed("GetMultiOptionBars")
+	}
+	return f.GetMultiOptionBarsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestOptionTrade(
+	symbol string, req marketdata.GetLatestOptionTradeRequest,
+) (*marketdata.OptionTrade, error) {
+	return f.GetLatestOptionTradeWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestOptionTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestOptionTradeRequest,
+) (*marketdata.OptionTrade, error) {
+	if f.GetLatestOptionTradeFunc == nil {
+		return nil, notStubbed("GetLatestOptionTrade")
+	}
+	return f.GetLatestOptionTradeFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestOptionTrades(
+	symbols []string, req marketdata.GetLatestOptionTradeRequest,
+) (map[string]marketdata.OptionTrade, error) {
+	return f.GetLatestOptionTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestOptionTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestOptionTradeRequest,
+) (map[string]marketdata.OptionTrade, error) {
+	if f.GetLatestOptionTradesFunc == nil {
+		return nil, notStubbed("GetLatestOptionTrades")
+	}
+	return f.GetLatestOptionTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestOptionQuote(
+	symbol string, req marketdata.GetLatestOptionQuoteRequest,
+) (*marketdata.OptionQuote, error) {
+	return f.GetLatestOptionQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestOptionQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestOptionQuoteRequest,
+) (*marketdata.OptionQuote, error) {
+	if f.GetLatestOptionQuoteFunc == nil {
+		return nil, notStubbed("GetLatestOptionQuote")
+	}
+	return f.GetLatestOptionQuoteFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestOptionQuotes(
+	symbols []string, req marketdata.GetLatestOptionQuoteRequest,
+) (map[string]marketdata.OptionQuote, error) {
+	return f.GetLatestOptionQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestOptionQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestOptionQuoteRequest,
+) (map[string]marketdata.OptionQuote, error) {
+	if f.GetLatestOptionQuotesFunc == nil {
+		return nil, notStubbed("GetLatestOptionQuotes")
+	}
+	return f.GetLatestOptionQuotesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetOptionSnapshot(
+	symbol string, req marketdata.GetOptionSnapshotRequest,
+) (*marketdata.OptionSnapshot, error) {
+	return f.GetOptionSnapshotWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetOptionSnapshotWithContext(
+	ctx context.Context, symbol string, req marketdata.GetOptionSnapshotRequest,
+) (*marketdata.OptionSnapshot, error) {
+	if f.GetOptionSnapshotFunc == nil {
+		return nil, notStubbed("GetOptionSnapshot")
+	}
+	return f.GetOptionSnapshotFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetOptionSnapshots(
+	symbols []string, req marketdata.GetOptionSnapshotRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	return f.GetOptionSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetOptionSnapshotsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetOptionSnapshotRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	if f.GetOptionSnapshotsFunc == nil {
+		return nil, notStubbed("GetOptionSnapshots")
+	}
+	return f.GetOptionSnapshotsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetOptionChain(
+	underlyingSymbol string, req marketdata.GetOptionChainRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	return f.GetOptionChainWithContext(context.Background(), underlyingSymbol, req)
+}
+
+func (f *Fake) GetOptionChainWithContext(
+	ctx context.Context, underlyingSymbol string, req marketdata.GetOptionChainRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	if f.GetOptionChainFunc == nil {
+		return nil, notStubbed("GetOptionChain")
+	}
+	return f.GetOptionChainFunc(ctx, underlyingSymbol, req)
+}
+
+func (f *Fake) GetTrades(symbol string, req marketdata.GetTradesRequest) ([]marketdata.Trade, error) {
+	return f.GetTradesWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetTradesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetTradesRequest,
+) ([]marketdata.Trade, error) {
+	if f.GetTradesFunc == nil {
+		return nil, notStubbed("GetTrades")
+	}
+	return f.GetTradesFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetMultiTrades(
+	symbols []string, req marketdata.GetTradesRequest,
+) (map[string][]marketdata.Trade, error) {
+	return f.GetMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetMultiTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetTradesRequest,
+) (map[string][]marketdata.Trade, error) {
+	if f.GetMultiTradesFunc == nil {
+		return nil, notStubbed("GetMultiTrades")
+	}
+	return f.GetMultiTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetQuotes(symbol string, req marketdata.GetQuotesRequest) ([]marketdata.Quote, error) {
+	return f.GetQuotesWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetQuotesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetQuotesRequest,
+) ([]marketdata.Quote, error) {
+	if f.GetQuotesFunc == nil {
+		return nil, notStubbed("GetQuotes")
+	}
+	return f.GetQuotesFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetMultiQuotes(
+	symbols []string, req marketdata.GetQuotesRequest,
+) (map[string][]marketdata.Quote, error) {
+	return f.GetMultiQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetQuotesRequest,
+) (map[string][]marketdata.Quote, error) {
+	if f.GetMultiQuotesFunc == nil {
+		return nil, notStubbed("GetMultiQuotes")
+	}
+	return f.GetMultiQuotesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetBars(symbol string, req marketdata.GetBarsRequest) ([]marketdata.Bar, error) {
+	return f.GetBarsWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetBarsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetBarsRequest,
+) ([]marketdata.Bar, error) {
+	if f.GetBarsFunc == nil {
+		return nil, notStubbed("GetBars")
+	}
+	return f.GetBarsFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetMultiBars(symbols []string, req marketdata.GetBarsRequest) (map[string][]marketdata.Bar, error) {
+	return f.GetMultiBarsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetMultiBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+) (map[string][]marketdata.Bar, error) {
+	if f.GetMultiBarsFunc == nil {
+		return nil, notStubbed("GetMultiBars")
+	}
+	return f.GetMultiBarsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetAuctions(symbol string, req marketdata.GetAuctionsRequest) ([]marketdata.DailyAuctions, error) {
+	return f.GetAuctionsWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetAuctionsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetAuctionsRequest,
+) ([]marketdata.DailyAuctions, error) {
+	if f.GetAuctionsFunc == nil {
+		return nil, notStubbed("GetAuctions")
+	}
+	return f.GetAuctionsFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetMultiAuctions(
+	symbols []string, req marketdata.GetAuctionsRequest,
+) (map[string][]marketdata.DailyAuctions, error) {
+	return f.GetMultiAuctionsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetMultiAuctionsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetAuctionsRequest,
+) (map[string][]marketdata.DailyAuctions, error) {
+	if f.GetMultiAuctionsFunc == nil {
+		return nil, notStubbed("GetMultiAuctions")
+	}
+	return f.GetMultiAuctionsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestBar(symbol string, req marketdata.GetLatestBarRequest) (*marketdata.Bar, error) {
+	return f.GetLatestBarWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestBarWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestBarRequest,
+) (*marketdata.Bar, error) {
+	if f.GetLatestBarFunc == nil {
+		return nil, notStubbed("GetLatestBar")
+	}
+	return f.GetLatestBarFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestBars(symbols []string, req marketdata.GetLatestBarRequest) (map[string]marketdata.Bar, error) {
+	return f.GetLatestBarsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestBarRequest,
+) (map[string]marketdata.Bar, error) {
+	if f.GetLatestBarsFunc == nil {
+		return nil, notStubbed("GetLatestBars")
+	}
+	return f.GetLatestBarsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestTrade(symbol string, req marketdata.GetLatestTradeRequest) (*marketdata.Trade, error) {
+	return f.GetLatestTradeWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestTradeRequest,
+) (*marketdata.Trade, error) {
+	if f.GetLatestTradeFunc == nil {
+		return nil, notStubbed("GetLatestTrade")
+	}
+	return f.GetLatestTradeFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestTrades(
+	symbols []string, req marketdata.GetLatestTradeRequest,
+) (map[string]marketdata.Trade, error) {
+	return f.GetLatestTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestTradeRequest,
+) (map[string]marketdata.Trade, error) {
+	if f.GetLatestTradesFunc == nil {
+		return nil, notStubbed("GetLatestTrades")
+	}
+	return f.GetLatestTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestQuote(symbol string, req marketdata.GetLatestQuoteRequest) (*marketdata.Quote, error) {
+	return f.GetLatestQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestQuoteRequest,
+) (*marketdata.Quote, error) {
+	if f.GetLatestQuoteFunc == nil {
+		return nil, notStubbed("GetLatestQuote")
+	}
+	return f.GetLatestQuoteFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestQuotes(
+	symbols []string, req marketdata.GetLatestQuoteRequest,
+) (map[string]marketdata.Quote, error) {
+	return f.GetLatestQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestQuoteRequest,
+) (map[string]marketdata.Quote, error) {
+	if f.GetLatestQuotesFunc == nil {
+		return nil, notStubbed("GetLatestQuotes")
+	}
+	return f.GetLatestQuotesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetSnapshot(symbol string, req marketdata.GetSnapshotRequest) (*marketdata.Snapshot, error) {
+	return f.GetSnapshotWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetSnapshotWithContext(
+	ctx context.Context, symbol string, req marketdata.GetSnapshotRequest,
+) (*marketdata.Snapshot, error) {
+	if f.GetSnapshotFunc == nil {
+		return nil, notStubbed("GetSnapshot")
+	}
+	return f.GetSnapshotFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetSnapshots(
+	symbols []string, req marketdata.GetSnapshotRequest,
+) (map[string]*marketdata.Snapshot, error) {
+	return f.GetSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetSnapshotsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetSnapshotRequest,
+) (map[string]*marketdata.Snapshot, error) {
+	if f.GetSnapshotsFunc == nil {
+		return nil, notStubbed("GetSnapshots")
+	}
+	return f.GetSnapshotsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetCryptoTrades(symbol string, req marketdata.GetCryptoTradesRequest) ([]marketdata.CryptoTrade, error) {
+	return f.GetCryptoTradesWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetCryptoTradesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoTradesRequest,
+) ([]marketdata.CryptoTrade, error) {
+	if f.GetCryptoTradesFunc == nil {
+		return nil, notStubbed("GetCryptoTrades")
+	}
+	return f.GetCryptoTradesFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetCryptoMultiTrades(
+	symbols []string, req marketdata.GetCryptoTradesRequest,
+) (map[string][]marketdata.CryptoTrade, error) {
+	return f.GetCryptoMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetCryptoMultiTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoTradesRequest,
+) (map[string][]marketdata.CryptoTrade, error) {
+	if f.GetCryptoMultiTradesFunc == nil {
+		return nil, notStubbed("GetCryptoMultiTrades")
+	}
+	return f.GetCryptoMultiTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetCryptoQuotes(symbol string, req marketdata.GetCryptoQuotesRequest) ([]marketdata.CryptoQuote, error) {
+	return f.GetCryptoQuotesWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetCryptoQuotesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoQuotesRequest,
+) ([]marketdata.CryptoQuote, error) {
+	if f.GetCryptoQuotesFunc == nil {
+		return nil, notStubbed("GetCryptoQuotes")
+	}
+	return f.GetCryptoQuotesFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetCryptoMultiQuotes(
+	symbols []string, req marketdata.GetCryptoQuotesRequest,
+) (map[string][]marketdata.CryptoQuote, error) {
+	return f.GetCryptoMultiQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetCryptoMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoQuotesRequest,
+) (map[string][]marketdata.CryptoQuote, error) {
+	if f.GetCryptoMultiQuotesFunc == nil {
+		return nil, notStubbed("GetCryptoMultiQuotes")
+	}
+	return f.GetCryptoMultiQuotesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetCryptoBars(symbol string, req marketdata.GetCryptoBarsRequest) ([]marketdata.CryptoBar, error) {
+	return f.GetCryptoBarsWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetCryptoBarsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoBarsRequest,
+) ([]marketdata.CryptoBar, error) {
+	if f.GetCryptoBarsFunc == nil {
+		return nil, notStubbed("GetCryptoBars")
+	}
+	return f.GetCryptoBarsFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetCryptoMultiBars(
+	symbols []string, req marketdata.GetCryptoBarsRequest,
+) (map[string][]marketdata.CryptoBar, error) {
+	return f.GetCryptoMultiBarsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetCryptoMultiBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoBarsRequest,
+) (map[string][]marketdata.CryptoBar, error) {
+	if f.GetCryptoMultiBarsFunc == nil {
+		return nil, notStubbed("GetCryptoMultiBars")
+	}
+	return f.GetCryptoMultiBarsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoBar(
+	symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoBar, error) {
+	return f.GetLatestCryptoBarWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoBarWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoBar, error) {
+	if f.GetLatestCryptoBarFunc == nil {
+		return nil, notStubbed("GetLatestCryptoBar")
+	}
+	return f.GetLatestCryptoBarFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpBar(
+	symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoPerpBar, error) {
+	return f.GetLatestCryptoPerpBarWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpBarWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoPerpBar, error) {
+	if f.GetLatestCryptoPerpBarFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpBar")
+	}
+	return f.GetLatestCryptoPerpBarFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpBars(
+	symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoPerpBar, error) {
+	return f.GetLatestCryptoPerpBarsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoPerpBar, error) {
+	if f.GetLatestCryptoPerpBarsFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpBars")
+	}
+	return f.GetLatestCryptoPerpBarsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoBars(
+	symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoBar, error) {
+	return f.GetLatestCryptoBarsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoBar, error) {
+	if f.GetLatestCryptoBarsFunc == nil {
+		return nil, notStubbed("GetLatestCryptoBars")
+	}
+	return f.GetLatestCryptoBarsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoTrade(
+	symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoTrade, error) {
+	return f.GetLatestCryptoTradeWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoTrade, error) {
+	if f.GetLatestCryptoTradeFunc == nil {
+		return nil, notStubbed("GetLatestCryptoTrade")
+	}
+	return f.GetLatestCryptoTradeFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpTrade(
+	symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoPerpTrade, error) {
+	return f.GetLatestCryptoPerpTradeWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoPerpTrade, error) {
+	if f.GetLatestCryptoPerpTradeFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpTrade")
+	}
+	return f.GetLatestCryptoPerpTradeFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpTrades(
+	symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoPerpTrade, error) {
+	return f.GetLatestCryptoPerpTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoPerpTrade, error) {
+	if f.GetLatestCryptoPerpTradesFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpTrades")
+	}
+	return f.GetLatestCryptoPerpTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoTrades(
+	symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoTrade, error) {
+	return f.GetLatestCryptoTradesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoTrade, error) {
+	if f.GetLatestCryptoTradesFunc == nil {
+		return nil, notStubbed("GetLatestCryptoTrades")
+	}
+	return f.GetLatestCryptoTradesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoQuote(
+	symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoQuote, error) {
+	return f.GetLatestCryptoQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoQuote, error) {
+	if f.GetLatestCryptoQuoteFunc == nil {
+		return nil, notStubbed("GetLatestCryptoQuote")
+	}
+	return f.GetLatestCryptoQuoteFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpQuote(
+	symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoPerpQuote, error) {
+	return f.GetLatestCryptoPerpQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoPerpQuote, error) {
+	if f.GetLatestCryptoPerpQuoteFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpQuote")
+	}
+	return f.GetLatestCryptoPerpQuoteFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpQuotes(
+	symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoPerpQuote, error) {
+	return f.GetLatestCryptoPerpQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoPerpQuote, error) {
+	if f.GetLatestCryptoPerpQuotesFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpQuotes")
+	}
+	return f.GetLatestCryptoPerpQuotesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoQuotes(
+	symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoQuote, error) {
+	return f.GetLatestCryptoQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoQuote, error) {
+	if f.GetLatestCryptoQuotesFunc == nil {
+		return nil, notStubbed("GetLatestCryptoQuotes")
+	}
+	return f.GetLatestCryptoQuotesFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpPricing(
+	symbol string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (*marketdata.CryptoPerpPricing, error) {
+	return f.GetLatestCryptoPerpPricingWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpPricingWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (*marketdata.CryptoPerpPricing, error) {
+	if f.GetLatestCryptoPerpPricingFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpPricing")
+	}
+	return f.GetLatestCryptoPerpPricingFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpPricingData(
+	symbols []string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (map[string]marketdata.CryptoPerpPricing, error) {
+	return f.GetLatestCryptoPerpPricingDataWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetLatestCryptoPerpPricingDataWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (map[string]marketdata.CryptoPerpPricing, error) {
+	if f.GetLatestCryptoPerpPricingDataFunc == nil {
+		return nil, notStubbed("GetLatestCryptoPerpPricingData")
+	}
+	return f.GetLatestCryptoPerpPricingDataFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetCryptoSnapshot(
+	symbol string, req marketdata.GetCryptoSnapshotRequest,
+) (*marketdata.CryptoSnapshot, error) {
+	return f.GetCryptoSnapshotWithContext(context.Background(), symbol, req)
+}
+
+func (f *Fake) GetCryptoSnapshotWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoSnapshotRequest,
+) (*marketdata.CryptoSnapshot, error) {
+	if f.GetCryptoSnapshotFunc == nil {
+		return nil, notStubbed("GetCryptoSnapshot")
+	}
+	return f.GetCryptoSnapshotFunc(ctx, symbol, req)
+}
+
+func (f *Fake) GetCryptoSnapshots(
+	symbols []string, req marketdata.GetCryptoSnapshotRequest,
+) (map[string]marketdata.CryptoSnapshot, error) {
+	return f.GetCryptoSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+func (f *Fake) GetCryptoSnapshotsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoSnapshotRequest,
+) (map[string]marketdata.CryptoSnapshot, error) {
+	if f.GetCryptoSnapshotsFunc == nil {
+		return nil, notStubbed("GetCryptoSnapshots")
+	}
+	return f.GetCryptoSnapshotsFunc(ctx, symbols, req)
+}
+
+func (f *Fake) GetNews(req marketdata.GetNewsRequest) ([]marketdata.News, error) {
+	return f.GetNewsWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetNewsWithContext(ctx context.Context, req marketdata.GetNewsRequest) ([]marketdata.News, error) {
+	if f.GetNewsFunc == nil {
+		return nil, notStubbed("GetNews")
+	}
+	return f.GetNewsFunc(ctx, req)
+}
+
+func (f *Fake) GetCorporateActions(req marketdata.GetCorporateActionsRequest) (marketdata.CorporateActions, error) {
+	return f.GetCorporateActionsWithContext(context.Background(), req)
+}
+
+func (f *Fake) GetCorporateActionsWithContext(
+	ctx context.Context, req marketdata.GetCorporateActionsRequest,
+) (marketdata.CorporateActions, error) {
+	if f.GetCorporateActionsFunc == nil {
+		return marketdata.CorporateActions{}, notStubbed("GetCorporateActions")
+	}
+	return f.GetCorporateActionsFunc(ctx, req)
+}
+
+func (f *Fake) GetFixedIncomeLatestPrice(isin string) (*marketdata.FixedIncomePrice, error) {
+	return f.GetFixedIncomeLatestPriceWithContext(context.Background(), isin)
+}
+
+func (f *Fake) GetFixedIncomeLatestPriceWithContext(
+	ctx context.Context, isin string,
+) (*marketdata.FixedIncomePrice, error) {
+	if f.GetFixedIncomeLatestPriceFunc == nil {
+		return nil, notStubbed("GetFixedIncomeLatestPrice")
+	}
+	return f.GetFixedIncomeLatestPriceFunc(ctx, isin)
+}
+
+func (f *Fake) GetFixedIncomeLatestPrices(isins []string) (map[string]marketdata.FixedIncomePrice, error) {
+	return f.GetFixedIncomeLatestPricesWithContext(context.Background(), isins)
+}
+
+func (f *Fake) GetFixedIncomeLatestPricesWithContext(
+	ctx context.Context, isins []string,
+) (map[string]marketdata.FixedIncomePrice, error) {
+	if f.GetFixedIncomeLatestPricesFunc == nil {
+		return nil, notStubbed("GetFixedIncomeLatestPrices")
+	}
+	return f.GetFixedIncomeLatestPricesFunc(ctx, isins)
+}
+
+func (f *Fake) DownloadTrades(
+	ctx context.Context, symbols []string, req marketdata.GetTradesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.Trade, error) {
+	if f.DownloadTradesFunc == nil {
+		return nil, notStubbed("DownloadTrades")
+	}
+	return f.DownloadTradesFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadQuotes(
+	ctx context.Context, symbols []string, req marketdata.GetQuotesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.Quote, error) {
+	if f.DownloadQuotesFunc == nil {
+		return nil, notStubbed("DownloadQuotes")
+	}
+	return f.DownloadQuotesFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadBars(
+	ctx context.Context, symbols []string, req marketdata.GetBarsRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.Bar, error) {
+	if f.DownloadBarsFunc == nil {
+		return nil, notStubbed("DownloadBars")
+	}
+	return f.DownloadBarsFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadCryptoTrades(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoTradesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.CryptoTrade, error) {
+	if f.DownloadCryptoTradesFunc == nil {
+		return nil, notStubbed("DownloadCryptoTrades")
+	}
+	return f.DownloadCryptoTradesFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadCryptoQuotes(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoQuotesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.CryptoQuote, error) {
+	if f.DownloadCryptoQuotesFunc == nil {
+		return nil, notStubbed("DownloadCryptoQuotes")
+	}
+	return f.DownloadCryptoQuotesFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadCryptoBars(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoBarsRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.CryptoBar, error) {
+	if f.DownloadCryptoBarsFunc == nil {
+		return nil, notStubbed("DownloadCryptoBars")
+	}
+	return f.DownloadCryptoBarsFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadOptionTrades(
+	ctx context.Context, symbols []string, req marketdata.GetOptionTradesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.OptionTrade, error) {
+	if f.DownloadOptionTradesFunc == nil {
+		return nil, notStubbed("DownloadOptionTrades")
+	}
+	return f.DownloadOptionTradesFunc(ctx, symbols, req, opts)
+}
+
+func (f *Fake) DownloadOptionBars(
+	ctx context.Context, symbols []string, req marketdata.GetOptionBarsRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.OptionBar, error) {
+	if f.DownloadOptionBarsFunc == nil {
+		return nil, notStubbed("DownloadOptionBars")
+	}
+	return f.DownloadOptionBarsFunc(ctx, symbols, req, opts)
+}
@@ -0,0 +1,42 @@
+package marketdatatest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+func TestFakeAndRecorder(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 13, 30, 0, 0, time.UTC)
+	fake := &Fake{
+		GetBarsFunc: func(_ context.Context, symbol string, req marketdata.GetBarsRequest) ([]marketdata.Bar, error) {
+			assert.Equal(t, "AAPL", symbol)
+			return []marketdata.Bar{{Timestamp: req.Start, Close: 100}}, nil
+		},
+	}
+	r := NewRecorder(fake)
+	var api marketdata.MarketDataAPI = r
+
+	req := marketdata.GetBarsRequest{TimeFrame: marketdata.OneMin, Start: ts}
+	bars, err := api.GetBars("AAPL", req)
+	require.NoError(t, err)
+	assert.Equal(t, []marketdata.Bar{{Timestamp: ts, Close: 100}}, bars)
+
+	_, err = api.GetLatestQuotesWithContext(context.Background(), []string{"AAPL"}, marketdata.GetLatestQuoteRequest{})
+	require.ErrorIs(t, err, ErrNotStubbed)
+	_, err = api.DownloadBars(context.Background(), []string{"AAPL"}, req, marketdata.DownloadOpts{})
+	require.ErrorIs(t, err, ErrNotStubbed)
+
+	calls := r.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, Call{Method: "GetBars", Args: []interface{}{"AAPL", req}}, calls[0])
+	assert.Equal(t, "GetLatestQuotes", calls[1].Method)
+	assert.ErrorIs(t, calls[1].Err, ErrNotStubbed)
+	assert.Equal(t, "DownloadBars", calls[2].Method)
+	assert.Len(t, r.CallsTo("GetBars"), 1)
+}
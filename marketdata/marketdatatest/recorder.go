@@ -0,0 +1,841 @@
+package marketdatatest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// Call is a method call recorded by Recorder.
+type Call struct {
+	// Method is the name of the method, without the WithContext suffix.
+	Method string
+	// Args are the arguments of the call, except the context.
+	Args []interface{}
+	// Err is the error returned by the call.
+	Err error
+}
+
+// Recorder is a marketdata.MarketDataAPI that forwards the calls to another marketdata.MarketDataAPI
+// (typically a Fake) and records them in memory. It's safe for concurrent use.
+type Recorder struct {
+	api marketdata.MarketDataAPI
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ marketdata.MarketDataAPI = (*Recorder)(nil)
+
+// NewRecorder returns a Recorder forwarding the calls to api.
+func NewRecorder(api marketdata.MarketDataAPI) *Recorder {
+	return &Recorder{api: api}
+}
+
+// Calls returns the recorded calls in the order they were made.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls of the given method (without the WithContext suffix).
+func (r *Recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *Recorder) record(method string, err error, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args, Err: err})
+}
+
+func (r *Recorder) GetOptionTrades(
+	symbol string, req marketdata.GetOptionTradesRequest,
+) ([]marketdata.OptionTrade, error) {
+	return r.GetOptionTradesWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetOptionTradesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetOptionTradesRequest,
+) ([]marketdata.OptionTrade, error) {
+	res, err := r.api.GetOptionTradesWithContext(ctx, symbol, req)
+	r.record("GetOptionTrades", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetOptionMultiTrades(
+	symbols []string, req marketdata.GetOptionTradesRequest,
+) (map[string][]marketdata.OptionTrade, error) {
+	return r.GetOptionMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetOptionMultiTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetOptionTradesRequest,
+) (map[string][]marketdata.OptionTrade, error) {
+	res, err := r.api.GetOptionMultiTradesWithContext(ctx, symbols, req)
+	r.record("GetOptionMultiTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetOptionBars(symbol string, req marketdata.GetOptionBarsRequest) ([]marketdata.OptionBar, error) {
+	return r.GetOptionBarsWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetOptionBarsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetOptionBarsRequest,
+) ([]marketdata.OptionBar, error) {
+	res, err := r.api.GetOptionBarsWithContext(ctx, symbol, req)
+	r.record("GetOptionBars", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetMultiOptionBars(
+	symbols []string, req marketdata.GetOptionBarsRequest,
+) (map[string][]marketdata.OptionBar, error) {
+	return r.GetMultiOptionBarsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetMultiOptionBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetOptionBarsRequest,
+) (map[string][]marketdata.OptionBar, error) {
+	res, err := r.api.GetMultiOptionBarsWithContext(ctx, symbols, req)
+	r.record("GetMultiOptionBars", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestOptionTrade(
+	symbol string, req marketdata.GetLatestOptionTradeRequest,
+) (*marketdata.OptionTrade, error) {
+	return r.GetLatestOptionTradeWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestOptionTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestOptionTradeRequest,
+) (*marketdata.OptionTrade, error) {
+	res, err := r.api.GetLatestOptionTradeWithContext(ctx, symbol, req)
+	r.record("GetLatestOptionTrade", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestOptionTrades(
+	symbols []string, req marketdata.GetLatestOptionTradeRequest,
+) (map[string]marketdata.OptionTrade, error) {
+	return r.GetLatestOptionTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestOptionTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestOptionTradeRequest,
+) (map[string]marketdata.OptionTrade, error) {
+	res, err := r.api.GetLatestOptionTradesWithContext(ctx, symbols, req)
+	r.record("GetLatestOptionTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestOptionQuote(
+	symbol string, req marketdata.GetLatestOptionQuoteRequest,
+) (*marketdata.OptionQuote, error) {
+	return r.GetLatestOptionQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestOptionQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestOptionQuoteRequest,
+) (*marketdata.OptionQuote, error) {
+	res, err := r.api.GetLatestOptionQuoteWithContext(ctx, symbol, req)
+	r.record("GetLatestOptionQuote", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestOptionQuotes(
+	symbols []string, req marketdata.GetLatestOptionQuoteRequest,
+) (map[string]marketdata.OptionQuote, error) {
+	return r.GetLatestOptionQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestOptionQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestOptionQuoteRequest,
+) (map[string]marketdata.OptionQuote, error) {
+	res, err := r.api.GetLatestOptionQuotesWithContext(ctx, symbols, req)
+	r.record("GetLatestOptionQuotes", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetOptionSnapshot(
+	symbol string, req marketdata.GetOptionSnapshotRequest,
+) (*marketdata.OptionSnapshot, error) {
+	return r.GetOptionSnapshotWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetOptionSnapshotWithContext(
+	ctx context.Context, symbol string, req marketdata.GetOptionSnapshotRequest,
+) (*marketdata.OptionSnapshot, error) {
+	res, err := r.api.GetOptionSnapshotWithContext(ctx, symbol, req)
+	r.record("GetOptionSnapshot", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetOptionSnapshots(
+	symbols []string, req marketdata.GetOptionSnapshotRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	return r.GetOptionSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetOptionSnapshotsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetOptionSnapshotRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	res, err := r.api.GetOptionSnapshotsWithContext(ctx, symbols, req)
+	r.record("GetOptionSnapshots", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetOptionChain(
+	underlyingSymbol string, req marketdata.GetOptionChainRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	return r.GetOptionChainWithContext(context.Background(), underlyingSymbol, req)
+}
+
+func (r *Recorder) GetOptionChainWithContext(
+	ctx context.Context, underlyingSymbol string, req marketdata.GetOptionChainRequest,
+) (map[string]marketdata.OptionSnapshot, error) {
+	res, err := r.api.GetOptionChainWithContext(ctx, underlyingSymbol, req)
+	r.record("GetOptionChain", err, underlyingSymbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetTrades(symbol string, req marketdata.GetTradesRequest) ([]marketdata.Trade, error) {
+	return r.GetTradesWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetTradesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetTradesRequest,
+) ([]marketdata.Trade, error) {
+	res, err := r.api.GetTradesWithContext(ctx, symbol, req)
+	r.record("GetTrades", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetMultiTrades(
+	symbols []string, req marketdata.GetTradesRequest,
+) (map[string][]marketdata.Trade, error) {
+	return r.GetMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetMultiTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetTradesRequest,
+) (map[string][]marketdata.Trade, error) {
+	res, err := r.api.GetMultiTradesWithContext(ctx, symbols, req)
+	r.record("GetMultiTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetQuotes(symbol string, req marketdata.GetQuotesRequest) ([]marketdata.Quote, error) {
+	return r.GetQuotesWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetQuotesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetQuotesRequest,
+) ([]marketdata.Quote, error) {
+	res, err := r.api.GetQuotesWithContext(ctx, symbol, req)
+	r.record("GetQuotes", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetMultiQuotes(
+	symbols []string, req marketdata.GetQuotesRequest,
+) (map[string][]marketdata.Quote, error) {
+	return r.GetMultiQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetQuotesRequest,
+) (map[string][]marketdata.Quote, error) {
+	res, err := r.api.GetMultiQuotesWithContext(ctx, symbols, req)
+	r.record("GetMultiQuotes", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetBars(symbol string, req marketdata.GetBarsRequest) ([]marketdata.Bar, error) {
+	return r.GetBarsWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetBarsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetBarsRequest,
+) ([]marketdata.Bar, error) {
+	res, err := r.api.GetBarsWithContext(ctx, symbol, req)
+	r.record("GetBars", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetMultiBars(symbols []string, req marketdata.GetBarsRequest) (map[string][]marketdata.Bar, error) {
+	return r.GetMultiBarsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetMultiBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetBarsRequest,
+) (map[string][]marketdata.Bar, error) {
+	res, err := r.api.GetMultiBarsWithContext(ctx, symbols, req)
+	r.record("GetMultiBars", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetAuctions(symbol string, req marketdata.GetAuctionsRequest) ([]marketdata.DailyAuctions, error) {
+	return r.GetAuctionsWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetAuctionsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetAuctionsRequest,
+) ([]marketdata.DailyAuctions, error) {
+	res, err := r.api.GetAuctionsWithContext(ctx, symbol, req)
+	r.record("GetAuctions", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetMultiAuctions(
+	symbols []string, req marketdata.GetAuctionsRequest,
+) (map[string][]marketdata.DailyAuctions, error) {
+	return r.GetMultiAuctionsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetMultiAuctionsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetAuctionsRequest,
+) (map[string][]marketdata.DailyAuctions, error) {
+	res, err := r.api.GetMultiAuctionsWithContext(ctx, symbols, req)
+	r.record("GetMultiAuctions", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestBar(symbol string, req marketdata.GetLatestBarRequest) (*marketdata.Bar, error) {
+	return r.GetLatestBarWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestBarWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestBarRequest,
+) (*marketdata.Bar, error) {
+	res, err := r.api.GetLatestBarWithContext(ctx, symbol, req)
+	r.record("GetLatestBar", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestBars(
+	symbols []string, req marketdata.GetLatestBarRequest,
+) (map[string]marketdata.Bar, error) {
+	return r.GetLatestBarsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestBarRequest,
+) (map[string]marketdata.Bar, error) {
+	res, err := r.api.GetLatestBarsWithContext(ctx, symbols, req)
+	r.record("GetLatestBars", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestTrade(symbol string, req marketdata.GetLatestTradeRequest) (*marketdata.Trade, error) {
+	return r.GetLatestTradeWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestTradeRequest,
+) (*marketdata.Trade, error) {
+	res, err := r.api.GetLatestTradeWithContext(ctx, symbol, req)
+	r.record("GetLatestTrade", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestTrades(
+	symbols []string, req marketdata.GetLatestTradeRequest,
+) (map[string]marketdata.Trade, error) {
+	return r.GetLatestTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestTradeRequest,
+) (map[string]marketdata.Trade, error) {
+	res, err := r.api.GetLatestTradesWithContext(ctx, symbols, req)
+	r.record("GetLatestTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestQuote(symbol string, req marketdata.GetLatestQuoteRequest) (*marketdata.Quote, error) {
+	return r.GetLatestQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestQuoteRequest,
+) (*marketdata.Quote, error) {
+	res, err := r.api.GetLatestQuoteWithContext(ctx, symbol, req)
+	r.record("GetLatestQuote", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestQuotes(
+	symbols []string, req marketdata.GetLatestQuoteRequest,
+) (map[string]marketdata.Quote, error) {
+	return r.GetLatestQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestQuoteRequest,
+) (map[string]marketdata.Quote, error) {
+	res, err := r.api.GetLatestQuotesWithContext(ctx, symbols, req)
+	r.record("GetLatestQuotes", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetSnapshot(symbol string, req marketdata.GetSnapshotRequest) (*marketdata.Snapshot, error) {
+	return r.GetSnapshotWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetSnapshotWithContext(
+	ctx context.Context, symbol string, req marketdata.GetSnapshotRequest,
+) (*marketdata.Snapshot, error) {
+	res, err := r.api.GetSnapshotWithContext(ctx, symbol, req)
+	r.record("GetSnapshot", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetSnapshots(
+	symbols []string, req marketdata.GetSnapshotRequest,
+) (map[string]*marketdata.Snapshot, error) {
+	return r.GetSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetSnapshotsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetSnapshotRequest,
+) (map[string]*marketdata.Snapshot, error) {
+	res, err := r.api.GetSnapshotsWithContext(ctx, symbols, req)
+	r.record("GetSnapshots", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoTrades(
+	symbol string, req marketdata.GetCryptoTradesRequest,
+) ([]marketdata.CryptoTrade, error) {
+	return r.GetCryptoTradesWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetCryptoTradesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoTradesRequest,
+) ([]marketdata.CryptoTrade, error) {
+	res, err := r.api.GetCryptoTradesWithContext(ctx, symbol, req)
+	r.record("GetCryptoTrades", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoMultiTrades(
+	symbols []string, req marketdata.GetCryptoTradesRequest,
+) (map[string][]marketdata.CryptoTrade, error) {
+	return r.GetCryptoMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetCryptoMultiTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoTradesRequest,
+) (map[string][]marketdata.CryptoTrade, error) {
+	res, err := r.api.GetCryptoMultiTradesWithContext(ctx, symbols, req)
+	r.record("GetCryptoMultiTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoQuotes(
+	symbol string, req marketdata.GetCryptoQuotesRequest,
+) ([]marketdata.CryptoQuote, error) {
+	return r.GetCryptoQuotesWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetCryptoQuotesWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoQuotesRequest,
+) ([]marketdata.CryptoQuote, error) {
+	res, err := r.api.GetCryptoQuotesWithContext(ctx, symbol, req)
+	r.record("GetCryptoQuotes", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoMultiQuotes(
+	symbols []string, req marketdata.GetCryptoQuotesRequest,
+) (map[string][]marketdata.CryptoQuote, error) {
+	return r.GetCryptoMultiQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetCryptoMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoQuotesRequest,
+) (map[string][]marketdata.CryptoQuote, error) {
+	res, err := r.api.GetCryptoMultiQuotesWithContext(ctx, symbols, req)
+	r.record("GetCryptoMultiQuotes", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoBars(symbol string, req marketdata.GetCryptoBarsRequest) ([]marketdata.CryptoBar, error) {
+	return r.GetCryptoBarsWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetCryptoBarsWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoBarsRequest,
+) ([]marketdata.CryptoBar, error) {
+	res, err := r.api.GetCryptoBarsWithContext(ctx, symbol, req)
+	r.record("GetCryptoBars", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoMultiBars(
+	symbols []string, req marketdata.GetCryptoBarsRequest,
+) (map[string][]marketdata.CryptoBar, error) {
+	return r.GetCryptoMultiBarsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetCryptoMultiBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoBarsRequest,
+) (map[string][]marketdata.CryptoBar, error) {
+	res, err := r.api.GetCryptoMultiBarsWithContext(ctx, symbols, req)
+	r.record("GetCryptoMultiBars", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoBar(
+	symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoBar, error) {
+	return r.GetLatestCryptoBarWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoBarWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoBar, error) {
+	res, err := r.api.GetLatestCryptoBarWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoBar", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpBar(
+	symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoPerpBar, error) {
+	return r.GetLatestCryptoPerpBarWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpBarWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoBarRequest,
+) (*marketdata.CryptoPerpBar, error) {
+	res, err := r.api.GetLatestCryptoPerpBarWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoPerpBar", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpBars(
+	symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoPerpBar, error) {
+	return r.GetLatestCryptoPerpBarsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoPerpBar, error) {
+	res, err := r.api.GetLatestCryptoPerpBarsWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoPerpBars", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoBars(
+	symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoBar, error) {
+	return r.GetLatestCryptoBarsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoBarsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoBarRequest,
+) (map[string]marketdata.CryptoBar, error) {
+	res, err := r.api.GetLatestCryptoBarsWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoBars", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoTrade(
+	symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoTrade, error) {
+	return r.GetLatestCryptoTradeWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoTrade, error) {
+	res, err := r.api.GetLatestCryptoTradeWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoTrade", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpTrade(
+	symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoPerpTrade, error) {
+	return r.GetLatestCryptoPerpTradeWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpTradeWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoTradeRequest,
+) (*marketdata.CryptoPerpTrade, error) {
+	res, err := r.api.GetLatestCryptoPerpTradeWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoPerpTrade", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpTrades(
+	symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoPerpTrade, error) {
+	return r.GetLatestCryptoPerpTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoPerpTrade, error) {
+	res, err := r.api.GetLatestCryptoPerpTradesWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoPerpTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoTrades(
+	symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoTrade, error) {
+	return r.GetLatestCryptoTradesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoTradesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoTradeRequest,
+) (map[string]marketdata.CryptoTrade, error) {
+	res, err := r.api.GetLatestCryptoTradesWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoTrades", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoQuote(
+	symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoQuote, error) {
+	return r.GetLatestCryptoQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoQuote, error) {
+	res, err := r.api.GetLatestCryptoQuoteWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoQuote", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpQuote(
+	symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoPerpQuote, error) {
+	return r.GetLatestCryptoPerpQuoteWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpQuoteWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoQuoteRequest,
+) (*marketdata.CryptoPerpQuote, error) {
+	res, err := r.api.GetLatestCryptoPerpQuoteWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoPerpQuote", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpQuotes(
+	symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoPerpQuote, error) {
+	return r.GetLatestCryptoPerpQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoPerpQuote, error) {
+	res, err := r.api.GetLatestCryptoPerpQuotesWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoPerpQuotes", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoQuotes(
+	symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoQuote, error) {
+	return r.GetLatestCryptoQuotesWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoQuotesWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoQuoteRequest,
+) (map[string]marketdata.CryptoQuote, error) {
+	res, err := r.api.GetLatestCryptoQuotesWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoQuotes", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpPricing(
+	symbol string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (*marketdata.CryptoPerpPricing, error) {
+	return r.GetLatestCryptoPerpPricingWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpPricingWithContext(
+	ctx context.Context, symbol string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (*marketdata.CryptoPerpPricing, error) {
+	res, err := r.api.GetLatestCryptoPerpPricingWithContext(ctx, symbol, req)
+	r.record("GetLatestCryptoPerpPricing", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetLatestCryptoPerpPricingData(
+	symbols []string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (map[string]marketdata.CryptoPerpPricing, error) {
+	return r.GetLatestCryptoPerpPricingDataWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetLatestCryptoPerpPricingDataWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetLatestCryptoPerpPricingRequest,
+) (map[string]marketdata.CryptoPerpPricing, error) {
+	res, err := r.api.GetLatestCryptoPerpPricingDataWithContext(ctx, symbols, req)
+	r.record("GetLatestCryptoPerpPricingData", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoSnapshot(
+	symbol string, req marketdata.GetCryptoSnapshotRequest,
+) (*marketdata.CryptoSnapshot, error) {
+	return r.GetCryptoSnapshotWithContext(context.Background(), symbol, req)
+}
+
+func (r *Recorder) GetCryptoSnapshotWithContext(
+	ctx context.Context, symbol string, req marketdata.GetCryptoSnapshotRequest,
+) (*marketdata.CryptoSnapshot, error) {
+	res, err := r.api.GetCryptoSnapshotWithContext(ctx, symbol, req)
+	r.record("GetCryptoSnapshot", err, symbol, req)
+	return res, err
+}
+
+func (r *Recorder) GetCryptoSnapshots(
+	symbols []string, req marketdata.GetCryptoSnapshotRequest,
+) (map[string]marketdata.CryptoSnapshot, error) {
+	return r.GetCryptoSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+func (r *Recorder) GetCryptoSnapshotsWithContext(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoSnapshotRequest,
+) (map[string]marketdata.CryptoSnapshot, error) {
+	res, err := r.api.GetCryptoSnapshotsWithContext(ctx, symbols, req)
+	r.record("GetCryptoSnapshots", err, symbols, req)
+	return res, err
+}
+
+func (r *Recorder) GetNews(req marketdata.GetNewsRequest) ([]marketdata.News, error) {
+	return r.GetNewsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetNewsWithContext(ctx context.Context, req marketdata.GetNewsRequest) ([]marketdata.News, error) {
+	res, err := r.api.GetNewsWithContext(ctx, req)
+	r.record("GetNews", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetCorporateActions(req marketdata.GetCorporateActionsRequest) (marketdata.CorporateActions, error) {
+	return r.GetCorporateActionsWithContext(context.Background(), req)
+}
+
+func (r *Recorder) GetCorporateActionsWithContext(
+	ctx context.Context, req marketdata.GetCorporateActionsRequest,
+) (marketdata.CorporateActions, error) {
+	res, err := r.api.GetCorporateActionsWithContext(ctx, req)
+	r.record("GetCorporateActions", err, req)
+	return res, err
+}
+
+func (r *Recorder) GetFixedIncomeLatestPrice(isin string) (*marketdata.FixedIncomePrice, error) {
+	return r.GetFixedIncomeLatestPriceWithContext(context.Background(), isin)
+}
+
+func (r *Recorder) GetFixedIncomeLatestPriceWithContext(
+	ctx context.Context, isin string,
+) (*marketdata.FixedIncomePrice, error) {
+	res, err := r.api.GetFixedIncomeLatestPriceWithContext(ctx, isin)
+	r.record("GetFixedIncomeLatestPrice", err, isin)
+	return res, err
+}
+
+func (r *Recorder) GetFixedIncomeLatestPrices(isins []string) (map[string]marketdata.FixedIncomePrice, error) {
+	return r.GetFixedIncomeLatestPricesWithContext(context.Background(), isins)
+}
+
+func (r *Recorder) GetFixedIncomeLatestPricesWithContext(
+	ctx context.Context, isins []string,
+) (map[string]marketdata.FixedIncomePrice, error) {
+	res, err := r.api.GetFixedIncomeLatestPricesWithContext(ctx, isins)
+	r.record("GetFixedIncomeLatestPrices", err, isins)
+	return res, err
+}
+
+func (r *Recorder) DownloadTrades(
+	ctx context.Context, symbols []string, req marketdata.GetTradesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.Trade, error) {
+	res, err := r.api.DownloadTrades(ctx, symbols, req, opts)
+	r.record("DownloadTrades", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadQuotes(
+	ctx context.Context, symbols []string, req marketdata.GetQuotesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.Quote, error) {
+	res, err := r.api.DownloadQuotes(ctx, symbols, req, opts)
+	r.record("DownloadQuotes", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadBars(
+	ctx context.Context, symbols []string, req marketdata.GetBarsRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.Bar, error) {
+	res, err := r.api.DownloadBars(ctx, symbols, req, opts)
+	r.record("DownloadBars", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadCryptoTrades(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoTradesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.CryptoTrade, error) {
+	res, err := r.api.DownloadCryptoTrades(ctx, symbols, req, opts)
+	r.record("DownloadCryptoTrades", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadCryptoQuotes(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoQuotesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.CryptoQuote, error) {
+	res, err := r.api.DownloadCryptoQuotes(ctx, symbols, req, opts)
+	r.record("DownloadCryptoQuotes", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadCryptoBars(
+	ctx context.Context, symbols []string, req marketdata.GetCryptoBarsRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.CryptoBar, error) {
+	res, err := r.api.DownloadCryptoBars(ctx, symbols, req, opts)
+	r.record("DownloadCryptoBars", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadOptionTrades(
+	ctx context.Context, symbols []string, req marketdata.GetOptionTradesRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.OptionTrade, error) {
+	res, err := r.api.DownloadOptionTrades(ctx, symbols, req, opts)
+	r.record("DownloadOptionTrades", err, symbols, req, opts)
+	return res, err
+}
+
+func (r *Recorder) DownloadOptionBars(
+	ctx context.Context, symbols []string, req marketdata.GetOptionBarsRequest, opts marketdata.DownloadOpts,
+) (map[string][]marketdata.OptionBar, error) {
+	res, err := r.api.DownloadOptionBars(ctx, symbols, req, opts)
+	r.record("DownloadOptionBars", err, symbols, req, opts)
+	return res, err
+}
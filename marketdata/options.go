@@ -1,6 +1,7 @@
 package marketdata
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -22,13 +23,23 @@ type GetOptionTradesRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// Sort is the sort direction of the data
 	Sort Sort
 }
 
 // GetOptionTrades returns the option trades for the given symbol.
 func (c *Client) GetOptionTrades(symbol string, req GetOptionTradesRequest) ([]OptionTrade, error) {
-	resp, err := c.GetOptionMultiTrades([]string{symbol}, req)
+	return c.GetOptionTradesWithContext(context.Background(), symbol, req)
+}
+
+// GetOptionTradesWithContext returns the option trades for the given symbol.
+func (c *Client) GetOptionTradesWithContext(
+	ctx context.Context, symbol string, req GetOptionTradesRequest,
+) ([]OptionTrade, error) {
+	resp, err := c.GetOptionMultiTradesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -37,45 +48,40 @@ func (c *Client) GetOptionTrades(symbol string, req GetOptionTradesRequest) ([]O
 
 // GetOptionMultiTrades returns option trades for the given symbols.
 func (c *Client) GetOptionMultiTrades(symbols []string, req GetOptionTradesRequest) (map[string][]OptionTrade, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/%s/trades", c.opts.BaseURL, optionPrefix))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	c.setBaseQuery(q, baseRequest{
-		Symbols: symbols,
-		Start:   req.Start,
-		End:     req.End,
-		Sort:    req.Sort,
-	})
-
-	trades := make(map[string][]OptionTrade, len(symbols))
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var tradeResp multiOptionTradeResponse
-		if err = unmarshal(resp, &tradeResp); err != nil {
-			return nil, err
-		}
+	return c.GetOptionMultiTradesWithContext(context.Background(), symbols, req)
+}
 
-		for symbol, t := range tradeResp.Trades {
-			trades[symbol] = append(trades[symbol], t...)
-			received += len(t)
-		}
-		if tradeResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *tradeResp.NextPageToken)
+// GetOptionMultiTradesWithContext returns option trades for the given symbols.
+func (c *Client) GetOptionMultiTradesWithContext(
+	ctx context.Context, symbols []string, req GetOptionTradesRequest,
+) (map[string][]OptionTrade, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[OptionTrade], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]OptionTrade, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetOptionMultiTradesWithContext(ctx, symbols, r)
+		})
 	}
-	return trades, nil
+	return collectPages(c.IterateOptionTrades(ctx, symbols, req), len(symbols))
+}
+
+// IterateOptionTrades returns an iterator over the pages of the option trades for the given
+// symbols. Unlike GetOptionMultiTrades, it doesn't accumulate the trades: a page is only
+// requested when the iterator is advanced.
+func (c *Client) IterateOptionTrades(
+	ctx context.Context, symbols []string, req GetOptionTradesRequest,
+) *PageIterator[OptionTrade] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/%s/trades", c.opts.BaseURL, optionPrefix), func(q url.Values) {
+		c.setBaseQuery(q, baseRequest{
+			Symbols: symbols,
+			Start:   req.Start,
+			End:     req.End,
+			Sort:    req.Sort,
+		})
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeOptionTrades)
 }
 
 // GetOptionBarsRequest contains optional parameters for getting bars
@@ -91,13 +97,23 @@ type GetOptionBarsRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// Sort is the sort direction of the data
 	Sort Sort
 }
 
 // GetOptionBars returns a slice of bars for the given symbol.
 func (c *Client) GetOptionBars(symbol string, req GetOptionBarsRequest) ([]OptionBar, error) {
-	resp, err := c.GetMultiOptionBars([]string{symbol}, req)
+	return c.GetOptionBarsWithContext(context.Background(), symbol, req)
+}
+
+// GetOptionBarsWithContext returns a slice of bars for the given symbol.
+func (c *Client) GetOptionBarsWithContext(
+	ctx context.Context, symbol string, req GetOptionBarsRequest,
+) ([]OptionBar, error) {
+	resp, err := c.GetMultiOptionBarsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -106,51 +122,45 @@ func (c *Client) GetOptionBars(symbol string, req GetOptionBarsRequest) ([]Optio
 
 // GetMultiOptionBars returns bars for the given symbols.
 func (c *Client) GetMultiOptionBars(symbols []string, req GetOptionBarsRequest) (map[string][]OptionBar, error) {
-	bars := make(map[string][]OptionBar, len(symbols))
-
-	u, err := url.Parse(fmt.Sprintf("%s/%s/bars", c.opts.BaseURL, optionPrefix))
-	if err != nil {
-		return nil, err
-	}
+	return c.GetMultiOptionBarsWithContext(context.Background(), symbols, req)
+}
 
-	q := u.Query()
-	c.setBaseQuery(q, baseRequest{
-		Symbols: symbols,
-		Start:   req.Start,
-		End:     req.End,
-		Sort:    req.Sort,
-	})
-	timeframe := OneDay
-	if req.TimeFrame.N != 0 {
-		timeframe = req.TimeFrame
+// GetMultiOptionBarsWithContext returns bars for the given symbols.
+func (c *Client) GetMultiOptionBarsWithContext(
+	ctx context.Context, symbols []string, req GetOptionBarsRequest,
+) (map[string][]OptionBar, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[OptionBar], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]OptionBar, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetMultiOptionBarsWithContext(ctx, symbols, r)
+		})
 	}
-	q.Set("timeframe", timeframe.String())
-
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var barResp multiOptionBarResponse
-		if err = unmarshal(resp, &barResp); err != nil {
-			return nil, err
-		}
+	return collectPages(c.IterateOptionBars(ctx, symbols, req), len(symbols))
+}
 
-		for symbol, b := range barResp.Bars {
-			bars[symbol] = append(bars[symbol], b...)
-			received += len(b)
-		}
-		if barResp.NextPageToken == nil {
-			break
+// IterateOptionBars returns an iterator over the pages of the option bars for the given
+// symbols. Unlike GetMultiOptionBars, it doesn't accumulate the bars: a page is only requested
+// when the iterator is advanced.
+func (c *Client) IterateOptionBars(
+	ctx context.Context, symbols []string, req GetOptionBarsRequest,
+) *PageIterator[OptionBar] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/%s/bars", c.opts.BaseURL, optionPrefix), func(q url.Values) {
+		c.setBaseQuery(q, baseRequest{
+			Symbols: symbols,
+			Start:   req.Start,
+			End:     req.End,
+			Sort:    req.Sort,
+		})
+		timeframe := OneDay
+		if req.TimeFrame.N != 0 {
+			timeframe = req.TimeFrame
 		}
-		q.Set("page_token", *barResp.NextPageToken)
-	}
-	return bars, nil
+		q.Set("timeframe", timeframe.String())
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeOptionBars)
 }
 
 type GetLatestOptionTradeRequest struct {
@@ -159,7 +169,14 @@ type GetLatestOptionTradeRequest struct {
 
 // GetLatestOptionTrade returns the latest option trade for a given symbol
 func (c *Client) GetLatestOptionTrade(symbol string, req GetLatestOptionTradeRequest) (*OptionTrade, error) {
-	resp, err := c.GetLatestOptionTrades([]string{symbol}, req)
+	return c.GetLatestOptionTradeWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestOptionTradeWithContext returns the latest option trade for a given symbol
+func (c *Client) GetLatestOptionTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestOptionTradeRequest,
+) (*OptionTrade, error) {
+	resp, err := c.GetLatestOptionTradesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +191,19 @@ func (c *Client) GetLatestOptionTrade(symbol string, req GetLatestOptionTradeReq
 func (c *Client) GetLatestOptionTrades(
 	symbols []string, req GetLatestOptionTradeRequest,
 ) (map[string]OptionTrade, error) {
+	return c.GetLatestOptionTradesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestOptionTradesWithContext returns the latest option trades for the given symbols
+func (c *Client) GetLatestOptionTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestOptionTradeRequest,
+) (map[string]OptionTrade, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]OptionTrade, error) {
+			return c.GetLatestOptionTradesWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/trades/latest", c.opts.BaseURL, optionPrefix))
 	if err != nil {
 		return nil, err
@@ -183,7 +213,7 @@ func (c *Client) GetLatestOptionTrades(
 		Feed:    req.Feed,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -202,7 +232,14 @@ type GetLatestOptionQuoteRequest struct {
 
 // GetLatestOptionQuote returns the latest option quote for a given symbol
 func (c *Client) GetLatestOptionQuote(symbol string, req GetLatestOptionQuoteRequest) (*OptionQuote, error) {
-	resp, err := c.GetLatestOptionQuotes([]string{symbol}, req)
+	return c.GetLatestOptionQuoteWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestOptionQuoteWithContext returns the latest option quote for a given symbol
+func (c *Client) GetLatestOptionQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestOptionQuoteRequest,
+) (*OptionQuote, error) {
+	resp, err := c.GetLatestOptionQuotesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +254,19 @@ func (c *Client) GetLatestOptionQuote(symbol string, req GetLatestOptionQuoteReq
 func (c *Client) GetLatestOptionQuotes(
 	symbols []string, req GetLatestOptionQuoteRequest,
 ) (map[string]OptionQuote, error) {
+	return c.GetLatestOptionQuotesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestOptionQuotesWithContext returns the latest option quotes for the given symbols
+func (c *Client) GetLatestOptionQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestOptionQuoteRequest,
+) (map[string]OptionQuote, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]OptionQuote, error) {
+			return c.GetLatestOptionQuotesWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/quotes/latest", c.opts.BaseURL, optionPrefix))
 	if err != nil {
 		return nil, err
@@ -226,7 +276,7 @@ func (c *Client) GetLatestOptionQuotes(
 		Feed:    req.Feed,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +301,14 @@ type GetOptionSnapshotRequest struct {
 
 // GetOptionSnapshot returns the snapshot for a given symbol
 func (c *Client) GetOptionSnapshot(symbol string, req GetOptionSnapshotRequest) (*OptionSnapshot, error) {
-	resp, err := c.GetOptionSnapshots([]string{symbol}, req)
+	return c.GetOptionSnapshotWithContext(context.Background(), symbol, req)
+}
+
+// GetOptionSnapshotWithContext returns the snapshot for a given symbol
+func (c *Client) GetOptionSnapshotWithContext(
+	ctx context.Context, symbol string, req GetOptionSnapshotRequest,
+) (*OptionSnapshot, error) {
+	resp, err := c.GetOptionSnapshotsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -264,6 +321,24 @@ func (c *Client) GetOptionSnapshot(symbol string, req GetOptionSnapshotRequest)
 
 // GetOptionSnapshots returns the snapshots for multiple symbols
 func (c *Client) GetOptionSnapshots(symbols []string, req GetOptionSnapshotRequest) (map[string]OptionSnapshot, error) {
+	return c.GetOptionSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+// GetOptionSnapshotsWithContext returns the snapshots for multiple symbols
+func (c *Client) GetOptionSnapshotsWithContext(
+	ctx context.Context, symbols []string, req GetOptionSnapshotRequest,
+) (map[string]OptionSnapshot, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit}
+		return getPagedBatches(ctx, c, batches, pr, func(OptionSnapshot) int { return 1 }, func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string]OptionSnapshot, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetOptionSnapshotsWithContext(ctx, symbols, r)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/snapshots", c.opts.BaseURL, optionPrefix))
 	if err != nil {
 		return nil, err
@@ -281,7 +356,7 @@ func (c *Client) GetOptionSnapshots(symbols []string, req GetOptionSnapshotReque
 		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
 		u.RawQuery = q.Encode()
 
-		resp, err := c.get(u)
+		resp, err := c.get(ctx, u)
 		if err != nil {
 			return nil, err
 		}
@@ -329,6 +404,13 @@ type GetOptionChainRequest struct {
 
 // GetOptionChain returns the snapshot chain for an underlying symbol (e.g. AAPL)
 func (c *Client) GetOptionChain(underlyingSymbol string, req GetOptionChainRequest) (map[string]OptionSnapshot, error) {
+	return c.GetOptionChainWithContext(context.Background(), underlyingSymbol, req)
+}
+
+// GetOptionChainWithContext returns the snapshot chain for an underlying symbol (e.g. AAPL)
+func (c *Client) GetOptionChainWithContext(
+	ctx context.Context, underlyingSymbol string, req GetOptionChainRequest,
+) (map[string]OptionSnapshot, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/snapshots/%s", c.opts.BaseURL, optionPrefix, underlyingSymbol))
 	if err != nil {
 		return nil, err
@@ -365,7 +447,7 @@ func (c *Client) GetOptionChain(underlyingSymbol string, req GetOptionChainReque
 		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
 		u.RawQuery = q.Encode()
 
-		resp, err := c.get(u)
+		resp, err := c.get(ctx, u)
 		if err != nil {
 			return nil, err
 		}
@@ -392,52 +474,125 @@ func GetOptionTrades(symbol string, req GetOptionTradesRequest) ([]OptionTrade,
 	return DefaultClient.GetOptionTrades(symbol, req)
 }
 
+// GetOptionTradesWithContext returns the option trades for the given symbol.
+func GetOptionTradesWithContext(ctx context.Context, symbol string, req GetOptionTradesRequest) ([]OptionTrade, error) {
+	return DefaultClient.GetOptionTradesWithContext(ctx, symbol, req)
+}
+
 // GetOptionMultiTrades returns option trades for the given symbols.
 func GetOptionMultiTrades(symbols []string, req GetOptionTradesRequest) (map[string][]OptionTrade, error) {
 	return DefaultClient.GetOptionMultiTrades(symbols, req)
 }
 
+// GetOptionMultiTradesWithContext returns option trades for the given symbols.
+func GetOptionMultiTradesWithContext(
+	ctx context.Context, symbols []string, req GetOptionTradesRequest,
+) (map[string][]OptionTrade, error) {
+	return DefaultClient.GetOptionMultiTradesWithContext(ctx, symbols, req)
+}
+
 // GetOptionBars returns a slice of bars for the given symbol.
 func GetOptionBars(symbol string, req GetOptionBarsRequest) ([]OptionBar, error) {
 	return DefaultClient.GetOptionBars(symbol, req)
 }
 
+// GetOptionBarsWithContext returns a slice of bars for the given symbol.
+func GetOptionBarsWithContext(ctx context.Context, symbol string, req GetOptionBarsRequest) ([]OptionBar, error) {
+	return DefaultClient.GetOptionBarsWithContext(ctx, symbol, req)
+}
+
 // GetMultiOptionBars returns bars for the given symbols.
 func GetMultiOptionBars(symbols []string, req GetOptionBarsRequest) (map[string][]OptionBar, error) {
 	return DefaultClient.GetMultiOptionBars(symbols, req)
 }
 
+// GetMultiOptionBarsWithContext returns bars for the given symbols.
+func GetMultiOptionBarsWithContext(
+	ctx context.Context, symbols []string, req GetOptionBarsRequest,
+) (map[string][]OptionBar, error) {
+	return DefaultClient.GetMultiOptionBarsWithContext(ctx, symbols, req)
+}
+
 // GetLatestOptionTrade returns the latest option trade for a given symbol
 func GetLatestOptionTrade(symbol string, req GetLatestOptionTradeRequest) (*OptionTrade, error) {
 	return DefaultClient.GetLatestOptionTrade(symbol, req)
 }
 
+// GetLatestOptionTradeWithContext returns the latest option trade for a given symbol
+func GetLatestOptionTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestOptionTradeRequest,
+) (*OptionTrade, error) {
+	return DefaultClient.GetLatestOptionTradeWithContext(ctx, symbol, req)
+}
+
 // GetLatestOptionTrades returns the latest option trades for the given symbols
 func GetLatestOptionTrades(symbols []string, req GetLatestOptionTradeRequest) (map[string]OptionTrade, error) {
 	return DefaultClient.GetLatestOptionTrades(symbols, req)
 }
 
+// GetLatestOptionTradesWithContext returns the latest option trades for the given symbols
+func GetLatestOptionTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestOptionTradeRequest,
+) (map[string]OptionTrade, error) {
+	return DefaultClient.GetLatestOptionTradesWithContext(ctx, symbols, req)
+}
+
 // GetLatestOptionQuote returns the latest option quote for a given symbol
 func GetLatestOptionQuote(symbol string, req GetLatestOptionQuoteRequest) (*OptionQuote, error) {
 	return DefaultClient.GetLatestOptionQuote(symbol, req)
 }
 
+// GetLatestOptionQuoteWithContext returns the latest option quote for a given symbol
+func GetLatestOptionQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestOptionQuoteRequest,
+) (*OptionQuote, error) {
+	return DefaultClient.GetLatestOptionQuoteWithContext(ctx, symbol, req)
+}
+
 // GetLatestOptionQuotes returns the latest option quotes for the given symbols
 func GetLatestOptionQuotes(symbols []string, req GetLatestOptionQuoteRequest) (map[string]OptionQuote, error) {
 	return DefaultClient.GetLatestOptionQuotes(symbols, req)
 }
 
+// GetLatestOptionQuotesWithContext returns the latest option quotes for the given symbols
+func GetLatestOptionQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestOptionQuoteRequest,
+) (map[string]OptionQuote, error) {
+	return DefaultClient.GetLatestOptionQuotesWithContext(ctx, symbols, req)
+}
+
 // GetOptionSnapshot returns the snapshot for a given symbol
 func GetOptionSnapshot(symbol string, req GetOptionSnapshotRequest) (*OptionSnapshot, error) {
 	return DefaultClient.GetOptionSnapshot(symbol, req)
 }
 
+// GetOptionSnapshotWithContext returns the snapshot for a given symbol
+func GetOptionSnapshotWithContext(
+	ctx context.Context, symbol string, req GetOptionSnapshotRequest,
+) (*OptionSnapshot, error) {
+	return DefaultClient.GetOptionSnapshotWithContext(ctx, symbol, req)
+}
+
 // GetOptionSnapshots returns the snapshots for multiple symbols
 func GetOptionSnapshots(symbols []string, req GetOptionSnapshotRequest) (map[string]OptionSnapshot, error) {
 	return DefaultClient.GetOptionSnapshots(symbols, req)
 }
 
+// GetOptionSnapshotsWithContext returns the snapshots for multiple symbols
+func GetOptionSnapshotsWithContext(
+	ctx context.Context, symbols []string, req GetOptionSnapshotRequest,
+) (map[string]OptionSnapshot, error) {
+	return DefaultClient.GetOptionSnapshotsWithContext(ctx, symbols, req)
+}
+
 // GetOptionChain returns the snapshot chain for an underlying symbol (e.g. AAPL)
 func GetOptionChain(underlyingSymbol string, req GetOptionChainRequest) (map[string]OptionSnapshot, error) {
 	return DefaultClient.GetOptionChain(underlyingSymbol, req)
 }
+
+// GetOptionChainWithContext returns the snapshot chain for an underlying symbol (e.g. AAPL)
+func GetOptionChainWithContext(
+	ctx context.Context, underlyingSymbol string, req GetOptionChainRequest,
+) (map[string]OptionSnapshot, error) {
+	return DefaultClient.GetOptionChainWithContext(ctx, underlyingSymbol, req)
+}
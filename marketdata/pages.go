@@ -0,0 +1,198 @@
+package marketdata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// PageIterator iterates over the pages of a historical data request, fetching a page only
+// when Next is called. Use it like a bufio.Scanner:
+//
+//	it := client.IterateTrades(ctx, symbols, req)
+//	for it.Next() {
+//		for symbol, trades := range it.Page() {
+//			// ...
+//		}
+//		checkpoint(it.NextPageToken())
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+//
+// Unlike the GetMulti methods, the records are not accumulated, so only one page is held in
+// memory. Stopping the iteration early doesn't send any further request. An interrupted
+// download can be resumed by setting the PageToken of the request to the last NextPageToken.
+type PageIterator[T any] struct {
+	c          *Client
+	ctx        context.Context
+	u          *url.URL
+	q          url.Values
+	totalLimit int
+	pageLimit  int
+	decode     func(resp *http.Response) (map[string][]T, *string, error)
+	received   int
+	page       map[string][]T
+	next       string
+	done       bool
+	err        error
+}
+
+// pageRequest contains the pagination parameters of a request.
+type pageRequest struct {
+	TotalLimit int
+	PageLimit  int
+	PageToken  string
+}
+
+func newPageIterator[T any](
+	ctx context.Context, c *Client, rawURL string, setQuery func(q url.Values), pr pageRequest,
+	decode func(resp *http.Response) (map[string][]T, *string, error),
+) *PageIterator[T] {
+	it := &PageIterator[T]{
+		c:          c,
+		ctx:        ctx,
+		totalLimit: pr.TotalLimit,
+		pageLimit:  pr.PageLimit,
+		decode:     decode,
+	}
+	if it.u, it.err = url.Parse(rawURL); it.err != nil {
+		return it
+	}
+	it.q = it.u.Query()
+	setQuery(it.q)
+	if pr.PageToken != "" {
+		it.q.Set("page_token", pr.PageToken)
+	}
+	return it
+}
+
+// Next fetches the next page, which will then be available through the Page method. It
+// returns false when there are no more pages or when an error occurred.
+func (it *PageIterator[T]) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+	if it.totalLimit != 0 && it.received >= it.totalLimit {
+		it.done = true
+		return false
+	}
+	setQueryLimit(it.q, it.totalLimit, it.pageLimit, it.received, v2MaxLimit)
+	it.u.RawQuery = it.q.Encode()
+	resp, err := it.c.get(it.ctx, it.u)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	page, token, err := it.decode(resp)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	for _, records := range page {
+		it.received += len(records)
+	}
+	it.page = page
+	if token == nil {
+		it.done = true
+		it.next = ""
+	} else {
+		it.next = *token
+		it.q.Set("page_token", *token)
+	}
+	return true
+}
+
+// Page returns the records of the current page, by symbol.
+func (it *PageIterator[T]) Page() map[string][]T {
+	return it.page
+}
+
+// NextPageToken returns the token of the page following the current one, or an empty string if
+// the current page is the last one.
+func (it *PageIterator[T]) NextPageToken() string {
+	return it.next
+}
+
+// Err returns the first error encountered by the iterator.
+func (it *PageIterator[T]) Err() error {
+	return it.err
+}
+
+// collectPages returns the records of all the pages of the iterator.
+func collectPages[T any](it *PageIterator[T], symbols int) (map[string][]T, error) {
+	records := make(map[string][]T, symbols)
+	for it.Next() {
+		for symbol, r := range it.Page() {
+			records[symbol] = append(records[symbol], r...)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func decodeTrades(resp *http.Response) (map[string][]Trade, *string, error) {
+	var r multiTradeResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Trades, r.NextPageToken, nil
+}
+
+func decodeQuotes(resp *http.Response) (map[string][]Quote, *string, error) {
+	var r multiQuoteResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Quotes, r.NextPageToken, nil
+}
+
+func decodeBars(resp *http.Response) (map[string][]Bar, *string, error) {
+	var r multiBarResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Bars, r.NextPageToken, nil
+}
+
+func decodeCryptoTrades(resp *http.Response) (map[string][]CryptoTrade, *string, error) {
+	var r cryptoMultiTradeResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Trades, r.NextPageToken, nil
+}
+
+func decodeCryptoQuotes(resp *http.Response) (map[string][]CryptoQuote, *string, error) {
+	var r cryptoMultiQuoteResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Quotes, r.NextPageToken, nil
+}
+
+func decodeCryptoBars(resp *http.Response) (map[string][]CryptoBar, *string, error) {
+	var r cryptoMultiBarResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Bars, r.NextPageToken, nil
+}
+
+func decodeOptionTrades(resp *http.Response) (map[string][]OptionTrade, *string, error) {
+	var r multiOptionTradeResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Trades, r.NextPageToken, nil
+}
+
+func decodeOptionBars(resp *http.Response) (map[string][]OptionBar, *string, error) {
+	var r multiOptionBarResponse
+	if err := unmarshal(resp, &r); err != nil {
+		return nil, nil, err
+	}
+	return r.Bars, r.NextPageToken, nil
+}
@@ -0,0 +1,132 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedTrades serves three pages of trades of AAPL and MSFT, and records the page tokens of the
+// requests.
+func pagedTrades(tokens *[]string) func(_ *Client, req *http.Request) (*http.Response, error) {
+	pages := map[string]string{
+		"": `{"trades":{"AAPL":[{"t":"2024-03-04T14:30:00Z","p":100,"s":1}],` +
+			`"MSFT":[{"t":"2024-03-04T14:30:00Z","p":400,"s":1}]},"next_page_token":"p2"}`,
+		"p2": `{"trades":{"MSFT":[{"t":"2024-03-04T14:31:00Z","p":401,"s":2}]},"next_page_token":"p3"}`,
+		"p3": `{"trades":{"MSFT":[{"t":"2024-03-04T14:32:00Z","p":402,"s":3}]},"next_page_token":null}`,
+	}
+	return func(_ *Client, req *http.Request) (*http.Response, error) {
+		token := req.URL.Query().Get("page_token")
+		*tokens = append(*tokens, token)
+		return &http.Response{Body: io.NopCloser(strings.NewReader(pages[token]))}, nil
+	}
+}
+
+func TestIterateTrades(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	var tokens []string
+	c.do = pagedTrades(&tokens)
+
+	it := c.IterateTrades(context.Background(), []string{"AAPL", "MSFT"}, GetTradesRequest{})
+	require.True(t, it.Next())
+	assert.Len(t, it.Page()["AAPL"], 1)
+	assert.Len(t, it.Page()["MSFT"], 1)
+	assert.Equal(t, "p2", it.NextPageToken())
+	require.True(t, it.Next())
+	assert.EqualValues(t, 401, it.Page()["MSFT"][0].Price)
+	assert.Equal(t, "p3", it.NextPageToken())
+	// Stopping early doesn't send any further request
+	assert.Equal(t, []string{"", "p2"}, tokens)
+
+	// Resumes from the checkpoint
+	tokens = nil
+	it = c.IterateTrades(context.Background(), []string{"AAPL", "MSFT"}, GetTradesRequest{PageToken: "p3"})
+	require.True(t, it.Next())
+	assert.EqualValues(t, 402, it.Page()["MSFT"][0].Price)
+	assert.Empty(t, it.NextPageToken())
+	assert.False(t, it.Next())
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"p3"}, tokens)
+}
+
+func TestIterateTrades_TotalLimit(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	var tokens []string
+	c.do = pagedTrades(&tokens)
+
+	it := c.IterateTrades(context.Background(), []string{"AAPL", "MSFT"}, GetTradesRequest{TotalLimit: 3})
+	pages := 0
+	for it.Next() {
+		pages++
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"", "p2"}, tokens)
+}
+
+func TestIterateTrades_Error(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	c.do = mockErrResp()
+	it := c.IterateTrades(context.Background(), []string{"AAPL"}, GetTradesRequest{})
+	assert.False(t, it.Next())
+	require.Error(t, it.Err())
+	assert.False(t, it.Next())
+
+	c = NewClient(ClientOpts{BaseURL: "://invalid"})
+	it = c.IterateTrades(context.Background(), []string{"AAPL"}, GetTradesRequest{})
+	assert.False(t, it.Next())
+	require.Error(t, it.Err())
+}
+
+func TestIterateCryptoAndOptions(t *testing.T) {
+	c := NewClient(ClientOpts{})
+	var paths []string
+	c.do = func(_ *Client, req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/bars"):
+			return mockResp(`{"bars":{"X":[{"t":"2024-03-04T14:30:00Z","c":1}]},"next_page_token":null}`)(c, req)
+		case strings.HasSuffix(req.URL.Path, "/trades"):
+			return mockResp(`{"trades":{"X":[{"t":"2024-03-04T14:30:00Z","p":1}]},"next_page_token":null}`)(c, req)
+		case strings.HasSuffix(req.URL.Path, "/quotes"):
+			return mockResp(`{"quotes":{"X":[{"t":"2024-03-04T14:30:00Z","bp":1}]},"next_page_token":null}`)(c, req)
+		}
+		return nil, errors.New("unexpected path " + req.URL.Path)
+	}
+	ctx := context.Background()
+	symbols := []string{"X"}
+	for _, next := range []func() (int, error){
+		pageLen(c.IterateQuotes(ctx, symbols, GetQuotesRequest{})),
+		pageLen(c.IterateBars(ctx, symbols, GetBarsRequest{})),
+		pageLen(c.IterateCryptoTrades(ctx, symbols, GetCryptoTradesRequest{})),
+		pageLen(c.IterateCryptoQuotes(ctx, symbols, GetCryptoQuotesRequest{})),
+		pageLen(c.IterateCryptoBars(ctx, symbols, GetCryptoBarsRequest{})),
+		pageLen(c.IterateOptionTrades(ctx, symbols, GetOptionTradesRequest{})),
+		pageLen(c.IterateOptionBars(ctx, symbols, GetOptionBarsRequest{})),
+	} {
+		n, err := next()
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+	assert.Equal(t, []string{
+		"/v2/stocks/quotes", "/v2/stocks/bars",
+		"/v1beta3/crypto/us/trades", "/v1beta3/crypto/us/quotes", "/v1beta3/crypto/us/bars",
+		"/v1beta1/options/trades", "/v1beta1/options/bars",
+	}, paths)
+}
+
+func pageLen[T any](it *PageIterator[T]) func() (int, error) {
+	return func() (int, error) {
+		n := 0
+		for it.Next() {
+			n += len(it.Page()["X"])
+		}
+		return n, it.Err()
+	}
+}
@@ -2,6 +2,7 @@ package marketdata
 
 import (
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/civil"
@@ -31,6 +33,10 @@ type ClientOpts struct {
 	BaseURL      string
 	RetryLimit   int
 	RetryDelay   time.Duration
+	// RetryPolicy configures how failed requests are retried. If nil, requests that
+	// failed with 429 Too Many Requests or 500 Internal Server Error are retried
+	// RetryLimit times, waiting RetryDelay between the attempts.
+	RetryPolicy *alpaca.RetryPolicy
 	// Feed is the default feed to be used by all requests. Can be overridden per request.
 	Feed Feed
 	// CryptoFeed is the default crypto feed to be used by all requests. Can be overridden per request.
@@ -38,6 +44,17 @@ type ClientOpts struct {
 	// Currency is the default currency to be used by all requests. Can be overridden per request.
 	// For the latest endpoints this is the only way to set this parameter.
 	Currency string
+	// RateLimiter throttles the requests sent by the client. It can be shared between
+	// multiple clients that are subject to the same rate limit. If nil, requests are not throttled.
+	RateLimiter *alpaca.RateLimiter
+	// MaxSymbolsPerRequest is the maximum number of symbols of a request. The multi-symbol
+	// methods, such as GetMultiBars or GetSnapshots, split longer symbol lists into batches,
+	// request them separately and merge the results. The page iterators don't split the symbols.
+	// Defaults to DefaultMaxSymbolsPerRequest, a negative value disables the batching.
+	MaxSymbolsPerRequest int
+	// SymbolBatchConcurrency is the maximum number of batches of symbols requested at the same
+	// time. Defaults to 1: the batches are requested one after the other.
+	SymbolBatchConcurrency int
 	// HTTPClient to be used for each http request.
 	HTTPClient *http.Client
 	// Host used to set the http request's host
@@ -48,6 +65,7 @@ type ClientOpts struct {
 type Client struct {
 	opts       ClientOpts
 	httpClient *http.Client
+	rateLimit  atomic.Pointer[alpaca.RateLimit]
 
 	do func(c *Client, req *http.Request) (*http.Response, error)
 }
@@ -76,6 +94,17 @@ func NewClient(opts ClientOpts) *Client {
 	if opts.RetryDelay == 0 {
 		opts.RetryDelay = time.Second
 	}
+	if opts.MaxSymbolsPerRequest == 0 {
+		opts.MaxSymbolsPerRequest = DefaultMaxSymbolsPerRequest
+	}
+	if opts.RetryPolicy == nil {
+		opts.RetryPolicy = &alpaca.RetryPolicy{
+			MaxRetries:        opts.RetryLimit,
+			BaseDelay:         opts.RetryDelay,
+			MaxDelay:          opts.RetryDelay,
+			RetryableStatuses: []int{http.StatusTooManyRequests, http.StatusInternalServerError},
+		}
+	}
 	httpClient := opts.HTTPClient
 	if httpClient == nil {
 		httpClient = &http.Client{
@@ -93,6 +122,15 @@ func NewClient(opts ClientOpts) *Client {
 // DefaultClient uses options from environment variables, or the defaults.
 var DefaultClient = NewClient(ClientOpts{})
 
+// RateLimit returns the rate limit state reported by the server in the most recent response.
+// It returns false if no response with rate limit headers has been received yet.
+func (c *Client) RateLimit() (alpaca.RateLimit, bool) {
+	if rl := c.rateLimit.Load(); rl != nil {
+		return *rl, true
+	}
+	return alpaca.RateLimit{}, false
+}
+
 func defaultDo(c *Client, req *http.Request) (*http.Response, error) {
 	req.Header.Set("User-Agent", alpaca.Version())
 	if c.opts.RequestHost != "" {
@@ -111,22 +149,30 @@ func defaultDo(c *Client, req *http.Request) (*http.Response, error) {
 
 	var resp *http.Response
 	var err error
-
-RetryLoop:
 	for i := 0; ; i++ {
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
+		if err = c.opts.RateLimiter.Wait(req.Context()); err != nil {
 			return nil, err
 		}
-		switch resp.StatusCode {
-		case http.StatusTooManyRequests, http.StatusInternalServerError:
-		default:
-			break RetryLoop
+		resp, err = c.httpClient.Do(req)
+		if resp != nil {
+			if rl, ok := alpaca.RateLimitFromHeader(resp.Header); ok {
+				c.rateLimit.Store(&rl)
+				c.opts.RateLimiter.Observe(rl)
+			}
 		}
-		if i >= c.opts.RetryLimit {
+		if !c.opts.RetryPolicy.ShouldRetry(req, resp, err, i) {
 			break
 		}
-		time.Sleep(c.opts.RetryDelay)
+		delay := c.opts.RetryPolicy.Backoff(i, resp)
+		if resp != nil {
+			closeResp(resp)
+		}
+		if err = sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if resp.StatusCode >= http.StatusMultipleChoices {
@@ -212,6 +258,9 @@ type GetTradesRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// Feed is the source of the data: sip or iex.
 	Feed Feed
 	// AsOf defines the date when the symbols are mapped. "-" means no mapping.
@@ -224,7 +273,12 @@ type GetTradesRequest struct {
 
 // GetTrades returns the trades for the given symbol.
 func (c *Client) GetTrades(symbol string, req GetTradesRequest) ([]Trade, error) {
-	resp, err := c.GetMultiTrades([]string{symbol}, req)
+	return c.GetTradesWithContext(context.Background(), symbol, req)
+}
+
+// GetTradesWithContext returns the trades for the given symbol.
+func (c *Client) GetTradesWithContext(ctx context.Context, symbol string, req GetTradesRequest) ([]Trade, error) {
+	resp, err := c.GetMultiTradesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -233,48 +287,43 @@ func (c *Client) GetTrades(symbol string, req GetTradesRequest) ([]Trade, error)
 
 // GetMultiTrades returns trades for the given symbols.
 func (c *Client) GetMultiTrades(symbols []string, req GetTradesRequest) (map[string][]Trade, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/%s/trades", c.opts.BaseURL, stockPrefix))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	c.setBaseQuery(q, baseRequest{
-		Symbols:  symbols,
-		Start:    req.Start,
-		End:      req.End,
-		Feed:     req.Feed,
-		AsOf:     req.AsOf,
-		Currency: req.Currency,
-		Sort:     req.Sort,
-	})
-
-	trades := make(map[string][]Trade, len(symbols))
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var tradeResp multiTradeResponse
-		if err = unmarshal(resp, &tradeResp); err != nil {
-			return nil, err
-		}
-
-		for symbol, t := range tradeResp.Trades {
-			trades[symbol] = append(trades[symbol], t...)
-			received += len(t)
-		}
-		if tradeResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *tradeResp.NextPageToken)
-	}
-	return trades, nil
+	return c.GetMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+// GetMultiTradesWithContext returns trades for the given symbols.
+func (c *Client) GetMultiTradesWithContext(
+	ctx context.Context, symbols []string, req GetTradesRequest,
+) (map[string][]Trade, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[Trade], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]Trade, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetMultiTradesWithContext(ctx, symbols, r)
+		})
+	}
+	return collectPages(c.IterateTrades(ctx, symbols, req), len(symbols))
+}
+
+// IterateTrades returns an iterator over the pages of the trades for the given symbols. Unlike
+// GetMultiTrades, it doesn't accumulate the trades: a page is only requested when the iterator
+// is advanced.
+func (c *Client) IterateTrades(
+	ctx context.Context, symbols []string, req GetTradesRequest,
+) *PageIterator[Trade] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/%s/trades", c.opts.BaseURL, stockPrefix), func(q url.Values) {
+		c.setBaseQuery(q, baseRequest{
+			Symbols:  symbols,
+			Start:    req.Start,
+			End:      req.End,
+			Feed:     req.Feed,
+			AsOf:     req.AsOf,
+			Currency: req.Currency,
+			Sort:     req.Sort,
+		})
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeTrades)
 }
 
 // GetQuotesRequest contains optional parameters for getting quotes
@@ -288,6 +337,9 @@ type GetQuotesRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// Feed is the source of the data: sip or iex.
 	Feed Feed
 	// AsOf defines the date when the symbols are mapped. "-" means no mapping.
@@ -300,7 +352,12 @@ type GetQuotesRequest struct {
 
 // GetQuotes returns the quotes for the given symbol.
 func (c *Client) GetQuotes(symbol string, req GetQuotesRequest) ([]Quote, error) {
-	resp, err := c.GetMultiQuotes([]string{symbol}, req)
+	return c.GetQuotesWithContext(context.Background(), symbol, req)
+}
+
+// GetQuotesWithContext returns the quotes for the given symbol.
+func (c *Client) GetQuotesWithContext(ctx context.Context, symbol string, req GetQuotesRequest) ([]Quote, error) {
+	resp, err := c.GetMultiQuotesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -309,48 +366,43 @@ func (c *Client) GetQuotes(symbol string, req GetQuotesRequest) ([]Quote, error)
 
 // GetMultiQuotes returns quotes for the given symbols.
 func (c *Client) GetMultiQuotes(symbols []string, req GetQuotesRequest) (map[string][]Quote, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/%s/quotes", c.opts.BaseURL, stockPrefix))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	c.setBaseQuery(q, baseRequest{
-		Symbols:  symbols,
-		Start:    req.Start,
-		End:      req.End,
-		Feed:     req.Feed,
-		AsOf:     req.AsOf,
-		Currency: req.Currency,
-		Sort:     req.Sort,
-	})
-
-	quotes := make(map[string][]Quote, len(symbols))
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var quoteResp multiQuoteResponse
-		if err = unmarshal(resp, &quoteResp); err != nil {
-			return nil, err
-		}
-
-		for symbol, q := range quoteResp.Quotes {
-			quotes[symbol] = append(quotes[symbol], q...)
-			received += len(q)
-		}
-		if quoteResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *quoteResp.NextPageToken)
-	}
-	return quotes, nil
+	return c.GetMultiQuotesWithContext(context.Background(), symbols, req)
+}
+
+// GetMultiQuotesWithContext returns quotes for the given symbols.
+func (c *Client) GetMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req GetQuotesRequest,
+) (map[string][]Quote, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[Quote], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]Quote, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetMultiQuotesWithContext(ctx, symbols, r)
+		})
+	}
+	return collectPages(c.IterateQuotes(ctx, symbols, req), len(symbols))
+}
+
+// IterateQuotes returns an iterator over the pages of the quotes for the given symbols. Unlike
+// GetMultiQuotes, it doesn't accumulate the quotes: a page is only requested when the iterator
+// is advanced.
+func (c *Client) IterateQuotes(
+	ctx context.Context, symbols []string, req GetQuotesRequest,
+) *PageIterator[Quote] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/%s/quotes", c.opts.BaseURL, stockPrefix), func(q url.Values) {
+		c.setBaseQuery(q, baseRequest{
+			Symbols:  symbols,
+			Start:    req.Start,
+			End:      req.End,
+			Feed:     req.Feed,
+			AsOf:     req.AsOf,
+			Currency: req.Currency,
+			Sort:     req.Sort,
+		})
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeQuotes)
 }
 
 // GetBarsRequest contains optional parameters for getting bars
@@ -368,6 +420,9 @@ type GetBarsRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// Feed is the source of the data: sip or iex.
 	// If provided, it overrides the client's Feed option.
 	Feed Feed
@@ -403,7 +458,12 @@ func (c *Client) setQueryBarRequest(q url.Values, symbols []string, req GetBarsR
 
 // GetBars returns a slice of bars for the given symbol.
 func (c *Client) GetBars(symbol string, req GetBarsRequest) ([]Bar, error) {
-	resp, err := c.GetMultiBars([]string{symbol}, req)
+	return c.GetBarsWithContext(context.Background(), symbol, req)
+}
+
+// GetBarsWithContext returns a slice of bars for the given symbol.
+func (c *Client) GetBarsWithContext(ctx context.Context, symbol string, req GetBarsRequest) ([]Bar, error) {
+	resp, err := c.GetMultiBarsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -412,41 +472,35 @@ func (c *Client) GetBars(symbol string, req GetBarsRequest) ([]Bar, error) {
 
 // GetMultiBars returns bars for the given symbols.
 func (c *Client) GetMultiBars(symbols []string, req GetBarsRequest) (map[string][]Bar, error) {
-	bars := make(map[string][]Bar, len(symbols))
+	return c.GetMultiBarsWithContext(context.Background(), symbols, req)
+}
 
-	u, err := url.Parse(fmt.Sprintf("%s/%s/bars", c.opts.BaseURL, stockPrefix))
-	if err != nil {
-		return nil, err
+// GetMultiBarsWithContext returns bars for the given symbols.
+func (c *Client) GetMultiBarsWithContext(
+	ctx context.Context, symbols []string, req GetBarsRequest,
+) (map[string][]Bar, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[Bar], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]Bar, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetMultiBarsWithContext(ctx, symbols, r)
+		})
 	}
+	return collectPages(c.IterateBars(ctx, symbols, req), len(symbols))
+}
 
-	q := u.Query()
-	c.setQueryBarRequest(q, symbols, req)
-
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var barResp multiBarResponse
-		if err = unmarshal(resp, &barResp); err != nil {
-			return nil, err
-		}
-
-		for symbol, b := range barResp.Bars {
-			bars[symbol] = append(bars[symbol], b...)
-			received += len(b)
-		}
-		if barResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *barResp.NextPageToken)
-	}
-	return bars, nil
+// IterateBars returns an iterator over the pages of the bars for the given symbols. Unlike
+// GetMultiBars, it doesn't accumulate the bars: a page is only requested when the iterator is
+// advanced.
+func (c *Client) IterateBars(
+	ctx context.Context, symbols []string, req GetBarsRequest,
+) *PageIterator[Bar] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/%s/bars", c.opts.BaseURL, stockPrefix), func(q url.Values) {
+		c.setQueryBarRequest(q, symbols, req)
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeBars)
 }
 
 // GetAuctionsRequest contains optional parameters for getting auctions
@@ -470,7 +524,14 @@ type GetAuctionsRequest struct {
 
 // GetAuctions returns the auctions for the given symbol.
 func (c *Client) GetAuctions(symbol string, req GetAuctionsRequest) ([]DailyAuctions, error) {
-	resp, err := c.GetMultiAuctions([]string{symbol}, req)
+	return c.GetAuctionsWithContext(context.Background(), symbol, req)
+}
+
+// GetAuctionsWithContext returns the auctions for the given symbol.
+func (c *Client) GetAuctionsWithContext(
+	ctx context.Context, symbol string, req GetAuctionsRequest,
+) ([]DailyAuctions, error) {
+	resp, err := c.GetMultiAuctionsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -481,6 +542,24 @@ func (c *Client) GetAuctions(symbol string, req GetAuctionsRequest) ([]DailyAuct
 func (c *Client) GetMultiAuctions(
 	symbols []string, req GetAuctionsRequest,
 ) (map[string][]DailyAuctions, error) {
+	return c.GetMultiAuctionsWithContext(context.Background(), symbols, req)
+}
+
+// GetMultiAuctionsWithContext returns auctions for the given symbols.
+func (c *Client) GetMultiAuctionsWithContext(
+	ctx context.Context, symbols []string, req GetAuctionsRequest,
+) (map[string][]DailyAuctions, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[DailyAuctions], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]DailyAuctions, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetMultiAuctionsWithContext(ctx, symbols, r)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/auctions", c.opts.BaseURL, stockPrefix))
 	if err != nil {
 		return nil, err
@@ -503,7 +582,7 @@ func (c *Client) GetMultiAuctions(
 		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
 		u.RawQuery = q.Encode()
 
-		resp, err := c.get(u)
+		resp, err := c.get(ctx, u)
 		if err != nil {
 			return nil, err
 		}
@@ -557,7 +636,12 @@ type GetLatestBarRequest struct {
 
 // GetLatestBar returns the latest minute bar for a given symbol
 func (c *Client) GetLatestBar(symbol string, req GetLatestBarRequest) (*Bar, error) {
-	resp, err := c.GetLatestBars([]string{symbol}, req)
+	return c.GetLatestBarWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestBarWithContext returns the latest minute bar for a given symbol
+func (c *Client) GetLatestBarWithContext(ctx context.Context, symbol string, req GetLatestBarRequest) (*Bar, error) {
+	resp, err := c.GetLatestBarsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -570,6 +654,19 @@ func (c *Client) GetLatestBar(symbol string, req GetLatestBarRequest) (*Bar, err
 
 // GetLatestBars returns the latest minute bars for the given symbols
 func (c *Client) GetLatestBars(symbols []string, req GetLatestBarRequest) (map[string]Bar, error) {
+	return c.GetLatestBarsWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestBarsWithContext returns the latest minute bars for the given symbols
+func (c *Client) GetLatestBarsWithContext(
+	ctx context.Context, symbols []string, req GetLatestBarRequest,
+) (map[string]Bar, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]Bar, error) {
+			return c.GetLatestBarsWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/bars/latest", c.opts.BaseURL, stockPrefix))
 	if err != nil {
 		return nil, err
@@ -580,7 +677,7 @@ func (c *Client) GetLatestBars(symbols []string, req GetLatestBarRequest) (map[s
 		Currency: req.Currency,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -600,7 +697,14 @@ type GetLatestTradeRequest struct {
 
 // GetLatestTrade returns the latest trade for a given symbol
 func (c *Client) GetLatestTrade(symbol string, req GetLatestTradeRequest) (*Trade, error) {
-	resp, err := c.GetLatestTrades([]string{symbol}, req)
+	return c.GetLatestTradeWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestTradeWithContext returns the latest trade for a given symbol
+func (c *Client) GetLatestTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestTradeRequest,
+) (*Trade, error) {
+	resp, err := c.GetLatestTradesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -613,6 +717,19 @@ func (c *Client) GetLatestTrade(symbol string, req GetLatestTradeRequest) (*Trad
 
 // GetLatestTrades returns the latest trades for the given symbols
 func (c *Client) GetLatestTrades(symbols []string, req GetLatestTradeRequest) (map[string]Trade, error) {
+	return c.GetLatestTradesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestTradesWithContext returns the latest trades for the given symbols
+func (c *Client) GetLatestTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestTradeRequest,
+) (map[string]Trade, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]Trade, error) {
+			return c.GetLatestTradesWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/trades/latest", c.opts.BaseURL, stockPrefix))
 	if err != nil {
 		return nil, err
@@ -623,7 +740,7 @@ func (c *Client) GetLatestTrades(symbols []string, req GetLatestTradeRequest) (m
 		Currency: req.Currency,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -643,7 +760,14 @@ type GetLatestQuoteRequest struct {
 
 // GetLatestQuote returns the latest quote for a given symbol
 func (c *Client) GetLatestQuote(symbol string, req GetLatestQuoteRequest) (*Quote, error) {
-	resp, err := c.GetLatestQuotes([]string{symbol}, req)
+	return c.GetLatestQuoteWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestQuoteWithContext returns the latest quote for a given symbol
+func (c *Client) GetLatestQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestQuoteRequest,
+) (*Quote, error) {
+	resp, err := c.GetLatestQuotesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -656,6 +780,19 @@ func (c *Client) GetLatestQuote(symbol string, req GetLatestQuoteRequest) (*Quot
 
 // GetLatestQuotes returns the latest quotes for the given symbols
 func (c *Client) GetLatestQuotes(symbols []string, req GetLatestQuoteRequest) (map[string]Quote, error) {
+	return c.GetLatestQuotesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestQuotesWithContext returns the latest quotes for the given symbols
+func (c *Client) GetLatestQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestQuoteRequest,
+) (map[string]Quote, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]Quote, error) {
+			return c.GetLatestQuotesWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/quotes/latest", c.opts.BaseURL, stockPrefix))
 	if err != nil {
 		return nil, err
@@ -666,7 +803,7 @@ func (c *Client) GetLatestQuotes(symbols []string, req GetLatestQuoteRequest) (m
 		Currency: req.Currency,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -686,7 +823,12 @@ type GetSnapshotRequest struct {
 
 // GetSnapshot returns the snapshot for a given symbol
 func (c *Client) GetSnapshot(symbol string, req GetSnapshotRequest) (*Snapshot, error) {
-	resp, err := c.GetSnapshots([]string{symbol}, req)
+	return c.GetSnapshotWithContext(context.Background(), symbol, req)
+}
+
+// GetSnapshotWithContext returns the snapshot for a given symbol
+func (c *Client) GetSnapshotWithContext(ctx context.Context, symbol string, req GetSnapshotRequest) (*Snapshot, error) {
+	resp, err := c.GetSnapshotsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -695,6 +837,19 @@ func (c *Client) GetSnapshot(symbol string, req GetSnapshotRequest) (*Snapshot,
 
 // GetSnapshots returns the snapshots for multiple symbol
 func (c *Client) GetSnapshots(symbols []string, req GetSnapshotRequest) (map[string]*Snapshot, error) {
+	return c.GetSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+// GetSnapshotsWithContext returns the snapshots for multiple symbol
+func (c *Client) GetSnapshotsWithContext(
+	ctx context.Context, symbols []string, req GetSnapshotRequest,
+) (map[string]*Snapshot, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]*Snapshot, error) {
+			return c.GetSnapshotsWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/%s/snapshots", c.opts.BaseURL, stockPrefix))
 	if err != nil {
 		return nil, err
@@ -705,7 +860,7 @@ func (c *Client) GetSnapshots(symbols []string, req GetSnapshotRequest) (map[str
 		Currency: req.Currency,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -754,6 +909,9 @@ type GetCryptoTradesRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// CryptoFeed is the crypto feed. Default is "us".
 	CryptoFeed CryptoFeed
 	// Sort is the sort direction of the data
@@ -767,7 +925,14 @@ func (r GetCryptoTradesRequest) isPerp() bool           { return r.perpetualFutu
 
 // GetCryptoTrades returns the trades for the given crypto symbol.
 func (c *Client) GetCryptoTrades(symbol string, req GetCryptoTradesRequest) ([]CryptoTrade, error) {
-	resp, err := c.GetCryptoMultiTrades([]string{symbol}, req)
+	return c.GetCryptoTradesWithContext(context.Background(), symbol, req)
+}
+
+// GetCryptoTradesWithContext returns the trades for the given crypto symbol.
+func (c *Client) GetCryptoTradesWithContext(
+	ctx context.Context, symbol string, req GetCryptoTradesRequest,
+) ([]CryptoTrade, error) {
+	resp, err := c.GetCryptoMultiTradesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -776,45 +941,40 @@ func (c *Client) GetCryptoTrades(symbol string, req GetCryptoTradesRequest) ([]C
 
 // GetCryptoMultiTrades returns trades for the given crypto symbols.
 func (c *Client) GetCryptoMultiTrades(symbols []string, req GetCryptoTradesRequest) (map[string][]CryptoTrade, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/trades", c.cryptoURL(req)))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	setCryptoBaseQuery(q, cryptoBaseRequest{
-		Symbols: symbols,
-		Start:   req.Start,
-		End:     req.End,
-		Sort:    req.Sort,
-	})
-
-	trades := make(map[string][]CryptoTrade, len(symbols))
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var tradeResp cryptoMultiTradeResponse
-		if err = unmarshal(resp, &tradeResp); err != nil {
-			return nil, err
-		}
-
-		for symbol, t := range tradeResp.Trades {
-			trades[symbol] = append(trades[symbol], t...)
-			received += len(t)
-		}
-		if tradeResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *tradeResp.NextPageToken)
-	}
-	return trades, nil
+	return c.GetCryptoMultiTradesWithContext(context.Background(), symbols, req)
+}
+
+// GetCryptoMultiTradesWithContext returns trades for the given crypto symbols.
+func (c *Client) GetCryptoMultiTradesWithContext(
+	ctx context.Context, symbols []string, req GetCryptoTradesRequest,
+) (map[string][]CryptoTrade, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[CryptoTrade], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]CryptoTrade, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetCryptoMultiTradesWithContext(ctx, symbols, r)
+		})
+	}
+	return collectPages(c.IterateCryptoTrades(ctx, symbols, req), len(symbols))
+}
+
+// IterateCryptoTrades returns an iterator over the pages of the crypto trades for the given
+// symbols. Unlike GetCryptoMultiTrades, it doesn't accumulate the trades: a page is only
+// requested when the iterator is advanced.
+func (c *Client) IterateCryptoTrades(
+	ctx context.Context, symbols []string, req GetCryptoTradesRequest,
+) *PageIterator[CryptoTrade] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/trades", c.cryptoURL(req)), func(q url.Values) {
+		setCryptoBaseQuery(q, cryptoBaseRequest{
+			Symbols: symbols,
+			Start:   req.Start,
+			End:     req.End,
+			Sort:    req.Sort,
+		})
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeCryptoTrades)
 }
 
 // GetCryptoQuotesRequest contains optional parameters for getting crypto quotes
@@ -828,6 +988,9 @@ type GetCryptoQuotesRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// CryptoFeed is the crypto feed. Default is "us".
 	CryptoFeed CryptoFeed
 	// Sort is the sort direction of the data
@@ -841,7 +1004,14 @@ func (r GetCryptoQuotesRequest) isPerp() bool           { return r.perpetualFutu
 
 // GetCryptoQuotes returns the trades for the given crypto symbol.
 func (c *Client) GetCryptoQuotes(symbol string, req GetCryptoQuotesRequest) ([]CryptoQuote, error) {
-	resp, err := c.GetCryptoMultiQuotes([]string{symbol}, req)
+	return c.GetCryptoQuotesWithContext(context.Background(), symbol, req)
+}
+
+// GetCryptoQuotesWithContext returns the trades for the given crypto symbol.
+func (c *Client) GetCryptoQuotesWithContext(
+	ctx context.Context, symbol string, req GetCryptoQuotesRequest,
+) ([]CryptoQuote, error) {
+	resp, err := c.GetCryptoMultiQuotesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -850,45 +1020,40 @@ func (c *Client) GetCryptoQuotes(symbol string, req GetCryptoQuotesRequest) ([]C
 
 // GetCryptoMultiQuotes returns quotes for the given crypto symbols.
 func (c *Client) GetCryptoMultiQuotes(symbols []string, req GetCryptoQuotesRequest) (map[string][]CryptoQuote, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/quotes", c.cryptoURL(req)))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	setCryptoBaseQuery(q, cryptoBaseRequest{
-		Symbols: symbols,
-		Start:   req.Start,
-		End:     req.End,
-		Sort:    req.Sort,
-	})
-
-	quotes := make(map[string][]CryptoQuote, len(symbols))
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var quoteResp cryptoMultiQuoteResponse
-		if err = unmarshal(resp, &quoteResp); err != nil {
-			return nil, err
-		}
-
-		for symbol, t := range quoteResp.Quotes {
-			quotes[symbol] = append(quotes[symbol], t...)
-			received += len(t)
-		}
-		if quoteResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *quoteResp.NextPageToken)
-	}
-	return quotes, nil
+	return c.GetCryptoMultiQuotesWithContext(context.Background(), symbols, req)
+}
+
+// GetCryptoMultiQuotesWithContext returns quotes for the given crypto symbols.
+func (c *Client) GetCryptoMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req GetCryptoQuotesRequest,
+) (map[string][]CryptoQuote, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[CryptoQuote], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]CryptoQuote, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetCryptoMultiQuotesWithContext(ctx, symbols, r)
+		})
+	}
+	return collectPages(c.IterateCryptoQuotes(ctx, symbols, req), len(symbols))
+}
+
+// IterateCryptoQuotes returns an iterator over the pages of the crypto quotes for the given
+// symbols. Unlike GetCryptoMultiQuotes, it doesn't accumulate the quotes: a page is only
+// requested when the iterator is advanced.
+func (c *Client) IterateCryptoQuotes(
+	ctx context.Context, symbols []string, req GetCryptoQuotesRequest,
+) *PageIterator[CryptoQuote] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/quotes", c.cryptoURL(req)), func(q url.Values) {
+		setCryptoBaseQuery(q, cryptoBaseRequest{
+			Symbols: symbols,
+			Start:   req.Start,
+			End:     req.End,
+			Sort:    req.Sort,
+		})
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeCryptoQuotes)
 }
 
 // GetCryptoBarsRequest contains optional parameters for getting crypto bars
@@ -904,6 +1069,9 @@ type GetCryptoBarsRequest struct {
 	TotalLimit int
 	// PageLimit is the pagination size. If empty, the default page size will be used.
 	PageLimit int
+	// PageToken is the token of the page to start from, e.g. the NextPageToken of a PageIterator,
+	// to resume an interrupted download.
+	PageToken string
 	// CryptoFeed is the crypto feed. Default is "us".
 	CryptoFeed CryptoFeed
 	// Sort is the sort direction of the data
@@ -931,7 +1099,14 @@ func setQueryCryptoBarRequest(q url.Values, symbols []string, req GetCryptoBarsR
 
 // GetCryptoBars returns a slice of bars for the given crypto symbol.
 func (c *Client) GetCryptoBars(symbol string, req GetCryptoBarsRequest) ([]CryptoBar, error) {
-	resp, err := c.GetCryptoMultiBars([]string{symbol}, req)
+	return c.GetCryptoBarsWithContext(context.Background(), symbol, req)
+}
+
+// GetCryptoBarsWithContext returns a slice of bars for the given crypto symbol.
+func (c *Client) GetCryptoBarsWithContext(
+	ctx context.Context, symbol string, req GetCryptoBarsRequest,
+) ([]CryptoBar, error) {
+	resp, err := c.GetCryptoMultiBarsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -940,41 +1115,36 @@ func (c *Client) GetCryptoBars(symbol string, req GetCryptoBarsRequest) ([]Crypt
 
 // GetCryptoMultiBars returns bars for the given crypto symbols.
 func (c *Client) GetCryptoMultiBars(symbols []string, req GetCryptoBarsRequest) (map[string][]CryptoBar, error) {
-	u, err := url.Parse(fmt.Sprintf("%s/%s/%s/bars",
-		c.opts.BaseURL, cryptoPrefix, c.cryptoFeed(req.CryptoFeed)))
-	if err != nil {
-		return nil, err
-	}
-
-	q := u.Query()
-	setQueryCryptoBarRequest(q, symbols, req)
-
-	bars := make(map[string][]CryptoBar, len(symbols))
-	received := 0
-	for req.TotalLimit == 0 || received < req.TotalLimit {
-		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
-		u.RawQuery = q.Encode()
-
-		resp, err := c.get(u)
-		if err != nil {
-			return nil, err
-		}
-
-		var barResp cryptoMultiBarResponse
-		if err = unmarshal(resp, &barResp); err != nil {
-			return nil, err
-		}
-
-		for symbol, b := range barResp.Bars {
-			bars[symbol] = append(bars[symbol], b...)
-			received += len(b)
-		}
-		if barResp.NextPageToken == nil {
-			break
-		}
-		q.Set("page_token", *barResp.NextPageToken)
-	}
-	return bars, nil
+	return c.GetCryptoMultiBarsWithContext(context.Background(), symbols, req)
+}
+
+// GetCryptoMultiBarsWithContext returns bars for the given crypto symbols.
+func (c *Client) GetCryptoMultiBarsWithContext(
+	ctx context.Context, symbols []string, req GetCryptoBarsRequest,
+) (map[string][]CryptoBar, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		pr := pageRequest{TotalLimit: req.TotalLimit, PageToken: req.PageToken}
+		return getPagedBatches(ctx, c, batches, pr, recordCount[CryptoBar], func(
+			ctx context.Context, symbols []string, totalLimit int,
+		) (map[string][]CryptoBar, error) {
+			r := req
+			r.TotalLimit = totalLimit
+			return c.GetCryptoMultiBarsWithContext(ctx, symbols, r)
+		})
+	}
+	return collectPages(c.IterateCryptoBars(ctx, symbols, req), len(symbols))
+}
+
+// IterateCryptoBars returns an iterator over the pages of the crypto bars for the given
+// symbols. Unlike GetCryptoMultiBars, it doesn't accumulate the bars: a page is only requested
+// when the iterator is advanced.
+func (c *Client) IterateCryptoBars(
+	ctx context.Context, symbols []string, req GetCryptoBarsRequest,
+) *PageIterator[CryptoBar] {
+	return newPageIterator(ctx, c, fmt.Sprintf("%s/%s/%s/bars",
+		c.opts.BaseURL, cryptoPrefix, c.cryptoFeed(req.CryptoFeed)), func(q url.Values) {
+		setQueryCryptoBarRequest(q, symbols, req)
+	}, pageRequest{TotalLimit: req.TotalLimit, PageLimit: req.PageLimit, PageToken: req.PageToken}, decodeCryptoBars)
 }
 
 type cryptoBaseLatestRequest struct {
@@ -1029,7 +1199,14 @@ func (c *Client) cryptoURL(fromReq cryptoRequest) string {
 
 // GetLatestCryptoBar returns the latest bar for a given crypto symbol
 func (c *Client) GetLatestCryptoBar(symbol string, req GetLatestCryptoBarRequest) (*CryptoBar, error) {
-	resp, err := c.GetLatestCryptoBars([]string{symbol}, req)
+	return c.GetLatestCryptoBarWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestCryptoBarWithContext returns the latest bar for a given crypto symbol
+func (c *Client) GetLatestCryptoBarWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoBarRequest,
+) (*CryptoBar, error) {
+	resp, err := c.GetLatestCryptoBarsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1042,9 +1219,16 @@ func (c *Client) GetLatestCryptoBar(symbol string, req GetLatestCryptoBarRequest
 
 // GetLatestCryptoPerpBar returns the latest bar for a given crypto perpetual future
 func (c *Client) GetLatestCryptoPerpBar(symbol string, req GetLatestCryptoBarRequest) (*CryptoPerpBar, error) {
+	return c.GetLatestCryptoPerpBarWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestCryptoPerpBarWithContext returns the latest bar for a given crypto perpetual future
+func (c *Client) GetLatestCryptoPerpBarWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoBarRequest,
+) (*CryptoPerpBar, error) {
 	req.perpetualFutures = true
 
-	latestBar, err := c.GetLatestCryptoBars([]string{symbol}, req)
+	latestBar, err := c.GetLatestCryptoBarsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1059,10 +1243,17 @@ func (c *Client) GetLatestCryptoPerpBar(symbol string, req GetLatestCryptoBarReq
 // GetLatestCryptoPerpBars returns the latest bars for the given crypto perpetual futures
 func (c *Client) GetLatestCryptoPerpBars(
 	symbols []string, req GetLatestCryptoBarRequest,
+) (map[string]CryptoPerpBar, error) {
+	return c.GetLatestCryptoPerpBarsWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoPerpBarsWithContext returns the latest bars for the given crypto perpetual futures
+func (c *Client) GetLatestCryptoPerpBarsWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoBarRequest,
 ) (map[string]CryptoPerpBar, error) {
 	req.perpetualFutures = true
 
-	bars, err := c.GetLatestCryptoBars(symbols, req)
+	bars, err := c.GetLatestCryptoBarsWithContext(ctx, symbols, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1076,6 +1267,19 @@ func (c *Client) GetLatestCryptoPerpBars(
 
 // GetLatestCryptoBars returns the latest bars for the given crypto symbols
 func (c *Client) GetLatestCryptoBars(symbols []string, req GetLatestCryptoBarRequest) (map[string]CryptoBar, error) {
+	return c.GetLatestCryptoBarsWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoBarsWithContext returns the latest bars for the given crypto symbols
+func (c *Client) GetLatestCryptoBarsWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoBarRequest,
+) (map[string]CryptoBar, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]CryptoBar, error) {
+			return c.GetLatestCryptoBarsWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/latest/bars", c.cryptoURL(req)))
 	if err != nil {
 		return nil, err
@@ -1084,7 +1288,7 @@ func (c *Client) GetLatestCryptoBars(symbols []string, req GetLatestCryptoBarReq
 		Symbols: symbols,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1108,7 +1312,14 @@ func (r GetLatestCryptoTradeRequest) isPerp() bool           { return r.perpetua
 
 // GetLatestCryptoTrade returns the latest trade for a given crypto symbol
 func (c *Client) GetLatestCryptoTrade(symbol string, req GetLatestCryptoTradeRequest) (*CryptoTrade, error) {
-	resp, err := c.GetLatestCryptoTrades([]string{symbol}, req)
+	return c.GetLatestCryptoTradeWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestCryptoTradeWithContext returns the latest trade for a given crypto symbol
+func (c *Client) GetLatestCryptoTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoTradeRequest,
+) (*CryptoTrade, error) {
+	resp, err := c.GetLatestCryptoTradesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1121,9 +1332,16 @@ func (c *Client) GetLatestCryptoTrade(symbol string, req GetLatestCryptoTradeReq
 
 // GetLatestCryptoPerpTrade returns the latest trade for a given crypto perp symbol
 func (c *Client) GetLatestCryptoPerpTrade(symbol string, req GetLatestCryptoTradeRequest) (*CryptoPerpTrade, error) {
+	return c.GetLatestCryptoPerpTradeWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestCryptoPerpTradeWithContext returns the latest trade for a given crypto perp symbol
+func (c *Client) GetLatestCryptoPerpTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoTradeRequest,
+) (*CryptoPerpTrade, error) {
 	req.perpetualFutures = true
 
-	latestTrade, err := c.GetLatestCryptoTrade(symbol, req)
+	latestTrade, err := c.GetLatestCryptoTradeWithContext(ctx, symbol, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1135,10 +1353,17 @@ func (c *Client) GetLatestCryptoPerpTrade(symbol string, req GetLatestCryptoTrad
 // GetLatestCryptoPerpTrades returns the latest trades for the given crypto perpetual futures
 func (c *Client) GetLatestCryptoPerpTrades(
 	symbols []string, req GetLatestCryptoTradeRequest,
+) (map[string]CryptoPerpTrade, error) {
+	return c.GetLatestCryptoPerpTradesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoPerpTradesWithContext returns the latest trades for the given crypto perpetual futures
+func (c *Client) GetLatestCryptoPerpTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoTradeRequest,
 ) (map[string]CryptoPerpTrade, error) {
 	req.perpetualFutures = true
 
-	trades, err := c.GetLatestCryptoTrades(symbols, req)
+	trades, err := c.GetLatestCryptoTradesWithContext(ctx, symbols, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1155,6 +1380,19 @@ func (c *Client) GetLatestCryptoPerpTrades(
 func (c *Client) GetLatestCryptoTrades(
 	symbols []string, req GetLatestCryptoTradeRequest,
 ) (map[string]CryptoTrade, error) {
+	return c.GetLatestCryptoTradesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoTradesWithContext returns the latest trades for the given crypto symbols
+func (c *Client) GetLatestCryptoTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoTradeRequest,
+) (map[string]CryptoTrade, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]CryptoTrade, error) {
+			return c.GetLatestCryptoTradesWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/latest/trades", c.cryptoURL(req)))
 	if err != nil {
 		return nil, err
@@ -1163,7 +1401,7 @@ func (c *Client) GetLatestCryptoTrades(
 		Symbols: symbols,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1187,7 +1425,14 @@ func (r GetLatestCryptoQuoteRequest) isPerp() bool           { return r.perpetua
 
 // GetLatestCryptoQuote returns the latest quote for a given crypto symbol
 func (c *Client) GetLatestCryptoQuote(symbol string, req GetLatestCryptoQuoteRequest) (*CryptoQuote, error) {
-	resp, err := c.GetLatestCryptoQuotes([]string{symbol}, req)
+	return c.GetLatestCryptoQuoteWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestCryptoQuoteWithContext returns the latest quote for a given crypto symbol
+func (c *Client) GetLatestCryptoQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoQuoteRequest,
+) (*CryptoQuote, error) {
+	resp, err := c.GetLatestCryptoQuotesWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1200,9 +1445,16 @@ func (c *Client) GetLatestCryptoQuote(symbol string, req GetLatestCryptoQuoteReq
 
 // GetLatestCryptoPerpQuote returns the latest quote for a given crypto perp symbol
 func (c *Client) GetLatestCryptoPerpQuote(symbol string, req GetLatestCryptoQuoteRequest) (*CryptoPerpQuote, error) {
+	return c.GetLatestCryptoPerpQuoteWithContext(context.Background(), symbol, req)
+}
+
+// GetLatestCryptoPerpQuoteWithContext returns the latest quote for a given crypto perp symbol
+func (c *Client) GetLatestCryptoPerpQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoQuoteRequest,
+) (*CryptoPerpQuote, error) {
 	req.perpetualFutures = true
 
-	latestQuote, err := c.GetLatestCryptoQuote(symbol, req)
+	latestQuote, err := c.GetLatestCryptoQuoteWithContext(ctx, symbol, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1214,10 +1466,17 @@ func (c *Client) GetLatestCryptoPerpQuote(symbol string, req GetLatestCryptoQuot
 // GetLatestCryptoPerpQuotes returns the latest quotes for the given crypto perpetual futures
 func (c *Client) GetLatestCryptoPerpQuotes(
 	symbols []string, req GetLatestCryptoQuoteRequest,
+) (map[string]CryptoPerpQuote, error) {
+	return c.GetLatestCryptoPerpQuotesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoPerpQuotesWithContext returns the latest quotes for the given crypto perpetual futures
+func (c *Client) GetLatestCryptoPerpQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoQuoteRequest,
 ) (map[string]CryptoPerpQuote, error) {
 	req.perpetualFutures = true
 
-	quotes, err := c.GetLatestCryptoQuotes(symbols, req)
+	quotes, err := c.GetLatestCryptoQuotesWithContext(ctx, symbols, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1234,6 +1493,19 @@ func (c *Client) GetLatestCryptoPerpQuotes(
 func (c *Client) GetLatestCryptoQuotes(
 	symbols []string, req GetLatestCryptoQuoteRequest,
 ) (map[string]CryptoQuote, error) {
+	return c.GetLatestCryptoQuotesWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoQuotesWithContext returns the latest quotes for the given crypto symbols
+func (c *Client) GetLatestCryptoQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoQuoteRequest,
+) (map[string]CryptoQuote, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]CryptoQuote, error) {
+			return c.GetLatestCryptoQuotesWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/latest/quotes", c.cryptoURL(req)))
 	if err != nil {
 		return nil, err
@@ -1242,7 +1514,7 @@ func (c *Client) GetLatestCryptoQuotes(
 		Symbols: symbols,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1266,10 +1538,16 @@ func (r GetLatestCryptoPerpPricingRequest) isPerp() bool           { return r.pe
 
 func (c *Client) GetLatestCryptoPerpPricing(
 	symbol string, req GetLatestCryptoPerpPricingRequest,
+) (*CryptoPerpPricing, error) {
+	return c.GetLatestCryptoPerpPricingWithContext(context.Background(), symbol, req)
+}
+
+func (c *Client) GetLatestCryptoPerpPricingWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoPerpPricingRequest,
 ) (*CryptoPerpPricing, error) {
 	req.perpetualFutures = true
 
-	resp, err := c.GetLatestCryptoPerpPricingData([]string{symbol}, req)
+	resp, err := c.GetLatestCryptoPerpPricingDataWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1284,6 +1562,19 @@ func (c *Client) GetLatestCryptoPerpPricing(
 func (c *Client) GetLatestCryptoPerpPricingData(
 	symbols []string, req GetLatestCryptoPerpPricingRequest,
 ) (map[string]CryptoPerpPricing, error) {
+	return c.GetLatestCryptoPerpPricingDataWithContext(context.Background(), symbols, req)
+}
+
+// GetLatestCryptoPerpPricingDataWithContext returns the latest pricing data for the given perp symbols
+func (c *Client) GetLatestCryptoPerpPricingDataWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoPerpPricingRequest,
+) (map[string]CryptoPerpPricing, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]CryptoPerpPricing, error) {
+			return c.GetLatestCryptoPerpPricingDataWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/latest/pricing", c.cryptoURL(req)))
 	if err != nil {
 		return nil, err
@@ -1292,7 +1583,7 @@ func (c *Client) GetLatestCryptoPerpPricingData(
 		Symbols: symbols,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1316,7 +1607,14 @@ func (r GetCryptoSnapshotRequest) isPerp() bool           { return r.perpetualFu
 
 // GetCryptoSnapshot returns the snapshot for a given crypto symbol
 func (c *Client) GetCryptoSnapshot(symbol string, req GetCryptoSnapshotRequest) (*CryptoSnapshot, error) {
-	resp, err := c.GetCryptoSnapshots([]string{symbol}, req)
+	return c.GetCryptoSnapshotWithContext(context.Background(), symbol, req)
+}
+
+// GetCryptoSnapshotWithContext returns the snapshot for a given crypto symbol
+func (c *Client) GetCryptoSnapshotWithContext(
+	ctx context.Context, symbol string, req GetCryptoSnapshotRequest,
+) (*CryptoSnapshot, error) {
+	resp, err := c.GetCryptoSnapshotsWithContext(ctx, []string{symbol}, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1329,6 +1627,19 @@ func (c *Client) GetCryptoSnapshot(symbol string, req GetCryptoSnapshotRequest)
 
 // GetCryptoSnapshots returns the snapshots for the given crypto symbols
 func (c *Client) GetCryptoSnapshots(symbols []string, req GetCryptoSnapshotRequest) (map[string]CryptoSnapshot, error) {
+	return c.GetCryptoSnapshotsWithContext(context.Background(), symbols, req)
+}
+
+// GetCryptoSnapshotsWithContext returns the snapshots for the given crypto symbols
+func (c *Client) GetCryptoSnapshotsWithContext(
+	ctx context.Context, symbols []string, req GetCryptoSnapshotRequest,
+) (map[string]CryptoSnapshot, error) {
+	if batches := c.symbolBatches(symbols); len(batches) > 1 {
+		return getBatches(ctx, c, batches, func(ctx context.Context, symbols []string) (map[string]CryptoSnapshot, error) {
+			return c.GetCryptoSnapshotsWithContext(ctx, symbols, req)
+		})
+	}
+
 	u, err := url.Parse(fmt.Sprintf("%s/snapshots", c.cryptoURL(req)))
 	if err != nil {
 		return nil, err
@@ -1337,7 +1648,7 @@ func (c *Client) GetCryptoSnapshots(symbols []string, req GetCryptoSnapshotReque
 		Symbols: symbols,
 	})
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1417,6 +1728,11 @@ func (c *Client) setNewsQuery(q url.Values, p GetNewsRequest) {
 
 // GetNews returns the news articles based on the given req.
 func (c *Client) GetNews(req GetNewsRequest) ([]News, error) {
+	return c.GetNewsWithContext(context.Background(), req)
+}
+
+// GetNewsWithContext returns the news articles based on the given req.
+func (c *Client) GetNewsWithContext(ctx context.Context, req GetNewsRequest) ([]News, error) {
 	if req.TotalLimit < 0 {
 		return nil, errors.New("negative total limit")
 	}
@@ -1444,7 +1760,7 @@ func (c *Client) GetNews(req GetNewsRequest) ([]News, error) {
 		setQueryLimit(q, totalLimit, req.PageLimit, received, newsMaxLimit)
 		u.RawQuery = q.Encode()
 
-		resp, err := c.get(u)
+		resp, err := c.get(ctx, u)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get news: %w", err)
 		}
@@ -1500,6 +1816,13 @@ type GetCorporateActionsRequest struct {
 
 // GetCorporateActions returns the corporate actions based on the given req.
 func (c *Client) GetCorporateActions(req GetCorporateActionsRequest) (CorporateActions, error) {
+	return c.GetCorporateActionsWithContext(context.Background(), req)
+}
+
+// GetCorporateActionsWithContext returns the corporate actions based on the given req.
+func (c *Client) GetCorporateActionsWithContext(
+	ctx context.Context, req GetCorporateActionsRequest,
+) (CorporateActions, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/v1/corporate-actions", c.opts.BaseURL))
 	if err != nil {
 		return CorporateActions{}, err
@@ -1528,7 +1851,7 @@ func (c *Client) GetCorporateActions(req GetCorporateActionsRequest) (CorporateA
 		setQueryLimit(q, req.TotalLimit, req.PageLimit, received, v2MaxLimit)
 		u.RawQuery = q.Encode()
 
-		resp, err := c.get(u)
+		resp, err := c.get(ctx, u)
 		if err != nil {
 			return cas, err
 		}
@@ -1568,7 +1891,12 @@ const fixedIncomePrefix = "v1beta1/fixed_income"
 
 // GetFixedIncomeLatestPrice returns the latest price for a given fixed income security identified by ISIN
 func (c *Client) GetFixedIncomeLatestPrice(isin string) (*FixedIncomePrice, error) {
-	resp, err := c.GetFixedIncomeLatestPrices([]string{isin})
+	return c.GetFixedIncomeLatestPriceWithContext(context.Background(), isin)
+}
+
+// GetFixedIncomeLatestPriceWithContext returns the latest price for a given fixed income security identified by ISIN
+func (c *Client) GetFixedIncomeLatestPriceWithContext(ctx context.Context, isin string) (*FixedIncomePrice, error) {
+	resp, err := c.GetFixedIncomeLatestPricesWithContext(ctx, []string{isin})
 	if err != nil {
 		return nil, err
 	}
@@ -1581,6 +1909,14 @@ func (c *Client) GetFixedIncomeLatestPrice(isin string) (*FixedIncomePrice, erro
 
 // GetFixedIncomeLatestPrices returns the latest prices for the given fixed income securities identified by ISINs
 func (c *Client) GetFixedIncomeLatestPrices(isins []string) (map[string]FixedIncomePrice, error) {
+	return c.GetFixedIncomeLatestPricesWithContext(context.Background(), isins)
+}
+
+// GetFixedIncomeLatestPricesWithContext returns the latest prices for the given fixed income securities
+// identified by ISINs
+func (c *Client) GetFixedIncomeLatestPricesWithContext(
+	ctx context.Context, isins []string,
+) (map[string]FixedIncomePrice, error) {
 	u, err := url.Parse(fmt.Sprintf("%s/%s/latest/prices", c.opts.BaseURL, fixedIncomePrefix))
 	if err != nil {
 		return nil, err
@@ -1591,7 +1927,7 @@ func (c *Client) GetFixedIncomeLatestPrices(isins []string) (map[string]FixedInc
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := c.get(u)
+	resp, err := c.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
@@ -1609,208 +1945,462 @@ func GetTrades(symbol string, req GetTradesRequest) ([]Trade, error) {
 	return DefaultClient.GetTrades(symbol, req)
 }
 
+// GetTradesWithContext returns the trades for the given symbol.
+func GetTradesWithContext(ctx context.Context, symbol string, req GetTradesRequest) ([]Trade, error) {
+	return DefaultClient.GetTradesWithContext(ctx, symbol, req)
+}
+
 // GetMultiTrades returns the trades for the given symbols.
 func GetMultiTrades(symbols []string, req GetTradesRequest) (map[string][]Trade, error) {
 	return DefaultClient.GetMultiTrades(symbols, req)
 }
 
+// GetMultiTradesWithContext returns the trades for the given symbols.
+func GetMultiTradesWithContext(
+	ctx context.Context, symbols []string, req GetTradesRequest,
+) (map[string][]Trade, error) {
+	return DefaultClient.GetMultiTradesWithContext(ctx, symbols, req)
+}
+
 // GetQuotes returns the quotes for the given symbol.
 func GetQuotes(symbol string, req GetQuotesRequest) ([]Quote, error) {
 	return DefaultClient.GetQuotes(symbol, req)
 }
 
+// GetQuotesWithContext returns the quotes for the given symbol.
+func GetQuotesWithContext(ctx context.Context, symbol string, req GetQuotesRequest) ([]Quote, error) {
+	return DefaultClient.GetQuotesWithContext(ctx, symbol, req)
+}
+
 // GetMultiQuotes returns the quotes for the given symbols.
 func GetMultiQuotes(symbols []string, req GetQuotesRequest) (map[string][]Quote, error) {
 	return DefaultClient.GetMultiQuotes(symbols, req)
 }
 
+// GetMultiQuotesWithContext returns the quotes for the given symbols.
+func GetMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req GetQuotesRequest,
+) (map[string][]Quote, error) {
+	return DefaultClient.GetMultiQuotesWithContext(ctx, symbols, req)
+}
+
 // GetBars returns the bars for the given symbol.
 func GetBars(symbol string, req GetBarsRequest) ([]Bar, error) {
 	return DefaultClient.GetBars(symbol, req)
 }
 
+// GetBarsWithContext returns the bars for the given symbol.
+func GetBarsWithContext(ctx context.Context, symbol string, req GetBarsRequest) ([]Bar, error) {
+	return DefaultClient.GetBarsWithContext(ctx, symbol, req)
+}
+
 // GetMultiBars returns the bars for the given symbols.
 func GetMultiBars(symbols []string, req GetBarsRequest) (map[string][]Bar, error) {
 	return DefaultClient.GetMultiBars(symbols, req)
 }
 
+// GetMultiBarsWithContext returns the bars for the given symbols.
+func GetMultiBarsWithContext(ctx context.Context, symbols []string, req GetBarsRequest) (map[string][]Bar, error) {
+	return DefaultClient.GetMultiBarsWithContext(ctx, symbols, req)
+}
+
 // GetAuctions returns the auctions for the given symbol.
 func GetAuctions(symbol string, req GetAuctionsRequest) ([]DailyAuctions, error) {
 	return DefaultClient.GetAuctions(symbol, req)
 }
 
+// GetAuctionsWithContext returns the auctions for the given symbol.
+func GetAuctionsWithContext(ctx context.Context, symbol string, req GetAuctionsRequest) ([]DailyAuctions, error) {
+	return DefaultClient.GetAuctionsWithContext(ctx, symbol, req)
+}
+
 // GetMultiAuctions returns the auctions for the given symbols.
 func GetMultiAuctions(symbols []string, req GetAuctionsRequest) (map[string][]DailyAuctions, error) {
 	return DefaultClient.GetMultiAuctions(symbols, req)
 }
 
+// GetMultiAuctionsWithContext returns the auctions for the given symbols.
+func GetMultiAuctionsWithContext(
+	ctx context.Context, symbols []string, req GetAuctionsRequest,
+) (map[string][]DailyAuctions, error) {
+	return DefaultClient.GetMultiAuctionsWithContext(ctx, symbols, req)
+}
+
 // GetLatestBar returns the latest minute bar for a given symbol.
 func GetLatestBar(symbol string, req GetLatestBarRequest) (*Bar, error) {
 	return DefaultClient.GetLatestBar(symbol, req)
 }
 
+// GetLatestBarWithContext returns the latest minute bar for a given symbol.
+func GetLatestBarWithContext(ctx context.Context, symbol string, req GetLatestBarRequest) (*Bar, error) {
+	return DefaultClient.GetLatestBarWithContext(ctx, symbol, req)
+}
+
 // GetLatestBars returns the latest minute bars for the given symbols.
 func GetLatestBars(symbols []string, req GetLatestBarRequest) (map[string]Bar, error) {
 	return DefaultClient.GetLatestBars(symbols, req)
 }
 
+// GetLatestBarsWithContext returns the latest minute bars for the given symbols.
+func GetLatestBarsWithContext(ctx context.Context, symbols []string, req GetLatestBarRequest) (map[string]Bar, error) {
+	return DefaultClient.GetLatestBarsWithContext(ctx, symbols, req)
+}
+
 // GetLatestTrade returns the latest trade for a given symbol.
 func GetLatestTrade(symbol string, req GetLatestTradeRequest) (*Trade, error) {
 	return DefaultClient.GetLatestTrade(symbol, req)
 }
 
+// GetLatestTradeWithContext returns the latest trade for a given symbol.
+func GetLatestTradeWithContext(ctx context.Context, symbol string, req GetLatestTradeRequest) (*Trade, error) {
+	return DefaultClient.GetLatestTradeWithContext(ctx, symbol, req)
+}
+
 // GetLatestTrades returns the latest trades for the given symbols.
 func GetLatestTrades(symbols []string, req GetLatestTradeRequest) (map[string]Trade, error) {
 	return DefaultClient.GetLatestTrades(symbols, req)
 }
 
+// GetLatestTradesWithContext returns the latest trades for the given symbols.
+func GetLatestTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestTradeRequest,
+) (map[string]Trade, error) {
+	return DefaultClient.GetLatestTradesWithContext(ctx, symbols, req)
+}
+
 // GetLatestQuote returns the latest quote for a given symbol.
 func GetLatestQuote(symbol string, req GetLatestQuoteRequest) (*Quote, error) {
 	return DefaultClient.GetLatestQuote(symbol, req)
 }
 
+// GetLatestQuoteWithContext returns the latest quote for a given symbol.
+func GetLatestQuoteWithContext(ctx context.Context, symbol string, req GetLatestQuoteRequest) (*Quote, error) {
+	return DefaultClient.GetLatestQuoteWithContext(ctx, symbol, req)
+}
+
 // GetLatestQuotes returns the latest quotes for the given symbols.
 func GetLatestQuotes(symbols []string, req GetLatestQuoteRequest) (map[string]Quote, error) {
 	return DefaultClient.GetLatestQuotes(symbols, req)
 }
 
+// GetLatestQuotesWithContext returns the latest quotes for the given symbols.
+func GetLatestQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestQuoteRequest,
+) (map[string]Quote, error) {
+	return DefaultClient.GetLatestQuotesWithContext(ctx, symbols, req)
+}
+
 // GetSnapshot returns the snapshot for a given symbol
 func GetSnapshot(symbol string, req GetSnapshotRequest) (*Snapshot, error) {
 	return DefaultClient.GetSnapshot(symbol, req)
 }
 
+// GetSnapshotWithContext returns the snapshot for a given symbol
+func GetSnapshotWithContext(ctx context.Context, symbol string, req GetSnapshotRequest) (*Snapshot, error) {
+	return DefaultClient.GetSnapshotWithContext(ctx, symbol, req)
+}
+
 // GetSnapshots returns the snapshots for a multiple symbols
 func GetSnapshots(symbols []string, req GetSnapshotRequest) (map[string]*Snapshot, error) {
 	return DefaultClient.GetSnapshots(symbols, req)
 }
 
+// GetSnapshotsWithContext returns the snapshots for a multiple symbols
+func GetSnapshotsWithContext(
+	ctx context.Context, symbols []string, req GetSnapshotRequest,
+) (map[string]*Snapshot, error) {
+	return DefaultClient.GetSnapshotsWithContext(ctx, symbols, req)
+}
+
 // GetCryptoTrades returns the trades for the given crypto symbol.
 func GetCryptoTrades(symbol string, req GetCryptoTradesRequest) ([]CryptoTrade, error) {
 	return DefaultClient.GetCryptoTrades(symbol, req)
 }
 
+// GetCryptoTradesWithContext returns the trades for the given crypto symbol.
+func GetCryptoTradesWithContext(ctx context.Context, symbol string, req GetCryptoTradesRequest) ([]CryptoTrade, error) {
+	return DefaultClient.GetCryptoTradesWithContext(ctx, symbol, req)
+}
+
 // GetCryptoMultiTrades returns trades for the given crypto symbols.
 func GetCryptoMultiTrades(symbols []string, req GetCryptoTradesRequest) (map[string][]CryptoTrade, error) {
 	return DefaultClient.GetCryptoMultiTrades(symbols, req)
 }
 
+// GetCryptoMultiTradesWithContext returns trades for the given crypto symbols.
+func GetCryptoMultiTradesWithContext(
+	ctx context.Context, symbols []string, req GetCryptoTradesRequest,
+) (map[string][]CryptoTrade, error) {
+	return DefaultClient.GetCryptoMultiTradesWithContext(ctx, symbols, req)
+}
+
 // GetCryptoQuotes returns the quotes for the given crypto symbol.
 func GetCryptoQuotes(symbol string, req GetCryptoQuotesRequest) ([]CryptoQuote, error) {
 	return DefaultClient.GetCryptoQuotes(symbol, req)
 }
 
+// GetCryptoQuotesWithContext returns the quotes for the given crypto symbol.
+func GetCryptoQuotesWithContext(ctx context.Context, symbol string, req GetCryptoQuotesRequest) ([]CryptoQuote, error) {
+	return DefaultClient.GetCryptoQuotesWithContext(ctx, symbol, req)
+}
+
 // GetCryptoMultiQuotes returns quotes for the given crypto symbols.
 func GetCryptoMultiQuotes(symbols []string, req GetCryptoQuotesRequest) (map[string][]CryptoQuote, error) {
 	return DefaultClient.GetCryptoMultiQuotes(symbols, req)
 }
 
+// GetCryptoMultiQuotesWithContext returns quotes for the given crypto symbols.
+func GetCryptoMultiQuotesWithContext(
+	ctx context.Context, symbols []string, req GetCryptoQuotesRequest,
+) (map[string][]CryptoQuote, error) {
+	return DefaultClient.GetCryptoMultiQuotesWithContext(ctx, symbols, req)
+}
+
 // GetCryptoBars returns the bars for the given crypto symbol.
 func GetCryptoBars(symbol string, req GetCryptoBarsRequest) ([]CryptoBar, error) {
 	return DefaultClient.GetCryptoBars(symbol, req)
 }
 
+// GetCryptoBarsWithContext returns the bars for the given crypto symbol.
+func GetCryptoBarsWithContext(ctx context.Context, symbol string, req GetCryptoBarsRequest) ([]CryptoBar, error) {
+	return DefaultClient.GetCryptoBarsWithContext(ctx, symbol, req)
+}
+
 // GetCryptoMultiBars returns the bars for the given crypto symbols.
 func GetCryptoMultiBars(symbols []string, req GetCryptoBarsRequest) (map[string][]CryptoBar, error) {
 	return DefaultClient.GetCryptoMultiBars(symbols, req)
 }
 
+// GetCryptoMultiBarsWithContext returns the bars for the given crypto symbols.
+func GetCryptoMultiBarsWithContext(
+	ctx context.Context, symbols []string, req GetCryptoBarsRequest,
+) (map[string][]CryptoBar, error) {
+	return DefaultClient.GetCryptoMultiBarsWithContext(ctx, symbols, req)
+}
+
 // GetLatestCryptoBar returns the latest bar for a given crypto symbol
 func GetLatestCryptoBar(symbol string, req GetLatestCryptoBarRequest) (*CryptoBar, error) {
 	return DefaultClient.GetLatestCryptoBar(symbol, req)
 }
 
+// GetLatestCryptoBarWithContext returns the latest bar for a given crypto symbol
+func GetLatestCryptoBarWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoBarRequest,
+) (*CryptoBar, error) {
+	return DefaultClient.GetLatestCryptoBarWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoBars returns the latest bars for the given crypto symbols
 func GetLatestCryptoBars(symbols []string, req GetLatestCryptoBarRequest) (map[string]CryptoBar, error) {
 	return DefaultClient.GetLatestCryptoBars(symbols, req)
 }
 
+// GetLatestCryptoBarsWithContext returns the latest bars for the given crypto symbols
+func GetLatestCryptoBarsWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoBarRequest,
+) (map[string]CryptoBar, error) {
+	return DefaultClient.GetLatestCryptoBarsWithContext(ctx, symbols, req)
+}
+
 // GetLatestCryptoTrade returns the latest trade for a given crypto symbol
 func GetLatestCryptoTrade(symbol string, req GetLatestCryptoTradeRequest) (*CryptoTrade, error) {
 	return DefaultClient.GetLatestCryptoTrade(symbol, req)
 }
 
+// GetLatestCryptoTradeWithContext returns the latest trade for a given crypto symbol
+func GetLatestCryptoTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoTradeRequest,
+) (*CryptoTrade, error) {
+	return DefaultClient.GetLatestCryptoTradeWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoTrades returns the latest trades for the given crypto symbols
 func GetLatestCryptoTrades(symbols []string, req GetLatestCryptoTradeRequest) (map[string]CryptoTrade, error) {
 	return DefaultClient.GetLatestCryptoTrades(symbols, req)
 }
 
+// GetLatestCryptoTradesWithContext returns the latest trades for the given crypto symbols
+func GetLatestCryptoTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoTradeRequest,
+) (map[string]CryptoTrade, error) {
+	return DefaultClient.GetLatestCryptoTradesWithContext(ctx, symbols, req)
+}
+
 // GetLatestCryptoQuote returns the latest quote for a given crypto symbol
 func GetLatestCryptoQuote(symbol string, req GetLatestCryptoQuoteRequest) (*CryptoQuote, error) {
 	return DefaultClient.GetLatestCryptoQuote(symbol, req)
 }
 
+// GetLatestCryptoQuoteWithContext returns the latest quote for a given crypto symbol
+func GetLatestCryptoQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoQuoteRequest,
+) (*CryptoQuote, error) {
+	return DefaultClient.GetLatestCryptoQuoteWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoQuotes returns the latest quotes for the given crypto symbols
 func GetLatestCryptoQuotes(symbols []string, req GetLatestCryptoQuoteRequest) (map[string]CryptoQuote, error) {
 	return DefaultClient.GetLatestCryptoQuotes(symbols, req)
 }
 
+// GetLatestCryptoQuotesWithContext returns the latest quotes for the given crypto symbols
+func GetLatestCryptoQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoQuoteRequest,
+) (map[string]CryptoQuote, error) {
+	return DefaultClient.GetLatestCryptoQuotesWithContext(ctx, symbols, req)
+}
+
 // GetCryptoSnapshot returns the snapshot for a given crypto symbol
 func GetCryptoSnapshot(symbol string, req GetCryptoSnapshotRequest) (*CryptoSnapshot, error) {
 	return DefaultClient.GetCryptoSnapshot(symbol, req)
 }
 
+// GetCryptoSnapshotWithContext returns the snapshot for a given crypto symbol
+func GetCryptoSnapshotWithContext(
+	ctx context.Context, symbol string, req GetCryptoSnapshotRequest,
+) (*CryptoSnapshot, error) {
+	return DefaultClient.GetCryptoSnapshotWithContext(ctx, symbol, req)
+}
+
 // GetCryptoSnapshots returns the snapshots for the given crypto symbols
 func GetCryptoSnapshots(symbols []string, req GetCryptoSnapshotRequest) (map[string]CryptoSnapshot, error) {
 	return DefaultClient.GetCryptoSnapshots(symbols, req)
 }
 
+// GetCryptoSnapshotsWithContext returns the snapshots for the given crypto symbols
+func GetCryptoSnapshotsWithContext(
+	ctx context.Context, symbols []string, req GetCryptoSnapshotRequest,
+) (map[string]CryptoSnapshot, error) {
+	return DefaultClient.GetCryptoSnapshotsWithContext(ctx, symbols, req)
+}
+
 // GetLatestCryptoPerpTrade returns the latest trade for a given crypto perp symbol
 func GetLatestCryptoPerpTrade(symbol string, req GetLatestCryptoTradeRequest) (*CryptoPerpTrade, error) {
 	return DefaultClient.GetLatestCryptoPerpTrade(symbol, req)
 }
 
+// GetLatestCryptoPerpTradeWithContext returns the latest trade for a given crypto perp symbol
+func GetLatestCryptoPerpTradeWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoTradeRequest,
+) (*CryptoPerpTrade, error) {
+	return DefaultClient.GetLatestCryptoPerpTradeWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoPerpPricing returns the latest perp pricing for a given crypto perp symbol
 func GetLatestCryptoPerpPricing(symbol string, req GetLatestCryptoPerpPricingRequest) (*CryptoPerpPricing, error) {
 	return DefaultClient.GetLatestCryptoPerpPricing(symbol, req)
 }
 
+// GetLatestCryptoPerpPricingWithContext returns the latest perp pricing for a given crypto perp symbol
+func GetLatestCryptoPerpPricingWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoPerpPricingRequest,
+) (*CryptoPerpPricing, error) {
+	return DefaultClient.GetLatestCryptoPerpPricingWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoPerpTrades returns the latest trades for the given crypto perpetual futures
 func GetLatestCryptoPerpTrades(symbols []string, req GetLatestCryptoTradeRequest) (map[string]CryptoPerpTrade, error) {
 	return DefaultClient.GetLatestCryptoPerpTrades(symbols, req)
 }
 
+// GetLatestCryptoPerpTradesWithContext returns the latest trades for the given crypto perpetual futures
+func GetLatestCryptoPerpTradesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoTradeRequest,
+) (map[string]CryptoPerpTrade, error) {
+	return DefaultClient.GetLatestCryptoPerpTradesWithContext(ctx, symbols, req)
+}
+
 // GetLatestCryptoPerpQuote returns the latest quote for a given crypto perpetual future
 func GetLatestCryptoPerpQuote(symbol string, req GetLatestCryptoQuoteRequest) (*CryptoPerpQuote, error) {
 	return DefaultClient.GetLatestCryptoPerpQuote(symbol, req)
 }
 
+// GetLatestCryptoPerpQuoteWithContext returns the latest quote for a given crypto perpetual future
+func GetLatestCryptoPerpQuoteWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoQuoteRequest,
+) (*CryptoPerpQuote, error) {
+	return DefaultClient.GetLatestCryptoPerpQuoteWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoPerpQuotes returns the latest quotes for the given crypto perpetual futures
 func GetLatestCryptoPerpQuotes(symbols []string, req GetLatestCryptoQuoteRequest) (map[string]CryptoPerpQuote, error) {
 	return DefaultClient.GetLatestCryptoPerpQuotes(symbols, req)
 }
 
+// GetLatestCryptoPerpQuotesWithContext returns the latest quotes for the given crypto perpetual futures
+func GetLatestCryptoPerpQuotesWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoQuoteRequest,
+) (map[string]CryptoPerpQuote, error) {
+	return DefaultClient.GetLatestCryptoPerpQuotesWithContext(ctx, symbols, req)
+}
+
 // GetLatestCryptoPerpBar returns the latest bar for a given crypto perpetual future
 func GetLatestCryptoPerpBar(symbol string, req GetLatestCryptoBarRequest) (*CryptoPerpBar, error) {
 	return DefaultClient.GetLatestCryptoPerpBar(symbol, req)
 }
 
+// GetLatestCryptoPerpBarWithContext returns the latest bar for a given crypto perpetual future
+func GetLatestCryptoPerpBarWithContext(
+	ctx context.Context, symbol string, req GetLatestCryptoBarRequest,
+) (*CryptoPerpBar, error) {
+	return DefaultClient.GetLatestCryptoPerpBarWithContext(ctx, symbol, req)
+}
+
 // GetLatestCryptoPerpBar returns the latest bar for a given crypto perpetual future
 func GetLatestCryptoPerpBars(symbols []string, req GetLatestCryptoBarRequest) (map[string]CryptoPerpBar, error) {
 	return DefaultClient.GetLatestCryptoPerpBars(symbols, req)
 }
 
+// GetLatestCryptoPerpBar returns the latest bar for a given crypto perpetual future
+func GetLatestCryptoPerpBarsWithContext(
+	ctx context.Context, symbols []string, req GetLatestCryptoBarRequest,
+) (map[string]CryptoPerpBar, error) {
+	return DefaultClient.GetLatestCryptoPerpBarsWithContext(ctx, symbols, req)
+}
+
 // GetNews returns the news articles based on the given req.
 func GetNews(req GetNewsRequest) ([]News, error) {
 	return DefaultClient.GetNews(req)
 }
 
+// GetNewsWithContext returns the news articles based on the given req.
+func GetNewsWithContext(ctx context.Context, req GetNewsRequest) ([]News, error) {
+	return DefaultClient.GetNewsWithContext(ctx, req)
+}
+
 // GetCorporateActions returns the corporate actions based on the given req.
 func GetCorporateActions(req GetCorporateActionsRequest) (CorporateActions, error) {
 	return DefaultClient.GetCorporateActions(req)
 }
 
+// GetCorporateActionsWithContext returns the corporate actions based on the given req.
+func GetCorporateActionsWithContext(ctx context.Context, req GetCorporateActionsRequest) (CorporateActions, error) {
+	return DefaultClient.GetCorporateActionsWithContext(ctx, req)
+}
+
 // GetFixedIncomeLatestPrice returns the latest price for a given fixed income security identified by ISIN
 func GetFixedIncomeLatestPrice(isin string) (*FixedIncomePrice, error) {
 	return DefaultClient.GetFixedIncomeLatestPrice(isin)
 }
 
+// GetFixedIncomeLatestPriceWithContext returns the latest price for a given fixed income security identified by ISIN
+func GetFixedIncomeLatestPriceWithContext(ctx context.Context, isin string) (*FixedIncomePrice, error) {
+	return DefaultClient.GetFixedIncomeLatestPriceWithContext(ctx, isin)
+}
+
 // GetFixedIncomeLatestPrices returns the latest prices for the given fixed income securities identified by ISINs
 func GetFixedIncomeLatestPrices(isins []string) (map[string]FixedIncomePrice, error) {
 	return DefaultClient.GetFixedIncomeLatestPrices(isins)
 }
 
-func (c *Client) get(u *url.URL) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+// GetFixedIncomeLatestPricesWithContext returns the latest prices for the given fixed income securities
+// identified by ISINs
+func GetFixedIncomeLatestPricesWithContext(ctx context.Context, isins []string) (map[string]FixedIncomePrice, error) {
+	return DefaultClient.GetFixedIncomeLatestPricesWithContext(ctx, isins)
+}
+
+func (c *Client) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1818,6 +2408,18 @@ func (c *Client) get(u *url.URL) (*http.Response, error) {
 	return c.do(c, req)
 }
 
+// sleepContext pauses the current goroutine for at least d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func unmarshal(resp *http.Response, v easyjson.Unmarshaler) error {
 	var (
 		reader io.ReadCloser
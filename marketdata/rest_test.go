@@ -2,6 +2,7 @@
 package marketdata
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/civil"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -88,6 +90,50 @@ func TestDefaultDo_TooMany429s(t *testing.T) {
 	assert.Equal(t, opts.RetryLimit+1, called) // +1 for the original request
 }
 
+func TestDefaultDo_CustomRetryPolicy(t *testing.T) {
+	called := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called++
+		if called == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"bars":{"SPY":{"t":"2021-11-20T00:59:00Z","o":469.18,"h":469.18,"l":469.11,"c":469.17,"v":740}}}`)
+	}))
+	defer server.Close()
+	client := NewClient(ClientOpts{
+		BaseURL:     server.URL,
+		RetryPolicy: alpaca.DefaultRetryPolicy(),
+	})
+	bar, err := client.GetLatestBar("SPY", GetLatestBarRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 469.18, bar.High)
+	assert.Equal(t, 2, called)
+}
+
+func TestDefaultDo_RateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "200")
+		w.Header().Set("X-RateLimit-Remaining", "150")
+		fmt.Fprint(w, `{"bars":{"SPY":{"t":"2021-11-20T00:59:00Z","o":469.18,"h":469.18,"l":469.11,"c":469.17,"v":740}}}`)
+	}))
+	defer server.Close()
+	limiter := alpaca.NewRateLimiter(200, time.Minute)
+	client := NewClient(ClientOpts{
+		BaseURL:     server.URL,
+		RateLimiter: limiter,
+	})
+	_, err := client.GetLatestBar("SPY", GetLatestBarRequest{})
+	require.NoError(t, err)
+	rl, ok := client.RateLimit()
+	require.True(t, ok)
+	assert.Equal(t, alpaca.RateLimit{Limit: 200, Remaining: 150}, rl)
+	state, ok := limiter.State()
+	require.True(t, ok)
+	assert.Equal(t, rl, state)
+}
+
 func TestDefaultDo_Timeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		time.Sleep(time.Second)
@@ -284,6 +330,25 @@ func TestGetMultiTrades(t *testing.T) {
 	assert.Equal(t, []string{" ", "4", "B"}, ge[1].Conditions)
 }
 
+func TestGetMultiTradesWithContext_CanceledDuringPagination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		// The consumer gives up after receiving the first page
+		cancel()
+		fmt.Fprint(w, `{"trades":{"AAPL":[{"t":"2021-10-13T08:00:00.08960768Z","x":"P","p":140.2,"s":595,"c":["@","T"],"i":1,"z":"C"}]},"next_page_token":"token"}`)
+	}))
+	defer server.Close()
+	c := NewClient(ClientOpts{
+		BaseURL: server.URL,
+	})
+	_, err := c.GetMultiTradesWithContext(ctx, []string{"AAPL"}, GetTradesRequest{})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
 func TestGetQuotes(t *testing.T) {
 	c := DefaultClient
 	c.do = func(_ *Client, req *http.Request) (*http.Response, error) {
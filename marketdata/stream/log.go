@@ -1,47 +1,20 @@
 package stream
 
 import (
-	"log"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
 )
 
-// Logger wraps methods for leveled, formatted logging.
-type Logger interface {
-	Infof(format string, v ...interface{})
-	Warnf(format string, v ...interface{})
-	Errorf(format string, v ...interface{})
-}
-
-type defaultLogger struct{}
-
-func (*defaultLogger) Infof(format string, v ...interface{}) {
-	log.Printf("INFO "+format, v...)
-}
-
-func (*defaultLogger) Warnf(format string, v ...interface{}) {
-	log.Printf("WARN "+format, v...)
-}
-
-func (*defaultLogger) Errorf(format string, v ...interface{}) {
-	log.Printf("ERROR "+format, v...)
-}
+// Logger wraps methods for leveled, formatted logging. It's the same interface as
+// alpaca.Logger, so a logger can be shared by the market data and the trade updates streams.
+type Logger = alpaca.Logger
 
 // DefaultLogger returns a Logger that uses the standard go log package to
 // print leveled logs to the standard error.
 func DefaultLogger() Logger {
-	return &defaultLogger{}
-}
-
-type errorOnlyLogger struct{}
-
-var _ Logger = (*errorOnlyLogger)(nil)
-
-func (*errorOnlyLogger) Infof(_ string, _ ...interface{}) {}
-func (*errorOnlyLogger) Warnf(_ string, _ ...interface{}) {}
-func (*errorOnlyLogger) Errorf(format string, v ...interface{}) {
-	log.Printf(format, v...)
+	return alpaca.DefaultLogger()
 }
 
 // ErrorOnlyLogger returns a Logger that only logs errors to the standard error.
 func ErrorOnlyLogger() Logger {
-	return &errorOnlyLogger{}
+	return alpaca.ErrorOnlyLogger()
 }
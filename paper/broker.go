@@ -0,0 +1,302 @@
+// Package paper provides a local paper-trading broker that fills orders against market data,
+// for dry-running strategies offline with realistic order handling.
+package paper
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// DefaultCash is the initial cash balance of the account if BrokerOpts.Cash is not set.
+var DefaultCash = decimal.NewFromInt(100_000)
+
+// BrokerOpts contains the options of a Broker.
+type BrokerOpts struct {
+	// Cash is the initial cash balance of the account. DefaultCash is used if it's zero.
+	Cash decimal.Decimal
+	// DisableShorting makes the broker reject the sell orders exceeding the long position.
+	DisableShorting bool
+	// UnlimitedLiquidity makes the orders fill entirely, regardless of the size of the trades,
+	// the quotes and the bars they are matched against. By default, an order fills at most the
+	// traded size (or the quoted size, or the volume of the bar) on each event, so large orders
+	// may be partially filled.
+	UnlimitedLiquidity bool
+	// Slippage adjusts the prices of the fills, e.g. BpsSlippage. The limit orders are never
+	// filled beyond their limit price. There is no slippage if it's nil.
+	Slippage SlippageFunc
+	// Commission is charged on each fill, e.g. PerShareCommission. The commissions are
+	// deducted from the cash balance. There are no commissions if it's nil.
+	Commission CommissionFunc
+}
+
+// Broker is a local paper-trading broker. It has the same order and account methods as
+// alpaca.Client (PlaceOrder, ReplaceOrder, CancelOrder, GetPositions, GetAccount...), but
+// the orders are filled locally against the market data passed to ProcessTrade,
+// ProcessQuote and ProcessBar. Its clock is the timestamp of the last processed market data.
+//
+// The orders are matched against the market data processed after they are placed, so that
+// a strategy reacting to a bar is filled on the next one. IOC and FOK orders are matched
+// against the last processed market data of their symbol instead, and canceled if they
+// can't be filled (entirely for FOK). OPG orders are matched at the first market data after
+// MarketOpen and CLS orders are filled at the last price by MarketClose, which also expires
+// the orders with a day time in force.
+//
+// The market, limit, stop, stop limit and trailing stop order types are supported, as well as
+// the bracket, OCO and OTO order classes. The legs of a bracket or an OTO order are held
+// until the entry order is entirely filled.
+//
+// The changes of the orders are sent as TradeUpdates to the handlers registered with
+// SubscribeTradeUpdates. A Broker is safe for concurrent use.
+type Broker struct {
+	opts BrokerOpts
+
+	mu         sync.Mutex
+	now        time.Time
+	cash       decimal.Decimal
+	orders     []*order
+	ordersByID map[string]*order
+	positions  map[string]*position
+	lastPrices map[string]decimal.Decimal
+	lastTicks  map[string]tick
+	lastID     int
+	lastEvent  int
+	handlers   []func(alpaca.TradeUpdate)
+	// pending contains the trade updates to be sent to the handlers once the lock is released
+	pending []alpaca.TradeUpdate
+}
+
+type position struct {
+	qty           decimal.Decimal
+	avgEntryPrice decimal.Decimal
+}
+
+// NewBroker creates a new Broker with an empty account.
+func NewBroker(opts BrokerOpts) *Broker {
+	if opts.Cash.IsZero() {
+		opts.Cash = DefaultCash
+	}
+	return &Broker{
+		opts:       opts,
+		cash:       opts.Cash,
+		ordersByID: make(map[string]*order),
+		positions:  make(map[string]*position),
+		lastPrices: make(map[string]decimal.Decimal),
+		lastTicks:  make(map[string]tick),
+	}
+}
+
+// SubscribeTradeUpdates registers handler to be called for each trade update. The handlers are
+// called synchronously, in the goroutine that caused the update (e.g. the one calling
+// ProcessBar), after the broker has been updated, so they may call the methods of the broker.
+func (b *Broker) SubscribeTradeUpdates(handler func(alpaca.TradeUpdate)) {
+	b.mu.Lock()
+	defer b.unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// unlock releases the lock and sends the pending trade updates to the handlers.
+func (b *Broker) unlock() {
+	events := b.pending
+	b.pending = nil
+	handlers := b.handlers
+	b.mu.Unlock()
+	for _, tu := range events {
+		for _, h := range handlers {
+			h(tu)
+		}
+	}
+}
+
+// Now returns the current time of the broker: the timestamp of the last processed market data,
+// or the time it was advanced to by AdvanceClock.
+func (b *Broker) Now() time.Time {
+	b.mu.Lock()
+	defer b.unlock()
+	return b.clock()
+}
+
+// AdvanceClock moves the time of the broker forward to t, e.g. to the end of the bar passed to
+// ProcessBar, which is stamped with its start, so that the orders placed on the bar are
+// timestamped when it's received and only matched against the market data after it. The time
+// never moves back.
+func (b *Broker) AdvanceClock(t time.Time) {
+	b.mu.Lock()
+	defer b.unlock()
+	if t.After(b.now) {
+		b.now = t
+	}
+}
+
+func (b *Broker) clock() time.Time {
+	if b.now.IsZero() {
+		return time.Now().UTC()
+	}
+	return b.now
+}
+
+// SetPosition sets the position of the symbol, e.g. to start from an existing portfolio.
+// A zero qty removes the position. A negative qty is a short position.
+func (b *Broker) SetPosition(symbol string, qty, avgEntryPrice decimal.Decimal) {
+	b.mu.Lock()
+	defer b.unlock()
+	if qty.IsZero() {
+		delete(b.positions, symbol)
+		return
+	}
+	b.positions[symbol] = &position{qty: qty, avgEntryPrice: avgEntryPrice}
+}
+
+// GetAccount returns the account. The positions are valued at the last processed prices.
+func (b *Broker) GetAccount() (*alpaca.Account, error) {
+	b.mu.Lock()
+	defer b.unlock()
+	var long, short decimal.Decimal
+	for symbol, p := range b.positions {
+		mv := p.qty.Mul(b.price(symbol, p.avgEntryPrice))
+		if mv.IsNegative() {
+			short = short.Add(mv)
+		} else {
+			long = long.Add(mv)
+		}
+	}
+	equity := b.cash.Add(long).Add(short)
+	buyingPower := decimal.Max(b.buyingPower(), decimal.Zero)
+	return &alpaca.Account{
+		ID:                   "paper",
+		AccountNumber:        "paper",
+		Status:               "ACTIVE",
+		Currency:             "USD",
+		ShortingEnabled:      !b.opts.DisableShorting,
+		Multiplier:           decimal.NewFromInt(1),
+		Cash:                 b.cash,
+		BuyingPower:          buyingPower,
+		RegTBuyingPower:      buyingPower,
+		NonMarginBuyingPower: buyingPower,
+		EffectiveBuyingPower: buyingPower,
+		LongMarketValue:      long,
+		ShortMarketValue:     short,
+		PositionMarketValue:  long.Sub(short),
+		Equity:               equity,
+		LastEquity:           equity,
+		PortfolioValue:       equity,
+	}, nil
+}
+
+// buyingPower returns the cash that isn't backing short positions. There is no margin.
+func (b *Broker) buyingPower() decimal.Decimal {
+	bp := b.cash
+	for symbol, p := range b.positions {
+		if p.qty.IsNegative() {
+			bp = bp.Add(p.qty.Mul(b.price(symbol, p.avgEntryPrice)))
+		}
+	}
+	return bp
+}
+
+// GetPositions returns the open positions, sorted by symbol.
+func (b *Broker) GetPositions() ([]alpaca.Position, error) {
+	b.mu.Lock()
+	defer b.unlock()
+	symbols := make([]string, 0, len(b.positions))
+	for symbol := range b.positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	positions := make([]alpaca.Position, 0, len(symbols))
+	for _, symbol := range symbols {
+		positions = append(positions, b.positionView(symbol))
+	}
+	return positions, nil
+}
+
+// GetPosition returns the open position of the symbol.
+func (b *Broker) GetPosition(symbol string) (*alpaca.Position, error) {
+	b.mu.Lock()
+	defer b.unlock()
+	if _, ok := b.positions[symbol]; !ok {
+		return nil, apiError(http.StatusNotFound, "position does not exist")
+	}
+	p := b.positionView(symbol)
+	return &p, nil
+}
+
+func (b *Broker) positionView(symbol string) alpaca.Position {
+	p := b.positions[symbol]
+	price := b.price(symbol, p.avgEntryPrice)
+	marketValue := p.qty.Mul(price)
+	costBasis := p.qty.Mul(p.avgEntryPrice)
+	unrealizedPL := marketValue.Sub(costBasis)
+	unrealizedPLPC := decimal.Zero
+	if !costBasis.IsZero() {
+		unrealizedPLPC = unrealizedPL.Div(costBasis.Abs())
+	}
+	side := "long"
+	if p.qty.IsNegative() {
+		side = "short"
+	}
+	return alpaca.Position{
+		Symbol:         symbol,
+		AssetClass:     assetClass(symbol),
+		Qty:            p.qty,
+		QtyAvailable:   p.qty,
+		AvgEntryPrice:  p.avgEntryPrice,
+		Side:           side,
+		MarketValue:    &marketValue,
+		CostBasis:      costBasis,
+		UnrealizedPL:   &unrealizedPL,
+		UnrealizedPLPC: &unrealizedPLPC,
+		CurrentPrice:   &price,
+	}
+}
+
+// price returns the last price of the symbol, or fallback if no market data has been processed.
+func (b *Broker) price(symbol string, fallback decimal.Decimal) decimal.Decimal {
+	if p, ok := b.lastPrices[symbol]; ok {
+		return p
+	}
+	return fallback
+}
+
+// newID returns a new unique ID in UUID format.
+func (b *Broker) newID() string {
+	b.lastID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", b.lastID)
+}
+
+// emit queues a trade update of the order. For fills, tu contains the execution details.
+func (b *Broker) emit(event alpaca.TradeUpdateEvent, o *order, tu *alpaca.TradeUpdate) {
+	if tu == nil {
+		tu = &alpaca.TradeUpdate{}
+	}
+	b.lastEvent++
+	tu.At = b.clock()
+	tu.Event = event
+	tu.EventID = fmt.Sprintf("%026d", b.lastEvent)
+	tu.Order = o.view()
+	b.pending = append(b.pending, *tu)
+}
+
+// apiError returns an error like the ones returned by the API.
+func apiError(status int, format string, args ...interface{}) error {
+	return &alpaca.APIError{
+		StatusCode: status,
+		Code:       status*100000 + 10000,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+func assetClass(symbol string) alpaca.AssetClass {
+	for _, c := range symbol {
+		if c == '/' {
+			return alpaca.Crypto
+		}
+	}
+	return alpaca.USEquity
+}
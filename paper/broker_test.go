@@ -0,0 +1,469 @@
+package paper
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+var t0 = time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+
+func dec(s string) *decimal.Decimal {
+	d := decimal.RequireFromString(s)
+	return &d
+}
+
+func bar(minute int, o, h, l, c float64, v uint64) marketdata.Bar {
+	return marketdata.Bar{
+		Timestamp: t0.Add(time.Duration(minute) * time.Minute),
+		Open:      o, High: h, Low: l, Close: c, Volume: v,
+	}
+}
+
+type recorder struct {
+	updates []alpaca.TradeUpdate
+}
+
+func (r *recorder) events(orderID string) []alpaca.TradeUpdateEvent {
+	var events []alpaca.TradeUpdateEvent
+	for _, tu := range r.updates {
+		if tu.Order.ID == orderID {
+			events = append(events, tu.Event)
+		}
+	}
+	return events
+}
+
+func newTestBroker(opts BrokerOpts) (*Broker, *recorder) {
+	b := NewBroker(opts)
+	r := &recorder{}
+	b.SubscribeTradeUpdates(func(tu alpaca.TradeUpdate) {
+		r.updates = append(r.updates, tu)
+	})
+	return b, r
+}
+
+func getOrder(t *testing.T, b *Broker, id string) *alpaca.Order {
+	o, err := b.GetOrder(id)
+	require.NoError(t, err)
+	return o
+}
+
+func TestMarketOrder(t *testing.T) {
+	b, r := newTestBroker(BrokerOpts{})
+	b.ProcessBar("AAPL", bar(0, 100, 101, 99, 100.5, 1000))
+
+	o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, alpaca.OrderNew, o.Status)
+
+	// Filled at the open of the next bar
+	b.ProcessBar("AAPL", bar(1, 102, 103, 101, 102.5, 1000))
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+	assert.Equal(t, "102", o.FilledAvgPrice.String())
+	assert.Equal(t, t0.Add(time.Minute), *o.FilledAt)
+	assert.Equal(t, []alpaca.TradeUpdateEvent{alpaca.TradeEventNew, alpaca.TradeEventFill}, r.events(o.ID))
+	fill := r.updates[1]
+	assert.Equal(t, "10", fill.Qty.String())
+	assert.Equal(t, "10", fill.PositionQty.String())
+
+	positions, err := b.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "102.5", positions[0].CurrentPrice.String())
+	assert.Equal(t, "5", positions[0].UnrealizedPL.String())
+
+	acct, err := b.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, "98980", acct.Cash.String())
+	assert.Equal(t, "100005", acct.Equity.String())
+}
+
+func TestLimitOrder_PartialFills(t *testing.T) {
+	b, r := newTestBroker(BrokerOpts{})
+	o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("300"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("99.5"),
+		TimeInForce: alpaca.GTC,
+	})
+	require.NoError(t, err)
+
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0, Price: 100, Size: 500})
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0.Add(time.Second), Price: 99.4, Size: 100})
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, alpaca.OrderPartiallyFilled, o.Status)
+	assert.Equal(t, "100", o.FilledQty.String())
+
+	// The buy limit is filled at the limit price if it's within the range of the bar
+	b.ProcessBar("AAPL", bar(1, 100, 100.5, 99, 100, 1000))
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+	assert.Equal(t, "99.4666666666666667", o.FilledAvgPrice.String())
+	assert.Equal(t, []alpaca.TradeUpdateEvent{
+		alpaca.TradeEventNew, alpaca.TradeEventPartialFill, alpaca.TradeEventFill,
+	}, r.events(o.ID))
+}
+
+func TestQuotes(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{})
+	buy, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("5"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	sell, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("5"), Side: alpaca.Sell, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	b.ProcessQuote("AAPL", marketdata.Quote{Timestamp: t0, BidPrice: 99.9, BidSize: 2, AskPrice: 100.1, AskSize: 10})
+	assert.Equal(t, "100.1", getOrder(t, b, buy.ID).FilledAvgPrice.String())
+	sold := getOrder(t, b, sell.ID)
+	assert.Equal(t, alpaca.OrderPartiallyFilled, sold.Status)
+	assert.Equal(t, "2", sold.FilledQty.String())
+	assert.Equal(t, "99.9", sold.FilledAvgPrice.String())
+}
+
+func TestStopOrders(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{UnlimitedLiquidity: true})
+	b.SetPosition("SPY", decimal.NewFromInt(20), decimal.NewFromInt(500))
+	stop, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "SPY", Qty: dec("10"), Side: alpaca.Sell, Type: alpaca.Stop, StopPrice: dec("495"),
+		TimeInForce: alpaca.GTC,
+	})
+	require.NoError(t, err)
+	stopLimit, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "SPY", Qty: dec("10"), Side: alpaca.Sell, Type: alpaca.StopLimit, StopPrice: dec("494"),
+		LimitPrice: dec("493"), TimeInForce: alpaca.GTC,
+	})
+	require.NoError(t, err)
+
+	b.ProcessBar("SPY", bar(0, 500, 501, 496, 497, 1000))
+	assert.Equal(t, alpaca.OrderNew, getOrder(t, b, stop.ID).Status)
+	// Gap down: the stop is filled at the open, the stop limit is triggered but not filled
+	b.ProcessBar("SPY", bar(1, 492, 492.5, 490, 491, 1000))
+	assert.Equal(t, "492", getOrder(t, b, stop.ID).FilledAvgPrice.String())
+	assert.Equal(t, alpaca.OrderNew, getOrder(t, b, stopLimit.ID).Status)
+	b.ProcessBar("SPY", bar(2, 491, 493.5, 491, 493, 1000))
+	assert.Equal(t, "493", getOrder(t, b, stopLimit.ID).FilledAvgPrice.String())
+	positions, err := b.GetPositions()
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+}
+
+func TestTrailingStop(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{UnlimitedLiquidity: true})
+	b.ProcessTrade("TSLA", marketdata.Trade{Timestamp: t0, Price: 200, Size: 100})
+	o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "TSLA", Qty: dec("1"), Side: alpaca.Sell, Type: alpaca.TrailingStop, TrailPercent: dec("5"),
+		TimeInForce: alpaca.GTC,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "190", o.StopPrice.String())
+
+	b.ProcessBar("TSLA", bar(1, 201, 220, 195, 215, 1000))
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, "220", o.HWM.String())
+	assert.Equal(t, "209", o.StopPrice.String())
+	b.ProcessTrade("TSLA", marketdata.Trade{Timestamp: t0.Add(2 * time.Minute), Price: 210, Size: 100})
+	assert.Equal(t, alpaca.OrderNew, getOrder(t, b, o.ID).Status)
+	b.ProcessTrade("TSLA", marketdata.Trade{Timestamp: t0.Add(3 * time.Minute), Price: 208, Size: 100})
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+	assert.Equal(t, "208", o.FilledAvgPrice.String())
+}
+
+func TestBracketOrder(t *testing.T) {
+	b, r := newTestBroker(BrokerOpts{UnlimitedLiquidity: true})
+	o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.GTC,
+		OrderClass: alpaca.Bracket,
+		TakeProfit: &alpaca.TakeProfit{LimitPrice: dec("110")},
+		StopLoss:   &alpaca.StopLoss{StopPrice: dec("95")},
+	})
+	require.NoError(t, err)
+	require.Len(t, o.Legs, 2)
+	takeProfit, stopLoss := o.Legs[0], o.Legs[1]
+	assert.Equal(t, alpaca.OrderHeld, takeProfit.Status)
+	assert.Equal(t, alpaca.Sell, takeProfit.Side)
+	assert.Equal(t, alpaca.Stop, stopLoss.Type)
+
+	// The legs are activated when the entry is filled, but only matched against the next bar
+	b.ProcessBar("AAPL", bar(0, 100, 111, 99, 105, 1000))
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+	assert.Equal(t, alpaca.OrderNew, o.Legs[0].Status)
+	assert.Equal(t, alpaca.OrderNew, o.Legs[1].Status)
+
+	b.ProcessBar("AAPL", bar(1, 105, 112, 104, 111, 1000))
+	assert.Equal(t, alpaca.OrderFilled, getOrder(t, b, takeProfit.ID).Status)
+	assert.Equal(t, "110", getOrder(t, b, takeProfit.ID).FilledAvgPrice.String())
+	assert.Equal(t, alpaca.OrderCanceled, getOrder(t, b, stopLoss.ID).Status)
+	assert.Equal(t, []alpaca.TradeUpdateEvent{alpaca.TradeEventNew, alpaca.TradeEventCanceled}, r.events(stopLoss.ID))
+	positions, err := b.GetPositions()
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+}
+
+func TestOCOAndOTO(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{UnlimitedLiquidity: true})
+	b.SetPosition("AAPL", decimal.NewFromInt(10), decimal.NewFromInt(100))
+	oco, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Sell, Type: alpaca.Limit, TimeInForce: alpaca.GTC,
+		OrderClass: alpaca.OCO,
+		TakeProfit: &alpaca.TakeProfit{LimitPrice: dec("110")},
+		StopLoss:   &alpaca.StopLoss{StopPrice: dec("95"), LimitPrice: dec("94")},
+	})
+	require.NoError(t, err)
+	require.Len(t, oco.Legs, 1)
+	assert.Equal(t, alpaca.OrderNew, oco.Legs[0].Status)
+	assert.Equal(t, alpaca.StopLimit, oco.Legs[0].Type)
+
+	b.ProcessBar("AAPL", bar(0, 100, 101, 94.5, 96, 1000))
+	oco = getOrder(t, b, oco.ID)
+	assert.Equal(t, alpaca.OrderCanceled, oco.Status)
+	assert.Equal(t, alpaca.OrderFilled, oco.Legs[0].Status)
+	assert.Equal(t, "95", oco.Legs[0].FilledAvgPrice.String())
+
+}
+
+func TestOTO_PartialFill(t *testing.T) {
+	b, r := newTestBroker(BrokerOpts{})
+	oto, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("5"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("95"),
+		TimeInForce: alpaca.Day, OrderClass: alpaca.OTO,
+		StopLoss: &alpaca.StopLoss{StopPrice: dec("90")},
+	})
+	require.NoError(t, err)
+	require.Len(t, oto.Legs, 1)
+	stopLoss := oto.Legs[0]
+
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0, Price: 95, Size: 2})
+	// The held leg is activated for the filled qty when the entry expires
+	b.MarketClose()
+	oto = getOrder(t, b, oto.ID)
+	assert.Equal(t, alpaca.OrderExpired, oto.Status)
+	assert.Equal(t, alpaca.OrderNew, oto.Legs[0].Status)
+	assert.Equal(t, "2", oto.Legs[0].Qty.String())
+	// ... and expires at the next close since it's a day order too
+	b.MarketClose()
+	assert.Equal(t, []alpaca.TradeUpdateEvent{alpaca.TradeEventNew, alpaca.TradeEventExpired}, r.events(stopLoss.ID))
+}
+
+func TestTimeInForce(t *testing.T) {
+	b, r := newTestBroker(BrokerOpts{})
+	place := func(tif alpaca.TimeInForce, qty string) *alpaca.Order {
+		o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+			Symbol: "AAPL", Qty: dec(qty), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: tif,
+		})
+		require.NoError(t, err)
+		return o
+	}
+	// Without market data, IOC orders are canceled
+	ioc := place(alpaca.IOC, "1")
+	assert.Equal(t, alpaca.OrderCanceled, ioc.Status)
+
+	b.ProcessQuote("AAPL", marketdata.Quote{Timestamp: t0, BidPrice: 99, BidSize: 10, AskPrice: 100, AskSize: 10})
+	ioc = place(alpaca.IOC, "15")
+	assert.Equal(t, alpaca.OrderCanceled, ioc.Status)
+	assert.Equal(t, "10", ioc.FilledQty.String())
+	fok := place(alpaca.FOK, "15")
+	assert.Equal(t, alpaca.OrderCanceled, fok.Status)
+	assert.True(t, fok.FilledQty.IsZero())
+	fok = place(alpaca.FOK, "10")
+	assert.Equal(t, alpaca.OrderFilled, fok.Status)
+
+	opg := place(alpaca.OPG, "1")
+	cls := place(alpaca.CLS, "1")
+	day := place(alpaca.Day, "1")
+	gtc := place(alpaca.GTC, "1")
+	b.MarketOpen()
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0.Add(time.Minute), Price: 101, Size: 1})
+	assert.Equal(t, alpaca.OrderFilled, getOrder(t, b, opg.ID).Status)
+	assert.Equal(t, alpaca.OrderNew, getOrder(t, b, cls.ID).Status)
+	assert.Equal(t, alpaca.OrderNew, getOrder(t, b, day.ID).Status)
+	b.MarketClose()
+	assert.Equal(t, alpaca.OrderFilled, getOrder(t, b, cls.ID).Status)
+	assert.Equal(t, "101", getOrder(t, b, cls.ID).FilledAvgPrice.String())
+	assert.Equal(t, alpaca.OrderExpired, getOrder(t, b, day.ID).Status)
+	assert.Equal(t, alpaca.OrderNew, getOrder(t, b, gtc.ID).Status)
+	assert.Equal(t, []alpaca.TradeUpdateEvent{alpaca.TradeEventNew, alpaca.TradeEventExpired}, r.events(day.ID))
+}
+
+func TestReplaceAndCancel(t *testing.T) {
+	b, r := newTestBroker(BrokerOpts{})
+	o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("90"),
+		TimeInForce: alpaca.GTC, ClientOrderID: "entry",
+	})
+	require.NoError(t, err)
+	replaced, err := b.ReplaceOrder(o.ID, alpaca.ReplaceOrderRequest{LimitPrice: dec("95")})
+	require.NoError(t, err)
+	assert.Equal(t, o.ID, *replaced.Replaces)
+	assert.Equal(t, "95", replaced.LimitPrice.String())
+	assert.Equal(t, alpaca.OrderReplaced, getOrder(t, b, o.ID).Status)
+	assert.Equal(t, []alpaca.TradeUpdateEvent{alpaca.TradeEventNew, alpaca.TradeEventReplaced}, r.events(o.ID))
+
+	open, err := b.GetOrders(alpaca.GetOrdersRequest{})
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, replaced.ID, open[0].ID)
+
+	require.NoError(t, b.CancelOrder(replaced.ID))
+	var apiErr *alpaca.APIError
+	require.ErrorAs(t, b.CancelOrder(replaced.ID), &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	_, err = b.ReplaceOrder(replaced.ID, alpaca.ReplaceOrderRequest{LimitPrice: dec("96")})
+	require.ErrorAs(t, err, &apiErr)
+	_, err = b.GetOrder("unknown")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+	_, err = b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("1"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.GTC,
+		ClientOrderID: "entry",
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+}
+
+func TestRejections(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{Cash: decimal.NewFromInt(1000), DisableShorting: true})
+	var apiErr *alpaca.APIError
+	_, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("11"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("100"),
+		TimeInForce: alpaca.Day,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "insufficient buying power", apiErr.Message)
+
+	_, err = b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("1"), Side: alpaca.Sell, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+
+	_, err = b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+}
+
+func TestRejections_SellQtyAvailable(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{Cash: decimal.NewFromInt(10000), DisableShorting: true})
+	_, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0, Price: 100, Size: 100})
+
+	var apiErr *alpaca.APIError
+	// The notional sells are estimated from the last price
+	_, err = b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Notional: dec("1500"), Side: alpaca.Sell, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "insufficient qty available")
+
+	// The qty of the open sell orders is not available anymore
+	sell := alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Sell, Type: alpaca.Limit, LimitPrice: dec("200"),
+		TimeInForce: alpaca.GTC,
+	}
+	first, err := b.PlaceOrder(sell)
+	require.NoError(t, err)
+	_, err = b.PlaceOrder(sell)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "insufficient qty available for order (requested: 10, available: 0)", apiErr.Message)
+
+	require.NoError(t, b.CancelOrder(first.ID))
+	_, err = b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Notional: dec("500"), Side: alpaca.Sell, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+
+	// The open notional sells are estimated from the last price, not the price of the new order
+	_, err = b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("6"), Side: alpaca.Sell, Type: alpaca.Limit, LimitPrice: dec("250"),
+		TimeInForce: alpaca.GTC,
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "insufficient qty available for order (requested: 6, available: 5)", apiErr.Message)
+}
+
+func TestNotionalOrder(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{})
+	o, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Notional: dec("1000"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0, Price: 300, Size: 100})
+	o = getOrder(t, b, o.ID)
+	assert.Equal(t, alpaca.OrderFilled, o.Status)
+	assert.Equal(t, "3.333333333", o.FilledQty.String())
+}
+
+func TestSlippageAndCommission(t *testing.T) {
+	b, _ := newTestBroker(BrokerOpts{
+		Cash:       decimal.NewFromInt(10_000),
+		Slippage:   BpsSlippage(10),
+		Commission: PerShareCommission(decimal.RequireFromString("0.01"), decimal.NewFromInt(1)),
+	})
+	market, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	limit, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("10"), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: dec("100.05"),
+		TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0, Price: 100, Size: 100})
+
+	assert.Equal(t, "100.1", getOrder(t, b, market.ID).FilledAvgPrice.String())
+	// The slippage doesn't cross the limit price
+	assert.Equal(t, "100.05", getOrder(t, b, limit.ID).FilledAvgPrice.String())
+	acct, err := b.GetAccount()
+	require.NoError(t, err)
+	// 10_000 - 1001 - 1000.5 - 2 * 1 (the minimum commission)
+	assert.Equal(t, "7996.5", acct.Cash.String())
+
+	assert.Equal(t, "0.5", PercentCommission(0.5)(alpaca.Sell, decimal.NewFromInt(1), decimal.NewFromInt(100)).String())
+	assert.Equal(t, "99.9", BpsSlippage(10)(alpaca.Sell, decimal.NewFromInt(1), decimal.NewFromInt(100)).String())
+}
+
+func TestHandlerCanPlaceOrders(t *testing.T) {
+	b := NewBroker(BrokerOpts{UnlimitedLiquidity: true})
+	b.SubscribeTradeUpdates(func(tu alpaca.TradeUpdate) {
+		if tu.Event == alpaca.TradeEventFill && tu.Order.Side == alpaca.Buy {
+			_, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+				Symbol: tu.Order.Symbol, Qty: tu.Qty, Side: alpaca.Sell, Type: alpaca.Market,
+				TimeInForce: alpaca.Day,
+			})
+			assert.NoError(t, err)
+		}
+	})
+	_, err := b.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: dec("1"), Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	require.NoError(t, err)
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0, Price: 100, Size: 1})
+	b.ProcessTrade("AAPL", marketdata.Trade{Timestamp: t0.Add(time.Second), Price: 101, Size: 1})
+	positions, err := b.GetPositions()
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+	acct, err := b.GetAccount()
+	require.NoError(t, err)
+	assert.Equal(t, "100001", acct.Cash.String())
+	assert.Equal(t, t0.Add(time.Second), b.Now())
+}
@@ -0,0 +1,65 @@
+package paper
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// SlippageFunc returns the price a fill of qty for an order of the side is executed at, given
+// the price it has been matched at.
+type SlippageFunc func(side alpaca.Side, qty, price decimal.Decimal) decimal.Decimal
+
+// CommissionFunc returns the commission charged for a fill of qty at price for an order of the side.
+type CommissionFunc func(side alpaca.Side, qty, price decimal.Decimal) decimal.Decimal
+
+// BpsSlippage returns a SlippageFunc moving the prices against the orders by bps basis points:
+// the buy orders are filled higher, and the sell orders lower.
+func BpsSlippage(bps float64) SlippageFunc {
+	rate := decimal.NewFromFloat(bps).Div(decimal.NewFromInt(10_000))
+	return func(side alpaca.Side, _, price decimal.Decimal) decimal.Decimal {
+		if side == alpaca.Buy {
+			return price.Add(price.Mul(rate))
+		}
+		return price.Sub(price.Mul(rate))
+	}
+}
+
+// PerShareCommission returns a CommissionFunc charging perShare for each share of a fill,
+// with a minimum per fill.
+func PerShareCommission(perShare, minimum decimal.Decimal) CommissionFunc {
+	return func(_ alpaca.Side, qty, _ decimal.Decimal) decimal.Decimal {
+		return decimal.Max(qty.Abs().Mul(perShare), minimum)
+	}
+}
+
+// PercentCommission returns a CommissionFunc charging percent of the value of each fill.
+func PercentCommission(percent float64) CommissionFunc {
+	rate := decimal.NewFromFloat(percent).Div(decimal.NewFromInt(100))
+	return func(_ alpaca.Side, qty, price decimal.Decimal) decimal.Decimal {
+		return qty.Abs().Mul(price).Mul(rate)
+	}
+}
+
+// slipped applies the slippage to the price of a fill of the order, without crossing its limit price.
+func (b *Broker) slipped(o *order, qty, price decimal.Decimal) decimal.Decimal {
+	if b.opts.Slippage == nil {
+		return price
+	}
+	slipped := b.opts.Slippage(o.Side, qty, price)
+	if o.LimitPrice != nil {
+		if o.Side == alpaca.Buy {
+			return decimal.Min(slipped, *o.LimitPrice)
+		}
+		return decimal.Max(slipped, *o.LimitPrice)
+	}
+	return slipped
+}
+
+// commission returns the commission of a fill of the order.
+func (b *Broker) commission(o *order, qty, price decimal.Decimal) decimal.Decimal {
+	if b.opts.Commission == nil {
+		return decimal.Zero
+	}
+	return b.opts.Commission(o.Side, qty, price)
+}
@@ -0,0 +1,340 @@
+package paper
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// tick is a market data event that the orders are matched against.
+type tick struct {
+	time time.Time
+	buy  market
+	sell market
+}
+
+// market is the side of a tick that the buy (or the sell) orders are matched against.
+type market struct {
+	// price is the price a market order is filled at, e.g. the ask price for the buy orders.
+	price decimal.Decimal
+	// low and high are the range of prices reached during the tick, e.g. by a bar.
+	low, high decimal.Decimal
+	// size is the liquidity left on the tick.
+	size decimal.Decimal
+}
+
+func flatMarket(price decimal.Decimal, size decimal.Decimal) market {
+	return market{price: price, low: price, high: price, size: size}
+}
+
+func (t *tick) market(side alpaca.Side) *market {
+	if side == alpaca.Buy {
+		return &t.buy
+	}
+	return &t.sell
+}
+
+// ProcessTrade matches the open orders of the symbol against a trade.
+func (b *Broker) ProcessTrade(symbol string, trade marketdata.Trade) {
+	price := decimal.NewFromFloat(trade.Price)
+	size := decimal.NewFromInt(int64(trade.Size))
+	t := tick{time: trade.Timestamp, buy: flatMarket(price, size), sell: flatMarket(price, size)}
+	b.process(symbol, t, t, price)
+}
+
+// ProcessQuote matches the open orders of the symbol against a quote: the buy orders
+// against the ask and the sell orders against the bid. The positions are valued at the
+// midpoint of the quote.
+func (b *Broker) ProcessQuote(symbol string, quote marketdata.Quote) {
+	ask := decimal.NewFromFloat(quote.AskPrice)
+	bid := decimal.NewFromFloat(quote.BidPrice)
+	t := tick{
+		time: quote.Timestamp,
+		buy:  flatMarket(ask, decimal.NewFromInt(int64(quote.AskSize))),
+		sell: flatMarket(bid, decimal.NewFromInt(int64(quote.BidSize))),
+	}
+	price := ask.Add(bid).Div(decimal.NewFromInt(2))
+	switch {
+	case !ask.IsPositive():
+		price = bid
+	case !bid.IsPositive():
+		price = ask
+	}
+	b.process(symbol, t, t, price)
+}
+
+// ProcessBar matches the open orders of the symbol against a bar. The market orders are
+// filled at the open, the limit orders at the open or at their limit price if it's within
+// the range of the bar, and the stop orders are triggered if their stop price is within
+// the range of the bar. The orders placed after the start of the bar, e.g. on a trade within
+// it, are left for the next market data. The time of the broker is set to the start of the bar:
+// see AdvanceClock to move it to its end.
+func (b *Broker) ProcessBar(symbol string, bar marketdata.Bar) {
+	m := market{
+		price: decimal.NewFromFloat(bar.Open),
+		low:   decimal.NewFromFloat(bar.Low),
+		high:  decimal.NewFromFloat(bar.High),
+		size:  decimal.NewFromInt(int64(bar.Volume)),
+	}
+	closePrice := decimal.NewFromFloat(bar.Close)
+	last := flatMarket(closePrice, m.size)
+	b.process(symbol, tick{time: bar.Timestamp, buy: m, sell: m}, tick{time: bar.Timestamp, buy: last, sell: last},
+		closePrice)
+}
+
+// process matches the active orders of the symbol against t. The IOC and FOK orders placed
+// later are matched against last, and the positions are valued at price.
+func (b *Broker) process(symbol string, t, last tick, price decimal.Decimal) {
+	b.mu.Lock()
+	defer b.unlock()
+	if t.time.After(b.now) {
+		b.now = t.time
+	}
+	if price.IsPositive() {
+		b.lastPrices[symbol] = price
+	}
+	b.lastTicks[symbol] = last
+	// The orders activated by the tick (e.g. the legs of a bracket order) are only matched
+	// against the next one, and the orders placed after its time against a later one
+	var active []*order
+	for _, o := range b.orders {
+		if o.Symbol == symbol && o.active() && !o.placedAt.After(t.time) {
+			active = append(active, o)
+		}
+	}
+	for _, o := range active {
+		if !o.active() {
+			// Canceled by an order of its one-cancels-other group
+			continue
+		}
+		b.match(o, &t)
+		b.cancelUnfilled(o)
+	}
+}
+
+// MarketOpen opens the trading session: the OPG orders are matched against the next market
+// data of their symbol, and canceled if they can't be filled.
+func (b *Broker) MarketOpen() {
+	b.mu.Lock()
+	defer b.unlock()
+	for _, o := range b.orders {
+		if o.waitingForOpen && o.Status.IsOpen() {
+			o.waitingForOpen = false
+			o.immediate = true
+		}
+	}
+}
+
+// MarketClose closes the trading session: the CLS orders are filled at the last price of their
+// symbol (or canceled if they can't be), then the open orders with a day time in force and the
+// unfilled OPG orders expire.
+func (b *Broker) MarketClose() {
+	b.mu.Lock()
+	defer b.unlock()
+	orders := append([]*order(nil), b.orders...)
+	for _, o := range orders {
+		if o.TimeInForce != alpaca.CLS || !o.Status.IsOpen() {
+			continue
+		}
+		if price, ok := b.lastPrices[o.Symbol]; ok {
+			// The closing auction has unlimited liquidity
+			m := flatMarket(price, o.remaining(price))
+			t := tick{time: b.clock(), buy: m, sell: m}
+			b.match(o, &t)
+		}
+		if o.Status.IsOpen() {
+			b.finish(o, alpaca.OrderCanceled)
+		}
+	}
+	// The held legs expire with their parent, or are activated if it has been partially filled
+	var expiring []*order
+	for _, o := range orders {
+		if o.Status.IsOpen() && o.Status != alpaca.OrderHeld &&
+			(o.TimeInForce == alpaca.Day || o.TimeInForce == alpaca.OPG) {
+			expiring = append(expiring, o)
+		}
+	}
+	for _, o := range expiring {
+		if o.Status.IsOpen() {
+			b.finish(o, alpaca.OrderExpired)
+		}
+	}
+}
+
+// match fills the order as much as possible on the tick.
+func (b *Broker) match(o *order, t *tick) {
+	m := t.market(o.Side)
+	if !m.price.IsPositive() {
+		return
+	}
+	price, ok := b.executionPrice(o, m)
+	if !ok {
+		return
+	}
+	qty := o.remaining(price)
+	if !b.opts.UnlimitedLiquidity {
+		if o.TimeInForce == alpaca.FOK && m.size.LessThan(qty) {
+			return
+		}
+		qty = decimal.Min(qty, m.size)
+		m.size = m.size.Sub(qty)
+	}
+	if !qty.IsPositive() {
+		return
+	}
+	price = b.slipped(o, qty, price)
+	if o.Notional != nil {
+		qty = decimal.Min(qty, o.remaining(price))
+	}
+	b.fill(o, qty, price)
+}
+
+// executionPrice returns the price the order can be filled at on the market, if any.
+// The stop orders are triggered as a side effect.
+func (b *Broker) executionPrice(o *order, m *market) (decimal.Decimal, bool) {
+	buy := o.Side == alpaca.Buy
+	current := *m
+	isStop := o.Type == alpaca.Stop || o.Type == alpaca.StopLimit || o.Type == alpaca.TrailingStop
+	if isStop && !o.triggered {
+		if o.Type == alpaca.TrailingStop && o.HWM == nil {
+			b.trail(o, m.price)
+		}
+		price, triggered := stopTrigger(buy, *o.StopPrice, m)
+		if !triggered {
+			if o.Type == alpaca.TrailingStop {
+				// The high water mark is only moved after the trigger has been checked,
+				// since the order of the prices within a bar is unknown
+				if buy {
+					b.trail(o, m.low)
+				} else {
+					b.trail(o, m.high)
+				}
+			}
+			return decimal.Zero, false
+		}
+		o.triggered = true
+		current.price = price
+	}
+	switch o.Type {
+	case alpaca.Market, alpaca.Stop, alpaca.TrailingStop:
+		return current.price, true
+	case alpaca.Limit, alpaca.StopLimit:
+		return limitPrice(buy, *o.LimitPrice, &current)
+	}
+	return decimal.Zero, false
+}
+
+// stopTrigger returns the price a stop order is triggered at, if it's triggered.
+func stopTrigger(buy bool, stop decimal.Decimal, m *market) (decimal.Decimal, bool) {
+	if buy {
+		return decimal.Max(m.price, stop), m.high.GreaterThanOrEqual(stop)
+	}
+	return decimal.Min(m.price, stop), m.low.LessThanOrEqual(stop)
+}
+
+// limitPrice returns the price a limit order is filled at, if it's marketable.
+func limitPrice(buy bool, limit decimal.Decimal, m *market) (decimal.Decimal, bool) {
+	switch {
+	case buy && m.price.LessThanOrEqual(limit), !buy && m.price.GreaterThanOrEqual(limit):
+		return m.price, true
+	case buy && m.low.LessThanOrEqual(limit), !buy && m.high.GreaterThanOrEqual(limit):
+		return limit, true
+	}
+	return decimal.Zero, false
+}
+
+// trail moves the high water mark of a trailing stop order to price if it's more favorable
+// (the lowest price for a buy order), and updates its stop price.
+func (b *Broker) trail(o *order, price decimal.Decimal) {
+	buy := o.Side == alpaca.Buy
+	if o.HWM != nil && ((buy && price.GreaterThanOrEqual(*o.HWM)) || (!buy && price.LessThanOrEqual(*o.HWM))) {
+		return
+	}
+	hwm := price
+	o.HWM = &hwm
+	offset := decimal.Zero
+	switch {
+	case o.TrailPrice != nil:
+		offset = *o.TrailPrice
+	case o.TrailPercent != nil:
+		offset = hwm.Mul(*o.TrailPercent).Div(decimal.NewFromInt(100))
+	}
+	stop := hwm.Sub(offset)
+	if buy {
+		stop = hwm.Add(offset)
+	}
+	o.StopPrice = &stop
+}
+
+// fill fills qty of the order at price, and updates the position, the cash balance and
+// the other orders of its class.
+func (b *Broker) fill(o *order, qty, price decimal.Decimal) {
+	now := b.clock()
+	prevFilled := o.FilledQty
+	o.FilledQty = prevFilled.Add(qty)
+	avg := price
+	if o.FilledAvgPrice != nil {
+		avg = o.FilledAvgPrice.Mul(prevFilled).Add(price.Mul(qty)).Div(o.FilledQty)
+	}
+	o.FilledAvgPrice = &avg
+	o.UpdatedAt = now
+	event := alpaca.TradeEventPartialFill
+	o.Status = alpaca.OrderPartiallyFilled
+	if o.Qty == nil || o.FilledQty.GreaterThanOrEqual(*o.Qty) {
+		event = alpaca.TradeEventFill
+		o.Status = alpaca.OrderFilled
+		o.FilledAt = &now
+	}
+
+	signed := qty
+	if o.Side == alpaca.Sell {
+		signed = qty.Neg()
+	}
+	b.cash = b.cash.Sub(signed.Mul(price)).Sub(b.commission(o, qty, price))
+	positionQty := b.updatePosition(o.Symbol, signed, price)
+	b.emit(event, o, &alpaca.TradeUpdate{
+		ExecutionID: b.newID(),
+		PositionQty: &positionQty,
+		Price:       &price,
+		Qty:         &qty,
+		Timestamp:   &now,
+	})
+
+	for _, s := range o.ocoSiblings() {
+		if o.Status == alpaca.OrderFilled {
+			b.finish(s, alpaca.OrderCanceled)
+			continue
+		}
+		remaining := o.Qty.Sub(o.FilledQty)
+		s.Qty = &remaining
+	}
+	if o.Status == alpaca.OrderFilled && o.parent == nil && o.OrderClass != alpaca.OCO {
+		b.activateLegs(o)
+	}
+}
+
+// updatePosition adds signed qty at price to the position of the symbol and returns the new position qty.
+func (b *Broker) updatePosition(symbol string, signed, price decimal.Decimal) decimal.Decimal {
+	p, ok := b.positions[symbol]
+	if !ok {
+		p = &position{}
+		b.positions[symbol] = p
+	}
+	newQty := p.qty.Add(signed)
+	switch {
+	case p.qty.IsZero() || p.qty.Sign() == signed.Sign():
+		// The position is opened or increased
+		p.avgEntryPrice = p.qty.Abs().Mul(p.avgEntryPrice).Add(signed.Abs().Mul(price)).Div(newQty.Abs())
+	case newQty.Sign() != 0 && newQty.Sign() != p.qty.Sign():
+		// The position is reversed
+		p.avgEntryPrice = price
+	}
+	p.qty = newQty
+	if p.qty.IsZero() {
+		delete(b.positions, symbol)
+	}
+	return newQty
+}
@@ -0,0 +1,538 @@
+package paper
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+type order struct {
+	alpaca.Order
+
+	parent *order
+	legs   []*order
+	// triggered is set once the stop price of a stop, stop limit or trailing stop order is reached
+	triggered bool
+	// waitingForOpen is set for OPG orders until MarketOpen is called
+	waitingForOpen bool
+	// immediate orders are canceled if they are not filled by the next match (IOC, FOK, OPG)
+	immediate bool
+	// placedAt is the time of the market data when the order was placed, zero before any. The
+	// order is only matched against the market data from then on, e.g. not against a bar that
+	// started before it.
+	placedAt time.Time
+}
+
+// view returns the order with its legs as returned by the API.
+func (o *order) view() alpaca.Order {
+	v := o.Order
+	v.Legs = nil
+	for _, leg := range o.legs {
+		v.Legs = append(v.Legs, leg.view())
+	}
+	return v
+}
+
+// active reports whether the order can be matched against the market data.
+func (o *order) active() bool {
+	return o.Status.IsOpen() && o.Status != alpaca.OrderHeld && !o.waitingForOpen && o.TimeInForce != alpaca.CLS
+}
+
+// remaining returns the qty that is left to be filled at price.
+func (o *order) remaining(price decimal.Decimal) decimal.Decimal {
+	if o.Qty != nil {
+		return o.Qty.Sub(o.FilledQty)
+	}
+	filled := decimal.Zero
+	if o.FilledAvgPrice != nil {
+		filled = o.FilledAvgPrice.Mul(o.FilledQty)
+	}
+	return o.Notional.Sub(filled).Div(price).Truncate(9)
+}
+
+// ocoSiblings returns the other open orders of the one-cancels-other group of the order:
+// the legs of a bracket order, or the two orders of an OCO order.
+func (o *order) ocoSiblings() []*order {
+	var group []*order
+	switch {
+	case o.parent != nil && o.parent.OrderClass == alpaca.OCO:
+		group = append([]*order{o.parent}, o.parent.legs...)
+	case o.parent != nil && o.parent.OrderClass == alpaca.Bracket:
+		group = o.parent.legs
+	case o.parent == nil && o.OrderClass == alpaca.OCO:
+		group = o.legs
+	}
+	siblings := make([]*order, 0, len(group))
+	for _, s := range group {
+		if s != o && s.Status.IsOpen() {
+			siblings = append(siblings, s)
+		}
+	}
+	return siblings
+}
+
+func opposite(side alpaca.Side) alpaca.Side {
+	if side == alpaca.Buy {
+		return alpaca.Sell
+	}
+	return alpaca.Buy
+}
+
+// PlaceOrder submits an order. The orders rejected by the checks of the API (e.g. for
+// insufficient buying power) return an *alpaca.APIError with the same status code.
+func (b *Broker) PlaceOrder(req alpaca.PlaceOrderRequest) (*alpaca.Order, error) {
+	if req.OrderClass == alpaca.OCO && req.LimitPrice == nil && req.TakeProfit != nil {
+		// The limit price of an OCO order is the one of its take profit
+		req.LimitPrice = req.TakeProfit.LimitPrice
+	}
+	if err := req.Validate(); err != nil {
+		return nil, apiError(http.StatusUnprocessableEntity, "%s", err)
+	}
+	if err := validateSimulated(req); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.unlock()
+	if req.ClientOrderID != "" && slices.ContainsFunc(b.orders, func(o *order) bool {
+		return o.ClientOrderID == req.ClientOrderID
+	}) {
+		return nil, apiError(http.StatusUnprocessableEntity, "client_order_id must be unique")
+	}
+	if err := b.checkOrder(req); err != nil {
+		return nil, err
+	}
+	o := b.newOrder(req)
+	b.submit(o)
+	v := o.view()
+	return &v, nil
+}
+
+// validateSimulated checks the requirements of the order classes that PlaceOrderRequest.Validate
+// doesn't check, and rejects what the broker doesn't simulate.
+func validateSimulated(req alpaca.PlaceOrderRequest) error {
+	switch req.OrderClass {
+	case alpaca.MLeg:
+		return apiError(http.StatusUnprocessableEntity, "mleg orders are not supported")
+	case alpaca.OCO:
+		if req.Type != alpaca.Limit || req.TakeProfit == nil || req.StopLoss == nil {
+			return apiError(http.StatusUnprocessableEntity,
+				"oco orders must be limit orders with both take_profit and stop_loss")
+		}
+	case alpaca.Bracket, alpaca.OTO:
+		if req.Notional != nil {
+			return apiError(http.StatusUnprocessableEntity, "%s orders require qty", req.OrderClass)
+		}
+		if req.TimeInForce != alpaca.Day && req.TimeInForce != alpaca.GTC {
+			return apiError(http.StatusUnprocessableEntity, "%s orders must be day or gtc", req.OrderClass)
+		}
+	}
+	return nil
+}
+
+// checkOrder rejects the buy orders exceeding the buying power and, if shorting is disabled,
+// the sell orders exceeding the position.
+func (b *Broker) checkOrder(req alpaca.PlaceOrderRequest) error {
+	if req.Side == alpaca.Sell && b.opts.DisableShorting {
+		return b.checkSellQty(req)
+	}
+	if req.Side != alpaca.Buy {
+		return nil
+	}
+	cost := decimal.Zero
+	switch {
+	case req.Notional != nil:
+		cost = *req.Notional
+	case req.LimitPrice != nil:
+		cost = req.Qty.Mul(*req.LimitPrice)
+	default:
+		if price, ok := b.lastPrices[req.Symbol]; ok {
+			cost = req.Qty.Mul(price)
+		}
+	}
+	// Covering a short position doesn't use buying power
+	if p, ok := b.positions[req.Symbol]; ok && p.qty.IsNegative() && req.Qty != nil && !req.Qty.GreaterThan(p.qty.Neg()) {
+		return nil
+	}
+	if cost.GreaterThan(b.buyingPower()) {
+		return apiError(http.StatusForbidden, "insufficient buying power")
+	}
+	return nil
+}
+
+// checkSellQty rejects the sell orders exceeding the qty of the position that is not already
+// committed to the open sell orders. The qty of the notional orders, including the open ones,
+// is estimated from their own limit price or the last price of the symbol.
+func (b *Broker) checkSellQty(req alpaca.PlaceOrderRequest) error {
+	qty := decimal.Zero
+	if req.Qty != nil {
+		qty = *req.Qty
+	} else if price, ok := b.estimatePrice(req.Symbol, req.LimitPrice); ok {
+		qty = req.Notional.Div(price).Truncate(9)
+	}
+	available := decimal.Zero
+	if p, ok := b.positions[req.Symbol]; ok {
+		available = p.qty
+	}
+	counted := make(map[*order]bool)
+	for _, o := range b.orders {
+		if o.Symbol != req.Symbol || o.Side != alpaca.Sell || !o.Status.IsOpen() || o.Status == alpaca.OrderHeld {
+			continue
+		}
+		// Only one order of a one-cancels-other group can be filled
+		if slices.ContainsFunc(o.ocoSiblings(), func(s *order) bool { return counted[s] }) {
+			continue
+		}
+		counted[o] = true
+		price, ok := b.estimatePrice(o.Symbol, o.LimitPrice)
+		if o.Qty != nil || ok {
+			available = available.Sub(o.remaining(price))
+		}
+	}
+	available = decimal.Max(available, decimal.Zero)
+	if qty.GreaterThan(available) || (req.Qty == nil && available.IsZero()) {
+		return apiError(http.StatusForbidden, "insufficient qty available for order (requested: %s, available: %s)",
+			qty, available)
+	}
+	return nil
+}
+
+// estimatePrice returns the price the qty of a notional order is estimated at: its limit price
+// if it has one, or else the last price of the symbol. It returns false if neither is known.
+func (b *Broker) estimatePrice(symbol string, limitPrice *decimal.Decimal) (decimal.Decimal, bool) {
+	price, ok := b.lastPrices[symbol]
+	if limitPrice != nil {
+		price, ok = *limitPrice, true
+	}
+	return price, ok && price.IsPositive()
+}
+
+// newOrder creates the order of the request, with its legs for the advanced order classes.
+func (b *Broker) newOrder(req alpaca.PlaceOrderRequest) *order {
+	now := b.clock()
+	o := &order{Order: alpaca.Order{
+		ID:             b.newID(),
+		ClientOrderID:  req.ClientOrderID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		SubmittedAt:    now,
+		Symbol:         req.Symbol,
+		AssetClass:     assetClass(req.Symbol),
+		OrderClass:     req.OrderClass,
+		Type:           req.Type,
+		Side:           req.Side,
+		PositionIntent: req.PositionIntent,
+		TimeInForce:    req.TimeInForce,
+		Status:         alpaca.OrderNew,
+		Notional:       req.Notional,
+		Qty:            req.Qty,
+		LimitPrice:     req.LimitPrice,
+		StopPrice:      req.StopPrice,
+		TrailPrice:     req.TrailPrice,
+		TrailPercent:   req.TrailPercent,
+		ExtendedHours:  req.ExtendedHours,
+	}, placedAt: b.now}
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = o.ID
+	}
+	if o.OrderClass == "" {
+		o.OrderClass = alpaca.Simple
+	}
+	switch o.OrderClass {
+	case alpaca.OCO:
+		// The order itself is the take profit, the stop loss is its leg. Both are active.
+		o.LimitPrice = req.TakeProfit.LimitPrice
+		b.addStopLoss(o, req.StopLoss, o.Side, alpaca.OrderNew)
+	case alpaca.Bracket, alpaca.OTO:
+		if req.TakeProfit != nil {
+			b.addLeg(o, alpaca.Limit, opposite(o.Side), alpaca.OrderHeld, req.TakeProfit.LimitPrice, nil)
+		}
+		if req.StopLoss != nil {
+			b.addStopLoss(o, req.StopLoss, opposite(o.Side), alpaca.OrderHeld)
+		}
+	}
+	return o
+}
+
+func (b *Broker) addStopLoss(o *order, sl *alpaca.StopLoss, side alpaca.Side, status alpaca.OrderStatus) {
+	typ := alpaca.Stop
+	if sl.LimitPrice != nil {
+		typ = alpaca.StopLimit
+	}
+	b.addLeg(o, typ, side, status, sl.LimitPrice, sl.StopPrice)
+}
+
+func (b *Broker) addLeg(
+	o *order, typ alpaca.OrderType, side alpaca.Side, status alpaca.OrderStatus, limit, stop *decimal.Decimal,
+) {
+	leg := &order{Order: alpaca.Order{
+		ID:          b.newID(),
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+		SubmittedAt: o.SubmittedAt,
+		Symbol:      o.Symbol,
+		AssetClass:  o.AssetClass,
+		OrderClass:  o.OrderClass,
+		Type:        typ,
+		Side:        side,
+		TimeInForce: o.TimeInForce,
+		Status:      status,
+		Qty:         o.Qty,
+		LimitPrice:  limit,
+		StopPrice:   stop,
+	}, parent: o, placedAt: o.placedAt}
+	leg.ClientOrderID = leg.ID
+	o.legs = append(o.legs, leg)
+}
+
+// submit registers a new order and its legs.
+func (b *Broker) submit(o *order) {
+	for _, x := range append([]*order{o}, o.legs...) {
+		b.orders = append(b.orders, x)
+		b.ordersByID[x.ID] = x
+	}
+	b.accept(o)
+	for _, leg := range o.legs {
+		if leg.Status != alpaca.OrderHeld {
+			b.accept(leg)
+		}
+	}
+}
+
+// accept sends the new event of the order and handles its time in force.
+func (b *Broker) accept(o *order) {
+	b.emit(alpaca.TradeEventNew, o, nil)
+	if o.Type == alpaca.TrailingStop {
+		if price, ok := b.lastPrices[o.Symbol]; ok {
+			b.trail(o, price)
+		}
+	}
+	switch o.TimeInForce {
+	case alpaca.OPG:
+		o.waitingForOpen = true
+	case alpaca.IOC, alpaca.FOK:
+		o.immediate = true
+		if t, ok := b.lastTicks[o.Symbol]; ok {
+			b.match(o, &t)
+		}
+		b.cancelUnfilled(o)
+	}
+}
+
+// cancelUnfilled cancels the immediate order if it's not entirely filled.
+func (b *Broker) cancelUnfilled(o *order) {
+	if o.immediate && o.Status.IsOpen() {
+		b.finish(o, alpaca.OrderCanceled)
+	}
+}
+
+// finish cancels or expires the order. The held legs are activated if the order has been
+// partially filled, otherwise they are canceled or expired too.
+func (b *Broker) finish(o *order, status alpaca.OrderStatus) {
+	now := b.clock()
+	o.Status = status
+	o.UpdatedAt = now
+	if status == alpaca.OrderExpired {
+		o.ExpiredAt = &now
+	} else {
+		o.CanceledAt = &now
+	}
+	b.emit(alpaca.TradeUpdateEvent(status), o, nil)
+	if o.OrderClass != alpaca.OCO && o.FilledQty.IsPositive() {
+		b.activateLegs(o)
+		return
+	}
+	for _, leg := range o.legs {
+		if leg.Status.IsOpen() {
+			b.finish(leg, status)
+		}
+	}
+}
+
+// activateLegs activates the held legs of the order for its filled qty.
+func (b *Broker) activateLegs(o *order) {
+	qty := o.FilledQty
+	for _, leg := range o.legs {
+		if leg.Status != alpaca.OrderHeld {
+			continue
+		}
+		leg.Qty = &qty
+		leg.Status = alpaca.OrderNew
+		leg.UpdatedAt = b.clock()
+		b.accept(leg)
+	}
+}
+
+// ReplaceOrder replaces an open order. The replacement is a new order, linked to the
+// original one, which gets the replaced status.
+func (b *Broker) ReplaceOrder(orderID string, req alpaca.ReplaceOrderRequest) (*alpaca.Order, error) {
+	if err := req.Validate(); err != nil {
+		return nil, apiError(http.StatusUnprocessableEntity, "%s", err)
+	}
+	b.mu.Lock()
+	defer b.unlock()
+	old, ok := b.ordersByID[orderID]
+	if !ok {
+		return nil, apiError(http.StatusNotFound, "order not found")
+	}
+	if !old.Status.CanReplace() {
+		return nil, apiError(http.StatusUnprocessableEntity, "order is not replaceable")
+	}
+	now := b.clock()
+	o := &order{Order: old.Order, parent: old.parent, legs: old.legs, triggered: old.triggered,
+		waitingForOpen: old.waitingForOpen, immediate: old.immediate, placedAt: b.now}
+	o.ID = b.newID()
+	o.ClientOrderID = o.ID
+	if req.ClientOrderID != "" {
+		o.ClientOrderID = req.ClientOrderID
+	}
+	o.CreatedAt, o.UpdatedAt, o.SubmittedAt = now, now, now
+	o.Replaces = &old.ID
+	o.FilledQty = decimal.Zero
+	o.FilledAvgPrice = nil
+	applyReplacement(o, old, req)
+	for _, leg := range o.legs {
+		leg.parent = o
+	}
+	if o.parent != nil {
+		o.parent.legs[slices.Index(o.parent.legs, old)] = o
+	}
+
+	old.Status = alpaca.OrderReplaced
+	old.ReplacedAt = &now
+	old.ReplacedBy = &o.ID
+	old.UpdatedAt = now
+	old.legs = nil
+	b.emit(alpaca.TradeEventReplaced, old, nil)
+	b.orders = append(b.orders, o)
+	b.ordersByID[o.ID] = o
+	if o.Status != alpaca.OrderHeld {
+		o.Status = alpaca.OrderNew
+		b.accept(o)
+	}
+	v := o.view()
+	return &v, nil
+}
+
+func applyReplacement(o, old *order, req alpaca.ReplaceOrderRequest) {
+	if old.Qty != nil {
+		remaining := old.Qty.Sub(old.FilledQty)
+		o.Qty = &remaining
+	}
+	if req.Qty != nil {
+		o.Qty = req.Qty
+	}
+	if req.LimitPrice != nil {
+		o.LimitPrice = req.LimitPrice
+	}
+	if req.StopPrice != nil {
+		o.StopPrice = req.StopPrice
+	}
+	if req.Trail != nil {
+		if o.TrailPercent != nil {
+			o.TrailPercent = req.Trail
+		} else {
+			o.TrailPrice = req.Trail
+		}
+	}
+	if req.TimeInForce != "" {
+		o.TimeInForce = req.TimeInForce
+	}
+}
+
+// CancelOrder cancels an open order. Canceling an order of a one-cancels-other group
+// (e.g. a leg of a bracket order) cancels the other ones too.
+func (b *Broker) CancelOrder(orderID string) error {
+	b.mu.Lock()
+	defer b.unlock()
+	o, ok := b.ordersByID[orderID]
+	if !ok {
+		return apiError(http.StatusNotFound, "order not found")
+	}
+	if !o.Status.CanCancel() {
+		return apiError(http.StatusUnprocessableEntity, "order is not cancelable")
+	}
+	siblings := o.ocoSiblings()
+	b.finish(o, alpaca.OrderCanceled)
+	for _, s := range siblings {
+		if s.Status.IsOpen() {
+			b.finish(s, alpaca.OrderCanceled)
+		}
+	}
+	return nil
+}
+
+// CancelAllOrders cancels all the open orders.
+func (b *Broker) CancelAllOrders() error {
+	b.mu.Lock()
+	defer b.unlock()
+	for _, o := range b.orders {
+		if o.Status.IsOpen() {
+			b.finish(o, alpaca.OrderCanceled)
+		}
+	}
+	return nil
+}
+
+// GetOrder returns the order with the given ID.
+func (b *Broker) GetOrder(orderID string) (*alpaca.Order, error) {
+	b.mu.Lock()
+	defer b.unlock()
+	o, ok := b.ordersByID[orderID]
+	if !ok {
+		return nil, apiError(http.StatusNotFound, "order not found")
+	}
+	v := o.view()
+	return &v, nil
+}
+
+// GetOrderByClientOrderID returns the order with the given client order ID.
+func (b *Broker) GetOrderByClientOrderID(clientOrderID string) (*alpaca.Order, error) {
+	b.mu.Lock()
+	defer b.unlock()
+	for _, o := range b.orders {
+		if o.ClientOrderID == clientOrderID {
+			v := o.view()
+			return &v, nil
+		}
+	}
+	return nil, apiError(http.StatusNotFound, "order not found")
+}
+
+// GetOrders returns the orders matching the request, with the same defaults as the API.
+func (b *Broker) GetOrders(req alpaca.GetOrdersRequest) ([]alpaca.Order, error) {
+	b.mu.Lock()
+	defer b.unlock()
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	orders := slices.Clone(b.orders)
+	if req.Direction != "asc" {
+		slices.Reverse(orders)
+	}
+	resp := []alpaca.Order{}
+	for _, o := range orders {
+		if len(resp) == limit {
+			break
+		}
+		if matchesOrdersRequest(o, req) {
+			resp = append(resp, o.view())
+		}
+	}
+	return resp, nil
+}
+
+func matchesOrdersRequest(o *order, req alpaca.GetOrdersRequest) bool {
+	open := o.Status.IsOpen()
+	return !(req.Nested && o.parent != nil) &&
+		!(req.Status == "closed" && open) &&
+		!((req.Status == "" || req.Status == "open") && !open) &&
+		(len(req.Symbols) == 0 || slices.Contains(req.Symbols, o.Symbol)) &&
+		(req.Side == "" || string(o.Side) == req.Side) &&
+		(req.After.IsZero() || o.SubmittedAt.After(req.After)) &&
+		(req.Until.IsZero() || o.SubmittedAt.Before(req.Until))
+}
@@ -0,0 +1,102 @@
+package rebalance
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// Plan is the list of the orders rebalancing an account, returned by Rebalancer.Plan.
+type Plan struct {
+	Equity decimal.Decimal
+	Cash   decimal.Decimal
+	// Investable is the part of the equity allocated to the targets, without the cash buffer.
+	Investable decimal.Decimal
+	// Orders are the sells, then the buys, sorted by symbol.
+	Orders []Order
+	// Skipped are the symbols that are not traded, sorted by symbol.
+	Skipped []Skip
+}
+
+// Order is a market order of a Plan. Either Qty or Notional is set.
+type Order struct {
+	Symbol   string
+	Side     alpaca.Side
+	Qty      *decimal.Decimal
+	Notional *decimal.Decimal
+	// Price is the price used to size the order: the ask price for the buys and the bid price
+	// for the sells.
+	Price        decimal.Decimal
+	CurrentValue decimal.Decimal
+	TargetValue  decimal.Decimal
+}
+
+// EstimatedValue returns the value of the order at its price.
+func (o Order) EstimatedValue() decimal.Decimal {
+	if o.Notional != nil {
+		return *o.Notional
+	}
+	if o.Qty == nil {
+		return decimal.Zero
+	}
+	return o.Qty.Mul(o.Price)
+}
+
+// Request returns the request placing the order, a day market order.
+func (o Order) Request() alpaca.PlaceOrderRequest {
+	return alpaca.PlaceOrderRequest{
+		Symbol:      o.Symbol,
+		Qty:         o.Qty,
+		Notional:    o.Notional,
+		Side:        o.Side,
+		Type:        alpaca.Market,
+		TimeInForce: alpaca.Day,
+	}
+}
+
+// Skip is a symbol whose position is not traded by a Plan.
+type Skip struct {
+	Symbol       string
+	Reason       string
+	CurrentValue decimal.Decimal
+	TargetValue  decimal.Decimal
+}
+
+func (p *Plan) skip(o Order, reason string) {
+	p.Skipped = append(p.Skipped, Skip{
+		Symbol:       o.Symbol,
+		Reason:       reason,
+		CurrentValue: o.CurrentValue,
+		TargetValue:  o.TargetValue,
+	})
+}
+
+// String returns the plan as a table, e.g. to print it as a dry run.
+func (p *Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "equity %s, cash %s, investable %s\n", p.Equity.StringFixed(2), p.Cash.StringFixed(2),
+		p.Investable.StringFixed(2))
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tACTION\tQTY\tNOTIONAL\tPRICE\tCURRENT\tTARGET")
+	for _, o := range p.Orders {
+		qty, notional := "-", "-"
+		if o.Qty != nil {
+			qty = o.Qty.String()
+		}
+		if o.Notional != nil {
+			notional = o.Notional.StringFixed(2)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", o.Symbol, o.Side, qty, notional, o.Price.String(),
+			o.CurrentValue.StringFixed(2), o.TargetValue.StringFixed(2))
+	}
+	for _, s := range p.Skipped {
+		fmt.Fprintf(w, "%s\tskip (%s)\t-\t-\t-\t%s\t%s\n", s.Symbol, s.Reason, s.CurrentValue.StringFixed(2),
+			s.TargetValue.StringFixed(2))
+	}
+	_ = w.Flush()
+	return b.String()
+}
@@ -0,0 +1,341 @@
+// Package rebalance turns the target allocations of a portfolio into the orders bringing the
+// positions of the account to them.
+package rebalance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// DefaultMinNotional is the minimum value of the orders if Opts.MinNotional is not set.
+var DefaultMinNotional = decimal.NewFromInt(1)
+
+// DefaultPollInterval is the interval the sells are polled at if Opts.PollInterval is not set.
+const DefaultPollInterval = time.Second
+
+// ErrOverAllocated is returned when the targets exceed the investable value of the account.
+var ErrOverAllocated = errors.New("rebalance: the targets exceed the investable value")
+
+// TradingClient is the part of alpaca.TradingAPI used by a Rebalancer. It's implemented by
+// *alpaca.Client.
+type TradingClient interface {
+	GetAccountWithContext(ctx context.Context) (*alpaca.Account, error)
+	GetPositionsWithContext(ctx context.Context) ([]alpaca.Position, error)
+	GetAssetWithContext(ctx context.Context, symbol string) (*alpaca.Asset, error)
+	GetOrderWithContext(ctx context.Context, orderID string) (*alpaca.Order, error)
+	PlaceOrderWithContext(ctx context.Context, req alpaca.PlaceOrderRequest) (*alpaca.Order, error)
+}
+
+// QuoteSource provides the latest quotes used to price the orders. It's implemented by
+// *marketdata.Client.
+type QuoteSource interface {
+	GetLatestQuotesWithContext(
+		ctx context.Context, symbols []string, req marketdata.GetLatestQuoteRequest,
+	) (map[string]marketdata.Quote, error)
+}
+
+// Opts contains the options of a Rebalancer.
+type Opts struct {
+	// Client is the trading client of the account. It's required.
+	Client TradingClient
+	// Quotes provides the latest quotes. It's required.
+	Quotes QuoteSource
+	// Feed is the feed of the quotes.
+	Feed marketdata.Feed
+	// CashBuffer is the fraction of the equity kept in cash, e.g. 0.02 for 2%. The weights of
+	// the targets are fractions of the rest, the investable value.
+	CashBuffer decimal.Decimal
+	// Tolerance is the drift tolerance band, as a fraction of the investable value: the
+	// positions whose value differs from their target by at most that much are not traded.
+	Tolerance decimal.Decimal
+	// MinNotional is the minimum estimated value of an order. The smaller orders are skipped,
+	// except the ones closing a position. Defaults to DefaultMinNotional.
+	MinNotional decimal.Decimal
+	// CloseUntargeted makes the plans close the positions of the symbols without target.
+	// Otherwise, they are left untouched.
+	CloseUntargeted bool
+	// WaitForSells makes Execute wait for the sells to be filled before placing the buys, so
+	// that the buys don't fail for lack of buying power.
+	WaitForSells bool
+	// PollInterval is the interval the sells are polled at when WaitForSells is set. Defaults
+	// to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// Target is the target allocation of a symbol: either a Weight or an Amount. A target with
+// neither closes the position of the symbol.
+type Target struct {
+	Symbol string
+	// Weight is the fraction of the investable value allocated to the symbol.
+	Weight decimal.Decimal
+	// Amount is the market value allocated to the symbol, in dollars.
+	Amount decimal.Decimal
+}
+
+// Rebalancer plans and executes the orders rebalancing the account to target allocations.
+//
+// Only long positions are supported: the targets must not be negative and the symbols with a
+// short position can't be rebalanced. The buys of the fractionable assets are notional orders,
+// the other orders are for whole shares, except the ones closing a position.
+type Rebalancer struct {
+	opts Opts
+}
+
+// New returns a new Rebalancer.
+func New(opts Opts) *Rebalancer {
+	if opts.MinNotional.IsZero() {
+		opts.MinNotional = DefaultMinNotional
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	return &Rebalancer{opts: opts}
+}
+
+// Plan returns the plan of the orders rebalancing the account to the targets. It reads the
+// account, the positions, the latest quotes and the assets to trade, but doesn't place any
+// order: the plan can be printed as a dry run, then executed by Execute.
+//
+// If the estimated value of the buys exceeds the cash plus the estimated proceeds of the sells,
+// the buys are scaled down to it. The buys that become too small are skipped.
+func (r *Rebalancer) Plan(ctx context.Context, targets []Target) (*Plan, error) {
+	if r.opts.Client == nil || r.opts.Quotes == nil {
+		return nil, errors.New("rebalance: the client and the quote source are required")
+	}
+	account, err := r.opts.Client.GetAccountWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rebalance: failed to get the account: %w", err)
+	}
+	positions, err := r.opts.Client.GetPositionsWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rebalance: failed to get the positions: %w", err)
+	}
+	plan := &Plan{
+		Equity:     account.Equity,
+		Cash:       account.Cash,
+		Investable: account.Equity.Mul(decimal.NewFromInt(1).Sub(r.opts.CashBuffer)),
+	}
+	targetValues, err := r.targetValues(targets, plan.Investable)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]alpaca.Position, len(positions))
+	for _, p := range positions {
+		held[p.Symbol] = p
+		if _, ok := targetValues[p.Symbol]; !ok && r.opts.CloseUntargeted {
+			targetValues[p.Symbol] = decimal.Zero
+		}
+	}
+	symbols := make([]string, 0, len(targetValues))
+	for symbol := range targetValues {
+		if p, ok := held[symbol]; ok && p.Qty.IsNegative() {
+			return nil, fmt.Errorf("rebalance: %s is held short", symbol)
+		}
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	quotes, err := r.opts.Quotes.GetLatestQuotesWithContext(ctx, symbols, marketdata.GetLatestQuoteRequest{
+		Feed: r.opts.Feed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rebalance: failed to get the latest quotes: %w", err)
+	}
+	for _, symbol := range symbols {
+		if err := r.planSymbol(ctx, plan, held[symbol], symbol, targetValues[symbol], quotes[symbol]); err != nil {
+			return nil, err
+		}
+	}
+	// The sells free the cash of the buys
+	sort.SliceStable(plan.Orders, func(i, j int) bool {
+		return plan.Orders[i].Side == alpaca.Sell && plan.Orders[j].Side == alpaca.Buy
+	})
+	r.fitBuys(plan)
+	return plan, nil
+}
+
+// fitBuys scales the buys of the plan down to the cash available once the sells are filled.
+func (r *Rebalancer) fitBuys(plan *Plan) {
+	available, buys := plan.Cash, decimal.Zero
+	for _, o := range plan.Orders {
+		if o.Side == alpaca.Sell {
+			available = available.Add(o.EstimatedValue())
+		} else {
+			buys = buys.Add(o.EstimatedValue())
+		}
+	}
+	if buys.LessThanOrEqual(available) {
+		return
+	}
+	ratio := decimal.Max(available, decimal.Zero).Div(buys)
+	orders := plan.Orders[:0]
+	for _, o := range plan.Orders {
+		if o.Side == alpaca.Buy {
+			if o.Notional != nil {
+				notional := o.Notional.Mul(ratio).RoundDown(2)
+				o.Notional = &notional
+			} else {
+				qty := o.Qty.Mul(ratio).Floor()
+				o.Qty = &qty
+			}
+			if !o.EstimatedValue().IsPositive() || o.EstimatedValue().LessThan(r.opts.MinNotional) {
+				plan.skip(o, "insufficient cash")
+				continue
+			}
+		}
+		orders = append(orders, o)
+	}
+	plan.Orders = orders
+	sort.SliceStable(plan.Skipped, func(i, j int) bool { return plan.Skipped[i].Symbol < plan.Skipped[j].Symbol })
+}
+
+func (r *Rebalancer) targetValues(targets []Target, investable decimal.Decimal) (map[string]decimal.Decimal, error) {
+	values := make(map[string]decimal.Decimal, len(targets))
+	total := decimal.Zero
+	for _, t := range targets {
+		switch {
+		case t.Symbol == "":
+			return nil, errors.New("rebalance: the symbol of the targets is required")
+		case t.Weight.IsNegative() || t.Amount.IsNegative():
+			return nil, fmt.Errorf("rebalance: the target of %s is negative", t.Symbol)
+		case !t.Weight.IsZero() && !t.Amount.IsZero():
+			return nil, fmt.Errorf("rebalance: the target of %s has both a weight and an amount", t.Symbol)
+		}
+		if _, ok := values[t.Symbol]; ok {
+			return nil, fmt.Errorf("rebalance: %s has several targets", t.Symbol)
+		}
+		value := t.Amount
+		if !t.Weight.IsZero() {
+			value = t.Weight.Mul(investable)
+		}
+		values[t.Symbol] = value
+		total = total.Add(value)
+	}
+	if total.GreaterThan(investable) {
+		return nil, fmt.Errorf("%w: %s > %s", ErrOverAllocated, total, investable)
+	}
+	return values, nil
+}
+
+// planSymbol adds the order bringing the position of the symbol to its target value to the
+// plan, or the reason why it's skipped.
+func (r *Rebalancer) planSymbol(
+	ctx context.Context, plan *Plan, p alpaca.Position, symbol string, target decimal.Decimal, q marketdata.Quote,
+) error {
+	bid, ask := decimal.NewFromFloat(q.BidPrice), decimal.NewFromFloat(q.AskPrice)
+	if bid.IsZero() {
+		bid = ask
+	}
+	if ask.IsZero() {
+		ask = bid
+	}
+	current := p.Qty.Mul(bid.Add(ask).Div(decimal.NewFromInt(2)))
+	if p.MarketValue != nil {
+		current = *p.MarketValue
+	}
+	o := Order{Symbol: symbol, CurrentValue: current, TargetValue: target}
+	diff := target.Sub(current)
+	if diff.Abs().LessThanOrEqual(r.opts.Tolerance.Mul(plan.Investable)) {
+		plan.skip(o, "within tolerance")
+		return nil
+	}
+	if bid.IsZero() {
+		return fmt.Errorf("rebalance: no quote for %s", symbol)
+	}
+	asset, err := r.opts.Client.GetAssetWithContext(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("rebalance: failed to get the asset %s: %w", symbol, err)
+	}
+	if !asset.Tradable {
+		plan.skip(o, "not tradable")
+		return nil
+	}
+	closing := false
+	if diff.IsPositive() {
+		o.Side, o.Price = alpaca.Buy, ask
+		if asset.Fractionable {
+			notional := diff.RoundDown(2)
+			o.Notional = &notional
+		} else {
+			qty := diff.Div(ask).Floor()
+			o.Qty = &qty
+		}
+	} else {
+		o.Side, o.Price = alpaca.Sell, bid
+		qty := p.Qty
+		if !target.IsZero() {
+			if asset.Fractionable {
+				qty = decimal.Min(qty, diff.Neg().Div(bid).RoundDown(9))
+			} else {
+				qty = decimal.Min(qty, diff.Neg().Div(bid).Floor())
+			}
+		}
+		closing = qty.Equal(p.Qty)
+		o.Qty = &qty
+	}
+	switch {
+	case !o.EstimatedValue().IsPositive():
+		plan.skip(o, "less than one share")
+	case !closing && o.EstimatedValue().LessThan(r.opts.MinNotional):
+		plan.skip(o, "below the minimum notional")
+	default:
+		plan.Orders = append(plan.Orders, o)
+	}
+	return nil
+}
+
+// Execute places the orders of the plan, the sells first. Unless Opts.WaitForSells is set, it
+// doesn't wait for the orders to be filled. It stops at the first order that fails to be placed
+// (or at the first sell that is closed without being filled), and returns the orders placed
+// until then.
+func (r *Rebalancer) Execute(ctx context.Context, plan *Plan) ([]alpaca.Order, error) {
+	orders := make([]alpaca.Order, 0, len(plan.Orders))
+	waited := !r.opts.WaitForSells
+	for _, o := range plan.Orders {
+		if o.Side == alpaca.Buy && !waited {
+			if err := r.waitForFills(ctx, orders); err != nil {
+				return orders, err
+			}
+			waited = true
+		}
+		order, err := r.opts.Client.PlaceOrderWithContext(ctx, o.Request())
+		if err != nil {
+			return orders, fmt.Errorf("rebalance: failed to place the %s order of %s: %w", o.Side, o.Symbol, err)
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+// waitForFills polls the orders until they are closed, and updates them. It fails if one of them
+// is closed without being filled.
+func (r *Rebalancer) waitForFills(ctx context.Context, orders []alpaca.Order) error {
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+	for i := range orders {
+		for !orders[i].Status.IsTerminal() {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("rebalance: failed to wait for the %s order of %s: %w",
+					orders[i].Side, orders[i].Symbol, ctx.Err())
+			case <-ticker.C:
+			}
+			order, err := r.opts.Client.GetOrderWithContext(ctx, orders[i].ID)
+			if err != nil {
+				return fmt.Errorf("rebalance: failed to get the %s order of %s: %w", orders[i].Side, orders[i].Symbol, err)
+			}
+			orders[i] = *order
+		}
+		if orders[i].Status != alpaca.OrderFilled {
+			return fmt.Errorf("rebalance: the %s order of %s is %s", orders[i].Side, orders[i].Symbol, orders[i].Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package rebalance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca/alpacatest"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/marketdatatest"
+)
+
+func newServer() *alpacatest.Server {
+	srv := alpacatest.NewServer()
+	srv.SetCash(decimal.NewFromInt(8000))
+	srv.SetPosition("AAPL", decimal.NewFromInt(10), decimal.NewFromInt(90))
+	srv.SetPosition("MSFT", decimal.NewFromInt(5), decimal.NewFromInt(150))
+	srv.SetPrice("AAPL", decimal.NewFromInt(100))
+	srv.SetPrice("MSFT", decimal.NewFromInt(200))
+	srv.AddAsset(alpaca.Asset{Symbol: "AAPL", Tradable: true, Fractionable: true})
+	srv.AddAsset(alpaca.Asset{Symbol: "MSFT", Tradable: true})
+	srv.AddAsset(alpaca.Asset{Symbol: "TSLA", Tradable: true})
+	srv.AddAsset(alpaca.Asset{Symbol: "XYZ"})
+	return srv
+}
+
+var quotes = &marketdatatest.Fake{
+	GetLatestQuotesFunc: func(
+		_ context.Context, symbols []string, _ marketdata.GetLatestQuoteRequest,
+	) (map[string]marketdata.Quote, error) {
+		all := map[string]marketdata.Quote{
+			"AAPL": {BidPrice: 100, AskPrice: 100},
+			"MSFT": {BidPrice: 199, AskPrice: 201},
+			"NVDA": {BidPrice: 100, AskPrice: 100},
+			"TSLA": {BidPrice: 249, AskPrice: 250},
+			"XYZ":  {BidPrice: 10, AskPrice: 10},
+		}
+		res := make(map[string]marketdata.Quote)
+		for _, s := range symbols {
+			res[s] = all[s]
+		}
+		return res, nil
+	},
+}
+
+var targets = []Target{
+	{Symbol: "AAPL", Weight: decimal.RequireFromString("0.5")},
+	{Symbol: "TSLA", Weight: decimal.RequireFromString("0.3")},
+	{Symbol: "NVDA", Amount: decimal.NewFromInt(50)},
+	{Symbol: "XYZ", Weight: decimal.RequireFromString("0.1")},
+}
+
+func TestPlan(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	r := New(Opts{
+		Client:          srv.Client(),
+		Quotes:          quotes,
+		CashBuffer:      decimal.RequireFromString("0.02"),
+		Tolerance:       decimal.RequireFromString("0.01"),
+		CloseUntargeted: true,
+	})
+
+	plan, err := r.Plan(context.Background(), targets)
+	require.NoError(t, err)
+	assert.Equal(t, "10000", plan.Equity.String())
+	assert.Equal(t, "9800", plan.Investable.String())
+	require.Len(t, plan.Orders, 3)
+	// The untargeted position is closed first
+	assert.Equal(t, "MSFT", plan.Orders[0].Symbol)
+	assert.Equal(t, alpaca.Sell, plan.Orders[0].Side)
+	assert.Equal(t, "5", plan.Orders[0].Qty.String())
+	assert.Equal(t, "AAPL", plan.Orders[1].Symbol)
+	assert.Nil(t, plan.Orders[1].Qty)
+	assert.Equal(t, "3900", plan.Orders[1].Notional.String())
+	assert.Equal(t, "TSLA", plan.Orders[2].Symbol)
+	assert.Equal(t, "11", plan.Orders[2].Qty.String())
+	assert.Equal(t, "equity 10000.00, cash 8000.00, investable 9800.00\n"+
+		"SYMBOL  ACTION                   QTY  NOTIONAL  PRICE  CURRENT  TARGET\n"+
+		"MSFT    sell                     5    -         199    1000.00  0.00\n"+
+		"AAPL    buy                      -    3900.00   100    1000.00  4900.00\n"+
+		"TSLA    buy                      11   -         250    0.00     2940.00\n"+
+		"NVDA    skip (within tolerance)  -    -         -      0.00     50.00\n"+
+		"XYZ     skip (not tradable)      -    -         -      0.00     980.00\n", plan.String())
+	// A dry run places no order
+	assert.Empty(t, srv.Orders())
+
+	orders, err := r.Execute(context.Background(), plan)
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	placed := srv.Orders()
+	require.Len(t, placed, 3)
+	assert.Equal(t, "MSFT", placed[0].Symbol)
+	assert.Equal(t, alpaca.OrderFilled, placed[0].Status)
+	assert.Equal(t, "3900", placed[1].Notional.String())
+}
+
+func TestPlan_PartialOrders(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	r := New(Opts{Client: srv.Client(), Quotes: quotes, MinNotional: decimal.NewFromInt(300)})
+
+	plan, err := r.Plan(context.Background(), []Target{
+		{Symbol: "AAPL", Amount: decimal.NewFromInt(450)},
+		{Symbol: "MSFT", Amount: decimal.NewFromInt(850)},
+		{Symbol: "TSLA", Amount: decimal.NewFromInt(260)},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.Orders, 1)
+	assert.Equal(t, "AAPL", plan.Orders[0].Symbol)
+	assert.Equal(t, "5.5", plan.Orders[0].Qty.String())
+	require.Len(t, plan.Skipped, 2)
+	assert.Equal(t, "less than one share", plan.Skipped[0].Reason)
+	assert.Equal(t, "below the minimum notional", plan.Skipped[1].Reason)
+}
+
+func TestPlan_InsufficientCash(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	srv.SetCash(decimal.NewFromInt(1000))
+	srv.AddAsset(alpaca.Asset{Symbol: "NVDA", Tradable: true, Fractionable: true})
+	r := New(Opts{Client: srv.Client(), Quotes: quotes})
+
+	// The MSFT position is kept, so the buys (999 of AAPL, 4 TSLA at 250 and 1 of NVDA) are
+	// scaled down to the cash: 1000 / 2000 = 0.5
+	plan, err := r.Plan(context.Background(), []Target{
+		{Symbol: "AAPL", Amount: decimal.NewFromInt(1999)},
+		{Symbol: "TSLA", Amount: decimal.NewFromInt(1000)},
+		{Symbol: "NVDA", Amount: decimal.NewFromInt(1)},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.Orders, 2)
+	assert.Equal(t, "499.5", plan.Orders[0].Notional.String())
+	assert.Equal(t, "2", plan.Orders[1].Qty.String())
+	// The NVDA buy falls below the minimum notional
+	require.Len(t, plan.Skipped, 1)
+	assert.Equal(t, "NVDA", plan.Skipped[0].Symbol)
+	assert.Equal(t, "insufficient cash", plan.Skipped[0].Reason)
+}
+
+func TestExecute_WaitForSells(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	// NVDA has no price, so its sell isn't filled until it's filled by the test
+	srv.SetPosition("NVDA", decimal.NewFromInt(10), decimal.NewFromInt(100))
+	srv.AddAsset(alpaca.Asset{Symbol: "NVDA", Tradable: true})
+	r := New(Opts{Client: srv.Client(), Quotes: quotes, WaitForSells: true, PollInterval: 10 * time.Millisecond})
+	plan, err := r.Plan(context.Background(), []Target{
+		{Symbol: "AAPL", Amount: decimal.NewFromInt(2000)},
+		{Symbol: "NVDA"},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.Orders, 2)
+
+	execute := func() (chan []alpaca.Order, chan error) {
+		ordersCh, errCh := make(chan []alpaca.Order, 1), make(chan error, 1)
+		go func() {
+			orders, err := r.Execute(context.Background(), plan)
+			ordersCh <- orders
+			errCh <- err
+		}()
+		return ordersCh, errCh
+	}
+	ordersCh, errCh := execute()
+	require.Eventually(t, func() bool { return len(srv.Orders()) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	// The buy isn't placed until the sell is filled
+	require.Len(t, srv.Orders(), 1)
+	sell := srv.Orders()[0]
+	require.NoError(t, srv.Fill(sell.ID, decimal.NewFromInt(10), decimal.NewFromInt(100)))
+	orders := <-ordersCh
+	require.NoError(t, <-errCh)
+	require.Len(t, orders, 2)
+	assert.Equal(t, alpaca.OrderFilled, orders[0].Status)
+	assert.Equal(t, "AAPL", orders[1].Symbol)
+
+	// A rejected sell stops the execution
+	srv.SetPosition("NVDA", decimal.NewFromInt(10), decimal.NewFromInt(100))
+	ordersCh, errCh = execute()
+	require.Eventually(t, func() bool { return len(srv.Orders()) == 3 }, time.Second, time.Millisecond)
+	require.NoError(t, srv.Reject(srv.Orders()[2].ID))
+	orders = <-ordersCh
+	err = <-errCh
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected")
+	require.Len(t, orders, 1)
+	assert.Len(t, srv.Orders(), 3)
+}
+
+func TestPlan_Errors(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	r := New(Opts{Client: srv.Client(), Quotes: quotes})
+
+	_, err := r.Plan(context.Background(), []Target{
+		{Symbol: "AAPL", Weight: decimal.RequireFromString("0.6")},
+		{Symbol: "TSLA", Amount: decimal.NewFromInt(5000)},
+	})
+	require.ErrorIs(t, err, ErrOverAllocated)
+	_, err = r.Plan(context.Background(), []Target{{Symbol: "AAPL", Weight: decimal.NewFromInt(-1)}})
+	require.Error(t, err)
+	_, err = r.Plan(context.Background(), []Target{{Symbol: "AAPL"}, {Symbol: "AAPL"}})
+	require.Error(t, err)
+
+	srv.SetPosition("TSLA", decimal.NewFromInt(-1), decimal.NewFromInt(250))
+	_, err = r.Plan(context.Background(), []Target{{Symbol: "TSLA", Amount: decimal.NewFromInt(100)}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "short")
+
+	_, err = New(Opts{}).Plan(context.Background(), nil)
+	require.Error(t, err)
+}
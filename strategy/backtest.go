@@ -0,0 +1,30 @@
+package strategy
+
+import (
+	"context"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/backtest"
+)
+
+// RunBacktest runs the strategy on the historical market data of bt, with its orders placed on
+// bt.Broker(). OnMarketOpen and OnMarketClose are only called if the sessions of the backtest
+// are enabled (see backtest.Opts.Sessions).
+func RunBacktest(
+	ctx context.Context, s Strategy, subs Subscriptions, bt *backtest.Backtest,
+) (*backtest.Result, error) {
+	if err := s.OnStart(ctx, bt.Broker()); err != nil {
+		return nil, err
+	}
+	if len(subs.Bars) > 0 {
+		bt.SubscribeToBars(s.OnBar, subs.Bars...)
+	}
+	if len(subs.Trades) > 0 {
+		bt.SubscribeToTrades(s.OnTrade, subs.Trades...)
+	}
+	if len(subs.Quotes) > 0 {
+		bt.SubscribeToQuotes(s.OnQuote, subs.Quotes...)
+	}
+	bt.SubscribeToTradeUpdates(s.OnOrderUpdate)
+	bt.SubscribeToSessions(s.OnMarketOpen, s.OnMarketClose)
+	return bt.Run(ctx)
+}
@@ -0,0 +1,359 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/paper"
+)
+
+// MarketDataStream is the market data stream of a live strategy. It's implemented by
+// *stream.StocksClient.
+type MarketDataStream interface {
+	Connect(ctx context.Context) error
+	Terminated() <-chan error
+	SubscribeToBars(handler func(stream.Bar), symbols ...string) error
+	SubscribeToTrades(handler func(stream.Trade), symbols ...string) error
+	SubscribeToQuotes(handler func(stream.Quote), symbols ...string) error
+}
+
+var _ MarketDataStream = (*stream.StocksClient)(nil)
+
+// DefaultClockInterval is the interval the market clock is checked at if LiveOpts.ClockInterval is not set.
+const DefaultClockInterval = time.Minute
+
+// DefaultQueueSize is the size of the queue of the calls to the strategy if LiveOpts.QueueSize is not set.
+const DefaultQueueSize = 10_000
+
+// LiveOpts contains the options of RunLive.
+type LiveOpts struct {
+	// Client is the Trading API client. It's used for the market clock and, unless Simulator
+	// is set, to place the orders and to stream their updates. It's required.
+	Client *alpaca.Client
+	// Stream is the market data stream. It's connected by RunLive, and required if the
+	// strategy subscribes to market data.
+	Stream MarketDataStream
+	// Simulator, if set, fills the orders of the strategy locally against the live market data
+	// instead of sending them to the Trading API.
+	Simulator *paper.Broker
+	// ClockInterval is the interval the market clock is checked at to detect the market open
+	// and close. Defaults to DefaultClockInterval.
+	ClockInterval time.Duration
+	// QueueSize is the number of market data and order updates waiting for the strategy above
+	// which the streams are blocked until the strategy catches up. Defaults to DefaultQueueSize.
+	QueueSize int
+	// Logger logs the errors that don't stop the strategy, e.g. the failures to check the
+	// market clock. Defaults to alpaca.ErrorOnlyLogger().
+	Logger alpaca.Logger
+}
+
+// RunLive runs the strategy on the live market data until ctx is cancelled, or the market data
+// stream or the trade updates stream terminates with an error.
+//
+// The market data, the order updates and the market open and close are passed to the strategy
+// from a single goroutine, in the order they are received. The trade updates stream is connected
+// before OnStart, so that the updates of the orders it places are passed to the strategy right
+// after it. With a Simulator, the order updates are passed as soon as they happen, e.g. during
+// the call to PlaceOrder.
+//
+// On cancellation, RunLive waits for the streams to stop and returns nil: the strategy isn't
+// called anymore once it has returned.
+func RunLive(ctx context.Context, s Strategy, subs Subscriptions, opts LiveOpts) error {
+	if opts.Client == nil {
+		return errors.New("strategy: no trading client")
+	}
+	if opts.Stream == nil && !subs.empty() {
+		return errors.New("strategy: no market data stream")
+	}
+	if opts.ClockInterval == 0 {
+		opts.ClockInterval = DefaultClockInterval
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.Logger == nil {
+		opts.Logger = alpaca.ErrorOnlyLogger()
+	}
+	r := &liveRunner{strategy: s, opts: opts, ready: make(chan struct{}, 1)}
+	r.notFull = sync.NewCond(&r.mu)
+	return r.run(ctx, subs)
+}
+
+type liveRunner struct {
+	strategy Strategy
+	opts     LiveOpts
+	open     bool
+	started  bool
+
+	mu    sync.Mutex
+	queue []func()
+	// ready is signaled when the queue becomes non-empty, and notFull when it's drained
+	ready   chan struct{}
+	notFull *sync.Cond
+	// stopped is set once the queue isn't drained anymore
+	stopped bool
+}
+
+// push queues a call to the strategy. It never blocks, so that it can be called from the
+// goroutine draining the queue.
+func (r *liveRunner) push(f func()) {
+	r.mu.Lock()
+	r.queue = append(r.queue, f)
+	r.mu.Unlock()
+	r.signal()
+}
+
+// pushWait queues a call to the strategy like push, but waits while the queue is full, so that
+// a strategy slower than the streams blocks them instead of growing the queue without bound.
+// The call is dropped once the runner has stopped.
+func (r *liveRunner) pushWait(f func()) {
+	r.mu.Lock()
+	for len(r.queue) >= r.opts.QueueSize && !r.stopped {
+		r.notFull.Wait()
+	}
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	r.queue = append(r.queue, f)
+	r.mu.Unlock()
+	r.signal()
+}
+
+func (r *liveRunner) signal() {
+	select {
+	case r.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (r *liveRunner) drain() {
+	for {
+		r.mu.Lock()
+		queue := r.queue
+		r.queue = nil
+		r.notFull.Broadcast()
+		r.mu.Unlock()
+		if len(queue) == 0 {
+			return
+		}
+		for _, f := range queue {
+			f()
+		}
+	}
+}
+
+// stop releases the calls waiting for the queue, which isn't drained anymore.
+func (r *liveRunner) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	r.notFull.Broadcast()
+}
+
+func (r *liveRunner) run(ctx context.Context, subs Subscriptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var terminated []<-chan error
+	defer func() {
+		cancel()
+		r.stop()
+		for _, ch := range terminated {
+			<-ch
+		}
+	}()
+
+	var broker Broker = r.opts.Client
+	var tradeUpdates <-chan error
+	if r.opts.Simulator != nil {
+		broker = r.opts.Simulator
+		// The simulator is only used from this goroutine, so that its trade updates can be
+		// passed to the strategy right away, like in a backtest. The ones of the orders placed
+		// by OnStart are passed after it.
+		r.opts.Simulator.SubscribeTradeUpdates(func(tu alpaca.TradeUpdate) {
+			if !r.started {
+				r.push(func() { r.strategy.OnOrderUpdate(tu) })
+				return
+			}
+			r.strategy.OnOrderUpdate(tu)
+		})
+	} else {
+		var err error
+		if tradeUpdates, err = r.streamTradeUpdates(ctx); tradeUpdates == nil {
+			return err
+		}
+		terminated = append(terminated, tradeUpdates)
+	}
+	if err := r.strategy.OnStart(ctx, broker); err != nil {
+		return err
+	}
+	r.started = true
+
+	// The market is checked first, so that OnMarketOpen is called before the market data
+	r.checkClock(ctx)
+
+	var marketData <-chan error
+	if !subs.empty() {
+		if err := r.opts.Stream.Connect(ctx); err != nil {
+			return fmt.Errorf("strategy: failed to connect to the market data stream: %w", err)
+		}
+		marketData = r.opts.Stream.Terminated()
+		terminated = append(terminated, marketData)
+		if err := r.subscribe(subs); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(r.opts.ClockInterval)
+	defer ticker.Stop()
+	for {
+		r.drain()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.ready:
+		case <-ticker.C:
+			r.checkClock(ctx)
+		case err := <-marketData:
+			terminated = remove(terminated, marketData)
+			return terminationError(ctx, "market data", err)
+		case err := <-tradeUpdates:
+			terminated = remove(terminated, tradeUpdates)
+			return terminationError(ctx, "trade updates", err)
+		}
+	}
+}
+
+// streamTradeUpdates starts streaming the trade updates to the strategy and waits for the stream
+// to be connected. It returns the channel the stream terminates on, or nil and the error to
+// return if it terminated before connecting.
+func (r *liveRunner) streamTradeUpdates(ctx context.Context) (<-chan error, error) {
+	connected := make(chan struct{})
+	var once sync.Once
+	sub := r.opts.Client.StreamTradeUpdatesInBackground(ctx, func(tu alpaca.TradeUpdate) {
+		r.pushWait(func() { r.strategy.OnOrderUpdate(tu) })
+	}, alpaca.WithConnectCallback(func() {
+		once.Do(func() { close(connected) })
+	}))
+	select {
+	case <-connected:
+		return sub.Terminated(), nil
+	case err := <-sub.Terminated():
+		return nil, terminationError(ctx, "trade updates", err)
+	}
+}
+
+func remove(channels []<-chan error, ch <-chan error) []<-chan error {
+	kept := channels[:0]
+	for _, c := range channels {
+		if c != ch {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func terminationError(ctx context.Context, name string, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("strategy: the %s stream terminated", name)
+	}
+	return fmt.Errorf("strategy: the %s stream terminated: %w", name, err)
+}
+
+// subscribe subscribes the market data stream to the symbols. With a simulator, the market data
+// is processed by the simulator before being passed to the strategy.
+func (r *liveRunner) subscribe(subs Subscriptions) error {
+	if len(subs.Bars) > 0 {
+		if err := r.opts.Stream.SubscribeToBars(r.onBar, subs.Bars...); err != nil {
+			return fmt.Errorf("strategy: failed to subscribe to bars: %w", err)
+		}
+	}
+	if len(subs.Trades) > 0 {
+		if err := r.opts.Stream.SubscribeToTrades(r.onTrade, subs.Trades...); err != nil {
+			return fmt.Errorf("strategy: failed to subscribe to trades: %w", err)
+		}
+	}
+	if len(subs.Quotes) > 0 {
+		if err := r.opts.Stream.SubscribeToQuotes(r.onQuote, subs.Quotes...); err != nil {
+			return fmt.Errorf("strategy: failed to subscribe to quotes: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *liveRunner) onBar(b stream.Bar) {
+	r.pushWait(func() {
+		if sim := r.opts.Simulator; sim != nil {
+			sim.ProcessBar(b.Symbol, marketdata.Bar{
+				Timestamp: b.Timestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close,
+				Volume: b.Volume, TradeCount: b.TradeCount, VWAP: b.VWAP,
+			})
+			// The stream sends the minute bars at their end
+			sim.AdvanceClock(b.Timestamp.Add(time.Minute))
+		}
+		r.strategy.OnBar(b)
+	})
+}
+
+func (r *liveRunner) onTrade(t stream.Trade) {
+	r.pushWait(func() {
+		if sim := r.opts.Simulator; sim != nil {
+			sim.ProcessTrade(t.Symbol, marketdata.Trade{
+				Timestamp: t.Timestamp, Price: t.Price, Size: t.Size, Exchange: t.Exchange, ID: t.ID,
+				Conditions: t.Conditions, Tape: t.Tape,
+			})
+		}
+		r.strategy.OnTrade(t)
+	})
+}
+
+func (r *liveRunner) onQuote(q stream.Quote) {
+	r.pushWait(func() {
+		if sim := r.opts.Simulator; sim != nil {
+			sim.ProcessQuote(q.Symbol, marketdata.Quote{
+				Timestamp: q.Timestamp, BidPrice: q.BidPrice, BidSize: q.BidSize, BidExchange: q.BidExchange,
+				AskPrice: q.AskPrice, AskSize: q.AskSize, AskExchange: q.AskExchange,
+				Conditions: q.Conditions, Tape: q.Tape,
+			})
+		}
+		r.strategy.OnQuote(q)
+	})
+}
+
+// checkClock calls OnMarketOpen or OnMarketClose if the market has opened or closed since the
+// last check. With a simulator, its trading session is opened or closed first.
+func (r *liveRunner) checkClock(ctx context.Context) {
+	clock, err := r.opts.Client.GetClockWithContext(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.opts.Logger.Errorf("strategy: failed to get the market clock: %v", err)
+		}
+		return
+	}
+	wasOpen := r.open
+	r.open = clock.IsOpen
+	switch {
+	case clock.IsOpen && !wasOpen:
+		r.push(func() {
+			if r.opts.Simulator != nil {
+				r.opts.Simulator.MarketOpen()
+			}
+			r.strategy.OnMarketOpen()
+		})
+	case !clock.IsOpen && wasOpen:
+		r.push(func() {
+			if r.opts.Simulator != nil {
+				r.opts.Simulator.MarketClose()
+			}
+			r.strategy.OnMarketClose()
+		})
+	}
+}
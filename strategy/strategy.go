@@ -0,0 +1,73 @@
+// Package strategy runs trading strategies. The same Strategy can be run live with RunLive,
+// placing its orders on the Trading API or on a local paper.Broker, or on historical market
+// data with RunBacktest.
+package strategy
+
+import (
+	"context"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
+)
+
+// Strategy is a trading strategy. Its methods are called by the runners, never concurrently,
+// so a strategy doesn't need to synchronize its state.
+//
+// Embed Base to only implement the methods the strategy needs.
+type Strategy interface {
+	// OnStart is called once before any other method, with the broker the strategy places
+	// its orders on. The strategy isn't run if it returns an error.
+	OnStart(ctx context.Context, broker Broker) error
+	// OnBar is called for each minute bar of the symbols subscribed to.
+	OnBar(bar stream.Bar)
+	// OnTrade is called for each trade of the symbols subscribed to.
+	OnTrade(trade stream.Trade)
+	// OnQuote is called for each quote of the symbols subscribed to.
+	OnQuote(quote stream.Quote)
+	// OnOrderUpdate is called for each update of the orders of the account.
+	OnOrderUpdate(tu alpaca.TradeUpdate)
+	// OnMarketOpen is called when the market opens, or on start if it's already open.
+	OnMarketOpen()
+	// OnMarketClose is called when the market closes.
+	OnMarketClose()
+}
+
+// Base implements all the methods of Strategy as no-ops. It's meant to be embedded in the
+// strategies, which then only implement the methods they need.
+type Base struct{}
+
+var _ Strategy = Base{}
+
+func (Base) OnStart(_ context.Context, _ Broker) error { return nil }
+func (Base) OnBar(_ stream.Bar)                        {}
+func (Base) OnTrade(_ stream.Trade)                    {}
+func (Base) OnQuote(_ stream.Quote)                    {}
+func (Base) OnOrderUpdate(_ alpaca.TradeUpdate)        {}
+func (Base) OnMarketOpen()                             {}
+func (Base) OnMarketClose()                            {}
+
+// Broker is the order and account backend of a strategy. It's implemented by *alpaca.Client
+// and by *paper.Broker.
+type Broker interface {
+	GetAccount() (*alpaca.Account, error)
+	GetPositions() ([]alpaca.Position, error)
+	GetPosition(symbol string) (*alpaca.Position, error)
+	GetOrders(req alpaca.GetOrdersRequest) ([]alpaca.Order, error)
+	GetOrder(orderID string) (*alpaca.Order, error)
+	GetOrderByClientOrderID(clientOrderID string) (*alpaca.Order, error)
+	PlaceOrder(req alpaca.PlaceOrderRequest) (*alpaca.Order, error)
+	ReplaceOrder(orderID string, req alpaca.ReplaceOrderRequest) (*alpaca.Order, error)
+	CancelOrder(orderID string) error
+	CancelAllOrders() error
+}
+
+// Subscriptions are the symbols whose market data is passed to a strategy.
+type Subscriptions struct {
+	Bars   []string
+	Trades []string
+	Quotes []string
+}
+
+func (s Subscriptions) empty() bool {
+	return len(s.Bars) == 0 && len(s.Trades) == 0 && len(s.Quotes) == 0
+}
@@ -0,0 +1,351 @@
+package strategy
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca/alpacatest"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/backtest"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/marketdatatest"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/paper"
+)
+
+var t0 = time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)
+
+// buyOnFirstBar buys 10 shares of the symbol of the first bar, and records the calls.
+type buyOnFirstBar struct {
+	Base
+	broker Broker
+
+	mu     sync.Mutex
+	calls  []string
+	placed bool
+}
+
+func (s *buyOnFirstBar) record(call string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *buyOnFirstBar) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.calls)
+}
+
+func (s *buyOnFirstBar) OnStart(_ context.Context, broker Broker) error {
+	s.broker = broker
+	s.record("start")
+	return nil
+}
+
+func (s *buyOnFirstBar) OnBar(bar stream.Bar) {
+	s.record("bar " + bar.Symbol)
+	if s.placed {
+		return
+	}
+	s.placed = true
+	qty := decimal.NewFromInt(10)
+	_, err := s.broker.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: bar.Symbol, Qty: &qty, Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	if err != nil {
+		s.record("error " + err.Error())
+	}
+}
+
+func (s *buyOnFirstBar) OnOrderUpdate(tu alpaca.TradeUpdate) {
+	s.record("order " + string(tu.Event))
+}
+
+func (s *buyOnFirstBar) OnMarketOpen() {
+	s.record("open")
+}
+
+func (s *buyOnFirstBar) OnMarketClose() {
+	s.record("close")
+}
+
+func TestRunBacktest(t *testing.T) {
+	source := &marketdatatest.Fake{
+		GetMultiBarsFunc: func(
+			_ context.Context, _ []string, _ marketdata.GetBarsRequest,
+		) (map[string][]marketdata.Bar, error) {
+			return map[string][]marketdata.Bar{"AAPL": {
+				{Timestamp: t0, Open: 100, High: 100, Low: 100, Close: 100, Volume: 100},
+				{Timestamp: t0.Add(time.Minute), Open: 101, High: 101, Low: 101, Close: 101, Volume: 100},
+			}}, nil
+		},
+	}
+	bt := backtest.New(backtest.Opts{Source: source, Sessions: true})
+	s := &buyOnFirstBar{}
+
+	res, err := RunBacktest(context.Background(), s, Subscriptions{Bars: []string{"AAPL"}}, bt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start", "open", "bar AAPL", "order new", "order fill", "bar AAPL", "close"}, s.Calls())
+	require.Len(t, res.Trades, 1)
+	assert.Equal(t, "101", res.Trades[0].Price.String())
+}
+
+type fakeStream struct {
+	mu           sync.Mutex
+	onBar        func(stream.Bar)
+	connected    bool
+	disconnected bool
+	subscribeErr error
+	terminated   chan error
+}
+
+// Connect connects the stream, which terminates without error when ctx is cancelled.
+func (f *fakeStream) Connect(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connected = true
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		f.disconnected = true
+		f.mu.Unlock()
+		select {
+		case f.terminated <- nil:
+		default:
+		}
+	}()
+	return nil
+}
+
+func (f *fakeStream) Terminated() <-chan error {
+	return f.terminated
+}
+
+func (f *fakeStream) SubscribeToBars(handler func(stream.Bar), _ ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subscribeErr != nil {
+		return f.subscribeErr
+	}
+	f.onBar = handler
+	return nil
+}
+
+func (f *fakeStream) SubscribeToTrades(_ func(stream.Trade), _ ...string) error {
+	return nil
+}
+
+func (f *fakeStream) SubscribeToQuotes(_ func(stream.Quote), _ ...string) error {
+	return nil
+}
+
+func (f *fakeStream) sendBar(bar stream.Bar) {
+	f.mu.Lock()
+	onBar := f.onBar
+	f.mu.Unlock()
+	onBar(bar)
+}
+
+func (f *fakeStream) subscribed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected && f.onBar != nil
+}
+
+func (f *fakeStream) stopped() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.disconnected
+}
+
+// buyOnStart buys 10 shares of AAPL on start.
+type buyOnStart struct {
+	buyOnFirstBar
+}
+
+func (s *buyOnStart) OnStart(ctx context.Context, broker Broker) error {
+	if err := s.buyOnFirstBar.OnStart(ctx, broker); err != nil {
+		return err
+	}
+	qty := decimal.NewFromInt(10)
+	_, err := broker.PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol: "AAPL", Qty: &qty, Side: alpaca.Buy, Type: alpaca.Market, TimeInForce: alpaca.Day,
+	})
+	return err
+}
+
+// blockingBars blocks in OnBar until release is closed.
+type blockingBars struct {
+	Base
+	release chan struct{}
+	bars    atomic.Int32
+}
+
+func (s *blockingBars) OnBar(stream.Bar) {
+	s.bars.Add(1)
+	<-s.release
+}
+
+func TestRunLive_Simulator(t *testing.T) {
+	srv := alpacatest.NewServer()
+	defer srv.Close()
+	srv.SetClock(alpaca.Clock{Timestamp: t0, IsOpen: true})
+	fs := &fakeStream{terminated: make(chan error, 1)}
+	sim := paper.NewBroker(paper.BrokerOpts{})
+	s := &buyOnFirstBar{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunLive(ctx, s, Subscriptions{Bars: []string{"AAPL"}}, LiveOpts{
+			Client:        srv.Client(),
+			Stream:        fs,
+			Simulator:     sim,
+			ClockInterval: 10 * time.Millisecond,
+		})
+	}()
+	require.Eventually(t, fs.subscribed, time.Second, time.Millisecond)
+	fs.sendBar(stream.Bar{Symbol: "AAPL", Timestamp: t0, Open: 100, High: 100, Low: 100, Close: 100, Volume: 100})
+	fs.sendBar(stream.Bar{Symbol: "AAPL", Timestamp: t0.Add(time.Minute), Open: 101, High: 101, Low: 101, Close: 101,
+		Volume: 100})
+	require.Eventually(t, func() bool { return len(s.Calls()) >= 6 }, time.Second, time.Millisecond)
+	srv.SetClock(alpaca.Clock{Timestamp: t0.Add(7 * time.Hour), IsOpen: false})
+	require.Eventually(t, func() bool { return len(s.Calls()) >= 7 }, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Equal(t, []string{"start", "open", "bar AAPL", "order new", "order fill", "bar AAPL", "close"}, s.Calls())
+	p, err := sim.GetPosition("AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, "10", p.Qty.String())
+	assert.Empty(t, srv.Orders())
+	// The order is placed at the end of the first bar
+	orders, err := sim.GetOrders(alpaca.GetOrdersRequest{Status: "all"})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, t0.Add(time.Minute), orders[0].SubmittedAt)
+	assert.Equal(t, t0.Add(2*time.Minute), sim.Now())
+}
+
+func TestRunLive_Client(t *testing.T) {
+	srv := alpacatest.NewServer()
+	defer srv.Close()
+	fs := &fakeStream{terminated: make(chan error, 1)}
+	s := &buyOnFirstBar{}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- RunLive(context.Background(), s, Subscriptions{Bars: []string{"AAPL"}}, LiveOpts{
+			Client: srv.Client(),
+			Stream: fs,
+		})
+	}()
+	require.Eventually(t, func() bool {
+		return fs.subscribed() && slices.Contains(srv.Requests(), "GET /v2/events/trades")
+	}, time.Second, time.Millisecond)
+	fs.sendBar(stream.Bar{Symbol: "AAPL", Timestamp: t0, Close: 100})
+	require.Eventually(t, func() bool { return len(srv.Orders()) == 1 }, time.Second, time.Millisecond)
+	srv.SetPrice("AAPL", decimal.NewFromInt(100))
+	require.Eventually(t, func() bool {
+		return slices.Contains(s.Calls(), "order fill")
+	}, time.Second, time.Millisecond)
+
+	fs.terminated <- assert.AnError
+	err := <-done
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Contains(t, err.Error(), "market data stream terminated")
+}
+
+func TestRunLive_OrdersPlacedOnStart(t *testing.T) {
+	srv := alpacatest.NewServer()
+	defer srv.Close()
+	s := &buyOnStart{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunLive(ctx, s, Subscriptions{}, LiveOpts{Client: srv.Client()})
+	}()
+	// The trade updates stream is connected before OnStart
+	require.Eventually(t, func() bool {
+		return slices.Contains(s.Calls(), "order new")
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, "start", s.Calls()[0])
+}
+
+func TestRunLive_QueueSize(t *testing.T) {
+	srv := alpacatest.NewServer()
+	defer srv.Close()
+	fs := &fakeStream{terminated: make(chan error, 1)}
+	s := &blockingBars{release: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunLive(ctx, s, Subscriptions{Bars: []string{"AAPL"}}, LiveOpts{
+			Client:    srv.Client(),
+			Stream:    fs,
+			QueueSize: 1,
+		})
+	}()
+	require.Eventually(t, fs.subscribed, time.Second, time.Millisecond)
+	fs.sendBar(stream.Bar{Symbol: "AAPL"})
+	require.Eventually(t, func() bool { return s.bars.Load() == 1 }, time.Second, time.Millisecond)
+	fs.sendBar(stream.Bar{Symbol: "AAPL"})
+
+	// The queue is full: the stream is blocked until the strategy catches up
+	sent := make(chan struct{})
+	go func() {
+		fs.sendBar(stream.Bar{Symbol: "AAPL"})
+		close(sent)
+	}()
+	assert.Never(t, func() bool {
+		select {
+		case <-sent:
+			return true
+		default:
+			return false
+		}
+	}, 50*time.Millisecond, time.Millisecond)
+	close(s.release)
+	<-sent
+	require.Eventually(t, func() bool { return s.bars.Load() == 3 }, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestRunLive_SubscribeError(t *testing.T) {
+	srv := alpacatest.NewServer()
+	defer srv.Close()
+	fs := &fakeStream{terminated: make(chan error, 1), subscribeErr: assert.AnError}
+
+	err := RunLive(context.Background(), &buyOnFirstBar{}, Subscriptions{Bars: []string{"AAPL"}}, LiveOpts{
+		Client: srv.Client(),
+		Stream: fs,
+	})
+	require.ErrorIs(t, err, assert.AnError)
+	// The stream connected before the failure is stopped
+	assert.True(t, fs.stopped())
+}
+
+func TestRunLive_Errors(t *testing.T) {
+	s := &buyOnFirstBar{}
+	require.Error(t, RunLive(context.Background(), s, Subscriptions{}, LiveOpts{}))
+	srv := alpacatest.NewServer()
+	defer srv.Close()
+	require.Error(t, RunLive(context.Background(), s, Subscriptions{Bars: []string{"AAPL"}}, LiveOpts{
+		Client: srv.Client(),
+	}))
+	assert.Empty(t, s.Calls())
+}